@@ -76,7 +76,7 @@ more secure.
 			log.Fatalln(err)
 		}
 
-		parsedFile, err := util.ParseFile(ctx, f)
+		parsedFile, err := util.ParseSource(ctx, f, parseOptions()...)
 		if err != nil {
 			log.Fatalln(err)
 		}
@@ -218,14 +218,7 @@ more secure.
 
 		// Should be able to dump the loaded values to a file.
 		if dumpFilename != "" {
-			file, err := os.Create(dumpFilename)
-			if err != nil {
-				log.Fatalln(err)
-			}
-
-			defer file.Close()
-
-			if err := DumpToFile(file, finalValues, rawValue); err != nil {
+			if err := dumpToFile(dumpFilename, finalValues, rawValue); err != nil {
 				log.Fatalln(err)
 			}
 		}
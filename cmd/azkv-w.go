@@ -34,7 +34,7 @@ The following environment variable can be used to configure the provider:
 			log.Fatalln(err)
 		}
 
-		parsedFile, err := util.ParseFile(ctx, file)
+		parsedFile, err := util.ParseSource(ctx, file, parseOptions()...)
 		if err != nil {
 			log.Fatalln(err)
 		}
@@ -78,14 +78,7 @@ NOTE: A double dash (--) marks the end of configurer options. Everything after
 		}
 
 		if dumpFilename != "" {
-			file, err := os.Create(dumpFilename)
-			if err != nil {
-				log.Fatalln(err)
-			}
-
-			defer file.Close()
-
-			if err := DumpToFile(file, finalValues, rawValue); err != nil {
+			if err := dumpToFile(dumpFilename, finalValues, rawValue); err != nil {
 				log.Fatalln(err)
 			}
 		}
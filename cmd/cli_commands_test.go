@@ -3,13 +3,19 @@
 package cmd
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -19,11 +25,14 @@ import (
 	"github.com/thalesfsp/configurer/awsssm"
 	"github.com/thalesfsp/configurer/azkv"
 	"github.com/thalesfsp/configurer/doppler"
+	"github.com/thalesfsp/configurer/encfile"
 	"github.com/thalesfsp/configurer/gcpsm"
 	"github.com/thalesfsp/configurer/k8ssecret"
 	"github.com/thalesfsp/configurer/noop"
 	"github.com/thalesfsp/configurer/onepassword"
+	"github.com/thalesfsp/configurer/plugin"
 	"github.com/thalesfsp/configurer/provider"
+	"github.com/thalesfsp/configurer/sops"
 	"github.com/thalesfsp/configurer/vault"
 )
 
@@ -141,6 +150,30 @@ func TestCLIFlagBindingAndLocalProviders(t *testing.T) {
 			},
 			wantOutput: "from-text",
 		},
+		{
+			name: "happy path load text flattens nested documents",
+			setup: func(t *testing.T) ([]string, map[string]string, func(*testing.T, string)) {
+				t.Helper()
+
+				args := []string{
+					"--flush-interval=1ms",
+					"--flatten",
+					"load",
+					"--override",
+					"text",
+					"--format", "yaml",
+					"--",
+					"/bin/sh",
+					"-c",
+					`printf "%s,%s" "$CONFIGURER_FLATTEN__HOST" "$CONFIGURER_FLATTEN__PORTS__1"`,
+				}
+
+				return args, map[string]string{
+					cliStdinEnv: "configurer_flatten:\n  host: db\n  ports: [5432, 5433]\n",
+				}, nil
+			},
+			wantOutput: "db,5433",
+		},
 		{
 			name: "bad path load text rejects unsupported format",
 			setup: func(t *testing.T) ([]string, map[string]string, func(*testing.T, string)) {
@@ -204,6 +237,561 @@ func TestCLIFlagBindingAndLocalProviders(t *testing.T) {
 				return args, nil, verify
 			},
 		},
+		{
+			name: "happy path write dotenv from a Kubernetes Secret manifest",
+			setup: func(t *testing.T) ([]string, map[string]string, func(*testing.T, string)) {
+				t.Helper()
+
+				sourceFile := filepath.Join(t.TempDir(), "secret.yaml")
+				targetFile := filepath.Join(t.TempDir(), "target.env")
+				require.NoError(t, os.WriteFile(
+					sourceFile,
+					[]byte("apiVersion: v1\nkind: Secret\ndata:\n  MANIFEST_KEY: bWFuaWZlc3QtdmFsdWU=\n"),
+					0o600,
+				))
+
+				args := []string{
+					"write",
+					"--source", sourceFile,
+					"dotenv",
+					"--target", targetFile,
+				}
+
+				verify := func(t *testing.T, _ string) {
+					t.Helper()
+
+					written, err := os.ReadFile(targetFile)
+					require.NoError(t, err)
+					assert.Contains(t, string(written), `MANIFEST_KEY="manifest-value"`)
+				}
+
+				return args, nil, verify
+			},
+		},
+		{
+			name: "happy path write k8ssecret from a Kubernetes ConfigMap manifest",
+			setup: func(t *testing.T) ([]string, map[string]string, func(*testing.T, string)) {
+				t.Helper()
+
+				sourceFile := filepath.Join(t.TempDir(), "configmap.yaml")
+				require.NoError(t, os.WriteFile(
+					sourceFile,
+					[]byte("apiVersion: v1\nkind: ConfigMap\ndata:\n  MANIFEST_KEY: manifest-value\n"),
+					0o600,
+				))
+
+				patches := make(chan []byte, 1)
+
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if r.Method != http.MethodPatch || r.URL.Path != "/api/v1/namespaces/application/secrets/app-secret" {
+						w.WriteHeader(http.StatusNotFound)
+
+						return
+					}
+
+					body, _ := io.ReadAll(r.Body)
+					patches <- body
+
+					_, _ = w.Write([]byte(`{}`))
+				}))
+				t.Cleanup(server.Close)
+
+				args := []string{
+					"write",
+					"--source", sourceFile,
+					"k8ssecret",
+					"--api-server", server.URL,
+					"--namespace", "application",
+					"--secret-name", "app-secret",
+					"--token", "api-token",
+				}
+
+				verify := func(t *testing.T, _ string) {
+					t.Helper()
+
+					select {
+					case patch := <-patches:
+						assert.JSONEq(t, `{"data": {"MANIFEST_KEY": "bWFuaWZlc3QtdmFsdWU="}}`, string(patch))
+					default:
+						t.Fatal("the secret wasn't patched")
+					}
+				}
+
+				return args, nil, verify
+			},
+		},
+		{
+			name: "happy path load dotenv dumps encrypted values",
+			setup: func(t *testing.T) ([]string, map[string]string, func(*testing.T, string)) {
+				t.Helper()
+
+				recipient, privateKey, err := encfile.GenerateKey()
+				require.NoError(t, err)
+
+				sourceFile := filepath.Join(t.TempDir(), "source.env")
+				dumpFile := filepath.Join(t.TempDir(), ".env.enc")
+				require.NoError(t, os.WriteFile(sourceFile, []byte("ENCFILE_DUMP_KEY=dump-secret\n"), 0o600))
+
+				args := []string{
+					"--flush-interval=1ms",
+					"load",
+					"--dump", dumpFile,
+					"--dump-encrypt-to", recipient,
+					"dotenv",
+					"--files", sourceFile,
+					"--",
+					"/bin/sh",
+					"-c",
+					"true",
+				}
+
+				verify := func(t *testing.T, _ string) {
+					t.Helper()
+
+					written, err := os.ReadFile(dumpFile)
+					require.NoError(t, err)
+					assert.NotContains(t, string(written), "dump-secret")
+
+					key, value, found := strings.Cut(strings.TrimSpace(string(written)), "=")
+					require.True(t, found)
+					assert.Equal(t, "ENCFILE_DUMP_KEY", key)
+
+					decrypted, err := encfile.Decrypt(key, value, privateKey)
+					require.NoError(t, err)
+					assert.Equal(t, "dump-secret", decrypted)
+				}
+
+				return args, nil, verify
+			},
+		},
+		{
+			name: "happy path load encfile decrypts values",
+			setup: func(t *testing.T) ([]string, map[string]string, func(*testing.T, string)) {
+				t.Helper()
+
+				recipient, privateKey, err := encfile.GenerateKey()
+				require.NoError(t, err)
+
+				encrypted, err := encfile.Encrypt("ENCFILE_LOAD_KEY", "load-secret", recipient)
+				require.NoError(t, err)
+
+				dir := t.TempDir()
+				encryptedFile := filepath.Join(dir, ".env.enc")
+				keyFile := filepath.Join(dir, "key.txt")
+				require.NoError(t, os.WriteFile(encryptedFile, []byte("ENCFILE_LOAD_KEY="+encrypted+"\n"), 0o600))
+				require.NoError(t, os.WriteFile(keyFile, []byte(privateKey+"\n"), 0o600))
+
+				return []string{
+					"--flush-interval=1ms",
+					"load",
+					"encfile",
+					"--files", encryptedFile,
+					"--private-key-file", keyFile,
+					"--",
+					"/bin/sh",
+					"-c",
+					`printf "%s" "$ENCFILE_LOAD_KEY"`,
+				}, nil, nil
+			},
+			wantOutput: "load-secret",
+		},
+		{
+			name: "happy path load encfile with the key file written by keygen",
+			setup: func(t *testing.T) ([]string, map[string]string, func(*testing.T, string)) {
+				t.Helper()
+
+				dir := t.TempDir()
+
+				keygen, err := runCLIHelper(t, dir, nil, false, "keygen")
+				require.NoError(t, err, keygen)
+
+				recipient, _, found := strings.Cut(strings.TrimPrefix(keygen, "# Recipient: "), "\n")
+				require.True(t, found, keygen)
+
+				keyFile := filepath.Join(dir, "key.txt")
+				require.NoError(t, os.WriteFile(keyFile, []byte(keygen), 0o600))
+
+				sourceFile := filepath.Join(dir, "source.env")
+				encryptedFile := filepath.Join(dir, ".env.enc")
+				require.NoError(t, os.WriteFile(sourceFile, []byte("ENCFILE_KEYGEN_KEY=keygen-secret\n"), 0o600))
+
+				written, err := runCLIHelper(t, dir, nil, false,
+					"write",
+					"--source", sourceFile,
+					"encfile",
+					"--target", encryptedFile,
+					"--recipient", recipient,
+				)
+				require.NoError(t, err, written)
+
+				return []string{
+					"--flush-interval=1ms",
+					"load",
+					"encfile",
+					"--files", encryptedFile,
+					"--private-key-file", keyFile,
+					"--",
+					"/bin/sh",
+					"-c",
+					`printf "%s" "$ENCFILE_KEYGEN_KEY"`,
+				}, nil, nil
+			},
+			wantOutput: "keygen-secret",
+		},
+		{
+			name: "happy path load sops decrypts, and flattens values",
+			setup: func(t *testing.T) ([]string, map[string]string, func(*testing.T, string)) {
+				t.Helper()
+
+				identity, recipient, err := sops.GenerateIdentity()
+				require.NoError(t, err)
+
+				dir := t.TempDir()
+				encryptedFile := filepath.Join(dir, "secrets.enc.yaml")
+				keyFile := filepath.Join(dir, "keys.txt")
+				require.NoError(t, os.WriteFile(keyFile, []byte("# public key: "+recipient+"\n"+identity+"\n"), 0o600))
+
+				writer, err := sops.New(false, false, &sops.Config{
+					FilePaths:  []string{encryptedFile},
+					Recipients: []string{recipient},
+				})
+				require.NoError(t, err)
+				require.NoError(t, writer.Write(t.Context(), map[string]interface{}{
+					"sops_load": map[string]any{"key": "sops-secret"},
+				}))
+
+				return []string{
+					"--flush-interval=1ms",
+					"--flatten",
+					"load",
+					"sops",
+					"--files", encryptedFile,
+					"--age-key-file", keyFile,
+					"--",
+					"/bin/sh",
+					"-c",
+					`printf "%s" "$SOPS_LOAD__KEY"`,
+				}, nil, nil
+			},
+			wantOutput: "sops-secret",
+		},
+		{
+			name: "happy path load keepass exports the entry fields",
+			setup: func(t *testing.T) ([]string, map[string]string, func(*testing.T, string)) {
+				t.Helper()
+
+				database, err := filepath.Abs(filepath.Join("..", "keepass", "testdata", "argon2d.kdbx"))
+				require.NoError(t, err)
+
+				return []string{
+					"--flush-interval=1ms",
+					"load",
+					"kp",
+					"--file", database,
+					"--path", "Production/Database",
+					"--",
+					"/bin/sh",
+					"-c",
+					`printf "%s:%s" "$USERNAME" "$DB_NAME"`,
+				}, map[string]string{"KEEPASS_PASSWORD": "correct horse"}, nil
+			},
+			wantOutput: "admin:app",
+		},
+		{
+			name: "happy path load files recurses, and filters",
+			setup: func(t *testing.T) ([]string, map[string]string, func(*testing.T, string)) {
+				t.Helper()
+
+				dir := t.TempDir()
+				require.NoError(t, os.MkdirAll(filepath.Join(dir, "db"), 0o700))
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "db", "password"), []byte("files-secret\n"), 0o600))
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "server.pem"), []byte("excluded-pem"), 0o600))
+
+				return []string{
+					"--flush-interval=1ms",
+					"load",
+					"files",
+					"--recursive",
+					"--exclude", "*.pem",
+					"--",
+					"env",
+				}, map[string]string{"CREDENTIALS_DIRECTORY": dir}, func(t *testing.T, output string) {
+					t.Helper()
+
+					assert.NotContains(t, output, "excluded-pem")
+				}
+			},
+			wantOutput: "db__password=files-secret",
+		},
+		{
+			name: "happy path load exec exports the raw output",
+			setup: func(t *testing.T) ([]string, map[string]string, func(*testing.T, string)) {
+				t.Helper()
+
+				return []string{
+					"--flush-interval=1ms",
+					"load",
+					"exec",
+					"--command", `printf 'exec-token\n'`,
+					"--format", "raw",
+					"--key", "EXEC_LOAD_TOKEN",
+					"--",
+					"/bin/sh",
+					"-c",
+					`printf "[%s]" "$EXEC_LOAD_TOKEN"`,
+				}, nil, nil
+			},
+			wantOutput: "[exec-token]",
+		},
+		{
+			name: "bad path load exec non-zero exit",
+			setup: func(t *testing.T) ([]string, map[string]string, func(*testing.T, string)) {
+				t.Helper()
+
+				return []string{
+					"load",
+					"exec",
+					"--command", "echo broker unavailable >&2; exit 2",
+				}, nil, nil
+			},
+			wantExitCode: 1,
+			wantOutput:   "broker unavailable",
+		},
+		{
+			name: "happy path load http selects the pointer",
+			setup: func(t *testing.T) ([]string, map[string]string, func(*testing.T, string)) {
+				t.Helper()
+
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if r.Header.Get("Authorization") != "Bearer http-token" || r.Header.Get("X-Tenant") != "app" {
+						w.WriteHeader(http.StatusUnauthorized)
+
+						return
+					}
+
+					w.Header().Set("Content-Type", "application/json")
+					_, _ = w.Write([]byte(`{"data": {"HTTP_LOAD_SECRET": "http-secret"}}`))
+				}))
+				t.Cleanup(server.Close)
+
+				return []string{
+					"--flush-interval=1ms",
+					"load",
+					"http",
+					"--url", server.URL,
+					"--header", "X-Tenant: app",
+					"--pointer", "/data",
+					"--",
+					"/bin/sh",
+					"-c",
+					`printf "[%s]" "$HTTP_LOAD_SECRET"`,
+				}, map[string]string{"HTTP_BEARER_TOKEN": "http-token"}, nil
+			},
+			wantOutput: "[http-secret]",
+		},
+		{
+			name: "happy path load consul reads the prefix",
+			setup: func(t *testing.T) ([]string, map[string]string, func(*testing.T, string)) {
+				t.Helper()
+
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if r.Header.Get("X-Consul-Token") != "consul-token" || r.URL.Path != "/v1/kv/app/prod/" || r.URL.Query().Get("recurse") != "true" {
+						w.WriteHeader(http.StatusForbidden)
+
+						return
+					}
+
+					_, _ = w.Write([]byte(`[{"Key": "app/prod/db/password", "Value": "Y29uc3VsLXNlY3JldA=="}]`))
+				}))
+				t.Cleanup(server.Close)
+
+				return []string{
+					"--flush-interval=1ms",
+					"load",
+					"consul",
+					"--prefix", "app/prod",
+					"--",
+					"/bin/sh",
+					"-c",
+					`printf "[%s]" "$db__password"`,
+				}, map[string]string{"CONSUL_HTTP_ADDR": server.URL, "CONSUL_HTTP_TOKEN": "consul-token"}, nil
+			},
+			wantOutput: "[consul-secret]",
+		},
+		{
+			name: "happy path load etcd authenticates, and reads the prefix",
+			setup: func(t *testing.T) ([]string, map[string]string, func(*testing.T, string)) {
+				t.Helper()
+
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					switch {
+					case r.URL.Path == "/v3/auth/authenticate":
+						_, _ = w.Write([]byte(`{"token": "etcd-token"}`))
+					case r.URL.Path == "/v3/kv/range" && r.Header.Get("Authorization") == "etcd-token":
+						_, _ = w.Write([]byte(`{"header": {"revision": "7"}, "kvs": [{"key": "L2FwcC9wcm9kL0VUQ0RfU0VDUkVU", "value": "ZXRjZC1zZWNyZXQ="}]}`))
+					default:
+						w.WriteHeader(http.StatusUnauthorized)
+					}
+				}))
+				t.Cleanup(server.Close)
+
+				return []string{
+					"--flush-interval=1ms",
+					"load",
+					"etcd",
+					"--prefix", "/app/prod",
+					"--",
+					"/bin/sh",
+					"-c",
+					`printf "[%s]" "$ETCD_SECRET"`,
+				}, map[string]string{"ETCDCTL_ENDPOINTS": server.URL, "ETCDCTL_USER": "root:s3cr3t"}, nil
+			},
+			wantOutput: "[etcd-secret]",
+		},
+		{
+			name: "happy path load redis authenticates, and reads the hash",
+			setup: func(t *testing.T) ([]string, map[string]string, func(*testing.T, string)) {
+				t.Helper()
+
+				listener, err := net.Listen("tcp", "127.0.0.1:0")
+				require.NoError(t, err)
+				t.Cleanup(func() { listener.Close() })
+
+				// Replies, in order, to AUTH, and HGETALL.
+				go func() {
+					c, err := listener.Accept()
+					if err != nil {
+						return
+					}
+
+					defer c.Close()
+
+					reader := bufio.NewReader(c)
+
+					for _, reply := range []string{"+OK\r\n", "*2\r\n$12\r\nREDIS_SECRET\r\n$12\r\nredis-secret\r\n"} {
+						line, err := reader.ReadString('\n')
+						if err != nil {
+							return
+						}
+
+						count, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+
+						for range 2 * count {
+							if _, err := reader.ReadString('\n'); err != nil {
+								return
+							}
+						}
+
+						_, _ = c.Write([]byte(reply))
+					}
+				}()
+
+				return []string{
+					"--flush-interval=1ms",
+					"load",
+					"redis",
+					"--hash", "config:prod",
+					"--",
+					"/bin/sh",
+					"-c",
+					`printf "[%s]" "$REDIS_SECRET"`,
+				}, map[string]string{"REDIS_URL": "redis://app:s3cr3t@" + listener.Addr().String()}, nil
+			},
+			wantOutput: "[redis-secret]",
+		},
+		{
+			name: "happy path load gitlab reads the project variables of the environment",
+			setup: func(t *testing.T) ([]string, map[string]string, func(*testing.T, string)) {
+				t.Helper()
+
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if r.URL.EscapedPath() != "/api/v4/projects/group%2Fproject/variables" || r.Header.Get("PRIVATE-TOKEN") != "glpat-token" {
+						w.WriteHeader(http.StatusUnauthorized)
+
+						return
+					}
+
+					_, _ = w.Write([]byte(`[{"key": "GITLAB_SECRET", "value": "all", "environment_scope": "*"}, {"key": "GITLAB_SECRET", "value": "gitlab-secret", "environment_scope": "production"}]`))
+				}))
+				t.Cleanup(server.Close)
+
+				return []string{
+					"--flush-interval=1ms",
+					"load",
+					"gitlab",
+					"--environment-scope", "production",
+					"--",
+					"/bin/sh",
+					"-c",
+					`printf "[%s]" "$GITLAB_SECRET"`,
+				}, map[string]string{"GITLAB_URL": server.URL, "GITLAB_TOKEN": "glpat-token", "GITLAB_PROJECT": "group/project"}, nil
+			},
+			wantOutput: "[gitlab-secret]",
+		},
+		{
+			name: "happy path load infisical logs in, and reads the folder",
+			setup: func(t *testing.T) ([]string, map[string]string, func(*testing.T, string)) {
+				t.Helper()
+
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					switch {
+					case r.URL.Path == "/api/v1/auth/universal-auth/login":
+						_, _ = w.Write([]byte(`{"accessToken": "access-token", "expiresIn": 7200}`))
+					case r.URL.Path == "/api/v3/secrets/raw" && r.Header.Get("Authorization") == "Bearer access-token" && r.URL.Query().Get("secretPath") == "/backend":
+						_, _ = w.Write([]byte(`{"secrets": [{"secretKey": "INFISICAL_SECRET", "secretValue": "infisical-secret"}]}`))
+					default:
+						w.WriteHeader(http.StatusUnauthorized)
+					}
+				}))
+				t.Cleanup(server.Close)
+
+				return []string{
+					"--flush-interval=1ms",
+					"load",
+					"infisical",
+					"--path", "/backend",
+					"--",
+					"/bin/sh",
+					"-c",
+					`printf "[%s]" "$INFISICAL_SECRET"`,
+				}, map[string]string{
+					"INFISICAL_API_URL":                      server.URL + "/api",
+					"INFISICAL_UNIVERSAL_AUTH_CLIENT_ID":     "client-id",
+					"INFISICAL_UNIVERSAL_AUTH_CLIENT_SECRET": "client-secret",
+					"INFISICAL_PROJECT_ID":                   "p1",
+					"INFISICAL_ENVIRONMENT":                  "prod",
+				}, nil
+			},
+			wantOutput: "[infisical-secret]",
+		},
+		{
+			name: "happy path write files splits keys into directories",
+			setup: func(t *testing.T) ([]string, map[string]string, func(*testing.T, string)) {
+				t.Helper()
+
+				sourceFile := filepath.Join(t.TempDir(), "source.env")
+				targetDir := filepath.Join(t.TempDir(), "secrets")
+				require.NoError(t, os.WriteFile(sourceFile, []byte("DB__PASSWORD=written\n"), 0o600))
+
+				args := []string{
+					"write",
+					"--source", sourceFile,
+					"files",
+					"--target", targetDir,
+					"--recursive",
+				}
+
+				verify := func(t *testing.T, _ string) {
+					t.Helper()
+
+					written, err := os.ReadFile(filepath.Join(targetDir, "DB", "PASSWORD"))
+					require.NoError(t, err)
+					assert.Equal(t, "written", string(written))
+				}
+
+				return args, nil, verify
+			},
+		},
 		{
 			name: "bad path write dotenv missing source",
 			setup: func(t *testing.T) ([]string, map[string]string, func(*testing.T, string)) {
@@ -1277,6 +1865,79 @@ func TestExecuteWrapperErrorPath(t *testing.T) {
 	assertProcessExitCode(t, 1, err, output)
 }
 
+func TestCLIPluginsAreRegistered(t *testing.T) {
+	dir := t.TempDir()
+
+	// Answers load when the tenant setting is sent, and records writes.
+	script := `#!/bin/sh
+read -r request
+case "$request" in
+*'"method":"write"'*)
+	printf '%s' "$request" > "$(dirname "$0")/written.json"
+	echo '{"jsonrpc":"2.0","id":1,"result":null}' ;;
+*'"tenant":"app"'*)
+	echo '{"jsonrpc":"2.0","id":1,"result":{"values":{"PLUGIN_CLI_SECRET":"plugin-secret"}}}' ;;
+*)
+	echo '{"jsonrpc":"2.0","id":1,"error":{"code":-32603,"message":"unknown tenant"}}' ;;
+esac
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, plugin.Prefix+"acme"), []byte(script), 0o700))
+
+	// Built-in providers have precedence.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, plugin.Prefix+"dotenv"), []byte("#!/bin/sh\necho shadowed\n"), 0o700))
+
+	environment := map[string]string{"PATH": dir + string(os.PathListSeparator) + os.Getenv("PATH")}
+
+	output, err := runCLIHelper(t, t.TempDir(), environment, true,
+		"--flush-interval=1ms",
+		"load",
+		"acme",
+		"--setting", "tenant=app",
+		"--",
+		"/bin/sh",
+		"-c",
+		`printf "[%s]" "$PLUGIN_CLI_SECRET"`,
+	)
+
+	assertProcessExitCode(t, 0, err, output)
+	assert.Contains(t, output, "[plugin-secret]")
+
+	output, err = runCLIHelper(t, t.TempDir(), environment, true, "load", "acme")
+
+	assertProcessExitCode(t, 1, err, output)
+	assert.Contains(t, output, "unknown tenant")
+
+	sourceFile := filepath.Join(t.TempDir(), "source.env")
+	require.NoError(t, os.WriteFile(sourceFile, []byte("PLUGIN_CLI_WRITTEN=1\n"), 0o600))
+
+	output, err = runCLIHelper(t, t.TempDir(), environment, true, "write", "--source", sourceFile, "acme", "--target", "prod")
+
+	assertProcessExitCode(t, 0, err, output)
+
+	written, err := os.ReadFile(filepath.Join(dir, "written.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(written), `"target":"prod"`)
+	assert.Contains(t, string(written), `"PLUGIN_CLI_WRITTEN":"1"`)
+
+	dotEnvFile := filepath.Join(t.TempDir(), ".env")
+	require.NoError(t, os.WriteFile(dotEnvFile, []byte("PLUGIN_CLI_BUILTIN=builtin\n"), 0o600))
+
+	output, err = runCLIHelper(t, t.TempDir(), environment, true,
+		"--flush-interval=1ms",
+		"load",
+		"dotenv",
+		"--files", dotEnvFile,
+		"--",
+		"/bin/sh",
+		"-c",
+		`printf "[%s]" "$PLUGIN_CLI_BUILTIN"`,
+	)
+
+	assertProcessExitCode(t, 0, err, output)
+	assert.Contains(t, output, "[builtin]")
+	assert.NotContains(t, output, "shadowed")
+}
+
 //////
 // Parent command validation.
 //////
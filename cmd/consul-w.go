@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/thalesfsp/configurer/util"
+)
+
+// consulWCmd represents the Consul KV write command.
+var consulWCmd = &cobra.Command{
+	Short:   "Consul KV provider",
+	Use:     "consul",
+	Example: "  configurer w --source prod.env consul --address https://consul.internal:8500 --prefix app/prod",
+	Long: `Consul KV provider will write secrets to HashiCorp Consul KV, with
+transactions. With "--prefix", each secret is a key, the separator replaced
+by "/". With "--key", secrets are a JSON, or YAML ("--format yaml")
+document.
+
+NOTE: Secrets are written atomically, with a single transaction. Consul
+      limits transactions to 64 operations, so "--prefix" can't write more
+      than 64 secrets, nothing is written. Use "--key" instead.
+
+The following environment variables can configure the provider:
+- CONSUL_HTTP_ADDR: The address of the Consul agent.
+- CONSUL_HTTP_TOKEN: The ACL token.
+- CONSUL_DATACENTER: The datacenter.
+- CONSUL_NAMESPACE: The namespace (Consul Enterprise).
+- CONSUL_PREFIX: The key prefix.
+- CONSUL_KEY: The key of the document.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Context with timeout.
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		f, err := os.Open(sourceFilename)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		parsedFile, err := util.ParseSource(ctx, f, parseOptions()...)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		config, err := consulConfig(cmd)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		consulProvider, err := newConsulProvider(false, false, config)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		if err := consulProvider.Write(ctx, parsedFile); err != nil {
+			log.Fatalln(err)
+		}
+
+		os.Exit(0)
+	},
+}
+
+func init() {
+	writeCmd.AddCommand(consulWCmd)
+
+	addConsulFlags(consulWCmd)
+
+	consulWCmd.SetUsageTemplate(providerUsageTemplate)
+}
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/thalesfsp/configurer/consul"
+	"github.com/thalesfsp/configurer/option"
+	"github.com/thalesfsp/configurer/parser"
+)
+
+var newConsulProvider = consul.New
+
+// consulCmd represents the Consul KV load command.
+var consulCmd = &cobra.Command{
+	Short:   "Consul KV provider",
+	Use:     "consul",
+	Example: "  configurer l consul --address https://consul.internal:8500 --prefix app/prod -- env",
+	Long: `Consul KV provider will load values from HashiCorp Consul KV, export them
+to the environment, and then run, if any, the specified command.
+
+With "--prefix", keys are read recursively, one value per leaf, "/" replaced
+by "--separator", e.g.: "app/prod/db/host" -> "db__host". With "--key", a
+single key holds a document, parsed per "--format". Use "--flatten" to
+flatten its nested values, otherwise they're JSON-encoded.
+
+The following environment variables can configure the provider:
+- CONSUL_HTTP_ADDR: The address of the Consul agent.
+- CONSUL_HTTP_TOKEN: The ACL token.
+- CONSUL_DATACENTER: The datacenter.
+- CONSUL_NAMESPACE: The namespace (Consul Enterprise).
+- CONSUL_PREFIX: The key prefix.
+- CONSUL_KEY: The key of the document.
+
+NOTE: Already exported environment variables have precedence over loaded
+      ones. Set the override flag to true to override them.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		shouldOverride := cmd.Flag("override").Value.String() == "true"
+		rawValue := cmd.Flag("rawValue").Value.String() == "true"
+
+		//////
+		// Build config.
+		//////
+
+		config, err := consulConfig(cmd)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		config.ParseOptions = parseOptions()
+
+		consulProvider, err := newConsulProvider(shouldOverride, rawValue, config)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		var options []option.LoadKeyFunc
+
+		if keyCaserOptions != "" {
+			options = append(options, option.WithKeyCaser(keyCaserOptions))
+		}
+
+		if keyPrefixerOptions != "" {
+			options = append(options, option.WithKeyPrefixer(keyPrefixerOptions))
+		}
+
+		if keySuffixerOptions != "" {
+			options = append(options, option.WithKeySuffixer(keySuffixerOptions))
+		}
+
+		finalValues, err := consulProvider.Load(context.Background(), options...)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		if dumpFilename != "" {
+			if err := dumpToFile(dumpFilename, finalValues, rawValue); err != nil {
+				log.Fatalln(err)
+			}
+		}
+
+		ConcurrentRunner(consulProvider, commands, args)
+	},
+}
+
+// consulConfig builds the settings shared by the load, and write commands.
+func consulConfig(cmd *cobra.Command) (*consul.Config, error) {
+	timeout, err := cmd.Flags().GetDuration("timeout")
+	if err != nil {
+		return nil, err
+	}
+
+	return &consul.Config{
+		Address:    cmd.Flag("address").Value.String(),
+		Token:      cmd.Flag("token").Value.String(),
+		Datacenter: cmd.Flag("datacenter").Value.String(),
+		Namespace:  cmd.Flag("namespace").Value.String(),
+		Prefix:     cmd.Flag("prefix").Value.String(),
+		Key:        cmd.Flag("key").Value.String(),
+		Format:     cmd.Flag("format").Value.String(),
+		Separator:  cmd.Flag("separator").Value.String(),
+		Timeout:    timeout,
+	}, nil
+}
+
+// addConsulFlags adds the flags shared by the load, and write commands.
+func addConsulFlags(cmd *cobra.Command) {
+	address := consul.DefaultAddress
+	if value := os.Getenv("CONSUL_HTTP_ADDR"); value != "" {
+		address = value
+	}
+
+	// Connection.
+	cmd.Flags().StringP("address", "a", address, "Address of the Consul agent")
+	cmd.Flags().String("token", os.Getenv("CONSUL_HTTP_TOKEN"), "ACL token")
+	cmd.Flags().String("datacenter", os.Getenv("CONSUL_DATACENTER"), "Datacenter. Defaults to the agent's one")
+	cmd.Flags().StringP("namespace", "n", os.Getenv("CONSUL_NAMESPACE"), "Namespace (Consul Enterprise)")
+	cmd.Flags().Duration("timeout", consul.DefaultTimeout, "The request timeout")
+
+	// Keys.
+	cmd.Flags().StringP("prefix", "p", os.Getenv("CONSUL_PREFIX"), "Key prefix, read recursively")
+	cmd.Flags().String("key", os.Getenv("CONSUL_KEY"), "Key of a document, e.g.: a JSON, or YAML blob")
+	cmd.Flags().String("format", parser.Auto, "Format of the document. Available: "+strings.Join(parser.Formats(), ", ")+", "+parser.Auto)
+	cmd.Flags().String("separator", option.DefaultSeparator, "Separator replacing / in keys under the prefix")
+}
+
+func init() {
+	loadCmd.AddCommand(consulCmd)
+
+	addConsulFlags(consulCmd)
+
+	consulCmd.SetUsageTemplate(providerUsageTemplate)
+}
@@ -36,7 +36,7 @@ Project and config are optional when using a Doppler service token.`,
 
 		defer file.Close()
 
-		values, err := util.ParseFile(ctx, file)
+		values, err := util.ParseSource(ctx, file, parseOptions()...)
 		if err != nil {
 			log.Fatalln(err)
 		}
@@ -70,14 +70,7 @@ NOTE: Already exported environment variables have precedence over loaded
 		}
 
 		if dumpFilename != "" {
-			file, err := os.Create(dumpFilename)
-			if err != nil {
-				log.Fatalln(err)
-			}
-
-			defer file.Close()
-
-			if err := DumpToFile(file, finalValues, rawValue); err != nil {
+			if err := dumpToFile(dumpFilename, finalValues, rawValue); err != nil {
 				log.Fatalln(err)
 			}
 		}
@@ -30,7 +30,7 @@ var dotEnvWCmd = &cobra.Command{
 			log.Fatalln(err)
 		}
 
-		parsedFile, err := util.ParseFile(ctx, f)
+		parsedFile, err := util.ParseSource(ctx, f, parseOptions()...)
 		if err != nil {
 			log.Fatalln(err)
 		}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/thalesfsp/configurer/encfile"
+	"github.com/thalesfsp/configurer/util"
+)
+
+// encFileWCmd represents the encrypted file write command.
+var encFileWCmd = &cobra.Command{
+	Aliases: []string{"enc"},
+	Short:   "Encrypted file provider",
+	Use:     "encfile",
+	Example: "  configurer w --source prod.env encfile --recipient <public key> --target .env.enc",
+	Long: `Encrypted file provider will encrypt secrets to a recipient, and write
+them to a file. Keys stay readable, values are sealed. The format is the one of
+the extension before ".enc", e.g.: "secrets.json.enc" is JSON, ".env.enc" is
+".env" formatted.
+
+The following environment variables can configure the provider:
+- CONFIGURER_RECIPIENT: Recipient (public key) to encrypt to.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Context with timeout.
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		f, err := os.Open(sourceFilename)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		parsedFile, err := util.ParseSource(ctx, f, parseOptions()...)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		config := &encfile.Config{
+			FilePaths: []string{cmd.Flag("target").Value.String()},
+			Recipient: cmd.Flag("recipient").Value.String(),
+		}
+
+		encFileProvider, err := newEncFileProvider(false, false, config)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		if err := encFileProvider.Write(ctx, parsedFile); err != nil {
+			log.Fatalln(err)
+		}
+
+		os.Exit(0)
+	},
+}
+
+func init() {
+	writeCmd.AddCommand(encFileWCmd)
+
+	encFileWCmd.Flags().StringP("target", "t", ".env.enc", "The encrypted file to write")
+	encFileWCmd.Flags().StringP("recipient", "r", os.Getenv("CONFIGURER_RECIPIENT"), "Recipient (public key) to encrypt to")
+
+	encFileWCmd.SetUsageTemplate(providerUsageTemplate)
+}
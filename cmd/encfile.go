@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/thalesfsp/configurer/encfile"
+	"github.com/thalesfsp/configurer/option"
+	"github.com/thalesfsp/customerror"
+)
+
+var newEncFileProvider = encfile.New
+
+// encFileCmd represents the encrypted file load command.
+var encFileCmd = &cobra.Command{
+	Aliases: []string{"enc"},
+	Short:   "Encrypted file provider",
+	Use:     "encfile",
+	Example: "  configurer l encfile -f .env.enc --private-key-file key.txt -- env",
+	Long: `Encrypted file provider will load secrets from files, e.g.: ".env.enc",
+where values are encrypted, decrypt them, export them to the environment,
+and then run, if any, the specified command.
+
+Create files with "--dump-encrypt-to <recipient>", or "configurer w encfile".
+Generate a key pair with "configurer keygen".
+
+The following environment variables can configure the provider:
+- CONFIGURER_PRIVATE_KEY: Private key.
+- CONFIGURER_PRIVATE_KEY_FILE: File containing the private key.
+
+NOTE: Already exported environment variables have precedence over loaded
+      ones. Set the override flag to true to override them.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		shouldOverride := cmd.Flag("override").Value.String() == "true"
+		rawValue := cmd.Flag("rawValue").Value.String() == "true"
+
+		//////
+		// Build config.
+		//////
+
+		privateKey, err := readPrivateKey(
+			cmd.Flag("private-key").Value.String(),
+			cmd.Flag("private-key-file").Value.String(),
+		)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		files, err := cmd.Flags().GetStringSlice("files")
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		config := &encfile.Config{
+			FilePaths:  files,
+			PrivateKey: privateKey,
+		}
+
+		encFileProvider, err := newEncFileProvider(shouldOverride, rawValue, config)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		var options []option.LoadKeyFunc
+
+		if keyCaserOptions != "" {
+			options = append(options, option.WithKeyCaser(keyCaserOptions))
+		}
+
+		if keyPrefixerOptions != "" {
+			options = append(options, option.WithKeyPrefixer(keyPrefixerOptions))
+		}
+
+		if keySuffixerOptions != "" {
+			options = append(options, option.WithKeySuffixer(keySuffixerOptions))
+		}
+
+		finalValues, err := encFileProvider.Load(context.Background(), options...)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		if dumpFilename != "" {
+			if err := dumpToFile(dumpFilename, finalValues, rawValue); err != nil {
+				log.Fatalln(err)
+			}
+		}
+
+		ConcurrentRunner(encFileProvider, commands, args)
+	},
+}
+
+// readPrivateKey returns `privateKey`, or the first line of `privateKeyFile`
+// which isn't blank, or a `#` comment, like age identity files. The output of
+// `configurer keygen` is a valid key file.
+func readPrivateKey(privateKey, privateKeyFile string) (string, error) {
+	if privateKey != "" || privateKeyFile == "" {
+		return privateKey, nil
+	}
+
+	content, err := os.ReadFile(privateKeyFile)
+	if err != nil {
+		return "", err
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return line, nil
+		}
+	}
+
+	return "", customerror.NewRequiredError("private key in " + privateKeyFile)
+}
+
+func init() {
+	loadCmd.AddCommand(encFileCmd)
+
+	encFileCmd.Flags().StringSliceP("files", "f", []string{".env.enc"}, "The encrypted files to load")
+	encFileCmd.Flags().String("private-key", os.Getenv("CONFIGURER_PRIVATE_KEY"), "Private key")
+	encFileCmd.Flags().String("private-key-file", os.Getenv("CONFIGURER_PRIVATE_KEY_FILE"), "File containing the private key")
+
+	encFileCmd.SetUsageTemplate(providerUsageTemplate)
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/thalesfsp/configurer/util"
+)
+
+// etcdWCmd represents the etcd write command.
+var etcdWCmd = &cobra.Command{
+	Short:   "etcd provider",
+	Use:     "etcd",
+	Example: "  configurer w --source prod.env etcd --endpoints https://etcd.internal:2379 --prefix /app/prod",
+	Long: `etcd provider will write secrets under a prefix to etcd v3, over its JSON
+gateway, in a single transaction, so they land atomically. The separator is
+replaced by "/", e.g.: "db__host" -> "/app/prod/db/host".
+
+The following environment variables can configure the provider:
+- ETCDCTL_ENDPOINTS: Comma-separated etcd endpoints.
+- ETCDCTL_USER: The username, or "username:password".
+- ETCDCTL_PASSWORD: The password.
+- ETCDCTL_CACERT: The CA certificates file.
+- ETCDCTL_CERT: The client certificate file.
+- ETCDCTL_KEY: The client key file.
+- ETCD_PREFIX: The key prefix.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Context with timeout.
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		f, err := os.Open(sourceFilename)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		parsedFile, err := util.ParseSource(ctx, f, parseOptions()...)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		config, err := etcdConfig(cmd)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		etcdProvider, err := newEtcdProvider(false, false, config)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		if err := etcdProvider.Write(ctx, parsedFile); err != nil {
+			log.Fatalln(err)
+		}
+
+		os.Exit(0)
+	},
+}
+
+func init() {
+	writeCmd.AddCommand(etcdWCmd)
+
+	addEtcdFlags(etcdWCmd)
+
+	etcdWCmd.SetUsageTemplate(providerUsageTemplate)
+}
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/thalesfsp/configurer/etcd"
+	"github.com/thalesfsp/configurer/option"
+)
+
+var newEtcdProvider = etcd.New
+
+// etcdCmd represents the etcd load command.
+var etcdCmd = &cobra.Command{
+	Short:   "etcd provider",
+	Use:     "etcd",
+	Example: "  configurer l etcd --endpoints https://etcd.internal:2379 --prefix /app/prod -- env",
+	Long: `etcd provider will load the keys under a prefix from etcd v3, over its JSON
+gateway, export them to the environment, and then run, if any, the specified
+command.
+
+Keys are mapped without the prefix, "/" replaced by "--separator", e.g.:
+"/app/prod/db/host" -> "db__host". "--revision" pins the load to a revision.
+
+It supports the following authentication methods:
+- Username, and password ("--user name:password", or "--password")
+- TLS client certificate
+
+The following environment variables can configure the provider:
+- ETCDCTL_ENDPOINTS: Comma-separated etcd endpoints.
+- ETCDCTL_USER: The username, or "username:password".
+- ETCDCTL_PASSWORD: The password.
+- ETCDCTL_CACERT: The CA certificates file.
+- ETCDCTL_CERT: The client certificate file.
+- ETCDCTL_KEY: The client key file.
+- ETCD_PREFIX: The key prefix.
+
+NOTE: Already exported environment variables have precedence over loaded
+      ones. Set the override flag to true to override them.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		shouldOverride := cmd.Flag("override").Value.String() == "true"
+		rawValue := cmd.Flag("rawValue").Value.String() == "true"
+
+		//////
+		// Build config.
+		//////
+
+		config, err := etcdConfig(cmd)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		config.Revision, err = cmd.Flags().GetInt64("revision")
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		etcdProvider, err := newEtcdProvider(shouldOverride, rawValue, config)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		var options []option.LoadKeyFunc
+
+		if keyCaserOptions != "" {
+			options = append(options, option.WithKeyCaser(keyCaserOptions))
+		}
+
+		if keyPrefixerOptions != "" {
+			options = append(options, option.WithKeyPrefixer(keyPrefixerOptions))
+		}
+
+		if keySuffixerOptions != "" {
+			options = append(options, option.WithKeySuffixer(keySuffixerOptions))
+		}
+
+		finalValues, err := etcdProvider.Load(context.Background(), options...)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		if dumpFilename != "" {
+			if err := dumpToFile(dumpFilename, finalValues, rawValue); err != nil {
+				log.Fatalln(err)
+			}
+		}
+
+		ConcurrentRunner(etcdProvider, commands, args)
+	},
+}
+
+// etcdConfig builds the settings shared by the load, and write commands.
+func etcdConfig(cmd *cobra.Command) (*etcd.Config, error) {
+	endpoints, err := cmd.Flags().GetStringSlice("endpoints")
+	if err != nil {
+		return nil, err
+	}
+
+	timeout, err := cmd.Flags().GetDuration("timeout")
+	if err != nil {
+		return nil, err
+	}
+
+	// Like etcdctl, the user can be `name:password`.
+	username, password, found := strings.Cut(cmd.Flag("user").Value.String(), ":")
+	if !found {
+		password = cmd.Flag("password").Value.String()
+	}
+
+	return &etcd.Config{
+		Endpoints: endpoints,
+		Username:  username,
+		Password:  password,
+		CAFile:    cmd.Flag("cacert").Value.String(),
+		CertFile:  cmd.Flag("cert").Value.String(),
+		KeyFile:   cmd.Flag("key").Value.String(),
+		Prefix:    cmd.Flag("prefix").Value.String(),
+		Separator: cmd.Flag("separator").Value.String(),
+		Timeout:   timeout,
+	}, nil
+}
+
+// addEtcdFlags adds the flags shared by the load, and write commands.
+func addEtcdFlags(cmd *cobra.Command) {
+	endpoints := []string{etcd.DefaultEndpoint}
+	if value := os.Getenv("ETCDCTL_ENDPOINTS"); value != "" {
+		endpoints = strings.Split(value, ",")
+	}
+
+	// Connection.
+	cmd.Flags().StringSlice("endpoints", endpoints, "etcd endpoints, tried in order")
+	cmd.Flags().Duration("timeout", etcd.DefaultTimeout, "The request timeout")
+
+	// Auth.
+	cmd.Flags().String("user", os.Getenv("ETCDCTL_USER"), "Username, or username:password")
+	cmd.Flags().String("password", os.Getenv("ETCDCTL_PASSWORD"), "Password")
+	cmd.Flags().String("cacert", os.Getenv("ETCDCTL_CACERT"), "CA certificates file, in addition to the system ones")
+	cmd.Flags().String("cert", os.Getenv("ETCDCTL_CERT"), "Client certificate file")
+	cmd.Flags().String("key", os.Getenv("ETCDCTL_KEY"), "Client key file")
+
+	// Keys.
+	cmd.Flags().StringP("prefix", "p", os.Getenv("ETCD_PREFIX"), "Key prefix, e.g.: /app/prod")
+	cmd.Flags().String("separator", option.DefaultSeparator, "Separator replacing / in keys")
+}
+
+func init() {
+	loadCmd.AddCommand(etcdCmd)
+
+	addEtcdFlags(etcdCmd)
+
+	etcdCmd.Flags().Int64("revision", 0, "Revision to load. Defaults to the latest")
+
+	etcdCmd.SetUsageTemplate(providerUsageTemplate)
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/thalesfsp/configurer/exec"
+	"github.com/thalesfsp/configurer/option"
+	"github.com/thalesfsp/configurer/parser"
+)
+
+var newExecProvider = exec.New
+
+// execCmd represents the exec load command.
+var execCmd = &cobra.Command{
+	Short:   "Exec provider",
+	Use:     "exec",
+	Example: "  configurer l exec --command 'gcloud auth print-access-token' --format raw --key GCP_TOKEN -- env",
+	Long: `Exec provider will run a command, parse its output, export the values to
+the environment, and then run, if any, the specified command.
+
+The command is run with "/bin/sh -c", and fails the load if it exits with a
+non-zero code, or times out. Its output is parsed per "--format", detected
+with "auto", or, with "raw", exported as is, with one trailing newline
+removed, under "--key". Use "--flatten" to flatten nested values, otherwise
+they're JSON-encoded.
+
+The following environment variables can configure the provider:
+- EXEC_COMMAND: The command to run.
+- EXEC_FORMAT: The output format.
+- EXEC_KEY: The key of the raw output.
+
+NOTE: Already exported environment variables have precedence over loaded
+      ones. Set the override flag to true to override them.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		shouldOverride := cmd.Flag("override").Value.String() == "true"
+		rawValue := cmd.Flag("rawValue").Value.String() == "true"
+
+		//////
+		// Build config.
+		//////
+
+		timeout, err := cmd.Flags().GetDuration("timeout")
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		var command []string
+
+		if value := cmd.Flag("command").Value.String(); value != "" {
+			command = []string{"/bin/sh", "-c", value}
+		}
+
+		config := &exec.Config{
+			Command:      command,
+			Dir:          cmd.Flag("dir").Value.String(),
+			Format:       cmd.Flag("format").Value.String(),
+			Key:          cmd.Flag("key").Value.String(),
+			ParseOptions: parseOptions(),
+			Timeout:      timeout,
+		}
+
+		execProvider, err := newExecProvider(shouldOverride, rawValue, config)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		var options []option.LoadKeyFunc
+
+		if keyCaserOptions != "" {
+			options = append(options, option.WithKeyCaser(keyCaserOptions))
+		}
+
+		if keyPrefixerOptions != "" {
+			options = append(options, option.WithKeyPrefixer(keyPrefixerOptions))
+		}
+
+		if keySuffixerOptions != "" {
+			options = append(options, option.WithKeySuffixer(keySuffixerOptions))
+		}
+
+		finalValues, err := execProvider.Load(context.Background(), options...)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		if dumpFilename != "" {
+			if err := dumpToFile(dumpFilename, finalValues, rawValue); err != nil {
+				log.Fatalln(err)
+			}
+		}
+
+		ConcurrentRunner(execProvider, commands, args)
+	},
+}
+
+func init() {
+	loadCmd.AddCommand(execCmd)
+
+	format := parser.Auto
+	if value := os.Getenv("EXEC_FORMAT"); value != "" {
+		format = value
+	}
+
+	execCmd.Flags().String("command", os.Getenv("EXEC_COMMAND"), "The command to run")
+	execCmd.Flags().String("format", format, "Output format. Available: "+strings.Join(parser.Formats(), ", ")+", "+parser.Auto+", "+exec.FormatRaw)
+	execCmd.Flags().String("key", os.Getenv("EXEC_KEY"), "The key of the raw output")
+	execCmd.Flags().Duration("timeout", exec.DefaultTimeout, "The command timeout")
+	execCmd.Flags().String("dir", "", "The command working directory")
+
+	execCmd.SetUsageTemplate(providerUsageTemplate)
+}
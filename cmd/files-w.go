@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/thalesfsp/configurer/files"
+	"github.com/thalesfsp/configurer/option"
+	"github.com/thalesfsp/configurer/util"
+)
+
+// filesWCmd represents the directory of files write command.
+var filesWCmd = &cobra.Command{
+	Short:   "Directory of files provider",
+	Use:     "files",
+	Example: "  configurer w --source prod.env files --target ./secrets",
+	Long: `Directory of files provider will write each secret to a file named after
+its key, only readable by the owner, e.g.: to build a Docker secrets, or
+systemd credentials directory. With "--recursive", keys are split by the
+separator into subdirectories, e.g.: "db__password" -> "db/password".
+
+The following environment variables can configure the provider:
+- FILES_DIRECTORY: The directory to write.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Context with timeout.
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		f, err := os.Open(sourceFilename)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		parsedFile, err := util.ParseSource(ctx, f, parseOptions()...)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		config := &files.Config{
+			Directory: cmd.Flag("target").Value.String(),
+			Recursive: cmd.Flag("recursive").Value.String() == "true",
+			Separator: cmd.Flag("separator").Value.String(),
+		}
+
+		filesProvider, err := newFilesProvider(false, false, config)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		if err := filesProvider.Write(ctx, parsedFile); err != nil {
+			log.Fatalln(err)
+		}
+
+		os.Exit(0)
+	},
+}
+
+func init() {
+	writeCmd.AddCommand(filesWCmd)
+
+	target := "secrets"
+	if value := os.Getenv("FILES_DIRECTORY"); value != "" {
+		target = value
+	}
+
+	filesWCmd.Flags().StringP("target", "t", target, "The directory to write")
+	filesWCmd.Flags().BoolP("recursive", "r", false, "Split keys by the separator into subdirectories")
+	filesWCmd.Flags().String("separator", option.DefaultSeparator, "Separator splitting keys into subdirectories")
+
+	filesWCmd.SetUsageTemplate(providerUsageTemplate)
+}
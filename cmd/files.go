@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/thalesfsp/configurer/files"
+	"github.com/thalesfsp/configurer/option"
+)
+
+var newFilesProvider = files.New
+
+// filesCmd represents the directory of files load command.
+var filesCmd = &cobra.Command{
+	Short:   "Directory of files provider",
+	Use:     "files",
+	Example: "  configurer l files --directory /run/secrets --exclude '*.pem' -- env",
+	Long: `Directory of files provider will load secrets from a directory with one
+file per key, e.g.: Docker, and Swarm secrets ("/run/secrets"), systemd
+credentials ("$CREDENTIALS_DIRECTORY", see "LoadCredential="), or a mounted
+Kubernetes Secret, export them to the environment, and then run, if any, the
+specified command.
+
+File names are the keys, one trailing newline is removed from the content.
+With "--recursive", subdirectories are loaded too, and keys are relative paths
+joined with the separator, e.g.: "db/password" -> "db__password". Globs
+without "/" match the file name at any depth. Binary files are base64 encoded,
+skipped, or fail the load, per "--binary". Hidden files, and directories are
+skipped.
+
+The following environment variables can configure the provider:
+- FILES_DIRECTORY: The directory. Defaults to CREDENTIALS_DIRECTORY, if set,
+  or "/run/secrets".
+- FILES_INCLUDE: Globs of files to load, comma-separated.
+- FILES_EXCLUDE: Globs of files to skip, comma-separated.
+
+NOTE: Already exported environment variables have precedence over loaded
+      ones. Set the override flag to true to override them.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		shouldOverride := cmd.Flag("override").Value.String() == "true"
+		rawValue := cmd.Flag("rawValue").Value.String() == "true"
+
+		//////
+		// Build config.
+		//////
+
+		include, err := cmd.Flags().GetStringSlice("include")
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		exclude, err := cmd.Flags().GetStringSlice("exclude")
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		maxSize, err := cmd.Flags().GetInt64("max-size")
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		config := &files.Config{
+			Binary:    cmd.Flag("binary").Value.String(),
+			Directory: cmd.Flag("directory").Value.String(),
+			Exclude:   exclude,
+			Include:   include,
+			MaxSize:   maxSize,
+			Recursive: cmd.Flag("recursive").Value.String() == "true",
+			Separator: cmd.Flag("separator").Value.String(),
+		}
+
+		filesProvider, err := newFilesProvider(shouldOverride, rawValue, config)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		var options []option.LoadKeyFunc
+
+		if keyCaserOptions != "" {
+			options = append(options, option.WithKeyCaser(keyCaserOptions))
+		}
+
+		if keyPrefixerOptions != "" {
+			options = append(options, option.WithKeyPrefixer(keyPrefixerOptions))
+		}
+
+		if keySuffixerOptions != "" {
+			options = append(options, option.WithKeySuffixer(keySuffixerOptions))
+		}
+
+		finalValues, err := filesProvider.Load(context.Background(), options...)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		if dumpFilename != "" {
+			if err := dumpToFile(dumpFilename, finalValues, rawValue); err != nil {
+				log.Fatalln(err)
+			}
+		}
+
+		ConcurrentRunner(filesProvider, commands, args)
+	},
+}
+
+func init() {
+	loadCmd.AddCommand(filesCmd)
+
+	var include []string
+	if value := os.Getenv("FILES_INCLUDE"); value != "" {
+		include = strings.Split(value, ",")
+	}
+
+	var exclude []string
+	if value := os.Getenv("FILES_EXCLUDE"); value != "" {
+		exclude = strings.Split(value, ",")
+	}
+
+	filesCmd.Flags().String("directory", os.Getenv("FILES_DIRECTORY"), "The directory to load, defaults to $CREDENTIALS_DIRECTORY, or /run/secrets")
+	filesCmd.Flags().BoolP("recursive", "r", false, "Load subdirectories, keys are relative paths joined with the separator")
+	filesCmd.Flags().String("separator", option.DefaultSeparator, "Separator joining relative paths into keys")
+	filesCmd.Flags().StringSlice("include", include, "Globs of files to load, e.g.: db/*")
+	filesCmd.Flags().StringSlice("exclude", exclude, "Globs of files to skip, e.g.: *.pem")
+	filesCmd.Flags().Int64("max-size", files.DefaultMaxSize, "Maximum file size in bytes, larger files fail the load")
+	filesCmd.Flags().String("binary", files.BinaryBase64, "What to do with binary files. Available: base64, skip, error")
+
+	filesCmd.SetUsageTemplate(providerUsageTemplate)
+}
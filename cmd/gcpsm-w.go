@@ -40,7 +40,7 @@ secret does not exist, it is created with automatic replication.`,
 		}
 		defer file.Close()
 
-		parsedFile, err := util.ParseFile(ctx, file)
+		parsedFile, err := util.ParseSource(ctx, file, parseOptions()...)
 		if err != nil {
 			log.Fatalln(err)
 		}
@@ -55,7 +55,7 @@ to read your public key.
 			log.Fatalln(err)
 		}
 
-		parsedFile, err := util.ParseFile(ctx, f)
+		parsedFile, err := util.ParseSource(ctx, f, parseOptions()...)
 		if err != nil {
 			log.Fatalln(err)
 		}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/thalesfsp/configurer/gitlab"
+	"github.com/thalesfsp/configurer/util"
+)
+
+// gitlabWCmd represents the gitlab write command.
+var gitlabWCmd = &cobra.Command{
+	Short:   "GitLab provider",
+	Use:     "gitlab",
+	Example: "  configurer w --source prod.env gitlab --project group/project --environment-scope production --masked --protected",
+	Long: `GitLab provider will create, or update CI/CD variables of a project
+("--project"), a group ("--group"), or the instance ("--instance"), in an
+environment scope ("--environment-scope"), with the "--masked", "--protected",
+"--raw" flags, and "--variable-type".
+
+The following environment variables can configure the provider:
+- GITLAB_URL: The GitLab URL, for self-managed instances.
+- GITLAB_TOKEN: The access token, with the "api" scope.
+- GITLAB_PROJECT: The project ID, or path.
+- GITLAB_GROUP: The group ID, or path.
+
+NOTE: GitLab rejects masked variables with values shorter than 8 characters,
+      or with unsupported characters.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Context with timeout.
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		f, err := os.Open(sourceFilename)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		parsedFile, err := util.ParseSource(ctx, f, parseOptions()...)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		config, err := gitlabConfig(cmd)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		if config.Masked, err = cmd.Flags().GetBool("masked"); err != nil {
+			log.Fatalln(err)
+		}
+
+		if config.Protected, err = cmd.Flags().GetBool("protected"); err != nil {
+			log.Fatalln(err)
+		}
+
+		if config.Raw, err = cmd.Flags().GetBool("raw"); err != nil {
+			log.Fatalln(err)
+		}
+
+		config.VariableType = cmd.Flag("variable-type").Value.String()
+
+		gitlabProvider, err := newGitLabProvider(false, false, config)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		if err := gitlabProvider.Write(ctx, parsedFile); err != nil {
+			log.Fatalln(err)
+		}
+
+		os.Exit(0)
+	},
+}
+
+func init() {
+	writeCmd.AddCommand(gitlabWCmd)
+
+	addGitLabFlags(gitlabWCmd)
+
+	gitlabWCmd.Flags().Bool("masked", false, "Masks the values in job logs")
+	gitlabWCmd.Flags().Bool("protected", false, "Exports the variables only to protected branches, and tags")
+	gitlabWCmd.Flags().Bool("raw", false, "Doesn't expand variable references in the values")
+	gitlabWCmd.Flags().String("variable-type", gitlab.EnvVar, "Variable type: env_var, or file")
+
+	gitlabWCmd.SetUsageTemplate(providerUsageTemplate)
+}
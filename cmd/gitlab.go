@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/thalesfsp/configurer/gitlab"
+	"github.com/thalesfsp/configurer/option"
+)
+
+var newGitLabProvider = gitlab.New
+
+// gitlabCmd represents the gitlab load command.
+var gitlabCmd = &cobra.Command{
+	Short:   "GitLab provider",
+	Use:     "gitlab",
+	Example: "  configurer l gitlab --project group/project --environment-scope production -- env",
+	Long: `GitLab provider will load the CI/CD variables of a project ("--project"), a
+group ("--group"), or the instance ("--instance"), export them to the
+environment, and then run, if any, the specified command.
+
+"--environment-scope" selects the variables of an environment, e.g.:
+"production". Like in CI jobs, variables scoped to it, or to a matching
+wildcard scope, e.g.: "review/*", have precedence over the ones scoped to all
+environments ("*", the default). File variables export their content.
+
+The following environment variables can configure the provider:
+- GITLAB_URL: The GitLab URL, for self-managed instances.
+- GITLAB_TOKEN: The access token, with the "api" scope.
+- GITLAB_PROJECT: The project ID, or path.
+- GITLAB_GROUP: The group ID, or path.
+
+NOTES:
+- Reading variables requires, at least, the Maintainer role, and instance
+  variables, an administrator.
+- Already exported environment variables have precedence over loaded ones.
+  Set the override flag to true to override them.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		shouldOverride := cmd.Flag("override").Value.String() == "true"
+		rawValue := cmd.Flag("rawValue").Value.String() == "true"
+
+		//////
+		// Build config.
+		//////
+
+		config, err := gitlabConfig(cmd)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		gitlabProvider, err := newGitLabProvider(shouldOverride, rawValue, config)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		var options []option.LoadKeyFunc
+
+		if keyCaserOptions != "" {
+			options = append(options, option.WithKeyCaser(keyCaserOptions))
+		}
+
+		if keyPrefixerOptions != "" {
+			options = append(options, option.WithKeyPrefixer(keyPrefixerOptions))
+		}
+
+		if keySuffixerOptions != "" {
+			options = append(options, option.WithKeySuffixer(keySuffixerOptions))
+		}
+
+		finalValues, err := gitlabProvider.Load(context.Background(), options...)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		if dumpFilename != "" {
+			if err := dumpToFile(dumpFilename, finalValues, rawValue); err != nil {
+				log.Fatalln(err)
+			}
+		}
+
+		ConcurrentRunner(gitlabProvider, commands, args)
+	},
+}
+
+// gitlabConfig builds the settings shared by the load, and write commands.
+func gitlabConfig(cmd *cobra.Command) (*gitlab.Config, error) {
+	instance, err := cmd.Flags().GetBool("instance")
+	if err != nil {
+		return nil, err
+	}
+
+	timeout, err := cmd.Flags().GetDuration("timeout")
+	if err != nil {
+		return nil, err
+	}
+
+	return &gitlab.Config{
+		BaseURL:          cmd.Flag("url").Value.String(),
+		Token:            cmd.Flag("token").Value.String(),
+		Project:          cmd.Flag("project").Value.String(),
+		Group:            cmd.Flag("group").Value.String(),
+		Instance:         instance,
+		EnvironmentScope: cmd.Flag("environment-scope").Value.String(),
+		Timeout:          timeout,
+	}, nil
+}
+
+// addGitLabFlags adds the flags shared by the load, and write commands.
+func addGitLabFlags(cmd *cobra.Command) {
+	baseURL := os.Getenv("GITLAB_URL")
+	if baseURL == "" {
+		baseURL = gitlab.DefaultBaseURL
+	}
+
+	// Connection.
+	cmd.Flags().String("url", baseURL, "GitLab URL, for self-managed instances")
+	cmd.Flags().String("token", os.Getenv("GITLAB_TOKEN"), "Access token, with the api scope")
+	cmd.Flags().Duration("timeout", gitlab.DefaultTimeout, "The request timeout")
+
+	// Scope.
+	cmd.Flags().String("project", os.Getenv("GITLAB_PROJECT"), "Project ID, or path, e.g.: group/project")
+	cmd.Flags().String("group", os.Getenv("GITLAB_GROUP"), "Group ID, or path, e.g.: group/subgroup")
+	cmd.Flags().Bool("instance", false, "Instance-level variables")
+	cmd.Flags().String("environment-scope", gitlab.AllEnvironments, "Environment scope, e.g.: production")
+}
+
+func init() {
+	loadCmd.AddCommand(gitlabCmd)
+
+	addGitLabFlags(gitlabCmd)
+
+	gitlabCmd.SetUsageTemplate(providerUsageTemplate)
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/thalesfsp/configurer/util"
+)
+
+// httpWCmd represents the HTTP endpoint write command.
+var httpWCmd = &cobra.Command{
+	Short:   "HTTP endpoint provider",
+	Use:     "http",
+	Example: "  configurer w --source prod.env http --url https://config.internal/app --method PATCH",
+	Long: `HTTP endpoint provider will send secrets, as a JSON object, to an HTTP
+endpoint. With "--pointer", e.g.: "/data", they're nested under it.
+
+The following environment variables can configure the provider:
+- HTTP_URL: The URL to write.
+- HTTP_BEARER_TOKEN: The bearer token to use for authentication.
+- HTTP_USERNAME: The username to use for basic authentication.
+- HTTP_PASSWORD: The password to use for basic authentication.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Context with timeout.
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		f, err := os.Open(sourceFilename)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		parsedFile, err := util.ParseSource(ctx, f, parseOptions()...)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		config, err := httpConfig(cmd)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		config.WriteMethod = cmd.Flag("method").Value.String()
+
+		httpProvider, err := newHTTPProvider(false, false, config)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		if err := httpProvider.Write(ctx, parsedFile); err != nil {
+			log.Fatalln(err)
+		}
+
+		os.Exit(0)
+	},
+}
+
+func init() {
+	writeCmd.AddCommand(httpWCmd)
+
+	addHTTPFlags(httpWCmd)
+
+	httpWCmd.Flags().String("method", "PUT", "The write method. Available: POST, PUT, PATCH")
+
+	httpWCmd.SetUsageTemplate(providerUsageTemplate)
+}
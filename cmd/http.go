@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/thalesfsp/configurer/http"
+	"github.com/thalesfsp/configurer/option"
+	"github.com/thalesfsp/configurer/parser"
+	"github.com/thalesfsp/customerror"
+)
+
+var newHTTPProvider = http.New
+
+// httpCmd represents the HTTP endpoint load command.
+var httpCmd = &cobra.Command{
+	Short:   "HTTP endpoint provider",
+	Use:     "http",
+	Example: "  configurer l http --url https://config.internal/app --bearer-token 123 --pointer /data -- env",
+	Long: `HTTP endpoint provider will fetch values from an HTTP endpoint, e.g.: an
+internal config service, export them to the environment, and then run, if
+any, the specified command.
+
+The response is parsed per "--format", or, with "auto", its Content-Type.
+"--pointer", a JSON pointer, e.g.: "/data/secrets", selects the object to
+load. Use "--flatten" to flatten nested values, otherwise they're
+JSON-encoded. With "--cache-file", the response is cached, and revalidated
+with its ETag.
+
+The following environment variables can configure the provider:
+- HTTP_URL: The URL to fetch.
+- HTTP_BEARER_TOKEN: The bearer token to use for authentication.
+- HTTP_USERNAME: The username to use for basic authentication.
+- HTTP_PASSWORD: The password to use for basic authentication.
+
+NOTE: Already exported environment variables have precedence over loaded
+      ones. Set the override flag to true to override them.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		shouldOverride := cmd.Flag("override").Value.String() == "true"
+		rawValue := cmd.Flag("rawValue").Value.String() == "true"
+
+		//////
+		// Build config.
+		//////
+
+		config, err := httpConfig(cmd)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		config.CacheFile = cmd.Flag("cache-file").Value.String()
+		config.Format = cmd.Flag("format").Value.String()
+		config.ParseOptions = parseOptions()
+
+		httpProvider, err := newHTTPProvider(shouldOverride, rawValue, config)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		var options []option.LoadKeyFunc
+
+		if keyCaserOptions != "" {
+			options = append(options, option.WithKeyCaser(keyCaserOptions))
+		}
+
+		if keyPrefixerOptions != "" {
+			options = append(options, option.WithKeyPrefixer(keyPrefixerOptions))
+		}
+
+		if keySuffixerOptions != "" {
+			options = append(options, option.WithKeySuffixer(keySuffixerOptions))
+		}
+
+		finalValues, err := httpProvider.Load(context.Background(), options...)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		if dumpFilename != "" {
+			if err := dumpToFile(dumpFilename, finalValues, rawValue); err != nil {
+				log.Fatalln(err)
+			}
+		}
+
+		ConcurrentRunner(httpProvider, commands, args)
+	},
+}
+
+// httpConfig builds the connection settings shared by the load, and write
+// commands.
+func httpConfig(cmd *cobra.Command) (*http.Config, error) {
+	timeout, err := cmd.Flags().GetDuration("timeout")
+	if err != nil {
+		return nil, err
+	}
+
+	rawHeaders, err := cmd.Flags().GetStringArray("header")
+	if err != nil {
+		return nil, err
+	}
+
+	headers := make(map[string]string, len(rawHeaders))
+
+	for _, rawHeader := range rawHeaders {
+		name, value, found := strings.Cut(rawHeader, ":")
+		if !found || strings.TrimSpace(name) == "" {
+			return nil, customerror.NewInvalidError("header " + rawHeader + ", allowed: Name: value")
+		}
+
+		headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+
+	return &http.Config{
+		URL:         cmd.Flag("url").Value.String(),
+		BearerToken: cmd.Flag("bearer-token").Value.String(),
+		Username:    cmd.Flag("username").Value.String(),
+		Password:    cmd.Flag("password").Value.String(),
+		Headers:     headers,
+		CAFile:      cmd.Flag("ca-file").Value.String(),
+		CertFile:    cmd.Flag("cert-file").Value.String(),
+		KeyFile:     cmd.Flag("key-file").Value.String(),
+		Pointer:     cmd.Flag("pointer").Value.String(),
+		Timeout:     timeout,
+	}, nil
+}
+
+// addHTTPFlags adds the connection flags shared by the load, and write
+// commands.
+func addHTTPFlags(cmd *cobra.Command) {
+	// Connection.
+	cmd.Flags().String("url", os.Getenv("HTTP_URL"), "The URL to fetch")
+	cmd.Flags().StringArray("header", nil, "Header to send, e.g.: \"X-Tenant: app\". Can be repeated")
+	cmd.Flags().String("pointer", "", "JSON pointer of the values, e.g.: /data/secrets")
+	cmd.Flags().Duration("timeout", http.DefaultTimeout, "The request timeout")
+
+	// Auth.
+	cmd.Flags().String("bearer-token", os.Getenv("HTTP_BEARER_TOKEN"), "Bearer token to use for authentication")
+	cmd.Flags().String("username", os.Getenv("HTTP_USERNAME"), "Username to use for basic authentication")
+	cmd.Flags().String("password", os.Getenv("HTTP_PASSWORD"), "Password to use for basic authentication")
+
+	// TLS.
+	cmd.Flags().String("ca-file", "", "CA certificates file, in addition to the system ones")
+	cmd.Flags().String("cert-file", "", "Client certificate file, for mTLS")
+	cmd.Flags().String("key-file", "", "Client key file, for mTLS")
+}
+
+func init() {
+	loadCmd.AddCommand(httpCmd)
+
+	addHTTPFlags(httpCmd)
+
+	httpCmd.Flags().String("format", parser.Auto, "Response format. Available: "+strings.Join(parser.Formats(), ", ")+", "+parser.Auto)
+	httpCmd.Flags().String("cache-file", "", "File caching the response, revalidated with its ETag")
+
+	httpCmd.SetUsageTemplate(providerUsageTemplate)
+}
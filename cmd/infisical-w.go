@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/thalesfsp/configurer/util"
+)
+
+// infisicalWCmd represents the infisical write command.
+var infisicalWCmd = &cobra.Command{
+	Short:   "Infisical provider",
+	Use:     "infisical",
+	Example: "  configurer w --source prod.env infisical --project-id 6e1c... --env prod --path /backend",
+	Long: `Infisical provider will create, or update secrets of a project, environment,
+and folder in Infisical.
+
+The following environment variables can configure the provider:
+- INFISICAL_API_URL: The Infisical URL, for self-hosted deployments.
+- INFISICAL_UNIVERSAL_AUTH_CLIENT_ID: The machine identity client ID.
+- INFISICAL_UNIVERSAL_AUTH_CLIENT_SECRET: The machine identity client secret.
+- INFISICAL_TOKEN: The service token.
+- INFISICAL_PROJECT_ID: The project ID.
+- INFISICAL_ENVIRONMENT: The environment slug.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Context with timeout.
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		f, err := os.Open(sourceFilename)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		parsedFile, err := util.ParseSource(ctx, f, parseOptions()...)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		config, err := infisicalConfig(cmd)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		infisicalProvider, err := newInfisicalProvider(false, false, config)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		if err := infisicalProvider.Write(ctx, parsedFile); err != nil {
+			log.Fatalln(err)
+		}
+
+		os.Exit(0)
+	},
+}
+
+func init() {
+	writeCmd.AddCommand(infisicalWCmd)
+
+	addInfisicalFlags(infisicalWCmd)
+
+	infisicalWCmd.SetUsageTemplate(providerUsageTemplate)
+}
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/thalesfsp/configurer/infisical"
+	"github.com/thalesfsp/configurer/option"
+)
+
+var newInfisicalProvider = infisical.New
+
+// infisicalCmd represents the infisical load command.
+var infisicalCmd = &cobra.Command{
+	Short:   "Infisical provider",
+	Use:     "infisical",
+	Example: "  configurer l infisical --project-id 6e1c... --env prod --path /backend --recursive -- env",
+	Long: `Infisical provider will load the secrets of a project, environment, and
+folder from Infisical, export them to the environment, and then run, if any,
+the specified command.
+
+"--recursive" loads the secrets of the subfolders, and "--include-imports"
+the secrets imported into the folder, too; secrets of the folder have
+precedence. Secret references, e.g.: "${prod.backend.DB_HOST}", are expanded,
+unless "--keep-references" is set.
+
+It supports the following authentication methods:
+- Machine identity, with universal auth ("--client-id", and "--client-secret")
+- Service token ("--token")
+
+The following environment variables can configure the provider:
+- INFISICAL_API_URL: The Infisical URL, for self-hosted deployments.
+- INFISICAL_UNIVERSAL_AUTH_CLIENT_ID: The machine identity client ID.
+- INFISICAL_UNIVERSAL_AUTH_CLIENT_SECRET: The machine identity client secret.
+- INFISICAL_TOKEN: The service token.
+- INFISICAL_PROJECT_ID: The project ID.
+- INFISICAL_ENVIRONMENT: The environment slug.
+
+NOTE: Already exported environment variables have precedence over loaded
+      ones. Set the override flag to true to override them.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		shouldOverride := cmd.Flag("override").Value.String() == "true"
+		rawValue := cmd.Flag("rawValue").Value.String() == "true"
+
+		//////
+		// Build config.
+		//////
+
+		config, err := infisicalConfig(cmd)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		if config.Recursive, err = cmd.Flags().GetBool("recursive"); err != nil {
+			log.Fatalln(err)
+		}
+
+		if config.Imports, err = cmd.Flags().GetBool("include-imports"); err != nil {
+			log.Fatalln(err)
+		}
+
+		if config.KeepReferences, err = cmd.Flags().GetBool("keep-references"); err != nil {
+			log.Fatalln(err)
+		}
+
+		infisicalProvider, err := newInfisicalProvider(shouldOverride, rawValue, config)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		var options []option.LoadKeyFunc
+
+		if keyCaserOptions != "" {
+			options = append(options, option.WithKeyCaser(keyCaserOptions))
+		}
+
+		if keyPrefixerOptions != "" {
+			options = append(options, option.WithKeyPrefixer(keyPrefixerOptions))
+		}
+
+		if keySuffixerOptions != "" {
+			options = append(options, option.WithKeySuffixer(keySuffixerOptions))
+		}
+
+		finalValues, err := infisicalProvider.Load(context.Background(), options...)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		if dumpFilename != "" {
+			if err := dumpToFile(dumpFilename, finalValues, rawValue); err != nil {
+				log.Fatalln(err)
+			}
+		}
+
+		ConcurrentRunner(infisicalProvider, commands, args)
+	},
+}
+
+// infisicalConfig builds the settings shared by the load, and write commands.
+func infisicalConfig(cmd *cobra.Command) (*infisical.Config, error) {
+	timeout, err := cmd.Flags().GetDuration("timeout")
+	if err != nil {
+		return nil, err
+	}
+
+	return &infisical.Config{
+		BaseURL:      cmd.Flag("url").Value.String(),
+		ClientID:     cmd.Flag("client-id").Value.String(),
+		ClientSecret: cmd.Flag("client-secret").Value.String(),
+		ServiceToken: cmd.Flag("token").Value.String(),
+		ProjectID:    cmd.Flag("project-id").Value.String(),
+		Environment:  cmd.Flag("env").Value.String(),
+		SecretPath:   cmd.Flag("path").Value.String(),
+		Timeout:      timeout,
+	}, nil
+}
+
+// addInfisicalFlags adds the flags shared by the load, and write commands.
+func addInfisicalFlags(cmd *cobra.Command) {
+	baseURL := os.Getenv("INFISICAL_API_URL")
+	if baseURL == "" {
+		baseURL = infisical.DefaultBaseURL
+	}
+
+	// Connection.
+	cmd.Flags().String("url", baseURL, "Infisical URL, for self-hosted deployments")
+	cmd.Flags().Duration("timeout", infisical.DefaultTimeout, "The request timeout")
+
+	// Auth.
+	cmd.Flags().String("client-id", os.Getenv("INFISICAL_UNIVERSAL_AUTH_CLIENT_ID"), "Machine identity client ID")
+	cmd.Flags().String("client-secret", os.Getenv("INFISICAL_UNIVERSAL_AUTH_CLIENT_SECRET"), "Machine identity client secret")
+	cmd.Flags().String("token", os.Getenv("INFISICAL_TOKEN"), "Service token")
+
+	// Secrets.
+	cmd.Flags().String("project-id", os.Getenv("INFISICAL_PROJECT_ID"), "Project ID")
+	cmd.Flags().String("env", os.Getenv("INFISICAL_ENVIRONMENT"), "Environment slug, e.g.: prod")
+	cmd.Flags().String("path", infisical.DefaultSecretPath, "Folder of the secrets, e.g.: /backend")
+}
+
+func init() {
+	loadCmd.AddCommand(infisicalCmd)
+
+	addInfisicalFlags(infisicalCmd)
+
+	infisicalCmd.Flags().Bool("recursive", false, "Loads the secrets of the subfolders, too")
+	infisicalCmd.Flags().Bool("include-imports", false, "Loads the imported secrets, too")
+	infisicalCmd.Flags().Bool("keep-references", false, "Doesn't expand secret references")
+
+	infisicalCmd.SetUsageTemplate(providerUsageTemplate)
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/thalesfsp/configurer/keepass"
+	"github.com/thalesfsp/configurer/util"
+)
+
+// keepassWCmd represents the KeePass write command.
+var keepassWCmd = &cobra.Command{
+	Aliases: []string{"kp"},
+	Short:   "KeePass provider",
+	Use:     "keepass",
+	Example: "  configurer w --source prod.env keepass -t secrets.kdbx --path Production/Database",
+	Long: `KeePass provider will add, or update an entry of a KeePass KDBX4
+database, creating missing groups, or the database, if it doesn't exist.
+
+USERNAME, PASSWORD, URL, and NOTES (any case) set the standard fields, other
+keys set protected custom string fields. The previous version of an updated
+entry is kept in its history.
+
+The following environment variables can configure the provider:
+- KEEPASS_FILE: The KDBX4 database.
+- KEEPASS_PASSWORD: The database password.
+- KEEPASS_KEY_FILE: The database key file.
+- KEEPASS_PATH: The entry to write.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Context with timeout.
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		f, err := os.Open(sourceFilename)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		parsedFile, err := util.ParseSource(ctx, f, parseOptions()...)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		config := &keepass.Config{
+			FilePath: cmd.Flag("target").Value.String(),
+			KeyFile:  cmd.Flag("key-file").Value.String(),
+			Password: cmd.Flag("password").Value.String(),
+			Path:     cmd.Flag("path").Value.String(),
+		}
+
+		keepassProvider, err := newKeePassProvider(false, false, config)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		if err := keepassProvider.Write(ctx, parsedFile); err != nil {
+			log.Fatalln(err)
+		}
+
+		os.Exit(0)
+	},
+}
+
+func init() {
+	writeCmd.AddCommand(keepassWCmd)
+
+	target := "secrets.kdbx"
+	if value := os.Getenv("KEEPASS_FILE"); value != "" {
+		target = value
+	}
+
+	keepassWCmd.Flags().StringP("target", "t", target, "The KDBX4 database to write")
+	keepassWCmd.Flags().String("password", os.Getenv("KEEPASS_PASSWORD"), "The database password")
+	keepassWCmd.Flags().String("key-file", os.Getenv("KEEPASS_KEY_FILE"), "The database key file")
+	keepassWCmd.Flags().String("path", os.Getenv("KEEPASS_PATH"), "The entry to write, e.g.: Production/Database")
+
+	keepassWCmd.SetUsageTemplate(providerUsageTemplate)
+}
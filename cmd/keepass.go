@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/thalesfsp/configurer/keepass"
+	"github.com/thalesfsp/configurer/option"
+)
+
+var newKeePassProvider = keepass.New
+
+// keepassCmd represents the KeePass load command.
+var keepassCmd = &cobra.Command{
+	Aliases: []string{"kp"},
+	Short:   "KeePass provider",
+	Use:     "keepass",
+	Example: "  configurer l keepass -f secrets.kdbx --path Production/Database -- env",
+	Long: `KeePass provider will load secrets from a KeePass KDBX4 database, export
+them to the environment, and then run, if any, the specified command.
+
+The path selects an entry, or a group, e.g.: "Production/Database", relative
+to the root group. The standard fields of an entry are exported as USERNAME,
+PASSWORD, URL, and NOTES, custom string fields as is. Entries of a group, and
+its subgroups are exported with the subgroup, and entry names as prefix, e.g.:
+"Database__PASSWORD". The recycle bin is skipped.
+
+The following environment variables can configure the provider:
+- KEEPASS_FILE: The KDBX4 database.
+- KEEPASS_PASSWORD: The database password.
+- KEEPASS_KEY_FILE: The database key file.
+- KEEPASS_PATH: The entry, or group to load.
+
+NOTE: Already exported environment variables have precedence over loaded
+      ones. Set the override flag to true to override them.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		shouldOverride := cmd.Flag("override").Value.String() == "true"
+		rawValue := cmd.Flag("rawValue").Value.String() == "true"
+
+		//////
+		// Build config.
+		//////
+
+		config := &keepass.Config{
+			FilePath: cmd.Flag("file").Value.String(),
+			KeyFile:  cmd.Flag("key-file").Value.String(),
+			Password: cmd.Flag("password").Value.String(),
+			Path:     cmd.Flag("path").Value.String(),
+		}
+
+		keepassProvider, err := newKeePassProvider(shouldOverride, rawValue, config)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		var options []option.LoadKeyFunc
+
+		if keyCaserOptions != "" {
+			options = append(options, option.WithKeyCaser(keyCaserOptions))
+		}
+
+		if keyPrefixerOptions != "" {
+			options = append(options, option.WithKeyPrefixer(keyPrefixerOptions))
+		}
+
+		if keySuffixerOptions != "" {
+			options = append(options, option.WithKeySuffixer(keySuffixerOptions))
+		}
+
+		finalValues, err := keepassProvider.Load(context.Background(), options...)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		if dumpFilename != "" {
+			if err := dumpToFile(dumpFilename, finalValues, rawValue); err != nil {
+				log.Fatalln(err)
+			}
+		}
+
+		ConcurrentRunner(keepassProvider, commands, args)
+	},
+}
+
+func init() {
+	loadCmd.AddCommand(keepassCmd)
+
+	file := "secrets.kdbx"
+	if value := os.Getenv("KEEPASS_FILE"); value != "" {
+		file = value
+	}
+
+	keepassCmd.Flags().StringP("file", "f", file, "The KDBX4 database to load")
+	keepassCmd.Flags().String("password", os.Getenv("KEEPASS_PASSWORD"), "The database password")
+	keepassCmd.Flags().String("key-file", os.Getenv("KEEPASS_KEY_FILE"), "The database key file")
+	keepassCmd.Flags().String("path", os.Getenv("KEEPASS_PATH"), "The entry, or group to load, e.g.: Production/Database")
+
+	keepassCmd.SetUsageTemplate(providerUsageTemplate)
+}
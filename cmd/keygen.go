@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+	"github.com/thalesfsp/configurer/encfile"
+)
+
+// keygenCmd represents the keygen command.
+var keygenCmd = &cobra.Command{
+	Use:   "keygen",
+	Short: "Generates a key pair for encrypted files",
+	Long: `Generates a key pair for encrypted files. The recipient (public key)
+is used by "--dump-encrypt-to", and "configurer w encfile", the private key
+by "configurer l encfile". Keep the private key secret.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		recipient, privateKey, err := encfile.GenerateKey()
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		fmt.Printf("# Recipient: %s\n%s\n", recipient, privateKey)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(keygenCmd)
+}
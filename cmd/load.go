@@ -11,6 +11,8 @@ import (
 var (
 	commands           []string
 	dumpFilename       string
+	dumpEncryptTo      string
+	dumpFormat         string
 	keyCaserOptions    string
 	keyPrefixerOptions string
 	keySuffixerOptions string
@@ -54,7 +56,21 @@ func init() {
 		"dump",
 		"d",
 		"",
-		"If set, will dump the loaded config to a file. The extension determines the format. Supported are: .env, .json, .yaml | .yml",
+		"If set, will dump the loaded config to a file. The extension determines the format. Supported are: .env, .json, .yaml | .yml, .toml, .sh | .bash, .zsh, .fish, .ps1",
+	)
+
+	loadCmd.PersistentFlags().StringVar(
+		&dumpFormat,
+		"dump-format",
+		"",
+		"Format of the dump file, overrides the extension. Supported: "+strings.Join(dumpFormats, ", "),
+	)
+
+	loadCmd.PersistentFlags().StringVar(
+		&dumpEncryptTo,
+		"dump-encrypt-to",
+		"",
+		"If set, values are encrypted to this recipient (public key) when dumping, keys stay readable. SEE: configurer keygen, and configurer l encfile",
 	)
 
 	loadCmd.PersistentFlags().DurationVarP(
@@ -3,7 +3,6 @@ package cmd
 import (
 	"context"
 	"log"
-	"os"
 
 	"github.com/spf13/cobra"
 	"github.com/thalesfsp/configurer/noop"
@@ -74,14 +73,7 @@ NOTE: The "-c" flag have precedence over double dash (--)
 
 		// Should be able to dump the loaded values to a file.
 		if dumpFilename != "" {
-			file, err := os.Create(dumpFilename)
-			if err != nil {
-				log.Fatalln(err)
-			}
-
-			defer file.Close()
-
-			if err := DumpToFile(file, finalValues, rawValue); err != nil {
+			if err := dumpToFile(dumpFilename, finalValues, rawValue); err != nil {
 				log.Fatalln(err)
 			}
 		}
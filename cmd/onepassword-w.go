@@ -37,7 +37,7 @@ The following environment variables can configure the provider:
 
 		defer file.Close()
 
-		values, err := util.ParseFile(ctx, file)
+		values, err := util.ParseSource(ctx, file, parseOptions()...)
 		if err != nil {
 			log.Fatalln(err)
 		}
@@ -0,0 +1,226 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/thalesfsp/configurer/option"
+	"github.com/thalesfsp/configurer/plugin"
+	"github.com/thalesfsp/configurer/util"
+	"github.com/thalesfsp/customerror"
+)
+
+var newPluginProvider = plugin.New
+
+// registerPlugins adds a load, and a write command for each plugin on PATH,
+// e.g.: `configurer l acme` for `configurer-provider-acme`. Built-in providers
+// have precedence.
+func registerPlugins() {
+	plugins := plugin.Discover()
+
+	names := make([]string, 0, len(plugins))
+
+	for name := range plugins {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !hasSubcommand(loadCmd, name) {
+			loadCmd.AddCommand(newPluginLoadCmd(name, plugins[name]))
+		}
+
+		if !hasSubcommand(writeCmd, name) {
+			writeCmd.AddCommand(newPluginWriteCmd(name, plugins[name]))
+		}
+	}
+}
+
+// hasSubcommand checks if `cmd` has a subcommand, or alias, named `name`.
+func hasSubcommand(cmd *cobra.Command, name string) bool {
+	if name == "help" {
+		return true
+	}
+
+	for _, subcommand := range cmd.Commands() {
+		if subcommand.Name() == name || subcommand.HasAlias(name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// pluginConfig builds the plugin config from the flags.
+func pluginConfig(cmd *cobra.Command, name, path string) (*plugin.Config, error) {
+	timeout, err := cmd.Flags().GetDuration("timeout")
+	if err != nil {
+		return nil, err
+	}
+
+	rawSettings, err := cmd.Flags().GetStringArray("setting")
+	if err != nil {
+		return nil, err
+	}
+
+	settings := make(map[string]string, len(rawSettings))
+
+	for _, rawSetting := range rawSettings {
+		key, value, found := strings.Cut(rawSetting, "=")
+		if !found || key == "" {
+			return nil, customerror.NewInvalidError("setting " + rawSetting + ", allowed: key=value")
+		}
+
+		settings[key] = value
+	}
+
+	return &plugin.Config{
+		Name:     name,
+		Path:     path,
+		Settings: settings,
+		Timeout:  timeout,
+	}, nil
+}
+
+// addPluginFlags adds the flags shared by the load, and write commands.
+func addPluginFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArray("setting", nil, "Plugin setting, e.g.: tenant=app. Can be repeated")
+	cmd.Flags().Duration("timeout", plugin.DefaultTimeout, "The timeout of each plugin call")
+
+	cmd.SetUsageTemplate(providerUsageTemplate)
+}
+
+// newPluginLoadCmd creates the load command of a plugin.
+func newPluginLoadCmd(name, path string) *cobra.Command {
+	pluginCmd := &cobra.Command{
+		Short:   "Plugin provider (" + path + ")",
+		Use:     name,
+		Example: "  configurer l " + name + " --setting tenant=app -- env",
+		Long: `Plugin provider will call the "` + plugin.Prefix + name + `" plugin, export the
+loaded values to the environment, and then run, if any, the specified
+command.
+
+Settings are sent to the plugin, which also inherits the environment. Use
+"--flatten" to flatten nested values, otherwise they're JSON-encoded.
+
+NOTE: Already exported environment variables have precedence over loaded
+      ones. Set the override flag to true to override them.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			shouldOverride := cmd.Flag("override").Value.String() == "true"
+			rawValue := cmd.Flag("rawValue").Value.String() == "true"
+
+			//////
+			// Build config.
+			//////
+
+			config, err := pluginConfig(cmd, name, path)
+			if err != nil {
+				log.Fatalln(err)
+			}
+
+			config.ParseOptions = parseOptions()
+
+			pluginProvider, err := newPluginProvider(shouldOverride, rawValue, config)
+			if err != nil {
+				log.Fatalln(err)
+			}
+
+			var options []option.LoadKeyFunc
+
+			if keyCaserOptions != "" {
+				options = append(options, option.WithKeyCaser(keyCaserOptions))
+			}
+
+			if keyPrefixerOptions != "" {
+				options = append(options, option.WithKeyPrefixer(keyPrefixerOptions))
+			}
+
+			if keySuffixerOptions != "" {
+				options = append(options, option.WithKeySuffixer(keySuffixerOptions))
+			}
+
+			finalValues, err := pluginProvider.Load(context.Background(), options...)
+			if err != nil {
+				log.Fatalln(err)
+			}
+
+			if dumpFilename != "" {
+				if err := dumpToFile(dumpFilename, finalValues, rawValue); err != nil {
+					log.Fatalln(err)
+				}
+			}
+
+			ConcurrentRunner(pluginProvider, commands, args)
+		},
+	}
+
+	addPluginFlags(pluginCmd)
+
+	return pluginCmd
+}
+
+// newPluginWriteCmd creates the write command of a plugin.
+func newPluginWriteCmd(name, path string) *cobra.Command {
+	pluginWCmd := &cobra.Command{
+		Short:   "Plugin provider (" + path + ")",
+		Use:     name,
+		Example: "  configurer w --source prod.env " + name + " --setting tenant=app",
+		Long: `Plugin provider will send secrets to the "` + plugin.Prefix + name + `" plugin.
+
+Settings are sent to the plugin, which also inherits the environment.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			// Context with timeout.
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+
+			f, err := os.Open(sourceFilename)
+			if err != nil {
+				log.Fatalln(err)
+			}
+
+			parsedFile, err := util.ParseSource(ctx, f, parseOptions()...)
+			if err != nil {
+				log.Fatalln(err)
+			}
+
+			config, err := pluginConfig(cmd, name, path)
+			if err != nil {
+				log.Fatalln(err)
+			}
+
+			pluginProvider, err := newPluginProvider(false, false, config)
+			if err != nil {
+				log.Fatalln(err)
+			}
+
+			var options []option.WriteFunc
+
+			if value := cmd.Flag("target").Value.String(); value != "" {
+				options = append(options, option.WithTarget(value))
+			}
+
+			if value := cmd.Flag("environment").Value.String(); value != "" {
+				options = append(options, option.WithEnvironment(value))
+			}
+
+			if err := pluginProvider.Write(ctx, parsedFile, options...); err != nil {
+				log.Fatalln(err)
+			}
+
+			os.Exit(0)
+		},
+	}
+
+	pluginWCmd.Flags().StringP("target", "t", "", "Target sent to the plugin, if any")
+	pluginWCmd.Flags().StringP("environment", "e", "", "Environment sent to the plugin, if any")
+
+	addPluginFlags(pluginWCmd)
+
+	return pluginWCmd
+}
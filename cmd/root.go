@@ -2,9 +2,11 @@ package cmd
 
 import (
 	"os"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/thalesfsp/configurer/option"
 	"github.com/thalesfsp/customerror"
 	"github.com/thalesfsp/sypl/v2"
 	"github.com/thalesfsp/sypl/v2/level"
@@ -12,9 +14,10 @@ import (
 
 // Intentionally shared parent and persistent flag state:
 //
-//   - rootCmd: logOutputs, logSettings, execMode, sequentialDelay, flushInterval
-//   - loadCmd: commands, dumpFilename, keyCaserOptions, keyPrefixerOptions,
-//     keySuffixerOptions, shutdownTimeout
+//   - rootCmd: logOutputs, logSettings, execMode, sequentialDelay, flushInterval,
+//     flatten, flattenArrays, flattenCase, flattenSeparator
+//   - loadCmd: commands, dumpEncryptTo, dumpFilename, dumpFormat,
+//     keyCaserOptions, keyPrefixerOptions, keySuffixerOptions, shutdownTimeout
 //   - writeCmd: sourceFilename
 //
 // These variables are read by multiple child commands and must remain shared
@@ -36,6 +39,19 @@ var (
 
 	// sequentialDelay is the delay between one command and another.
 	sequentialDelay time.Duration
+
+	// flatten indicates nested documents should be flattened when parsed, and
+	// unflattened when dumped.
+	flatten bool
+
+	// flattenArrays is how arrays are flattened.
+	flattenArrays string
+
+	// flattenCase is the case of flattened keys.
+	flattenCase string
+
+	// flattenSeparator is the separator between nested keys.
+	flattenSeparator string
 )
 
 // rootCmd represents the base command when called without any subcommands.
@@ -63,11 +79,18 @@ func init() {
 
 	// flushInterval
 	rootCmd.PersistentFlags().DurationVar(&flushInterval, "flush-interval", 1*time.Second, "Flush interval for outputs, if any specified")
+
+	rootCmd.PersistentFlags().BoolVar(&flatten, "flatten", false, "Flatten nested JSON, YAML, and TOML documents into keys like DATABASE__HOST, and unflatten them when dumping to JSON, and YAML")
+	rootCmd.PersistentFlags().StringVar(&flattenArrays, "flatten-arrays", option.ArrayIndex, "How to flatten arrays. Available: "+strings.Join(option.AllowedArrayModes, ", "))
+	rootCmd.PersistentFlags().StringVar(&flattenCase, "flatten-case", option.Upper, "Case of flattened keys. Available: "+strings.Join(option.AllowedCases, ", "))
+	rootCmd.PersistentFlags().StringVar(&flattenSeparator, "flatten-separator", option.DefaultSeparator, "Separator between nested keys")
 }
 
 // Execute adds all child commands to the root command and sets flags
 // appropriately.
 func Execute() {
+	registerPlugins()
+
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
 	}
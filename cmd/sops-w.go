@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/thalesfsp/configurer/sops"
+	"github.com/thalesfsp/configurer/util"
+)
+
+// sopsWCmd represents the SOPS write command.
+var sopsWCmd = &cobra.Command{
+	Short:   "SOPS provider",
+	Use:     "sops",
+	Example: "  configurer w --source prod.env sops --age age1... --target secrets.enc.yaml",
+	Long: `SOPS provider will encrypt secrets with a new data key, encrypted to age
+recipients, and write them to a SOPS file, which SOPS can decrypt, and edit.
+The extension determines the format: ".yaml" | ".yml", ".json", or ".env".
+
+If no recipient is set, the existing file is re-encrypted to its recipients.
+Values of keys ending with "_unencrypted" are stored in plaintext.
+
+The following environment variables can configure the provider:
+- SOPS_AGE_RECIPIENTS: age recipients (age1...), comma-separated.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Context with timeout.
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		f, err := os.Open(sourceFilename)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		parsedFile, err := util.ParseSource(ctx, f, parseOptions()...)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		recipients, err := cmd.Flags().GetStringSlice("age")
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		config := &sops.Config{
+			FilePaths:  []string{cmd.Flag("target").Value.String()},
+			Recipients: recipients,
+		}
+
+		sopsProvider, err := newSOPSProvider(false, false, config)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		if err := sopsProvider.Write(ctx, parsedFile); err != nil {
+			log.Fatalln(err)
+		}
+
+		os.Exit(0)
+	},
+}
+
+func init() {
+	writeCmd.AddCommand(sopsWCmd)
+
+	var recipients []string
+	if value := os.Getenv("SOPS_AGE_RECIPIENTS"); value != "" {
+		recipients = strings.Split(value, ",")
+	}
+
+	sopsWCmd.Flags().StringP("target", "t", "secrets.enc.yaml", "The SOPS file to write")
+	sopsWCmd.Flags().StringSlice("age", recipients, "age recipients (age1...) to encrypt to")
+
+	sopsWCmd.SetUsageTemplate(providerUsageTemplate)
+}
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/thalesfsp/configurer/option"
+	"github.com/thalesfsp/configurer/sops"
+)
+
+var newSOPSProvider = sops.New
+
+// sopsCmd represents the SOPS load command.
+var sopsCmd = &cobra.Command{
+	Short:   "SOPS provider",
+	Use:     "sops",
+	Example: "  configurer l sops -f secrets.enc.yaml -- env",
+	Long: `SOPS provider will load secrets from SOPS encrypted files, e.g.:
+"secrets.enc.yaml", verify their MAC, decrypt them, export them to the
+environment, and then run, if any, the specified command.
+
+Files are decrypted natively, the "sops" binary isn't required. Only age
+recipients are supported. The extension determines the format: ".yaml" |
+".yml", ".json", or ".env". Use "--flatten" to flatten nested values,
+otherwise they're JSON-encoded.
+
+The following environment variables can configure the provider:
+- SOPS_AGE_KEY: age identities (AGE-SECRET-KEY-1...), one per line.
+- SOPS_AGE_KEY_FILE: File containing age identities. Defaults to
+  "sops/age/keys.txt" in the user config directory, like SOPS.
+
+NOTE: Already exported environment variables have precedence over loaded
+      ones. Set the override flag to true to override them.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		shouldOverride := cmd.Flag("override").Value.String() == "true"
+		rawValue := cmd.Flag("rawValue").Value.String() == "true"
+
+		//////
+		// Build config.
+		//////
+
+		identities, err := readAgeIdentities(
+			cmd.Flag("age-key").Value.String(),
+			cmd.Flag("age-key-file").Value.String(),
+		)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		files, err := cmd.Flags().GetStringSlice("files")
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		config := &sops.Config{
+			FilePaths:    files,
+			Identities:   identities,
+			ParseOptions: parseOptions(),
+		}
+
+		sopsProvider, err := newSOPSProvider(shouldOverride, rawValue, config)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		var options []option.LoadKeyFunc
+
+		if keyCaserOptions != "" {
+			options = append(options, option.WithKeyCaser(keyCaserOptions))
+		}
+
+		if keyPrefixerOptions != "" {
+			options = append(options, option.WithKeyPrefixer(keyPrefixerOptions))
+		}
+
+		if keySuffixerOptions != "" {
+			options = append(options, option.WithKeySuffixer(keySuffixerOptions))
+		}
+
+		finalValues, err := sopsProvider.Load(context.Background(), options...)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		if dumpFilename != "" {
+			if err := dumpToFile(dumpFilename, finalValues, rawValue); err != nil {
+				log.Fatalln(err)
+			}
+		}
+
+		ConcurrentRunner(sopsProvider, commands, args)
+	},
+}
+
+// readAgeIdentities returns the age identities of `key`, and `keyFile`. If
+// neither is set, the SOPS default key file is used, if it exists.
+func readAgeIdentities(key, keyFile string) ([]string, error) {
+	content := key
+
+	if key == "" && keyFile == "" {
+		configDir, err := os.UserConfigDir()
+		if err != nil {
+			return nil, nil
+		}
+
+		keyFile = filepath.Join(configDir, "sops", "age", "keys.txt")
+
+		if _, err := os.Stat(keyFile); errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+	}
+
+	if keyFile != "" {
+		fileContent, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		content += "\n" + string(fileContent)
+	}
+
+	var identities []string
+
+	// Like `age-keygen` output, `#` lines are comments.
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+
+		if line != "" && !strings.HasPrefix(line, "#") {
+			identities = append(identities, line)
+		}
+	}
+
+	return identities, nil
+}
+
+func init() {
+	loadCmd.AddCommand(sopsCmd)
+
+	sopsCmd.Flags().StringSliceP("files", "f", []string{"secrets.enc.yaml"}, "The SOPS files to load")
+	sopsCmd.Flags().String("age-key", os.Getenv("SOPS_AGE_KEY"), "age identities (AGE-SECRET-KEY-1...)")
+	sopsCmd.Flags().String("age-key-file", os.Getenv("SOPS_AGE_KEY_FILE"), "File containing age identities")
+
+	sopsCmd.SetUsageTemplate(providerUsageTemplate)
+}
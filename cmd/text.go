@@ -4,8 +4,10 @@ import (
 	"io"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/thalesfsp/configurer/parser"
 )
 
 var textContentFormat string
@@ -17,7 +19,7 @@ var textCmd = &cobra.Command{
 	Use:     "text",
 	Example: `  echo "X=1" > configurer l t -- env | grep X`,
 	Long: `Text provider will load secrets from text.
-Format must be set.
+Format must be set. Use "auto" to detect it from the content.
 
 ## About the command to run
 
@@ -65,7 +67,13 @@ NOTE: The "-c" flag have precedence over double dash (--)
 func init() {
 	loadCmd.AddCommand(textCmd)
 
-	textCmd.Flags().StringVarP(&textContentFormat, "format", "f", "env", "env format")
+	textCmd.Flags().StringVarP(
+		&textContentFormat,
+		"format",
+		"f",
+		"env",
+		"Content format. Available: "+strings.Join(parser.Formats(), ", ")+", "+parser.Auto,
+	)
 
 	textCmd.SetUsageTemplate(providerUsageTemplate)
 }
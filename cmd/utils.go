@@ -22,6 +22,8 @@ import (
 	"github.com/kvz/logstreamer"
 	"github.com/thalesfsp/concurrentloop"
 	"github.com/thalesfsp/configurer/dotenv"
+	"github.com/thalesfsp/configurer/encfile"
+	"github.com/thalesfsp/configurer/option"
 	"github.com/thalesfsp/configurer/provider"
 	"github.com/thalesfsp/configurer/util"
 	"github.com/thalesfsp/customerror"
@@ -37,6 +39,37 @@ import (
 // Regex pattern for .env extensions (.env, .env.local, .env.prod, etc.)
 var envRegex = regexp.MustCompile(`^\.env(\..+)?$`)
 
+// Regex pattern for characters not allowed in Kubernetes Secret names.
+var invalidSecretNameRegex = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// Dump formats, SEE: `DumpToFile`.
+const (
+	dumpFormatDockerEnv = "dockerenv"
+	dumpFormatEnv       = "env"
+	dumpFormatGitHubEnv = "github-env"
+	dumpFormatJSON      = "json"
+	dumpFormatK8sSecret = "k8s-secret"
+	dumpFormatSystemd   = "systemd"
+	dumpFormatTOML      = "toml"
+	dumpFormatYAML      = "yaml"
+)
+
+// dumpFormats are the formats allowed by `--dump-format`.
+var dumpFormats = []string{
+	dumpFormatEnv,
+	dumpFormatJSON,
+	dumpFormatYAML,
+	dumpFormatTOML,
+	util.ShellBash,
+	util.ShellZsh,
+	util.ShellFish,
+	util.ShellPowerShell,
+	dumpFormatDockerEnv,
+	dumpFormatK8sSecret,
+	dumpFormatSystemd,
+	dumpFormatGitHubEnv,
+}
+
 var newElasticsearchOutput = es.OutputWithDynamicIndex
 
 // shouldUseElasticsearch reports whether the configured log outputs request the
@@ -615,29 +648,129 @@ func ConcurrentRunner(p provider.IProvider, cmds []string, args []string) {
 	os.Exit(0)
 }
 
-// DumpToFile dumps the final loaded values to a file. Extension is used to
-// determine the format.
+// parseOptions returns the parsing options set by the `flatten` flags.
+func parseOptions() []option.ParseFunc {
+	if !flatten {
+		return nil
+	}
+
+	return []option.ParseFunc{
+		option.WithFlatten(flatten),
+		option.WithFlattenArrays(flattenArrays),
+		option.WithFlattenCase(flattenCase),
+		option.WithFlattenSeparator(flattenSeparator),
+	}
+}
+
+// DumpToFile dumps the final loaded values to a file. The `--dump-format` flag
+// determines the format, if not set, the extension is used.
 func DumpToFile(file *os.File, finalValues map[string]string, rawValue bool) error {
-	extension := filepath.Ext(file.Name())
+	return dumpTo(file, file.Name(), finalValues, rawValue)
+}
+
+// dumpToFile dumps the final loaded values to `filename`. Values are written
+// to a temporary file, only readable by the owner (0600), which atomically
+// replaces `filename`, so secrets are never world-readable, nor partially
+// written.
+func dumpToFile(filename string, finalValues map[string]string, rawValue bool) error {
+	return util.WriteFileAtomic(filename, func(file *os.File) error {
+		return dumpTo(file, filename, finalValues, rawValue)
+	})
+}
+
+// dumpTo dumps the final loaded values to `file`, in the format of the
+// `--dump-format` flag, or of the `filename` extension. Values are encrypted if
+// `--dump-encrypt-to` is set.
+func dumpTo(file *os.File, filename string, finalValues map[string]string, rawValue bool) error {
+	format := dumpFormat
+	if format == "" {
+		// Encrypted files, e.g.: `.env.enc`, are in the format before `.enc`.
+		format = dumpFormatFromExtension(filepath.Ext(strings.TrimSuffix(filename, ".enc")))
+	}
 
+	if dumpEncryptTo != "" {
+		encrypted := make(map[string]string, len(finalValues))
+
+		for key, value := range finalValues {
+			encryptedValue, err := encfile.Encrypt(key, value, dumpEncryptTo)
+			if err != nil {
+				return err
+			}
+
+			encrypted[key] = encryptedValue
+		}
+
+		finalValues = encrypted
+	}
+
+	switch format {
+	case dumpFormatEnv:
+		return util.DumpToEnv(file, finalValues, rawValue)
+	case dumpFormatJSON:
+		return util.DumpToJSON(file, finalValues, parseOptions()...)
+	case dumpFormatYAML:
+		return util.DumpToYAML(file, finalValues, parseOptions()...)
+	case dumpFormatTOML:
+		return util.DumpToTOML(file, finalValues, parseOptions()...)
+	case util.ShellBash, util.ShellZsh, util.ShellFish, util.ShellPowerShell:
+		return util.DumpToShell(file, finalValues, format)
+	case dumpFormatDockerEnv:
+		return util.DumpToDockerEnv(file, finalValues)
+	case dumpFormatK8sSecret:
+		return util.DumpToK8sSecret(file, finalValues, secretName(filename))
+	case dumpFormatSystemd:
+		return util.DumpToSystemd(file, finalValues)
+	case dumpFormatGitHubEnv:
+		return util.DumpToGitHubEnv(file, finalValues)
+	case "":
+		return customerror.NewInvalidError(
+			"file extension, allowed: .env.*, .json, .yaml | .yml, .toml, .sh | .bash, .zsh, .fish, .ps1. Use --dump-format for other formats",
+		)
+	default:
+		return customerror.NewInvalidError("dump format, allowed: " + strings.Join(dumpFormats, ", "))
+	}
+}
+
+// dumpFormatFromExtension returns the dump format for `extension`, or empty if
+// unknown.
+func dumpFormatFromExtension(extension string) string {
 	switch {
 	case envRegex.MatchString(extension):
-		if err := util.DumpToEnv(file, finalValues, rawValue); err != nil {
-			return err
-		}
+		return dumpFormatEnv
 	case extension == ".json":
-		if err := util.DumpToJSON(file, finalValues); err != nil {
-			return err
-		}
+		return dumpFormatJSON
 	case extension == ".yaml" || extension == ".yml":
-		if err := util.DumpToYAML(file, finalValues); err != nil {
-			return err
-		}
+		return dumpFormatYAML
+	case extension == ".toml":
+		return dumpFormatTOML
+	case extension == ".sh" || extension == ".bash":
+		return util.ShellBash
+	case extension == ".zsh":
+		return util.ShellZsh
+	case extension == ".fish":
+		return util.ShellFish
+	case extension == ".ps1":
+		return util.ShellPowerShell
 	default:
-		log.Fatalln("invalid file extension, allowed: .env.*, .json, .yaml | .yml")
+		return ""
+	}
+}
+
+// secretName derives a valid Kubernetes Secret name from the dump file name,
+// e.g.: `app.secret.yaml` becomes `app`.
+func secretName(filename string) string {
+	name := strings.ToLower(filepath.Base(filename))
+
+	if i := strings.IndexByte(name, '.'); i >= 0 {
+		name = name[:i]
+	}
+
+	name = strings.Trim(invalidSecretNameRegex.ReplaceAllString(name, "-"), "-")
+	if name == "" {
+		return "configurer"
 	}
 
-	return nil
+	return name
 }
 
 // CreateBridge creates a bridge.
@@ -793,7 +926,7 @@ func LoadFromText(
 		return nil, err
 	}
 
-	m, err := util.ParseFromText(context.Background(), contentFormat, data)
+	m, err := util.ParseFromText(context.Background(), contentFormat, data, parseOptions()...)
 	if err != nil {
 		return nil, err
 	}
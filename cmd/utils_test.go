@@ -2,8 +2,12 @@ package cmd
 
 import (
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestDumpToEnv(t *testing.T) {
@@ -107,3 +111,98 @@ func TestDumpToEnv(t *testing.T) {
 		t.Fatal("expected file to contain K2: V2", dataYMLasString)
 	}
 }
+
+func TestDumpToFileFormats(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		format   string
+		contain  string
+		wantErr  string
+	}{
+		{name: "toml by extension", filename: "dump.toml", contain: `K1 = "V1"`},
+		{name: "bash by extension", filename: "dump.sh", contain: "export K1='V1'"},
+		{name: "powershell by extension", filename: "dump.ps1", contain: "$env:K1 = 'V1'"},
+		{name: "format overrides extension", filename: "dump.json", format: "fish", contain: "set -gx K1 'V1'"},
+		{name: "docker env-file", filename: "app.env", format: "dockerenv", contain: "K1=V1\n"},
+		{name: "systemd", filename: "app.conf", format: "systemd", contain: `K1="V1"`},
+		{name: "github env", filename: "set_env", format: "github-env", contain: "K1<<ghadelimiter_"},
+		{name: "kubernetes secret named after the file", filename: "My_App.secret.yaml", format: "k8s-secret", contain: "name: my-app"},
+		{name: "unknown extension", filename: "dump.xml", wantErr: "Use --dump-format for other formats"},
+		{name: "unknown format", filename: "dump.json", format: "xml", wantErr: "dump format, allowed: env, json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := dumpFormat
+			t.Cleanup(func() {
+				dumpFormat = original
+			})
+
+			dumpFormat = tt.format
+
+			file, err := os.Create(filepath.Join(t.TempDir(), tt.filename))
+			require.NoError(t, err)
+
+			defer file.Close()
+
+			err = DumpToFile(file, map[string]string{"K1": "V1"}, false)
+
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			content, err := os.ReadFile(file.Name())
+			require.NoError(t, err)
+			assert.Contains(t, string(content), tt.contain)
+		})
+	}
+}
+
+func TestDumpToFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.secret.env")
+
+	// Existing, world-readable, file is replaced.
+	require.NoError(t, os.WriteFile(filename, []byte("OLD=1\n"), 0o644))
+
+	require.NoError(t, dumpToFile(filename, map[string]string{"B": "2", "A": "1"}, false))
+
+	content, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Equal(t, "A=1\nB=2\n", string(content))
+
+	info, err := os.Stat(filename)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	// Format comes from the target name, not from the temporary file.
+	original := dumpFormat
+	t.Cleanup(func() {
+		dumpFormat = original
+	})
+
+	dumpFormat = "k8s-secret"
+
+	require.NoError(t, dumpToFile(filename, map[string]string{"A": "1"}, false))
+
+	content, err = os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "name: app\n")
+
+	// Failures leave the target, and no temporary files, behind.
+	dumpFormat = "xml"
+
+	require.Error(t, dumpToFile(filename, map[string]string{"A": "1"}, false))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "app.secret.env", entries[0].Name())
+
+	require.Error(t, dumpToFile(filepath.Join(dir, "missing", "dump.env"), map[string]string{}, false))
+}
@@ -40,7 +40,7 @@ NOTE: If no app role is set, the provider will default to using token.`,
 			log.Fatalln(err)
 		}
 
-		parsedFile, err := util.ParseFile(ctx, f)
+		parsedFile, err := util.ParseSource(ctx, f, parseOptions()...)
 		if err != nil {
 			log.Fatalln(err)
 		}
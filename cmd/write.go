@@ -23,7 +23,7 @@ func init() {
 		"source",
 		"s",
 		"",
-		"Configuration source file",
+		"Configuration source file. Kubernetes Secret, and ConfigMap manifests, and PaaS exports (JSON, or YAML) are recognized",
 	)
 
 	writeCmd.SetUsageTemplate(`Usage:{{if .Runnable}}
@@ -1,10 +1,18 @@
 package config
 
 import (
+	"encoding/json"
+	"errors"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 
+	"github.com/pelletier/go-toml"
+	"github.com/thalesfsp/configurer/util"
 	"github.com/thalesfsp/customerror"
+	"github.com/thalesfsp/validation"
 	"gopkg.in/yaml.v3"
 )
 
@@ -13,12 +21,58 @@ const (
 	dirperm  = 0o755
 )
 
+const (
+	// JSON format.
+	JSON = "json"
+
+	// TOML format.
+	TOML = "toml"
+
+	// YAML format. Default when the extension isn't recognized.
+	YAML = "yaml"
+)
+
+// Extensions is the list of configuration file extensions, in lookup order.
+var Extensions = []string{".yaml", ".yml", ".json", ".toml"}
+
+// systemConfigDir is where system-wide configuration lives.
+var systemConfigDir = "/etc"
+
+// layer is a configuration file to be merged.
+type layer struct {
+	data   []byte
+	format string
+}
+
+//////
+// Exported feature(s).
+//////
+
 // LoadConfiguration is a generic (T) function that handles reading, and writing
-// configuration - currently only in YAML format. If the provided file does not
-// exist, it will write the default configuration to the file, otherwise it will
-// read the file and unmarshal it into the provided configuration struct.
-// `defaultConfiguration` is required. `appName` is only required if no
-// `filePath` is provided.
+// configuration. The format is determined by the file extension: `.json`,
+// `.toml`, or `.yaml` | `.yml` (default). `defaultConfiguration` is required.
+// `appName` is only required if no `filePath` is provided.
+//
+// If `filePath` is provided, it's the only file read. If it does not exist, or
+// is empty, the default configuration is written to it, atomically, and with
+// the current schema version, like `Save` does. Old files are upgraded with the
+// migrations registered for `T` (see `RegisterMigration`).
+//
+// Otherwise, the following files are merged, in order, later ones taking
+// precedence. For each, the first existing extension (see `Extensions`) wins:
+// 1. System: `/etc/<appName>/config.<ext>`
+// 2. User: `$XDG_CONFIG_HOME/<appName>/config.<ext>`, where `XDG_CONFIG_HOME`
+// defaults to `~/.config`.
+// 3. Project: `.<appName>.<ext>` in the working directory.
+//
+// Files are merged over the default configuration, and nothing is written.
+//
+// NOTE: Before layering, the user file was created with the default
+// configuration on the first run. To keep it, create `UserConfigDir`, and
+// `Save` the defaults to it before loading.
+//
+// Finally, it applies `util.SetDefault`, `util.SetEnv`, and validates the
+// result using the `validate` field tag.
 func LoadConfiguration[T any](
 	filePath string,
 	appName string,
@@ -33,90 +87,280 @@ func LoadConfiguration[T any](
 		return nil, customerror.NewRequiredError("appName")
 	}
 
+	var (
+		c   *T
+		err error
+	)
+
+	if filePath != "" {
+		c, err = loadFile(filePath, defaultConfiguration)
+	} else {
+		c, err = loadLayers(appName, defaultConfiguration)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := util.SetDefault(c); err != nil {
+		return nil, customerror.NewFailedToError("set default values", customerror.WithError(err))
+	}
+
+	if err := util.SetEnv(c); err != nil {
+		return nil, customerror.NewFailedToError("set values from env vars", customerror.WithError(err))
+	}
+
+	if err := validation.Validate(c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// UserConfigDir returns the directory of the user configuration for `appName`:
+// `$XDG_CONFIG_HOME/<appName>`, where `XDG_CONFIG_HOME` defaults to
+// `~/.config`.
+func UserConfigDir(appName string) (string, error) {
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); filepath.IsAbs(xdg) {
+		return filepath.Join(xdg, appName), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", customerror.NewFailedToError("get home directory", customerror.WithError(err))
+	}
+
+	return filepath.Join(home, ".config", appName), nil
+}
+
+//////
+// Helpers.
+//////
+
+// Format returns the format of the file based on its extension.
+func Format(filePath string) string {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".json":
+		return JSON
+	case ".toml":
+		return TOML
+	default:
+		return YAML
+	}
+}
+
+// loadFile reads `filePath`, writing the default configuration to it if it does
+// not exist, or is empty.
+func loadFile[T any](filePath string, defaultConfiguration *T) (*T, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, customerror.NewFailedToError("read config file", customerror.WithError(err))
+	}
+
+	if len(data) < 1 {
+		if data, err = writeDefault(filePath, defaultConfiguration); err != nil {
+			return nil, err
+		}
+
+		if data == nil {
+			return defaultConfiguration, nil
+		}
+	}
+
 	c := new(T)
 
-	// If no filePath provided, use default path.
-	if filePath == "" {
-		home, err := os.UserHomeDir()
+	if err := decodeVersioned(Format(filePath), data, c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// loadLayers merges the system, user, and project configuration files over
+// the default configuration.
+func loadLayers[T any](appName string, defaultConfiguration *T) (*T, error) {
+	userDir, err := UserConfigDir(appName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Layers, `default`, and `env` tags write through pointers, maps, and
+	// slices, which must not be shared with the caller's defaults.
+	c := clone(reflect.ValueOf(defaultConfiguration)).Interface().(*T)
+
+	for _, base := range []string{
+		filepath.Join(systemConfigDir, appName, "config"),
+		filepath.Join(userDir, "config"),
+		"." + appName,
+	} {
+		l, err := findLayer(base)
 		if err != nil {
-			return nil, customerror.NewFailedToError(
-				"get home directory: %w",
-				customerror.WithError(err),
-			)
+			return nil, err
+		}
+
+		if l == nil {
+			continue
 		}
 
-		filePath = filepath.Join(
-			home,
-			".config",
-			appName,
-			"config.yaml",
-		)
-
-		// Create config directory if using home dir.
-		dir := filepath.Dir(filePath)
-		if err := os.MkdirAll(dir, dirperm); err != nil {
-			return nil, customerror.NewFailedToError(
-				"create config directory: %w",
-				customerror.WithError(err),
-			)
+		if err := decode(l.format, l.data, c); err != nil {
+			return nil, err
 		}
 	}
 
-	// Try to read config file.
-	data, err := os.ReadFile(filePath)
-	if err != nil {
-		// File doesn't exist, proceed to write default config.
-		if !os.IsNotExist(err) {
-			return nil, customerror.NewFailedToError(
-				"read config file: %w",
-				customerror.WithError(err),
-			)
+	return c, nil
+}
+
+// clone returns a deep copy of `v`. Pointers, maps, slices, and interfaces
+// aren't shared with `v`. Unexported fields are copied shallowly.
+//
+//nolint:intrange
+func clone(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
 		}
 
-		// Convert default config to YAML.
-		data, err := yaml.Marshal(defaultConfiguration)
-		if err != nil {
-			return nil, customerror.NewFailedToError(
-				"marshal default config: %w",
-				customerror.WithError(err),
-			)
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(clone(v.Elem()))
+
+		return c
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+
+		c := reflect.New(v.Type()).Elem()
+		c.Set(clone(v.Elem()))
+
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+
+		for i := 0; i < v.NumField(); i++ {
+			if c.Field(i).CanSet() {
+				c.Field(i).Set(clone(v.Field(i)))
+			}
+		}
+
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+
+		for iter := v.MapRange(); iter.Next(); {
+			c.SetMapIndex(iter.Key(), clone(iter.Value()))
 		}
 
-		// Write yaml to file.
-		if err := os.WriteFile(filePath, data, fileperm); err != nil {
-			return nil, customerror.NewFailedToError(
-				"write default config: %w",
-				customerror.WithError(err),
-			)
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(clone(v.Index(i)))
+		}
+
+		return c
+	case reflect.Array:
+		c := reflect.New(v.Type()).Elem()
+
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(clone(v.Index(i)))
 		}
 
-		return defaultConfiguration, nil
+		return c
+	default:
+		return v
 	}
+}
 
-	if len(data) < 1 {
-		// Convert default config to YAML.
-		marshaled, err := yaml.Marshal(defaultConfiguration)
+// findLayer reads the first existing, non-empty `base` + extension file. It
+// returns nil if there's none.
+func findLayer(base string) (*layer, error) {
+	for _, extension := range Extensions {
+		data, err := os.ReadFile(base + extension)
 		if err != nil {
-			return nil, customerror.NewFailedToError("marshal default config: %w",
-				customerror.WithError(err),
-			)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+
+			return nil, customerror.NewFailedToError("read config file", customerror.WithError(err))
 		}
 
-		// Write yaml to file.
-		if err := os.WriteFile(filePath, marshaled, fileperm); err != nil {
-			return nil, customerror.NewFailedToError("write default config: %w",
-				customerror.WithError(err),
-			)
+		if len(data) < 1 {
+			continue
 		}
 
-		return defaultConfiguration, nil
+		return &layer{data: data, format: Format(base + extension)}, nil
 	}
 
-	if err := yaml.Unmarshal(data, c); err != nil {
-		return nil, customerror.NewFailedToError("parse config file: %w",
-			customerror.WithError(err),
-		)
+	return nil, nil
+}
+
+// writeDefault writes `defaultConfiguration` to `filePath`, like `Save`, but
+// without validating it, as `default`, and `env` tags aren't applied yet. If
+// another process wrote the file in the meantime, it returns its content
+// instead.
+func writeDefault[T any](filePath string, defaultConfiguration *T) ([]byte, error) {
+	unlock, err := lock(filePath)
+	if err != nil {
+		return nil, customerror.NewFailedToError("write default config", customerror.WithError(err))
 	}
 
-	return c, nil
+	defer unlock()
+
+	data, err := os.ReadFile(filePath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, customerror.NewFailedToError("read config file", customerror.WithError(err))
+	}
+
+	if len(data) > 0 {
+		return data, nil
+	}
+
+	if err := write(filePath, defaultConfiguration); err != nil {
+		return nil, customerror.NewFailedToError("write default config", customerror.WithError(err))
+	}
+
+	return nil, nil
+}
+
+// decode unmarshals `data` in `format` into `v`, overlaying existing values.
+func decode(format string, data []byte, v any) error {
+	var err error
+
+	switch format {
+	case JSON:
+		err = json.Unmarshal(data, v)
+	case TOML:
+		err = toml.Unmarshal(data, v)
+	default:
+		err = yaml.Unmarshal(data, v)
+	}
+
+	if err != nil {
+		return customerror.NewFailedToError("parse config file", customerror.WithError(err))
+	}
+
+	return nil
+}
+
+// encode marshals `v` in `format`.
+func encode(format string, v any) ([]byte, error) {
+	switch format {
+	case JSON:
+		return json.MarshalIndent(v, "", "  ")
+	case TOML:
+		return toml.Marshal(v)
+	default:
+		return yaml.Marshal(v)
+	}
 }
@@ -8,6 +8,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/thalesfsp/configurer/internal/testenv"
 )
 
 //////
@@ -43,18 +44,6 @@ func TestLoadConfigurationWritesDefaults(t *testing.T) {
 				return filepath.Join(t.TempDir(), "config.yaml")
 			},
 		},
-		{
-			name:    "default home path",
-			appName: "configurer-coverage",
-			filePath: func(t *testing.T) string {
-				t.Helper()
-
-				home := t.TempDir()
-				t.Setenv("HOME", home)
-
-				return ""
-			},
-		},
 	}
 
 	for _, tt := range tests {
@@ -66,12 +55,6 @@ func TestLoadConfigurationWritesDefaults(t *testing.T) {
 			require.NoError(t, err)
 			assert.Same(t, defaultConfig, got)
 
-			if filePath == "" {
-				home, err := os.UserHomeDir()
-				require.NoError(t, err)
-				filePath = filepath.Join(home, ".config", tt.appName, "config.yaml")
-			}
-
 			data, err := os.ReadFile(filePath)
 			require.NoError(t, err)
 			assert.Equal(t, "model: default\n", string(data))
@@ -83,6 +66,18 @@ func TestLoadConfigurationWritesDefaults(t *testing.T) {
 	}
 }
 
+func TestLoadConfigurationDefaultHomePathWritesNothing(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	testenv.Unset(t, "XDG_CONFIG_HOME")
+
+	got, err := LoadConfiguration("", "configurer-coverage", &coverageConfig{Model: "default"})
+	require.NoError(t, err)
+	assert.Equal(t, &coverageConfig{Model: "default"}, got)
+
+	assert.NoDirExists(t, filepath.Join(home, ".config", "configurer-coverage"))
+}
+
 //////
 // Validation and error paths.
 //////
@@ -116,6 +111,8 @@ func TestLoadConfigurationErrors(t *testing.T) {
 			setup: func(t *testing.T) (string, string, *coverageConfig) {
 				t.Helper()
 
+				testenv.Unset(t, "XDG_CONFIG_HOME")
+
 				home, hadHome := os.LookupEnv("HOME")
 				require.NoError(t, os.Unsetenv("HOME"))
 				t.Cleanup(func() {
@@ -131,17 +128,18 @@ func TestLoadConfigurationErrors(t *testing.T) {
 			wantError: "get home directory",
 		},
 		{
-			name: "config directory cannot be created",
+			name: "config directory is a file",
 			setup: func(t *testing.T) (string, string, *coverageConfig) {
 				t.Helper()
 
 				homeFile := filepath.Join(t.TempDir(), "home-file")
 				require.NoError(t, os.WriteFile(homeFile, []byte("not a directory"), 0o600))
 				t.Setenv("HOME", homeFile)
+				testenv.Unset(t, "XDG_CONFIG_HOME")
 
 				return "", "configurer-coverage", &coverageConfig{Model: "default"}
 			},
-			wantError: "create config directory",
+			wantError: "read config file",
 		},
 		{
 			name: "config path is a directory",
@@ -190,15 +188,16 @@ func TestLoadConfigurationErrors(t *testing.T) {
 			wantError: "read config file",
 		},
 		{
-			name: "empty file is not writable",
+			name: "empty file directory is not writable",
 			setup: func(t *testing.T) (string, string, *coverageConfig) {
 				t.Helper()
 
-				path := filepath.Join(t.TempDir(), "config.yaml")
+				dir := t.TempDir()
+				path := filepath.Join(dir, "config.yaml")
 				require.NoError(t, os.WriteFile(path, nil, 0o600))
-				require.NoError(t, os.Chmod(path, 0o400))
+				require.NoError(t, os.Chmod(dir, 0o500))
 				t.Cleanup(func() {
-					require.NoError(t, os.Chmod(path, 0o600))
+					require.NoError(t, os.Chmod(dir, 0o700))
 				})
 
 				return path, "", &coverageConfig{Model: "default"}
@@ -215,7 +214,7 @@ func TestLoadConfigurationErrors(t *testing.T) {
 					Unsupported: &coverageMarshalFailure{},
 				}
 			},
-			wantError: "marshal default config",
+			wantError: "marshal config",
 		},
 	}
 
@@ -0,0 +1,252 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thalesfsp/configurer/internal/testenv"
+)
+
+//////
+// Test types.
+//////
+
+type layeredDatabase struct {
+	Host string `json:"host" toml:"host" yaml:"host"`
+	Port int    `json:"port" toml:"port" yaml:"port" default:"5432"`
+}
+
+type layeredConfig struct {
+	Database layeredDatabase `json:"database" toml:"database" yaml:"database"`
+	Level    string          `json:"level"    toml:"level"    yaml:"level"    env:"LAYERED_LEVEL" validate:"required"`
+	Name     string          `json:"name"     toml:"name"     yaml:"name"`
+}
+
+type layeredShared struct {
+	Database *layeredDatabase  `json:"database" toml:"database" yaml:"database"`
+	Labels   map[string]string `json:"labels"   toml:"labels"   yaml:"labels"`
+	Hosts    []string          `json:"hosts"    toml:"hosts"    yaml:"hosts"`
+}
+
+// setupLayers isolates the system, user, and project configuration
+// directories, returning them.
+func setupLayers(t *testing.T) (string, string, string) {
+	t.Helper()
+
+	system := t.TempDir()
+	user := t.TempDir()
+	project := t.TempDir()
+
+	previous := systemConfigDir
+	systemConfigDir = system
+
+	t.Cleanup(func() { systemConfigDir = previous })
+
+	t.Setenv("XDG_CONFIG_HOME", user)
+	t.Chdir(project)
+
+	testenv.Unset(t, "LAYERED_LEVEL")
+
+	return system, user, project
+}
+
+func writeLayer(t *testing.T, path, content string) {
+	t.Helper()
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), dirperm))
+	require.NoError(t, os.WriteFile(path, []byte(content), fileperm))
+}
+
+//////
+// Formats.
+//////
+
+func TestLoadConfigurationFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		written string
+	}{
+		{
+			name:    "json",
+			file:    "config.json",
+			content: `{"name":"json","level":"info","database":{"host":"db"}}`,
+			written: "{\n  \"database\": {\n    \"host\": \"\",\n    \"port\": 0\n  },\n  \"level\": \"debug\",\n  \"name\": \"default\"\n}",
+		},
+		{
+			name:    "toml",
+			file:    "config.toml",
+			content: "name = \"toml\"\nlevel = \"info\"\n\n[database]\nhost = \"db\"\n",
+			written: "level = \"debug\"\nname = \"default\"\n\n[database]\n  host = \"\"\n  port = 0\n",
+		},
+		{
+			name:    "yml",
+			file:    "config.yml",
+			content: "name: yml\nlevel: info\ndatabase:\n  host: db\n",
+			written: "database:\n    host: \"\"\n    port: 0\nlevel: debug\nname: default\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testenv.Unset(t, "LAYERED_LEVEL")
+
+			path := filepath.Join(t.TempDir(), tt.file)
+			writeLayer(t, path, tt.content)
+
+			got, err := LoadConfiguration(path, "", &layeredConfig{Name: "default", Level: "debug"})
+			require.NoError(t, err)
+			assert.Equal(t, &layeredConfig{
+				Database: layeredDatabase{Host: "db", Port: 5432},
+				Level:    "info",
+				Name:     tt.name,
+			}, got)
+
+			t.Run("writes default", func(t *testing.T) {
+				path := filepath.Join(t.TempDir(), tt.file)
+
+				_, err := LoadConfiguration(path, "", &layeredConfig{Name: "default", Level: "debug"})
+				require.NoError(t, err)
+
+				content, err := os.ReadFile(path)
+				require.NoError(t, err)
+				assert.Equal(t, tt.written, string(content))
+			})
+		})
+	}
+}
+
+//////
+// Layering.
+//////
+
+func TestLoadConfigurationLayers(t *testing.T) {
+	t.Run("merges system, user, and project", func(t *testing.T) {
+		system, user, project := setupLayers(t)
+
+		writeLayer(t, filepath.Join(system, "layered", "config.toml"), "name = \"system\"\nlevel = \"warn\"\n\n[database]\nhost = \"system-db\"\nport = 1\n")
+		writeLayer(t, filepath.Join(user, "layered", "config.json"), `{"name":"user","database":{"host":"user-db"}}`)
+		writeLayer(t, filepath.Join(project, ".layered.yaml"), "name: project\n")
+
+		got, err := LoadConfiguration("", "layered", &layeredConfig{Name: "default", Level: "debug"})
+		require.NoError(t, err)
+		assert.Equal(t, &layeredConfig{
+			Database: layeredDatabase{Host: "user-db", Port: 1},
+			Level:    "warn",
+			Name:     "project",
+		}, got)
+	})
+
+	t.Run("missing user file merges system over defaults, and writes nothing", func(t *testing.T) {
+		system, user, project := setupLayers(t)
+
+		writeLayer(t, filepath.Join(system, "layered", "config.yaml"), "name: system\n")
+		writeLayer(t, filepath.Join(project, ".layered.json"), `{"level":"info"}`)
+
+		defaultConfig := &layeredConfig{Name: "default", Level: "debug"}
+
+		got, err := LoadConfiguration("", "layered", defaultConfig)
+		require.NoError(t, err)
+		assert.Equal(t, &layeredConfig{
+			Database: layeredDatabase{Port: 5432},
+			Level:    "info",
+			Name:     "system",
+		}, got)
+
+		assert.Equal(t, &layeredConfig{Name: "default", Level: "debug"}, defaultConfig)
+		assert.NoDirExists(t, filepath.Join(user, "layered"))
+
+		// The system file keeps applying on later runs.
+		writeLayer(t, filepath.Join(system, "layered", "config.yaml"), "name: system-updated\n")
+
+		got, err = LoadConfiguration("", "layered", &layeredConfig{Name: "default", Level: "debug"})
+		require.NoError(t, err)
+		assert.Equal(t, "system-updated", got.Name)
+	})
+
+	t.Run("loading twice doesn't leak into the defaults", func(t *testing.T) {
+		system, _, _ := setupLayers(t)
+
+		newDefaults := func() *layeredShared {
+			return &layeredShared{
+				Database: &layeredDatabase{Host: "default-db"},
+				Labels:   map[string]string{"team": "core"},
+				Hosts:    []string{"default"},
+			}
+		}
+
+		defaultConfig := newDefaults()
+
+		writeLayer(t, filepath.Join(system, "layered", "config.yaml"), "database:\n  host: system-db\nlabels:\n  env: prod\nhosts: [system]\n")
+
+		got, err := LoadConfiguration("", "layered", defaultConfig)
+		require.NoError(t, err)
+		assert.Equal(t, &layeredShared{
+			Database: &layeredDatabase{Host: "system-db", Port: 5432},
+			Labels:   map[string]string{"team": "core", "env": "prod"},
+			Hosts:    []string{"system"},
+		}, got)
+
+		assert.Equal(t, newDefaults(), defaultConfig)
+
+		require.NoError(t, os.Remove(filepath.Join(system, "layered", "config.yaml")))
+
+		got, err = LoadConfiguration("", "layered", defaultConfig)
+		require.NoError(t, err)
+		assert.Equal(t, &layeredShared{
+			Database: &layeredDatabase{Host: "default-db", Port: 5432},
+			Labels:   map[string]string{"team": "core"},
+			Hosts:    []string{"default"},
+		}, got)
+
+		assert.Equal(t, newDefaults(), defaultConfig)
+	})
+
+	t.Run("env var takes precedence", func(t *testing.T) {
+		_, user, _ := setupLayers(t)
+
+		writeLayer(t, filepath.Join(user, "layered", "config.yaml"), "level: info\n")
+		testenv.Set(t, "LAYERED_LEVEL", "error")
+
+		got, err := LoadConfiguration("", "layered", &layeredConfig{})
+		require.NoError(t, err)
+		assert.Equal(t, "error", got.Level)
+	})
+
+	t.Run("malformed layer", func(t *testing.T) {
+		_, user, project := setupLayers(t)
+
+		writeLayer(t, filepath.Join(user, "layered", "config.yaml"), "level: info\n")
+		writeLayer(t, filepath.Join(project, ".layered.toml"), "level = ")
+
+		_, err := LoadConfiguration("", "layered", &layeredConfig{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "parse config file")
+	})
+
+	t.Run("validation failure", func(t *testing.T) {
+		_, user, _ := setupLayers(t)
+
+		writeLayer(t, filepath.Join(user, "layered", "config.yaml"), "name: user\n")
+
+		_, err := LoadConfiguration("", "layered", &layeredConfig{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Level")
+	})
+}
+
+func TestFormat(t *testing.T) {
+	for path, want := range map[string]string{
+		"config.json": JSON,
+		"CONFIG.TOML": TOML,
+		"config.yaml": YAML,
+		"config.yml":  YAML,
+		"config":      YAML,
+	} {
+		assert.Equal(t, want, Format(path), path)
+	}
+}
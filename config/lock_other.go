@@ -0,0 +1,15 @@
+//go:build !unix && !windows
+
+package config
+
+import "os"
+
+// lockFile is a no-op, file locking isn't supported on this platform.
+func lockFile(_ *os.File) error {
+	return nil
+}
+
+// unlockFile is a no-op, file locking isn't supported on this platform.
+func unlockFile(_ *os.File) error {
+	return nil
+}
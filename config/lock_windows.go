@@ -0,0 +1,27 @@
+//go:build windows
+
+package config
+
+import (
+	"math"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockFile blocks until it takes an exclusive lock on `f`.
+func lockFile(f *os.File) error {
+	return windows.LockFileEx(
+		windows.Handle(f.Fd()),
+		windows.LOCKFILE_EXCLUSIVE_LOCK,
+		0,
+		math.MaxUint32,
+		math.MaxUint32,
+		new(windows.Overlapped),
+	)
+}
+
+// unlockFile releases the lock on `f`.
+func unlockFile(f *os.File) error {
+	return windows.UnlockFileEx(windows.Handle(f.Fd()), 0, math.MaxUint32, math.MaxUint32, new(windows.Overlapped))
+}
@@ -0,0 +1,352 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"reflect"
+	"sync"
+
+	"github.com/pelletier/go-toml"
+	"github.com/thalesfsp/configurer/util"
+	"github.com/thalesfsp/customerror"
+	"github.com/thalesfsp/validation"
+)
+
+//////
+// Vars, consts, and types.
+//////
+
+const (
+	// BackupExtension is appended to the file path to name the backup of the
+	// previous version.
+	BackupExtension = ".bak"
+
+	// LockExtension is appended to the file path to name the advisory lock.
+	LockExtension = ".lock"
+
+	// SchemaVersionKey is the top-level key holding the schema version of a
+	// configuration file.
+	SchemaVersionKey = "schemaVersion"
+)
+
+// Migration upgrades the raw content of a configuration file by one schema
+// version, in place.
+type Migration func(data map[string]interface{}) error
+
+var (
+	migrationsMu sync.RWMutex
+	migrations   = map[reflect.Type]map[int]Migration{}
+)
+
+//////
+// Exported feature(s).
+//////
+
+// RegisterMigration registers `m` to upgrade configuration files of `T` from
+// schema version `from` to `from + 1`. The current schema version of `T` is the
+// highest `from` registered plus one. Files without `SchemaVersionKey` are at
+// version 0.
+//
+// NOTE: Registering the same `from` twice replaces the previous migration.
+func RegisterMigration[T any](from int, m Migration) error {
+	if from < 0 {
+		return customerror.NewInvalidError("from, must be greater than or equal to 0")
+	}
+
+	if m == nil {
+		return customerror.NewRequiredError("migration")
+	}
+
+	t := reflect.TypeOf((*T)(nil)).Elem()
+
+	migrationsMu.Lock()
+	defer migrationsMu.Unlock()
+
+	if migrations[t] == nil {
+		migrations[t] = map[int]Migration{}
+	}
+
+	migrations[t][from] = m
+
+	return nil
+}
+
+// SchemaVersion returns the current schema version of `T`. It's 0 if no
+// migration is registered.
+func SchemaVersion[T any]() int {
+	migrationsMu.RLock()
+	defer migrationsMu.RUnlock()
+
+	version := 0
+
+	for from := range migrations[reflect.TypeOf((*T)(nil)).Elem()] {
+		if from+1 > version {
+			version = from + 1
+		}
+	}
+
+	return version
+}
+
+// Save validates, and writes `c` to `filePath`, in the format determined by its
+// extension. It:
+// 1. Takes an advisory lock on `filePath` + `LockExtension`
+// 2. Copies the previous version, if any, to `filePath` + `BackupExtension`
+// 3. Writes to a temporary file, and renames it over `filePath`, so readers
+// never see a partial file.
+//
+// If migrations are registered for `T`, `SchemaVersionKey` is set to the
+// current schema version.
+func Save[T any](filePath string, c *T) error {
+	if filePath == "" {
+		return customerror.NewRequiredError("filePath")
+	}
+
+	if c == nil {
+		return customerror.NewRequiredError("configuration")
+	}
+
+	unlock, err := lock(filePath)
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	return save(filePath, c)
+}
+
+// Update atomically reads, modifies, and saves the configuration file at
+// `filePath`. It holds the lock for the whole read-modify-write cycle, so
+// concurrent updates don't lose changes. Old files are upgraded with the
+// registered migrations before `fn` is called.
+//
+// NOTE: If `filePath` does not exist, `fn` receives a zero `T`.
+//
+// SEE: `Save` for details.
+func Update[T any](filePath string, fn func(*T) error) error {
+	if filePath == "" {
+		return customerror.NewRequiredError("filePath")
+	}
+
+	if fn == nil {
+		return customerror.NewRequiredError("fn")
+	}
+
+	unlock, err := lock(filePath)
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	c := new(T)
+
+	data, err := os.ReadFile(filePath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return customerror.NewFailedToError("read config file", customerror.WithError(err))
+	}
+
+	if len(data) > 0 {
+		if err := decodeVersioned(Format(filePath), data, c); err != nil {
+			return err
+		}
+	}
+
+	if err := fn(c); err != nil {
+		return customerror.NewFailedToError("update configuration", customerror.WithError(err))
+	}
+
+	return save(filePath, c)
+}
+
+//////
+// Helpers.
+//////
+
+// save validates, and writes `c`. The caller must hold the lock.
+func save[T any](filePath string, c *T) error {
+	if err := validation.Validate(c); err != nil {
+		return err
+	}
+
+	return write(filePath, c)
+}
+
+// write encodes `c`, stamping the schema version, backs up the previous
+// version, and writes it atomically. The caller must hold the lock.
+func write[T any](filePath string, c *T) error {
+	format := Format(filePath)
+
+	data, err := encode(format, c)
+	if err != nil {
+		return customerror.NewFailedToError("marshal config", customerror.WithError(err))
+	}
+
+	if version := SchemaVersion[T](); version > 0 {
+		data, err = setSchemaVersion(format, data, version)
+		if err != nil {
+			return err
+		}
+	}
+
+	previous, err := os.ReadFile(filePath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return customerror.NewFailedToError("read config file", customerror.WithError(err))
+	}
+
+	if err == nil {
+		if err := util.WriteFileAtomicBytes(filePath+BackupExtension, previous); err != nil {
+			return customerror.NewFailedToError("write config backup", customerror.WithError(err))
+		}
+	}
+
+	if err := util.WriteFileAtomicBytes(filePath, data); err != nil {
+		return customerror.NewFailedToError("write config file", customerror.WithError(err))
+	}
+
+	return nil
+}
+
+// lock takes an exclusive advisory lock on `filePath` + `LockExtension`,
+// blocking until it's available. The lock lives in a sidecar file because the
+// configuration file itself is replaced on every write.
+func lock(filePath string) (func(), error) {
+	f, err := os.OpenFile(filePath+LockExtension, os.O_CREATE|os.O_RDWR, fileperm)
+	if err != nil {
+		return nil, customerror.NewFailedToError("open lock file", customerror.WithError(err))
+	}
+
+	if err := lockFile(f); err != nil {
+		f.Close()
+
+		return nil, customerror.NewFailedToError("lock config file", customerror.WithError(err))
+	}
+
+	return func() {
+		_ = unlockFile(f)
+		_ = f.Close()
+	}, nil
+}
+
+// decodeVersioned decodes `data` into `v`, upgrading it first with the
+// migrations registered for the type of `v`.
+func decodeVersioned(format string, data []byte, v any) error {
+	migrationsMu.RLock()
+	registered := migrations[reflect.TypeOf(v).Elem()]
+	migrationsMu.RUnlock()
+
+	if len(registered) > 0 {
+		migrated, err := migrate(format, data, registered)
+		if err != nil {
+			return err
+		}
+
+		data = migrated
+	}
+
+	return decode(format, data, v)
+}
+
+// migrate upgrades `data` to the latest version in `registered`.
+func migrate(format string, data []byte, registered map[int]Migration) ([]byte, error) {
+	raw := map[string]interface{}{}
+
+	if err := decode(format, data, &raw); err != nil {
+		return nil, err
+	}
+
+	version, err := schemaVersion(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := 0
+
+	for from := range registered {
+		if from+1 > latest {
+			latest = from + 1
+		}
+	}
+
+	if version >= latest {
+		return data, nil
+	}
+
+	for ; version < latest; version++ {
+		m, ok := registered[version]
+		if !ok {
+			return nil, customerror.NewMissingError(fmt.Sprintf("migration from schema version %d", version))
+		}
+
+		if err := m(raw); err != nil {
+			return nil, customerror.NewFailedToError(
+				fmt.Sprintf("migrate config from schema version %d", version),
+				customerror.WithError(err),
+			)
+		}
+	}
+
+	raw[SchemaVersionKey] = latest
+
+	migrated, err := encodeMap(format, raw)
+	if err != nil {
+		return nil, customerror.NewFailedToError("marshal migrated config", customerror.WithError(err))
+	}
+
+	return migrated, nil
+}
+
+// schemaVersion reads `SchemaVersionKey` from `raw`. Its absence means 0.
+func schemaVersion(raw map[string]interface{}) (int, error) {
+	switch v := raw[SchemaVersionKey].(type) {
+	case nil:
+		return 0, nil
+	case int:
+		return v, nil
+	case int64:
+		return int(v), nil
+	case uint64:
+		return int(v), nil
+	case float64:
+		if v == float64(int(v)) {
+			return int(v), nil
+		}
+	}
+
+	return 0, customerror.NewInvalidError(SchemaVersionKey + ", must be an integer")
+}
+
+// setSchemaVersion sets `SchemaVersionKey` in the encoded `data`.
+func setSchemaVersion(format string, data []byte, version int) ([]byte, error) {
+	raw := map[string]interface{}{}
+
+	if err := decode(format, data, &raw); err != nil {
+		return nil, err
+	}
+
+	raw[SchemaVersionKey] = version
+
+	encoded, err := encodeMap(format, raw)
+	if err != nil {
+		return nil, customerror.NewFailedToError("marshal config", customerror.WithError(err))
+	}
+
+	return encoded, nil
+}
+
+// encodeMap marshals the generic `raw` in `format`.
+func encodeMap(format string, raw map[string]interface{}) ([]byte, error) {
+	if format != TOML {
+		return encode(format, raw)
+	}
+
+	tree, err := toml.TreeFromMap(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return tree.Marshal()
+}
@@ -0,0 +1,312 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//////
+// Test types.
+//////
+
+type saveConfig struct {
+	Count int    `json:"count" toml:"count" yaml:"count"`
+	Name  string `json:"name"  toml:"name"  yaml:"name"  validate:"required"`
+}
+
+type migratedConfig struct {
+	Host          string `json:"host"          toml:"host"          yaml:"host"`
+	Port          int    `json:"port"          toml:"port"          yaml:"port"`
+	SchemaVersion int    `json:"schemaVersion" toml:"schemaVersion" yaml:"schemaVersion"`
+}
+
+type gappedConfig struct {
+	Name string `yaml:"name"`
+}
+
+func init() {
+	// v0 -> v1: `address` was renamed to `host`.
+	if err := RegisterMigration[migratedConfig](0, func(data map[string]interface{}) error {
+		data["host"] = data["address"]
+
+		delete(data, "address")
+
+		return nil
+	}); err != nil {
+		panic(err)
+	}
+
+	// v1 -> v2: `port` became required, defaulting to 80.
+	if err := RegisterMigration[migratedConfig](1, func(data map[string]interface{}) error {
+		if _, ok := data["port"]; !ok {
+			data["port"] = 80
+		}
+
+		return nil
+	}); err != nil {
+		panic(err)
+	}
+
+	if err := RegisterMigration[gappedConfig](1, func(map[string]interface{}) error { return nil }); err != nil {
+		panic(err)
+	}
+}
+
+//////
+// Save.
+//////
+
+func TestSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	require.NoError(t, Save(path, &saveConfig{Name: "first"}))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "count: 0\nname: first\n", string(content))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(fileperm), info.Mode().Perm())
+
+	_, err = os.Stat(path + BackupExtension)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	require.NoError(t, Save(path, &saveConfig{Name: "second"}))
+
+	backup, err := os.ReadFile(path + BackupExtension)
+	require.NoError(t, err)
+	assert.Equal(t, "count: 0\nname: first\n", string(backup))
+
+	// Only the config, its backup, and the lock are left behind.
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 3)
+}
+
+func TestSaveErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name      string
+		filePath  string
+		config    *saveConfig
+		wantError string
+	}{
+		{
+			name:      "missing path",
+			config:    &saveConfig{Name: "name"},
+			wantError: "filePath",
+		},
+		{
+			name:      "missing configuration",
+			filePath:  filepath.Join(dir, "config.yaml"),
+			wantError: "configuration",
+		},
+		{
+			name:      "invalid configuration",
+			filePath:  filepath.Join(dir, "config.yaml"),
+			config:    &saveConfig{},
+			wantError: "Name",
+		},
+		{
+			name:      "missing parent directory",
+			filePath:  filepath.Join(dir, "missing", "config.yaml"),
+			config:    &saveConfig{Name: "name"},
+			wantError: "open lock file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Save(tt.filePath, tt.config)
+			require.Error(t, err)
+			assert.ErrorContains(t, err, tt.wantError)
+		})
+	}
+
+	_, err := os.Stat(filepath.Join(dir, "config.yaml"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+//////
+// Update.
+//////
+
+func TestUpdate(t *testing.T) {
+	for _, file := range []string{"config.json", "config.toml", "config.yaml"} {
+		t.Run(file, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), file)
+
+			require.NoError(t, Save(path, &saveConfig{Name: "app"}))
+
+			var wg sync.WaitGroup
+
+			for range 20 {
+				wg.Add(1)
+
+				go func() {
+					defer wg.Done()
+
+					assert.NoError(t, Update(path, func(c *saveConfig) error {
+						c.Count++
+
+						return nil
+					}))
+				}()
+			}
+
+			wg.Wait()
+
+			got, err := LoadConfiguration(path, "", &saveConfig{})
+			require.NoError(t, err)
+			assert.Equal(t, &saveConfig{Count: 20, Name: "app"}, got)
+		})
+	}
+}
+
+func TestUpdateErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	require.NoError(t, Save(path, &saveConfig{Name: "app"}))
+
+	t.Run("missing fn", func(t *testing.T) {
+		assert.ErrorContains(t, Update[saveConfig](path, nil), "fn")
+	})
+
+	t.Run("missing path", func(t *testing.T) {
+		assert.ErrorContains(t, Update("", func(*saveConfig) error { return nil }), "filePath")
+	})
+
+	t.Run("fn fails", func(t *testing.T) {
+		err := Update(path, func(*saveConfig) error { return errors.New("boom") })
+		assert.ErrorContains(t, err, "update configuration")
+	})
+
+	t.Run("invalid result", func(t *testing.T) {
+		err := Update(path, func(c *saveConfig) error {
+			c.Name = ""
+
+			return nil
+		})
+		assert.ErrorContains(t, err, "Name")
+	})
+
+	t.Run("malformed file", func(t *testing.T) {
+		malformed := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, os.WriteFile(malformed, []byte("name: [unterminated\n"), fileperm))
+
+		err := Update(malformed, func(*saveConfig) error { return nil })
+		assert.ErrorContains(t, err, "parse config file")
+	})
+
+	// Failed updates leave the file untouched.
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "count: 0\nname: app\n", string(content))
+}
+
+func TestUpdateMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+
+	require.NoError(t, Update(path, func(c *saveConfig) error {
+		c.Name = "created"
+
+		return nil
+	}))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"count":0,"name":"created"}`, string(content))
+}
+
+//////
+// Migrations.
+//////
+
+func TestMigrations(t *testing.T) {
+	assert.Equal(t, 2, SchemaVersion[migratedConfig]())
+	assert.Equal(t, 0, SchemaVersion[saveConfig]())
+
+	for file, content := range map[string]string{
+		"config.yaml": "address: db\n",
+		"config.json": `{"address":"db"}`,
+		"config.toml": "address = \"db\"\n",
+	} {
+		t.Run(file, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), file)
+			require.NoError(t, os.WriteFile(path, []byte(content), fileperm))
+
+			got, err := LoadConfiguration(path, "", &migratedConfig{})
+			require.NoError(t, err)
+			assert.Equal(t, &migratedConfig{Host: "db", Port: 80, SchemaVersion: 2}, got)
+
+			require.NoError(t, Update(path, func(c *migratedConfig) error {
+				assert.Equal(t, &migratedConfig{Host: "db", Port: 80, SchemaVersion: 2}, c)
+
+				c.Port = 8080
+
+				return nil
+			}))
+
+			// Up-to-date files aren't migrated again.
+			got, err = LoadConfiguration(path, "", &migratedConfig{})
+			require.NoError(t, err)
+			assert.Equal(t, &migratedConfig{Host: "db", Port: 8080, SchemaVersion: 2}, got)
+
+			backup, err := os.ReadFile(path + BackupExtension)
+			require.NoError(t, err)
+			assert.Equal(t, content, string(backup))
+		})
+	}
+}
+
+func TestMigrationsDefault(t *testing.T) {
+	for _, file := range []string{"config.yaml", "config.json", "config.toml"} {
+		t.Run(file, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), file)
+
+			got, err := LoadConfiguration(path, "", &migratedConfig{Host: "db", Port: 8080})
+			require.NoError(t, err)
+			assert.Equal(t, &migratedConfig{Host: "db", Port: 8080}, got)
+
+			// The default is stamped with the current schema version, so it
+			// isn't migrated again.
+			got, err = LoadConfiguration(path, "", &migratedConfig{})
+			require.NoError(t, err)
+			assert.Equal(t, &migratedConfig{Host: "db", Port: 8080, SchemaVersion: 2}, got)
+
+			assert.NoFileExists(t, path+BackupExtension)
+		})
+	}
+}
+
+func TestMigrationsErrors(t *testing.T) {
+	t.Run("invalid registration", func(t *testing.T) {
+		require.Error(t, RegisterMigration[saveConfig](-1, func(map[string]interface{}) error { return nil }))
+		require.Error(t, RegisterMigration[saveConfig](0, nil))
+		assert.Equal(t, 0, SchemaVersion[saveConfig]())
+	})
+
+	t.Run("missing migration", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("name: old\n"), fileperm))
+
+		_, err := LoadConfiguration(path, "", &gappedConfig{})
+		assert.ErrorContains(t, err, "migration from schema version 0")
+	})
+
+	t.Run("invalid schema version", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("schemaVersion: two\n"), fileperm))
+
+		_, err := LoadConfiguration(path, "", &migratedConfig{})
+		assert.ErrorContains(t, err, SchemaVersionKey)
+	})
+}
@@ -0,0 +1,453 @@
+package consul
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thalesfsp/configurer/option"
+	"github.com/thalesfsp/configurer/parser"
+	"github.com/thalesfsp/configurer/provider"
+	"github.com/thalesfsp/configurer/util"
+	"github.com/thalesfsp/customerror"
+	"github.com/thalesfsp/httpclient/v2"
+	"github.com/thalesfsp/validation"
+	"gopkg.in/yaml.v2"
+)
+
+//////
+// Vars, consts, and types.
+//////
+
+// Name of the provider.
+const Name = "consul"
+
+// DefaultAddress is the default address of the Consul agent.
+const DefaultAddress = "http://127.0.0.1:8500"
+
+// DefaultTimeout is the default request timeout.
+const DefaultTimeout = 30 * time.Second
+
+// maxTxnOperations is the maximum number of operations of a transaction.
+const maxTxnOperations = 64
+
+// Config contains the Consul settings.
+type Config struct {
+	// Address of the Consul agent. Defaults to `DefaultAddress`.
+	Address string `json:"address" validate:"required,url"`
+
+	// Token is the ACL token.
+	Token string `json:"-"`
+
+	// Datacenter to query. Defaults to the agent's one.
+	Datacenter string `json:"datacenter"`
+
+	// Namespace to query (Consul Enterprise).
+	Namespace string `json:"namespace"`
+
+	// Prefix is read recursively, one value per leaf key, e.g.: with the
+	// `app/` prefix, `app/db/host` becomes `db__host`.
+	Prefix string `json:"prefix"`
+
+	// Key is a single key holding a document, e.g.: a JSON, or YAML blob.
+	Key string `json:"key"`
+
+	// Format of the `Key` document: any `parser` format, or `auto` to detect
+	// it. Write supports `json`, and `yaml`. Defaults to `auto`.
+	Format string `json:"format"`
+
+	// ParseOptions flattens nested values of the `Key` document, e.g.:
+	// `database.host` becomes `DATABASE__HOST`. If not set, nested values are
+	// JSON-encoded.
+	ParseOptions []option.ParseFunc `json:"-"`
+
+	// Separator replaces `/` in keys under `Prefix`. Defaults to
+	// `option.DefaultSeparator`.
+	Separator string `json:"separator"`
+
+	// Timeout of requests. Defaults to `DefaultTimeout`.
+	Timeout time.Duration `json:"timeout" validate:"gte=0"`
+
+	// Wait enables blocking queries: each Load, after the first, blocks until
+	// the values change, or `Wait` elapses, e.g.: for `util.Watch`, with a
+	// short interval.
+	Wait time.Duration `json:"wait" validate:"gte=0"`
+}
+
+// Consul provider definition.
+type Consul struct {
+	*provider.Provider `json:"-" validate:"required"`
+
+	Configuration *Config            `json:"-" validate:"required"`
+	client        *httpclient.Client `json:"-" validate:"required"`
+
+	mu    sync.Mutex
+	index uint64
+}
+
+// kvPair is a KV entry.
+type kvPair struct {
+	Key   string `json:"Key"`
+	Value []byte `json:"Value"`
+}
+
+// txnKVOperation is a KV operation of a transaction.
+type txnKVOperation struct {
+	Verb      string `json:"Verb"`
+	Key       string `json:"Key"`
+	Value     []byte `json:"Value"`
+	Namespace string `json:"Namespace,omitempty"`
+}
+
+// txnOperation is an operation of a transaction.
+type txnOperation struct {
+	KV txnKVOperation `json:"KV"`
+}
+
+//////
+// IProvider implementation.
+//////
+
+// Load reads the keys under `Prefix`, or the `Key` document, and exports the
+// values to the environment.
+func (c *Consul) Load(ctx context.Context, opts ...option.LoadKeyFunc) (map[string]string, error) {
+	pairs, err := c.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]any)
+
+	if c.Configuration.Key != "" {
+		if values, err = c.parseDocument(ctx, pairs); err != nil {
+			return nil, err
+		}
+	} else {
+		for _, pair := range pairs {
+			name := strings.TrimPrefix(pair.Key, c.prefix())
+
+			// Folders.
+			if name == "" || strings.HasSuffix(name, "/") {
+				continue
+			}
+
+			values[strings.ReplaceAll(name, "/", c.Configuration.Separator)] = string(pair.Value)
+		}
+	}
+
+	finalValues := make(map[string]string, len(values))
+
+	for key, value := range values {
+		value, err := util.EncodeValue(value)
+		if err != nil {
+			return nil, customerror.NewFailedToError("encode "+key, customerror.WithError(err))
+		}
+
+		// Should allow to specify options.
+		for _, opt := range opts {
+			key = opt(key)
+		}
+
+		finalValue, err := provider.ExportToEnvVar(c, key, value)
+		if err != nil {
+			return nil, err
+		}
+
+		finalValues[key] = finalValue
+	}
+
+	return finalValues, nil
+}
+
+// Write stores `values` atomically, with a transaction: one key per value under
+// `Prefix`, the separator becoming `/`, or the `Key` document, encoded as
+// `Format`.
+//
+// NOTE: Transactions are limited to 64 operations, writing more than 64 keys
+// under `Prefix` is an error, nothing is written. Use `Key` instead.
+func (c *Consul) Write(ctx context.Context, values map[string]interface{}, opts ...option.WriteFunc) error {
+	if values == nil {
+		return customerror.NewRequiredError("values")
+	}
+
+	var options option.Write
+
+	for _, opt := range opts {
+		if err := opt(&options); err != nil {
+			return err
+		}
+	}
+
+	var operations []txnOperation
+
+	if c.Configuration.Key != "" {
+		document, err := c.encodeDocument(values)
+		if err != nil {
+			return err
+		}
+
+		operations = append(operations, c.setOperation(c.Configuration.Key, document))
+	} else {
+		keys := make([]string, 0, len(values))
+
+		for key := range values {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			encoded, err := util.EncodeValue(values[key])
+			if err != nil {
+				return customerror.NewFailedToError("encode "+key, customerror.WithError(err))
+			}
+
+			value := []byte(fmt.Sprint(encoded))
+
+			name := strings.ReplaceAll(key, c.Configuration.Separator, "/")
+
+			operations = append(operations, c.setOperation(c.prefix()+name, value))
+		}
+	}
+
+	// Splitting into several transactions would make partial writes possible.
+	if len(operations) > maxTxnOperations {
+		return customerror.NewInvalidError(fmt.Sprintf(
+			"values, a transaction is limited to %d keys, got %d. Write fewer keys, or a single document with `Key`",
+			maxTxnOperations, len(operations),
+		))
+	}
+
+	response, err := c.client.Put(
+		ctx,
+		c.Configuration.Address+"/v1/txn",
+		append(c.queryParams(), httpclient.WithReqBody(operations))...,
+	)
+	if err != nil {
+		return customerror.NewFailedToError("write values", customerror.WithError(err))
+	}
+
+	response.Body.Close()
+
+	return nil
+}
+
+//////
+// Helpers.
+//////
+
+// prefix returns the prefix, ending with `/`, if set.
+func (c *Consul) prefix() string {
+	if c.Configuration.Prefix == "" {
+		return ""
+	}
+
+	return strings.TrimSuffix(c.Configuration.Prefix, "/") + "/"
+}
+
+// queryParams returns the datacenter, and namespace query parameters.
+func (c *Consul) queryParams() []httpclient.Func {
+	var params []httpclient.Func
+
+	if c.Configuration.Datacenter != "" {
+		params = append(params, httpclient.WithQueryParam("dc", c.Configuration.Datacenter))
+	}
+
+	if c.Configuration.Namespace != "" {
+		params = append(params, httpclient.WithQueryParam("ns", c.Configuration.Namespace))
+	}
+
+	return params
+}
+
+// read gets the KV pairs, blocking until they change if `Wait` is set, and a
+// previous read set the index.
+func (c *Consul) read(ctx context.Context) ([]kvPair, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	path := c.Configuration.Key
+
+	params := c.queryParams()
+
+	if path == "" {
+		path = c.prefix()
+
+		params = append(params, httpclient.WithQueryParam("recurse", "true"))
+	}
+
+	if c.Configuration.Wait > 0 && c.index > 0 {
+		params = append(
+			params,
+			httpclient.WithQueryParam("index", strconv.FormatUint(c.index, 10)),
+			httpclient.WithQueryParam("wait", c.Configuration.Wait.String()),
+		)
+	}
+
+	var pairs []kvPair
+
+	params = append(params, httpclient.WithRespBody(&pairs))
+
+	response, err := c.client.Get(ctx, c.Configuration.Address+"/v1/kv/"+escapePath(path), params...)
+	if err != nil {
+		return nil, customerror.NewFailedToError("read "+path, customerror.WithError(err))
+	}
+
+	response.Body.Close()
+
+	// Resets the index if it goes backwards, e.g.: after a snapshot restore.
+	index, _ := strconv.ParseUint(response.Header.Get("X-Consul-Index"), 10, 64)
+	if index < c.index {
+		index = 0
+	}
+
+	c.index = index
+
+	return pairs, nil
+}
+
+// parseDocument parses the `Key` document.
+func (c *Consul) parseDocument(ctx context.Context, pairs []kvPair) (map[string]any, error) {
+	if len(pairs) == 0 {
+		return nil, customerror.NewNotFoundError("key " + c.Configuration.Key)
+	}
+
+	values, err := util.ParseContent(ctx, c.Configuration.Format, bytes.NewReader(pairs[0].Value), c.Configuration.ParseOptions...)
+	if err != nil {
+		return nil, customerror.NewFailedToError("parse key "+c.Configuration.Key, customerror.WithError(err))
+	}
+
+	return values, nil
+}
+
+// encodeDocument encodes `values` as the `Key` document.
+func (c *Consul) encodeDocument(values map[string]any) ([]byte, error) {
+	var (
+		document []byte
+		err      error
+	)
+
+	switch c.Configuration.Format {
+	case "yaml", "yml":
+		document, err = yaml.Marshal(values)
+	case "json", parser.Auto:
+		document, err = json.Marshal(values)
+	default:
+		return nil, customerror.NewInvalidError("format, allowed to write: json, yaml")
+	}
+
+	if err != nil {
+		return nil, customerror.NewFailedToError("encode key "+c.Configuration.Key, customerror.WithError(err))
+	}
+
+	return document, nil
+}
+
+// setOperation returns a transaction operation setting `key` to `value`.
+func (c *Consul) setOperation(key string, value []byte) txnOperation {
+	return txnOperation{KV: txnKVOperation{
+		Verb:      "set",
+		Key:       key,
+		Value:     value,
+		Namespace: c.Configuration.Namespace,
+	}}
+}
+
+// escapePath escapes each segment of a KV path.
+func escapePath(path string) string {
+	segments := strings.Split(path, "/")
+
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	return strings.Join(segments, "/")
+}
+
+//////
+// Factory.
+//////
+
+// New creates a Consul provider.
+func New(override, rawValue bool, config *Config) (provider.IProvider, error) {
+	if config == nil {
+		return nil, customerror.NewRequiredError("config")
+	}
+
+	if config.Address == "" {
+		config.Address = DefaultAddress
+	}
+
+	// E.g.: `CONSUL_HTTP_ADDR=127.0.0.1:8500`.
+	if !strings.Contains(config.Address, "://") {
+		config.Address = "http://" + config.Address
+	}
+
+	config.Address = strings.TrimSuffix(config.Address, "/")
+	config.Prefix = strings.TrimPrefix(config.Prefix, "/")
+	config.Key = strings.TrimPrefix(config.Key, "/")
+
+	if config.Format == "" {
+		config.Format = parser.Auto
+	}
+
+	if config.Separator == "" {
+		config.Separator = option.DefaultSeparator
+	}
+
+	if config.Timeout == 0 {
+		config.Timeout = DefaultTimeout
+	}
+
+	if err := validation.Validate(config); err != nil {
+		return nil, err
+	}
+
+	if (config.Prefix == "") == (config.Key == "") {
+		return nil, customerror.NewInvalidError("prefix, and key, exactly one is required")
+	}
+
+	if config.Format != parser.Auto {
+		if _, err := parser.Get(config.Format); err != nil {
+			return nil, customerror.NewInvalidError("format, allowed: " + strings.Join(parser.Formats(), ", ") + ", " + parser.Auto)
+		}
+	}
+
+	baseProvider, err := provider.New(Name, override, rawValue)
+	if err != nil {
+		return nil, err
+	}
+
+	clientOptions := []httpclient.ClientFunc{httpclient.WithClientName(Name)}
+
+	if config.Token != "" {
+		clientOptions = append(clientOptions, httpclient.WithClientHeader("X-Consul-Token", config.Token))
+	}
+
+	client, err := httpclient.NewDefault(clientOptions...)
+	if err != nil {
+		return nil, customerror.NewFailedToError("initialize HTTP client", customerror.WithError(err))
+	}
+
+	// Consul adds up to `wait / 16` of jitter to blocking queries.
+	client.GetClient().Timeout = config.Timeout + config.Wait + config.Wait/16
+
+	c := &Consul{
+		Provider:      baseProvider,
+		Configuration: config,
+		client:        client,
+	}
+
+	if err := validation.Validate(c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
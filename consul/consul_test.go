@@ -0,0 +1,463 @@
+package consul
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thalesfsp/configurer/option"
+)
+
+// fakeConsul is an in-memory Consul KV, and transaction API.
+type fakeConsul struct {
+	mu      sync.Mutex
+	changed chan struct{}
+	index   uint64
+	kv      map[string][]byte
+	queries []string
+	txns    int
+}
+
+func newFakeConsul(t *testing.T, kv map[string]string) (*fakeConsul, *httptest.Server) {
+	t.Helper()
+
+	f := &fakeConsul{changed: make(chan struct{}), index: 1, kv: map[string][]byte{}}
+
+	for key, value := range kv {
+		f.kv[key] = []byte(value)
+	}
+
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+
+	return f, server
+}
+
+func (f *fakeConsul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Consul-Token") != "acl-token" {
+		http.Error(w, "ACL not found", http.StatusForbidden)
+
+		return
+	}
+
+	f.mu.Lock()
+	f.queries = append(f.queries, r.URL.RawQuery)
+	f.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/v1/kv/"):
+		f.get(w, r)
+	case r.Method == http.MethodPut && r.URL.Path == "/v1/txn":
+		f.txn(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeConsul) get(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	f.mu.Lock()
+	index, changed := f.index, f.changed
+	f.mu.Unlock()
+
+	// Blocking query.
+	if query.Get("index") == strconv.FormatUint(index, 10) {
+		wait, err := time.ParseDuration(query.Get("wait"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+
+			return
+		}
+
+		select {
+		case <-changed:
+		case <-time.After(wait):
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := strings.TrimPrefix(r.URL.Path, "/v1/kv/")
+
+	type pair struct {
+		Key         string
+		Value       []byte
+		ModifyIndex uint64
+	}
+
+	var pairs []pair
+
+	for k, value := range f.kv {
+		if k == key || (query.Has("recurse") && strings.HasPrefix(k, key)) {
+			pairs = append(pairs, pair{Key: k, Value: value, ModifyIndex: f.index})
+		}
+	}
+
+	w.Header().Set("X-Consul-Index", strconv.FormatUint(f.index, 10))
+
+	if len(pairs) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+
+		return
+	}
+
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].Key < pairs[j].Key })
+
+	_ = json.NewEncoder(w).Encode(pairs)
+}
+
+func (f *fakeConsul) txn(w http.ResponseWriter, r *http.Request) {
+	var operations []txnOperation
+
+	if err := json.NewDecoder(r.Body).Decode(&operations); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	if len(operations) > maxTxnOperations {
+		http.Error(w, "too many operations", http.StatusRequestEntityTooLarge)
+
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, operation := range operations {
+		if operation.KV.Verb != "set" || operation.KV.Namespace != "team" {
+			http.Error(w, "unexpected operation", http.StatusConflict)
+
+			return
+		}
+	}
+
+	for _, operation := range operations {
+		f.kv[operation.KV.Key] = operation.KV.Value
+	}
+
+	f.txns++
+	f.index++
+
+	close(f.changed)
+	f.changed = make(chan struct{})
+
+	_, _ = w.Write([]byte(`{"Results": [], "Errors": null}`))
+}
+
+// load loads, and unsets the exported values.
+func load(t *testing.T, c *Consul, opts ...option.LoadKeyFunc) map[string]string {
+	t.Helper()
+
+	got, err := c.Load(context.Background(), opts...)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		for key := range got {
+			os.Unsetenv(key)
+		}
+	})
+
+	return got
+}
+
+//////
+// Constructor.
+//////
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *Config
+		want    *Config
+		wantErr string
+	}{
+		{
+			name:   "happy path defaults",
+			config: &Config{Prefix: "/app/"},
+			want:   &Config{Address: DefaultAddress, Prefix: "app/", Format: "auto", Separator: option.DefaultSeparator, Timeout: DefaultTimeout},
+		},
+		{
+			name:   "happy path address without scheme",
+			config: &Config{Address: "consul.internal:8500/", Key: "app/config.yaml", Format: "yaml", Wait: time.Minute},
+			want:   &Config{Address: "http://consul.internal:8500", Key: "app/config.yaml", Format: "yaml", Separator: option.DefaultSeparator, Timeout: DefaultTimeout, Wait: time.Minute},
+		},
+		{
+			name:    "bad path nil config",
+			wantErr: "config",
+		},
+		{
+			name:    "bad path neither prefix, nor key",
+			config:  &Config{},
+			wantErr: "exactly one is required",
+		},
+		{
+			name:    "bad path prefix, and key",
+			config:  &Config{Prefix: "app", Key: "app/config"},
+			wantErr: "exactly one is required",
+		},
+		{
+			name:    "bad path unknown format",
+			config:  &Config{Key: "app/config", Format: "xml"},
+			wantErr: "format, allowed",
+		},
+		{
+			name:    "bad path negative wait",
+			config:  &Config{Prefix: "app", Wait: -time.Second},
+			wantErr: "Wait",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(false, false, tt.config)
+
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				assert.Nil(t, got)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, Name, got.GetName())
+			assert.Equal(t, tt.want, got.(*Consul).Configuration)
+		})
+	}
+}
+
+//////
+// IProvider implementation.
+//////
+
+func TestLoad(t *testing.T) {
+	f, server := newFakeConsul(t, map[string]string{
+		"app/":                 "",
+		"app/CONSUL_TOKEN":     "t0k3n",
+		"app/db/":              "",
+		"app/db/host":          "db.internal",
+		"app/db/port":          "5432",
+		"apple/CONSUL_LEAKED":  "leaked",
+		"config/app.json":      `{"CONSUL_JSON": "j", "CONSUL_NESTED": {"a": 1}}`,
+		"config/app.yaml":      "consul_yaml:\n  host: y\n",
+		"config/app with/a b":  "CONSUL_SPACES=1",
+		"config/app/malformed": `{"CONSUL_BROKEN": `,
+	})
+
+	tests := []struct {
+		name      string
+		config    *Config
+		opts      []option.LoadKeyFunc
+		want      map[string]string
+		wantQuery string
+		wantErr   string
+	}{
+		{
+			name:      "prefix, recursively",
+			config:    &Config{Prefix: "app", Datacenter: "dc2", Namespace: "team"},
+			want:      map[string]string{"CONSUL_TOKEN": "t0k3n", "db__host": "db.internal", "db__port": "5432"},
+			wantQuery: "dc=dc2&ns=team&recurse=true",
+		},
+		{
+			name:   "prefix, with separator, and options",
+			config: &Config{Prefix: "app/db", Separator: "_"},
+			opts:   []option.LoadKeyFunc{option.WithKeyCaser("upper"), option.WithKeyPrefixer("DB_")},
+			want:   map[string]string{"DB_HOST": "db.internal", "DB_PORT": "5432"},
+		},
+		{
+			name:   "JSON document, nested values encoded",
+			config: &Config{Key: "config/app.json"},
+			want:   map[string]string{"CONSUL_JSON": "j", "CONSUL_NESTED": `{"a":1}`},
+		},
+		{
+			name:   "YAML document, flattened",
+			config: &Config{Key: "config/app.yaml", Format: "yaml", ParseOptions: []option.ParseFunc{option.WithFlatten(true)}},
+			want:   map[string]string{"CONSUL_YAML__HOST": "y"},
+		},
+		{
+			name:   "escaped key",
+			config: &Config{Key: "config/app with/a b", Format: "env"},
+			want:   map[string]string{"CONSUL_SPACES": "1"},
+		},
+		{
+			name:    "missing prefix",
+			config:  &Config{Prefix: "missing"},
+			wantErr: "read missing/",
+		},
+		{
+			name:    "malformed document",
+			config:  &Config{Key: "config/app/malformed", Format: "json"},
+			wantErr: "parse key config/app/malformed",
+		},
+		{
+			name:    "invalid token",
+			config:  &Config{Prefix: "app", Token: "wrong"},
+			wantErr: "ACL not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			config.Address = server.URL
+
+			if config.Token == "" {
+				config.Token = "acl-token"
+			}
+
+			p, err := New(true, false, config)
+			require.NoError(t, err)
+
+			if tt.wantErr != "" {
+				_, err := p.Load(context.Background(), tt.opts...)
+				assert.ErrorContains(t, err, tt.wantErr)
+
+				return
+			}
+
+			got := load(t, p.(*Consul), tt.opts...)
+
+			assert.Equal(t, tt.want, got)
+
+			for key, value := range tt.want {
+				assert.Equal(t, value, os.Getenv(key))
+			}
+
+			if tt.wantQuery != "" {
+				f.mu.Lock()
+				assert.Equal(t, tt.wantQuery, f.queries[len(f.queries)-1])
+				f.mu.Unlock()
+			}
+		})
+	}
+}
+
+func TestLoadBlockingQuery(t *testing.T) {
+	f, server := newFakeConsul(t, map[string]string{"app/CONSUL_BLOCKING": "v1"})
+
+	p, err := New(true, false, &Config{Address: server.URL, Token: "acl-token", Prefix: "app", Namespace: "team", Wait: 5 * time.Second})
+	require.NoError(t, err)
+
+	c := p.(*Consul)
+
+	assert.Equal(t, map[string]string{"CONSUL_BLOCKING": "v1"}, load(t, c))
+
+	done := make(chan map[string]string)
+
+	go func() {
+		got, err := c.Load(context.Background())
+		assert.NoError(t, err)
+
+		done <- got
+	}()
+
+	// Blocks until the value changes.
+	select {
+	case <-done:
+		t.Fatal("blocking query returned before the change")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	w, err := New(false, false, &Config{Address: server.URL, Token: "acl-token", Prefix: "app", Namespace: "team"})
+	require.NoError(t, err)
+	require.NoError(t, w.Write(context.Background(), map[string]interface{}{"CONSUL_BLOCKING": "v2"}))
+
+	select {
+	case got := <-done:
+		assert.Equal(t, map[string]string{"CONSUL_BLOCKING": "v2"}, got)
+	case <-time.After(5 * time.Second):
+		t.Fatal("blocking query didn't return after the change")
+	}
+
+	t.Cleanup(func() { os.Unsetenv("CONSUL_BLOCKING") })
+
+	f.mu.Lock()
+	assert.Equal(t, "index=1&ns=team&recurse=true&wait=5s", f.queries[1])
+	f.mu.Unlock()
+
+	// Resets the index if it goes backwards.
+	f.mu.Lock()
+	f.index = 1
+	f.mu.Unlock()
+
+	load(t, c)
+	assert.Equal(t, uint64(0), c.index)
+}
+
+func TestWrite(t *testing.T) {
+	f, server := newFakeConsul(t, nil)
+
+	newConsul := func(config *Config) *Consul {
+		config.Address = server.URL
+		config.Token = "acl-token"
+		config.Namespace = "team"
+
+		p, err := New(false, false, config)
+		require.NoError(t, err)
+
+		return p.(*Consul)
+	}
+
+	ctx := context.Background()
+
+	// Prefix, up to the transaction limit.
+	values := map[string]interface{}{"db__host": "db.internal", "db__port": 5432, "empty": nil, "list": []any{"a"}}
+
+	for i := range maxTxnOperations - len(values) {
+		values[fmt.Sprintf("KEY_%03d", i)] = i
+	}
+
+	require.NoError(t, newConsul(&Config{Prefix: "app"}).Write(ctx, values))
+
+	f.mu.Lock()
+	assert.Equal(t, 1, f.txns)
+	assert.Equal(t, "db.internal", string(f.kv["app/db/host"]))
+	assert.Equal(t, "5432", string(f.kv["app/db/port"]))
+	assert.Empty(t, f.kv["app/empty"])
+	assert.Equal(t, `["a"]`, string(f.kv["app/list"]))
+	assert.Equal(t, "59", string(f.kv["app/KEY_059"]))
+	f.mu.Unlock()
+
+	// Over the transaction limit, nothing is written.
+	values["KEY_OVER"] = "over"
+
+	assert.ErrorContains(t, newConsul(&Config{Prefix: "app"}).Write(ctx, values), "limited to 64 keys, got 65")
+
+	f.mu.Lock()
+	assert.Equal(t, 1, f.txns)
+	assert.NotContains(t, f.kv, "app/KEY_OVER")
+	f.mu.Unlock()
+
+	// Documents.
+	require.NoError(t, newConsul(&Config{Key: "config/app.json"}).Write(ctx, map[string]interface{}{"A": "1"}))
+	require.NoError(t, newConsul(&Config{Key: "config/app.yaml", Format: "yml"}).Write(ctx, map[string]interface{}{"A": "1"}))
+
+	f.mu.Lock()
+	assert.JSONEq(t, `{"A": "1"}`, string(f.kv["config/app.json"]))
+	assert.Equal(t, "A: \"1\"\n", string(f.kv["config/app.yaml"]))
+	f.mu.Unlock()
+
+	// Round trip.
+	assert.Equal(t, map[string]string{"A": "1"}, load(t, newConsul(&Config{Key: "config/app.yaml", Format: "yml"})))
+
+	assert.ErrorContains(t, newConsul(&Config{Key: "config/app.env", Format: "env"}).Write(ctx, map[string]interface{}{"A": "1"}), "allowed to write: json, yaml")
+	assert.ErrorContains(t, newConsul(&Config{Prefix: "app"}).Write(ctx, nil), "values")
+
+	// Rolled back transaction.
+	p, err := New(false, false, &Config{Address: server.URL, Token: "acl-token", Prefix: "app"})
+	require.NoError(t, err)
+	assert.ErrorContains(t, p.Write(ctx, map[string]interface{}{"A": "1"}), "write values")
+}
@@ -0,0 +1,5 @@
+// Package consul provides a provider for HashiCorp Consul KV, reading a key
+// prefix recursively, or a JSON, or YAML document at a single key, with ACL
+// tokens, namespaces, datacenters, transactional writes, and blocking
+// queries.
+package consul
@@ -0,0 +1,4 @@
+// Package encfile provides a provider for encrypted files, e.g.: `.env.enc`,
+// where keys are readable, and values are sealed to a recipient's public key,
+// so they can be committed, and diffed by key.
+package encfile
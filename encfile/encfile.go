@@ -0,0 +1,320 @@
+package encfile
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/thalesfsp/configurer/option"
+	"github.com/thalesfsp/configurer/provider"
+	"github.com/thalesfsp/configurer/util"
+	"github.com/thalesfsp/customerror"
+	"github.com/thalesfsp/validation"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/nacl/box"
+)
+
+//////
+// Vars, consts, and types.
+//////
+
+// Name of the provider.
+const Name = "encfile"
+
+// Prefix identifies encrypted values, and the scheme version.
+const Prefix = "enc:v1:"
+
+// keySize is the size of X25519 keys.
+const keySize = 32
+
+// Config contains the encrypted file settings.
+type Config struct {
+	// FilePaths is the list of files to load. The extension determines the
+	// format, e.g.: `.env.enc` is read as env. SEE: `util.ParseFile`.
+	FilePaths []string `json:"filePaths" validate:"required,gte=1"`
+
+	// PrivateKey decrypts values when loading.
+	PrivateKey string `json:"-"`
+
+	// Recipient is the public key values are encrypted to when writing.
+	Recipient string `json:"recipient"`
+}
+
+// EncFile provider definition.
+type EncFile struct {
+	*provider.Provider `json:"-" validate:"required"`
+
+	Configuration *Config `json:"-" validate:"required"`
+}
+
+//////
+// IProvider implementation.
+//////
+
+// Load decrypts the values of the files, and exports them to the environment.
+// Files are loaded in order, later files win. Values without `Prefix` are
+// used as is.
+func (e *EncFile) Load(ctx context.Context, opts ...option.LoadKeyFunc) (map[string]string, error) {
+	if e.Configuration.PrivateKey == "" {
+		return nil, customerror.NewRequiredError("private key")
+	}
+
+	values := make(map[string]any)
+
+	for _, filePath := range e.Configuration.FilePaths {
+		parsed, err := parseFile(ctx, filePath)
+		if err != nil {
+			return nil, err
+		}
+
+		for key, value := range parsed {
+			values[key] = value
+		}
+	}
+
+	finalValues := make(map[string]string, len(values))
+
+	for key, value := range values {
+		if s, ok := value.(string); ok && IsEncrypted(s) {
+			decrypted, err := Decrypt(key, s, e.Configuration.PrivateKey)
+			if err != nil {
+				return nil, err
+			}
+
+			value = decrypted
+		}
+
+		// Should allow to specify options.
+		for _, opt := range opts {
+			key = opt(key)
+		}
+
+		finalValue, err := provider.ExportToEnvVar(e, key, value)
+		if err != nil {
+			return nil, err
+		}
+
+		finalValues[key] = finalValue
+	}
+
+	return finalValues, nil
+}
+
+// Write encrypts `values` to the recipient, and writes them to the file,
+// replacing it atomically. The format is the one of the extension before
+// `.enc`, e.g.: `secrets.json.enc` is JSON, like `Load` expects. Files without
+// one, e.g.: `.env.enc`, are in env format.
+func (e *EncFile) Write(ctx context.Context, values map[string]interface{}, opts ...option.WriteFunc) error {
+	if values == nil {
+		return customerror.NewRequiredError("values")
+	}
+
+	// This operation is 1:1.
+	if len(e.Configuration.FilePaths) > 1 {
+		return customerror.NewInvalidError("filePaths, for the Write operation only one file should be used")
+	}
+
+	if e.Configuration.Recipient == "" {
+		return customerror.NewRequiredError("recipient")
+	}
+
+	var options option.Write
+
+	for _, opt := range opts {
+		if err := opt(&options); err != nil {
+			return err
+		}
+	}
+
+	content := make(map[string]string, len(values))
+
+	for key, value := range values {
+		encrypted, err := Encrypt(key, fmt.Sprintf("%v", value), e.Configuration.Recipient)
+		if err != nil {
+			return err
+		}
+
+		content[key] = encrypted
+	}
+
+	filePath := e.Configuration.FilePaths[0]
+
+	var dump func(file *os.File) error
+
+	// Same format `Load` parses the file with.
+	switch format := fileFormat(filePath); format {
+	case "", "env":
+		dump = func(file *os.File) error { return util.DumpToEnv(file, content, false) }
+	case "json":
+		dump = func(file *os.File) error { return util.DumpToJSON(file, content) }
+	case "yaml", "yml":
+		dump = func(file *os.File) error { return util.DumpToYAML(file, content) }
+	case "toml":
+		dump = func(file *os.File) error { return util.DumpToTOML(file, content) }
+	default:
+		return customerror.NewInvalidError(filePath + ", can't write " + format + " files, use env, json, yaml, or toml")
+	}
+
+	return util.WriteFileAtomic(filePath, dump)
+}
+
+//////
+// Exported feature(s).
+//////
+
+// GenerateKey generates a key pair. The recipient (public key) is used to
+// encrypt, e.g.: `--dump-encrypt-to`, the private key to decrypt.
+func GenerateKey() (recipient string, privateKey string, err error) {
+	public, private, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", customerror.NewFailedToError("generate key", customerror.WithError(err))
+	}
+
+	return encodeKey(public), encodeKey(private), nil
+}
+
+// IsEncrypted reports whether `value` is encrypted.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, Prefix)
+}
+
+// Encrypt seals `value` of `key` to `recipient`, using an anonymous NaCl box
+// (X25519, XSalsa20, and Poly1305). The key is sealed along with the value,
+// so values can't be swapped between keys.
+func Encrypt(key, value, recipient string) (string, error) {
+	public, err := decodeKey("recipient", recipient)
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := box.SealAnonymous(nil, []byte(key+"\x00"+value), public, rand.Reader)
+	if err != nil {
+		return "", customerror.NewFailedToError("encrypt "+key, customerror.WithError(err))
+	}
+
+	return Prefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens `value` of `key`, encrypted with `Encrypt`, with `privateKey`.
+func Decrypt(key, value, privateKey string) (string, error) {
+	private, err := decodeKey("private key", privateKey)
+	if err != nil {
+		return "", err
+	}
+
+	public, err := curve25519.X25519(private[:], curve25519.Basepoint)
+	if err != nil {
+		return "", customerror.NewInvalidError("private key", customerror.WithError(err))
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, Prefix))
+	if err != nil {
+		return "", customerror.NewInvalidError(key+", not base64", customerror.WithError(err))
+	}
+
+	opened, ok := box.OpenAnonymous(nil, sealed, (*[keySize]byte)(public), private)
+	if !ok {
+		return "", customerror.NewFailedToError("decrypt " + key + ", wrong private key, or tampered value")
+	}
+
+	sealedKey, plaintext, found := strings.Cut(string(opened), "\x00")
+	if !found || sealedKey != key {
+		return "", customerror.NewInvalidError(key + ", value was encrypted for another key")
+	}
+
+	return plaintext, nil
+}
+
+//////
+// Helpers.
+//////
+
+// encodeKey encodes an X25519 key.
+func encodeKey(key *[keySize]byte) string {
+	return base64.StdEncoding.EncodeToString(key[:])
+}
+
+// decodeKey decodes an X25519 key.
+func decodeKey(name, key string) (*[keySize]byte, error) {
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(key))
+	if err != nil {
+		return nil, customerror.NewInvalidError(name+", not base64", customerror.WithError(err))
+	}
+
+	if len(decoded) != keySize {
+		return nil, customerror.NewInvalidError(fmt.Sprintf("%s, expected %d bytes, got %d", name, keySize, len(decoded)))
+	}
+
+	return (*[keySize]byte)(decoded), nil
+}
+
+// parseFile parses `filePath`, ignoring the `.enc` extension.
+func parseFile(ctx context.Context, filePath string) (map[string]any, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, customerror.NewFailedToError("read path", customerror.WithError(err))
+	}
+
+	defer f.Close()
+
+	return util.ParseContent(ctx, fileFormat(filePath), f)
+}
+
+// fileFormat returns the format of `filePath`, from the extension before
+// `.enc`, or empty if there's none.
+func fileFormat(filePath string) string {
+	// `.env.enc`, or `.env`.
+	if strings.HasPrefix(filepath.Base(filePath), ".env") {
+		return "env"
+	}
+
+	return strings.TrimPrefix(filepath.Ext(strings.TrimSuffix(filePath, ".enc")), ".")
+}
+
+//////
+// Factory.
+//////
+
+// New creates an encrypted file provider. The private key is required to
+// load, the recipient to write.
+func New(override, rawValue bool, config *Config) (provider.IProvider, error) {
+	if config == nil {
+		return nil, customerror.NewRequiredError("config")
+	}
+
+	if err := validation.Validate(config); err != nil {
+		return nil, err
+	}
+
+	if config.PrivateKey != "" {
+		if _, err := decodeKey("private key", config.PrivateKey); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.Recipient != "" {
+		if _, err := decodeKey("recipient", config.Recipient); err != nil {
+			return nil, err
+		}
+	}
+
+	baseProvider, err := provider.New(Name, override, rawValue)
+	if err != nil {
+		return nil, err
+	}
+
+	encFile := &EncFile{
+		Provider:      baseProvider,
+		Configuration: config,
+	}
+
+	if err := validation.Validate(encFile); err != nil {
+		return nil, err
+	}
+
+	return encFile, nil
+}
@@ -0,0 +1,342 @@
+package encfile
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thalesfsp/configurer/internal/testenv"
+	"github.com/thalesfsp/configurer/option"
+)
+
+// keyPair generates a key pair for tests.
+func keyPair(t *testing.T) (string, string) {
+	t.Helper()
+
+	recipient, privateKey, err := GenerateKey()
+	require.NoError(t, err)
+
+	return recipient, privateKey
+}
+
+//////
+// Constructor.
+//////
+
+func TestNew(t *testing.T) {
+	recipient, privateKey := keyPair(t)
+
+	tests := []struct {
+		name    string
+		config  *Config
+		wantErr string
+	}{
+		{
+			name:   "happy path load",
+			config: &Config{FilePaths: []string{".env.enc"}, PrivateKey: privateKey},
+		},
+		{
+			name:   "happy path write",
+			config: &Config{FilePaths: []string{".env.enc"}, Recipient: recipient},
+		},
+		{
+			name:    "bad path nil config",
+			wantErr: "config",
+		},
+		{
+			name:    "bad path missing files",
+			config:  &Config{PrivateKey: privateKey},
+			wantErr: "FilePaths",
+		},
+		{
+			name:    "bad path invalid private key",
+			config:  &Config{FilePaths: []string{".env.enc"}, PrivateKey: "not base64!"},
+			wantErr: "private key, not base64",
+		},
+		{
+			name:    "bad path short recipient",
+			config:  &Config{FilePaths: []string{".env.enc"}, Recipient: "YWJj"},
+			wantErr: "recipient, expected 32 bytes, got 3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(false, false, tt.config)
+
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				assert.Nil(t, got)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, Name, got.GetName())
+		})
+	}
+}
+
+//////
+// Encryption.
+//////
+
+func TestEncryptDecrypt(t *testing.T) {
+	recipient, privateKey := keyPair(t)
+	_, otherPrivateKey := keyPair(t)
+
+	encrypted, err := Encrypt("PASSWORD", "s3cr3t\nwith = # chars", recipient)
+	require.NoError(t, err)
+	assert.True(t, IsEncrypted(encrypted))
+	assert.NotContains(t, encrypted, "s3cr3t")
+
+	// Sealing is randomized.
+	again, err := Encrypt("PASSWORD", "s3cr3t\nwith = # chars", recipient)
+	require.NoError(t, err)
+	assert.NotEqual(t, encrypted, again)
+
+	decrypted, err := Decrypt("PASSWORD", encrypted, privateKey)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cr3t\nwith = # chars", decrypted)
+
+	tests := []struct {
+		name       string
+		key        string
+		value      string
+		privateKey string
+		wantErr    string
+	}{
+		{
+			name:       "wrong private key",
+			key:        "PASSWORD",
+			value:      encrypted,
+			privateKey: otherPrivateKey,
+			wantErr:    "wrong private key, or tampered value",
+		},
+		{
+			name:       "tampered value",
+			key:        "PASSWORD",
+			value:      encrypted[:len(encrypted)-4] + "AAA=",
+			privateKey: privateKey,
+			wantErr:    "wrong private key, or tampered value",
+		},
+		{
+			name:       "value moved to another key",
+			key:        "OTHER",
+			value:      encrypted,
+			privateKey: privateKey,
+			wantErr:    "value was encrypted for another key",
+		},
+		{
+			name:       "not base64",
+			key:        "PASSWORD",
+			value:      Prefix + "%%%",
+			privateKey: privateKey,
+			wantErr:    "PASSWORD, not base64",
+		},
+		{
+			name:       "invalid private key",
+			key:        "PASSWORD",
+			value:      encrypted,
+			privateKey: "YWJj",
+			wantErr:    "private key, expected 32 bytes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decrypt(tt.key, tt.value, tt.privateKey)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+
+	_, err = Encrypt("PASSWORD", "s3cr3t", "YWJj")
+	assert.ErrorContains(t, err, "recipient, expected 32 bytes")
+}
+
+//////
+// IProvider implementation.
+//////
+
+func TestWriteLoad(t *testing.T) {
+	recipient, privateKey := keyPair(t)
+
+	filePath := filepath.Join(t.TempDir(), ".env.enc")
+
+	writer, err := New(false, false, &Config{FilePaths: []string{filePath}, Recipient: recipient})
+	require.NoError(t, err)
+
+	require.NoError(t, writer.Write(context.Background(), map[string]interface{}{
+		"CONFIGURER_ENCFILE_PASSWORD": "s3cr3t",
+		"CONFIGURER_ENCFILE_PORT":     8080,
+	}))
+
+	content, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+
+	// Keys are readable, values sealed.
+	assert.Contains(t, string(content), "CONFIGURER_ENCFILE_PASSWORD="+Prefix)
+	assert.NotContains(t, string(content), "s3cr3t")
+
+	info, err := os.Stat(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	// Plaintext values, in later files, are used as is.
+	plainPath := filepath.Join(t.TempDir(), "plain.env")
+	require.NoError(t, os.WriteFile(plainPath, []byte("CONFIGURER_ENCFILE_PORT=9090\n"), 0o600))
+
+	reader, err := New(true, false, &Config{FilePaths: []string{filePath, plainPath}, PrivateKey: privateKey})
+	require.NoError(t, err)
+
+	got, err := reader.Load(context.Background(), option.WithKeyPrefixer("APP_"))
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		os.Unsetenv("APP_CONFIGURER_ENCFILE_PASSWORD")
+		os.Unsetenv("APP_CONFIGURER_ENCFILE_PORT")
+	})
+
+	assert.Equal(t, map[string]string{
+		"APP_CONFIGURER_ENCFILE_PASSWORD": "s3cr3t",
+		"APP_CONFIGURER_ENCFILE_PORT":     "9090",
+	}, got)
+	assert.Equal(t, "s3cr3t", os.Getenv("APP_CONFIGURER_ENCFILE_PASSWORD"))
+}
+
+func TestWriteLoadFormats(t *testing.T) {
+	recipient, privateKey := keyPair(t)
+
+	tests := []struct {
+		name     string
+		fileName string
+		want     string
+		wantErr  string
+	}{
+		{
+			name:     "env",
+			fileName: ".env.enc",
+			want:     "CONFIGURER_ENCFILE_FORMAT=" + Prefix,
+		},
+		{
+			name:     "json",
+			fileName: "secrets.json.enc",
+			want:     `"CONFIGURER_ENCFILE_FORMAT": "` + Prefix,
+		},
+		{
+			name:     "yaml",
+			fileName: "config.yaml.enc",
+			want:     "CONFIGURER_ENCFILE_FORMAT: " + Prefix,
+		},
+		{
+			name:     "toml",
+			fileName: "config.toml.enc",
+			want:     `CONFIGURER_ENCFILE_FORMAT = "` + Prefix,
+		},
+		{
+			name:     "unsupported format",
+			fileName: "config.ini.enc",
+			wantErr:  "can't write ini files",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testenv.Unset(t, "CONFIGURER_ENCFILE_FORMAT")
+
+			filePath := filepath.Join(t.TempDir(), tt.fileName)
+
+			writer, err := New(false, false, &Config{FilePaths: []string{filePath}, Recipient: recipient})
+			require.NoError(t, err)
+
+			err = writer.Write(context.Background(), map[string]interface{}{"CONFIGURER_ENCFILE_FORMAT": "value"})
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				assert.NoFileExists(t, filePath)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			content, err := os.ReadFile(filePath)
+			require.NoError(t, err)
+			assert.Contains(t, string(content), tt.want)
+
+			// Loads back what was written.
+			reader, err := New(false, false, &Config{FilePaths: []string{filePath}, PrivateKey: privateKey})
+			require.NoError(t, err)
+
+			got, err := reader.Load(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{"CONFIGURER_ENCFILE_FORMAT": "value"}, got)
+		})
+	}
+}
+
+func TestLoadWriteErrors(t *testing.T) {
+	recipient, privateKey := keyPair(t)
+	_, otherPrivateKey := keyPair(t)
+
+	filePath := filepath.Join(t.TempDir(), "secrets.json.enc")
+
+	encrypted, err := Encrypt("KEY", "value", recipient)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filePath, []byte(`{"KEY": "`+encrypted+`"}`), 0o600))
+
+	// JSON files are supported too.
+	p, err := New(false, false, &Config{FilePaths: []string{filePath}, PrivateKey: privateKey})
+	require.NoError(t, err)
+
+	got, err := p.Load(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "value", got["KEY"])
+
+	tests := []struct {
+		name    string
+		config  *Config
+		wantErr string
+	}{
+		{
+			name:    "load without private key",
+			config:  &Config{FilePaths: []string{filePath}},
+			wantErr: "private key",
+		},
+		{
+			name:    "load with wrong private key",
+			config:  &Config{FilePaths: []string{filePath}, PrivateKey: otherPrivateKey},
+			wantErr: "decrypt KEY",
+		},
+		{
+			name:    "load missing file",
+			config:  &Config{FilePaths: []string{filepath.Join(t.TempDir(), ".env.enc")}, PrivateKey: privateKey},
+			wantErr: "read path",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(false, false, tt.config)
+			require.NoError(t, err)
+
+			_, err = p.Load(context.Background())
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+
+	writer, err := New(false, false, &Config{FilePaths: []string{filePath}})
+	require.NoError(t, err)
+	assert.ErrorContains(t, writer.Write(context.Background(), map[string]interface{}{"A": "1"}), "recipient")
+	assert.ErrorContains(t, writer.Write(context.Background(), nil), "values")
+
+	writer, err = New(false, false, &Config{FilePaths: []string{filePath, filePath}, Recipient: recipient})
+	require.NoError(t, err)
+	assert.ErrorContains(t, writer.Write(context.Background(), map[string]interface{}{"A": "1"}), "only one file")
+
+	writer, err = New(false, false, &Config{FilePaths: []string{filepath.Join(t.TempDir(), "missing", "x")}, Recipient: recipient})
+	require.NoError(t, err)
+	assert.ErrorContains(t, writer.Write(context.Background(), map[string]interface{}{"A": "1"}), "write path")
+}
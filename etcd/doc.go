@@ -0,0 +1,4 @@
+// Package etcd provides a provider for etcd v3, over its JSON gateway,
+// loading a key prefix, mapped to env vars, with username, and password, or
+// TLS client-cert auth, transactional writes, and revision pinning.
+package etcd
@@ -0,0 +1,489 @@
+package etcd
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thalesfsp/configurer/option"
+	"github.com/thalesfsp/configurer/provider"
+	"github.com/thalesfsp/configurer/util"
+	"github.com/thalesfsp/customerror"
+	"github.com/thalesfsp/httpclient/v2"
+	"github.com/thalesfsp/validation"
+)
+
+//////
+// Vars, consts, and types.
+//////
+
+// Name of the provider.
+const Name = "etcd"
+
+// DefaultEndpoint is the default etcd endpoint.
+const DefaultEndpoint = "http://127.0.0.1:2379"
+
+// DefaultTimeout is the default request timeout.
+const DefaultTimeout = 30 * time.Second
+
+// Config contains the etcd settings.
+type Config struct {
+	// Endpoints of the etcd cluster, tried in order. Defaults to
+	// `DefaultEndpoint`.
+	Endpoints []string `json:"endpoints" validate:"required,gte=1,dive,url"`
+
+	// Username, and Password authenticate with etcd's auth.
+	Username string `json:"-"`
+	Password string `json:"-"`
+
+	// CAFile is a PEM file with CAs trusted in addition to the system ones.
+	CAFile string `json:"caFile"`
+
+	// CertFile, and KeyFile are the PEM client certificate, and key, for
+	// client-cert auth.
+	CertFile string `json:"certFile"`
+	KeyFile  string `json:"keyFile"`
+
+	// Prefix of the keys to load, e.g.: `/app/prod/`. Keys are mapped to env
+	// vars without it, `/` replaced by `Separator`, e.g.: `/app/prod/db/host`
+	// becomes `db__host`.
+	Prefix string `json:"prefix" validate:"required"`
+
+	// Revision pins Load to a revision, e.g.: to roll back. Defaults to the
+	// latest.
+	Revision int64 `json:"revision" validate:"gte=0"`
+
+	// Separator replaces `/` in keys. Defaults to `option.DefaultSeparator`.
+	Separator string `json:"separator"`
+
+	// Timeout of requests. Defaults to `DefaultTimeout`.
+	Timeout time.Duration `json:"timeout" validate:"gte=0"`
+}
+
+// Etcd provider definition.
+type Etcd struct {
+	*provider.Provider `json:"-" validate:"required"`
+
+	Configuration *Config            `json:"-" validate:"required"`
+	client        *httpclient.Client `json:"-" validate:"required"`
+
+	mu       sync.Mutex
+	revision int64
+}
+
+// int64String is an int64, which the JSON gateway encodes as a string.
+type int64String int64
+
+// UnmarshalJSON accepts both strings, and numbers.
+func (i *int64String) UnmarshalJSON(data []byte) error {
+	value, err := strconv.ParseInt(strings.Trim(string(data), `"`), 10, 64)
+	if err != nil {
+		return err
+	}
+
+	*i = int64String(value)
+
+	return nil
+}
+
+// responseHeader is the header of responses.
+type responseHeader struct {
+	Revision int64String `json:"revision"`
+}
+
+// keyValue is a key, and its value.
+type keyValue struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value"`
+}
+
+// rangeRequest gets the keys in [key, range_end).
+type rangeRequest struct {
+	Key      []byte `json:"key"`
+	RangeEnd []byte `json:"range_end,omitempty"`
+	Revision int64  `json:"revision,omitempty,string"`
+}
+
+// rangeResponse is the response of a range request.
+type rangeResponse struct {
+	Header responseHeader `json:"header"`
+	Kvs    []keyValue     `json:"kvs"`
+}
+
+// putRequest sets a key.
+type putRequest struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value"`
+}
+
+// requestOp is an operation of a transaction.
+type requestOp struct {
+	RequestPut *putRequest `json:"request_put,omitempty"`
+}
+
+// txnRequest is a transaction.
+type txnRequest struct {
+	Success []requestOp `json:"success"`
+}
+
+// txnResponse is the response of a transaction.
+type txnResponse struct {
+	Header    responseHeader `json:"header"`
+	Succeeded bool           `json:"succeeded"`
+}
+
+// authenticateRequest authenticates a user.
+type authenticateRequest struct {
+	Name     string `json:"name"`
+	Password string `json:"password"`
+}
+
+// authenticateResponse is the response of an authentication.
+type authenticateResponse struct {
+	Token string `json:"token"`
+}
+
+//////
+// IProvider implementation.
+//////
+
+// Load reads the keys under `Prefix`, at `Revision`, if set, and exports the
+// values to the environment.
+func (e *Etcd) Load(ctx context.Context, opts ...option.LoadKeyFunc) (map[string]string, error) {
+	var response rangeResponse
+
+	if err := e.call(ctx, "/v3/kv/range", rangeRequest{
+		Key:      []byte(e.Configuration.Prefix),
+		RangeEnd: prefixEnd([]byte(e.Configuration.Prefix)),
+		Revision: e.Configuration.Revision,
+	}, &response); err != nil {
+		return nil, customerror.NewFailedToError("read "+e.Configuration.Prefix, customerror.WithError(err))
+	}
+
+	e.mu.Lock()
+	e.revision = int64(response.Header.Revision)
+	e.mu.Unlock()
+
+	finalValues := make(map[string]string, len(response.Kvs))
+
+	for _, kv := range response.Kvs {
+		name := strings.Trim(strings.TrimPrefix(string(kv.Key), e.Configuration.Prefix), "/")
+		if name == "" {
+			continue
+		}
+
+		key := strings.ReplaceAll(name, "/", e.Configuration.Separator)
+
+		// Should allow to specify options.
+		for _, opt := range opts {
+			key = opt(key)
+		}
+
+		finalValue, err := provider.ExportToEnvVar(e, key, string(kv.Value))
+		if err != nil {
+			return nil, err
+		}
+
+		finalValues[key] = finalValue
+	}
+
+	return finalValues, nil
+}
+
+// Write stores `values` under `Prefix`, the separator becoming `/`, in a
+// single transaction, so they land atomically.
+//
+// NOTE: etcd limits the operations of a transaction, `--max-txn-ops`, 128 by
+// default.
+func (e *Etcd) Write(ctx context.Context, values map[string]interface{}, opts ...option.WriteFunc) error {
+	if values == nil {
+		return customerror.NewRequiredError("values")
+	}
+
+	var options option.Write
+
+	for _, opt := range opts {
+		if err := opt(&options); err != nil {
+			return err
+		}
+	}
+
+	keys := make([]string, 0, len(values))
+
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	request := txnRequest{Success: make([]requestOp, 0, len(keys))}
+
+	for _, key := range keys {
+		encoded, err := util.EncodeValue(values[key])
+		if err != nil {
+			return customerror.NewFailedToError("encode "+key, customerror.WithError(err))
+		}
+
+		value := []byte(fmt.Sprint(encoded))
+
+		name := strings.ReplaceAll(key, e.Configuration.Separator, "/")
+
+		request.Success = append(request.Success, requestOp{RequestPut: &putRequest{
+			Key:   []byte(e.Configuration.Prefix + name),
+			Value: value,
+		}})
+	}
+
+	var response txnResponse
+
+	if err := e.call(ctx, "/v3/kv/txn", request, &response); err != nil {
+		return customerror.NewFailedToError("write values", customerror.WithError(err))
+	}
+
+	if !response.Succeeded {
+		return customerror.NewFailedToError("write values, transaction failed")
+	}
+
+	return nil
+}
+
+//////
+// Exported feature(s).
+//////
+
+// Revision returns the revision of the last Load, e.g.: to pin later loads
+// with `Config.Revision`.
+func Revision(e *Etcd) int64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.revision
+}
+
+//////
+// Helpers.
+//////
+
+// call posts `request` to `path`, authenticating first if a username is set,
+// trying each endpoint in order until one answers.
+func (e *Etcd) call(ctx context.Context, path string, request, response any) error {
+	var lastErr error
+
+	for _, endpoint := range e.Configuration.Endpoints {
+		var requestOptions []httpclient.Func
+
+		if e.Configuration.Username != "" {
+			token, err := e.authenticate(ctx, endpoint)
+			if err != nil {
+				lastErr = err
+
+				if ctx.Err() != nil || !isConnectionError(err) {
+					return err
+				}
+
+				continue
+			}
+
+			requestOptions = append(requestOptions, httpclient.WithHeader("Authorization", token))
+		}
+
+		requestOptions = append(requestOptions, httpclient.WithReqBody(request), httpclient.WithRespBody(response))
+
+		r, err := e.client.Post(ctx, endpoint+path, requestOptions...)
+		if err != nil {
+			lastErr = err
+
+			// Only connection errors fail over.
+			if ctx.Err() != nil || !isConnectionError(err) {
+				return err
+			}
+
+			continue
+		}
+
+		r.Body.Close()
+
+		return nil
+	}
+
+	return lastErr
+}
+
+// authenticate gets a token for the user.
+func (e *Etcd) authenticate(ctx context.Context, endpoint string) (string, error) {
+	var response authenticateResponse
+
+	r, err := e.client.Post(
+		ctx,
+		endpoint+"/v3/auth/authenticate",
+		httpclient.WithReqBody(authenticateRequest{Name: e.Configuration.Username, Password: e.Configuration.Password}),
+		httpclient.WithRespBody(&response),
+	)
+	if err != nil {
+		return "", customerror.NewFailedToError("authenticate "+e.Configuration.Username, customerror.WithError(err))
+	}
+
+	r.Body.Close()
+
+	if response.Token == "" {
+		return "", customerror.NewFailedToError("authenticate " + e.Configuration.Username + ", no token")
+	}
+
+	return response.Token, nil
+}
+
+// isConnectionError checks if `err` happened before getting a response.
+func isConnectionError(err error) bool {
+	var netErr net.Error
+
+	return errors.As(err, &netErr)
+}
+
+// prefixEnd returns the end of the range of keys starting with `prefix`.
+func prefixEnd(prefix []byte) []byte {
+	end := bytes.Clone(prefix)
+
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+
+			return end[:i+1]
+		}
+	}
+
+	// All keys.
+	return []byte{0}
+}
+
+// newTLSConfig loads the CAs, and the client certificate.
+func newTLSConfig(config *Config) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if config.CAFile != "" {
+		certificate, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, customerror.NewFailedToError("read CA file", customerror.WithError(err))
+		}
+
+		certificates, err := x509.SystemCertPool()
+		if err != nil {
+			certificates = x509.NewCertPool()
+		}
+
+		if !certificates.AppendCertsFromPEM(certificate) {
+			return nil, customerror.NewInvalidError("CA file, no PEM certificates")
+		}
+
+		tlsConfig.RootCAs = certificates
+	}
+
+	if config.CertFile != "" || config.KeyFile != "" {
+		certificate, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+		if err != nil {
+			return nil, customerror.NewFailedToError("load client certificate", customerror.WithError(err))
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return tlsConfig, nil
+}
+
+//////
+// Factory.
+//////
+
+// New creates an etcd provider.
+func New(override, rawValue bool, config *Config) (provider.IProvider, error) {
+	if config == nil {
+		return nil, customerror.NewRequiredError("config")
+	}
+
+	if len(config.Endpoints) == 0 {
+		config.Endpoints = []string{DefaultEndpoint}
+	}
+
+	for i, endpoint := range config.Endpoints {
+		// E.g.: `ETCDCTL_ENDPOINTS=127.0.0.1:2379`.
+		if !strings.Contains(endpoint, "://") {
+			endpoint = "http://" + endpoint
+		}
+
+		config.Endpoints[i] = strings.TrimSuffix(endpoint, "/")
+	}
+
+	// Keys are mapped without the prefix, so it's a directory.
+	if config.Prefix != "" && !strings.HasSuffix(config.Prefix, "/") {
+		config.Prefix += "/"
+	}
+
+	if config.Separator == "" {
+		config.Separator = option.DefaultSeparator
+	}
+
+	if config.Timeout == 0 {
+		config.Timeout = DefaultTimeout
+	}
+
+	if err := validation.Validate(config); err != nil {
+		return nil, err
+	}
+
+	if config.Password != "" && config.Username == "" {
+		return nil, customerror.NewRequiredError("username, with the password")
+	}
+
+	if (config.CertFile == "") != (config.KeyFile == "") {
+		return nil, customerror.NewRequiredError("cert file, and key file, for client-cert auth")
+	}
+
+	tlsConfig, err := newTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	baseProvider, err := provider.New(Name, override, rawValue)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := httpclient.NewDefault(httpclient.WithClientName(Name))
+	if err != nil {
+		return nil, customerror.NewFailedToError("initialize HTTP client", customerror.WithError(err))
+	}
+
+	transport, ok := http.DefaultTransport.(*http.Transport)
+	if ok {
+		transport = transport.Clone()
+	} else {
+		transport = &http.Transport{}
+	}
+
+	transport.TLSClientConfig = tlsConfig
+
+	client.GetClient().Transport = transport
+	client.GetClient().Timeout = config.Timeout
+
+	e := &Etcd{
+		Provider:      baseProvider,
+		Configuration: config,
+		client:        client,
+	}
+
+	if err := validation.Validate(e); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
@@ -0,0 +1,408 @@
+package etcd
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thalesfsp/configurer/option"
+)
+
+// fakeEtcd is an in-memory etcd v3 JSON gateway, keeping every revision.
+type fakeEtcd struct {
+	mu        sync.Mutex
+	revisions []map[string]string
+	auth      bool
+}
+
+func newFakeEtcd(kv map[string]string) *fakeEtcd {
+	return &fakeEtcd{revisions: []map[string]string{{}, kv}}
+}
+
+func (f *fakeEtcd) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.URL.Path == "/v3/auth/authenticate" {
+		var request authenticateRequest
+
+		_ = json.NewDecoder(r.Body).Decode(&request)
+
+		if request.Name != "root" || request.Password != "s3cr3t" {
+			http.Error(w, `{"error": "etcdserver: authentication failed, invalid user ID or password"}`, http.StatusBadRequest)
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"token": "simple-token.1"}`))
+
+		return
+	}
+
+	if f.auth && r.Header.Get("Authorization") != "simple-token.1" {
+		http.Error(w, `{"error": "etcdserver: user name is empty"}`, http.StatusUnauthorized)
+
+		return
+	}
+
+	current := len(f.revisions) - 1
+
+	switch r.URL.Path {
+	case "/v3/kv/range":
+		var request struct {
+			Key      []byte `json:"key"`
+			RangeEnd []byte `json:"range_end"`
+			Revision string `json:"revision"`
+		}
+
+		_ = json.NewDecoder(r.Body).Decode(&request)
+
+		revision := current
+
+		if request.Revision != "" {
+			revision, _ = strconv.Atoi(request.Revision)
+
+			if revision > current {
+				http.Error(w, `{"error": "etcdserver: mvcc: required revision is a future revision"}`, http.StatusBadRequest)
+
+				return
+			}
+		}
+
+		var kvs []map[string][]byte
+
+		for key, value := range f.revisions[revision] {
+			if bytes.Compare([]byte(key), request.Key) >= 0 && bytes.Compare([]byte(key), request.RangeEnd) < 0 {
+				kvs = append(kvs, map[string][]byte{"key": []byte(key), "value": []byte(value)})
+			}
+		}
+
+		sort.Slice(kvs, func(i, j int) bool { return bytes.Compare(kvs[i]["key"], kvs[j]["key"]) < 0 })
+
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"header": map[string]string{"revision": strconv.Itoa(current)},
+			"kvs":    kvs,
+			"count":  strconv.Itoa(len(kvs)),
+		})
+	case "/v3/kv/txn":
+		var request txnRequest
+
+		_ = json.NewDecoder(r.Body).Decode(&request)
+
+		next := map[string]string{}
+
+		for key, value := range f.revisions[current] {
+			next[key] = value
+		}
+
+		for _, operation := range request.Success {
+			next[string(operation.RequestPut.Key)] = string(operation.RequestPut.Value)
+		}
+
+		f.revisions = append(f.revisions, next)
+
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"header":    map[string]string{"revision": strconv.Itoa(current + 1)},
+			"succeeded": true,
+		})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// load loads, and unsets the exported values.
+func load(t *testing.T, e *Etcd, opts ...option.LoadKeyFunc) map[string]string {
+	t.Helper()
+
+	got, err := e.Load(context.Background(), opts...)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		for key := range got {
+			os.Unsetenv(key)
+		}
+	})
+
+	return got
+}
+
+// clientCertificate creates a self-signed client certificate, and key.
+func clientCertificate(t *testing.T) (*x509.Certificate, string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "configurer"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	certificate, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+
+	certFile := filepath.Join(dir, "client.crt")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+
+	keyFile := filepath.Join(dir, "client.key")
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+
+	return certificate, certFile, keyFile
+}
+
+//////
+// Constructor.
+//////
+
+func TestNew(t *testing.T) {
+	_, certFile, keyFile := clientCertificate(t)
+
+	tests := []struct {
+		name    string
+		config  *Config
+		want    *Config
+		wantErr string
+	}{
+		{
+			name:   "happy path defaults",
+			config: &Config{Prefix: "/app/prod"},
+			want:   &Config{Endpoints: []string{DefaultEndpoint}, Prefix: "/app/prod/", Separator: option.DefaultSeparator, Timeout: DefaultTimeout},
+		},
+		{
+			name:   "happy path endpoints without scheme, and TLS",
+			config: &Config{Endpoints: []string{"10.0.0.1:2379", "https://etcd.internal:2379/"}, Prefix: "/", CertFile: certFile, KeyFile: keyFile, Revision: 42},
+			want:   &Config{Endpoints: []string{"http://10.0.0.1:2379", "https://etcd.internal:2379"}, Prefix: "/", CertFile: certFile, KeyFile: keyFile, Revision: 42, Separator: option.DefaultSeparator, Timeout: DefaultTimeout},
+		},
+		{
+			name:    "bad path nil config",
+			wantErr: "config",
+		},
+		{
+			name:    "bad path missing prefix",
+			config:  &Config{},
+			wantErr: "Prefix",
+		},
+		{
+			name:    "bad path negative revision",
+			config:  &Config{Prefix: "/app", Revision: -1},
+			wantErr: "Revision",
+		},
+		{
+			name:    "bad path password without username",
+			config:  &Config{Prefix: "/app", Password: "s3cr3t"},
+			wantErr: "username, with the password",
+		},
+		{
+			name:    "bad path cert without key",
+			config:  &Config{Prefix: "/app", CertFile: certFile},
+			wantErr: "cert file, and key file",
+		},
+		{
+			name:    "bad path invalid CA file",
+			config:  &Config{Prefix: "/app", CAFile: keyFile},
+			wantErr: "no PEM certificates",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(false, false, tt.config)
+
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				assert.Nil(t, got)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, Name, got.GetName())
+			assert.Equal(t, tt.want, got.(*Etcd).Configuration)
+		})
+	}
+}
+
+//////
+// IProvider implementation.
+//////
+
+func TestLoad(t *testing.T) {
+	f := newFakeEtcd(map[string]string{
+		"/app/prod/ETCD_TOKEN":   "t0k3n",
+		"/app/prod/db/host":      "db.internal",
+		"/app/prod/db/port":      "5432",
+		"/app/production/LEAKED": "leaked",
+		"/other/ETCD_OTHER":      "other",
+	})
+	f.auth = true
+
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+
+	// Nothing listens on a closed server's address.
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	tests := []struct {
+		name    string
+		config  *Config
+		opts    []option.LoadKeyFunc
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name:   "prefix, with auth",
+			config: &Config{Endpoints: []string{server.URL}, Username: "root", Password: "s3cr3t", Prefix: "/app/prod"},
+			want:   map[string]string{"ETCD_TOKEN": "t0k3n", "db__host": "db.internal", "db__port": "5432"},
+		},
+		{
+			name:   "separator, and options",
+			config: &Config{Endpoints: []string{server.URL}, Username: "root", Password: "s3cr3t", Prefix: "/app/prod/db/", Separator: "_"},
+			opts:   []option.LoadKeyFunc{option.WithKeyCaser("upper"), option.WithKeyPrefixer("DB_")},
+			want:   map[string]string{"DB_HOST": "db.internal", "DB_PORT": "5432"},
+		},
+		{
+			name:   "fails over to the next endpoint",
+			config: &Config{Endpoints: []string{closed.URL, server.URL}, Username: "root", Password: "s3cr3t", Prefix: "/other"},
+			want:   map[string]string{"ETCD_OTHER": "other"},
+		},
+		{
+			name:    "invalid password",
+			config:  &Config{Endpoints: []string{server.URL, closed.URL}, Username: "root", Password: "wrong", Prefix: "/app/prod"},
+			wantErr: "authenticate root",
+		},
+		{
+			name:    "no auth",
+			config:  &Config{Endpoints: []string{server.URL}, Prefix: "/app/prod"},
+			wantErr: "user name is empty",
+		},
+		{
+			name:    "future revision",
+			config:  &Config{Endpoints: []string{server.URL}, Username: "root", Password: "s3cr3t", Prefix: "/app/prod", Revision: 99},
+			wantErr: "future revision",
+		},
+		{
+			name:    "all endpoints down",
+			config:  &Config{Endpoints: []string{closed.URL}, Prefix: "/app/prod"},
+			wantErr: "read /app/prod/",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(true, false, tt.config)
+			require.NoError(t, err)
+
+			if tt.wantErr != "" {
+				_, err := p.Load(context.Background(), tt.opts...)
+				assert.ErrorContains(t, err, tt.wantErr)
+
+				return
+			}
+
+			got := load(t, p.(*Etcd), tt.opts...)
+
+			assert.Equal(t, tt.want, got)
+
+			for key, value := range tt.want {
+				assert.Equal(t, value, os.Getenv(key))
+			}
+		})
+	}
+}
+
+func TestLoadTLS(t *testing.T) {
+	certificate, certFile, keyFile := clientCertificate(t)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(certificate)
+
+	server := httptest.NewUnstartedServer(newFakeEtcd(map[string]string{"/app/ETCD_TLS": "ok"}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs, MinVersion: tls.VersionTLS12}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	caFile := filepath.Join(t.TempDir(), "ca.crt")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600))
+
+	p, err := New(true, false, &Config{Endpoints: []string{server.URL}, Prefix: "/app", CAFile: caFile, CertFile: certFile, KeyFile: keyFile})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{"ETCD_TLS": "ok"}, load(t, p.(*Etcd)))
+
+	// Without the client certificate.
+	p, err = New(true, false, &Config{Endpoints: []string{server.URL}, Prefix: "/app", CAFile: caFile})
+	require.NoError(t, err)
+
+	_, err = p.Load(context.Background())
+	assert.ErrorContains(t, err, "read /app/")
+}
+
+func TestWriteAndRevisionPinning(t *testing.T) {
+	f := newFakeEtcd(map[string]string{"/app/ETCD_A": "1"})
+
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+
+	newEtcd := func(revision int64) *Etcd {
+		p, err := New(true, false, &Config{Endpoints: []string{server.URL}, Prefix: "/app", Revision: revision})
+		require.NoError(t, err)
+
+		return p.(*Etcd)
+	}
+
+	ctx := context.Background()
+
+	e := newEtcd(0)
+
+	assert.Equal(t, map[string]string{"ETCD_A": "1"}, load(t, e))
+
+	pinned := Revision(e)
+	assert.Equal(t, int64(1), pinned)
+
+	// A single transaction.
+	require.NoError(t, e.Write(ctx, map[string]interface{}{"ETCD_A": "2", "db__port": 5432, "list": []any{"a"}, "empty": nil}))
+
+	f.mu.Lock()
+	assert.Len(t, f.revisions, 3)
+	assert.Equal(t, map[string]string{"/app/ETCD_A": "2", "/app/db/port": "5432", "/app/list": `["a"]`, "/app/empty": ""}, f.revisions[2])
+	f.mu.Unlock()
+
+	assert.Equal(t, map[string]string{"ETCD_A": "2", "db__port": "5432", "list": `["a"]`, "empty": ""}, load(t, e))
+	assert.Equal(t, int64(2), Revision(e))
+
+	// Pinned to the previous revision.
+	assert.Equal(t, map[string]string{"ETCD_A": "1"}, load(t, newEtcd(pinned)))
+
+	assert.ErrorContains(t, e.Write(ctx, nil), "values")
+}
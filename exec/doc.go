@@ -0,0 +1,5 @@
+// Package exec provides a provider which sources values from the output of an
+// external command, e.g.: a token broker, or `gcloud auth
+// print-access-token`, parsed as env, JSON, YAML, TOML, or as a single raw
+// value.
+package exec
@@ -0,0 +1,224 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	osexec "os/exec"
+	"strings"
+	"time"
+
+	"github.com/thalesfsp/configurer/option"
+	"github.com/thalesfsp/configurer/parser"
+	"github.com/thalesfsp/configurer/provider"
+	"github.com/thalesfsp/configurer/util"
+	"github.com/thalesfsp/customerror"
+	"github.com/thalesfsp/validation"
+)
+
+//////
+// Vars, consts, and types.
+//////
+
+// Name of the provider.
+const Name = "exec"
+
+// FormatRaw exports the whole output, with one trailing newline removed, under
+// a single key.
+const FormatRaw = "raw"
+
+// DefaultTimeout is the default command timeout.
+const DefaultTimeout = 30 * time.Second
+
+// maxStderrLength limits the stderr included in errors.
+const maxStderrLength = 4096
+
+// Config contains the exec settings.
+type Config struct {
+	// Command, and its arguments, run without a shell, e.g.:
+	// `["gcloud", "auth", "print-access-token"]`.
+	Command []string `json:"command" validate:"required,gte=1,dive,required"`
+
+	// Dir is the working directory of the command. Defaults to the current
+	// one.
+	Dir string `json:"dir"`
+
+	// Env are extra `KEY=VALUE` environment variables of the command, which
+	// inherits the current environment.
+	Env []string `json:"-"`
+
+	// Format of the output: any `parser` format, e.g.: `env`, `json`, `yaml`,
+	// `toml`, `auto` to detect it, or `raw`. Defaults to `auto`.
+	Format string `json:"format"`
+
+	// Key the output is exported as, required by the `raw` format.
+	Key string `json:"key"`
+
+	// ParseOptions flattens nested values, e.g.: `database.host` becomes
+	// `DATABASE__HOST`. If not set, nested values are JSON-encoded.
+	ParseOptions []option.ParseFunc `json:"-"`
+
+	// Timeout of the command. Defaults to `DefaultTimeout`.
+	Timeout time.Duration `json:"timeout" validate:"gte=0"`
+}
+
+// Exec provider definition.
+type Exec struct {
+	*provider.Provider `json:"-" validate:"required"`
+
+	Configuration *Config `json:"-" validate:"required"`
+}
+
+//////
+// IProvider implementation.
+//////
+
+// Load runs the command, parses its output, and exports the values. The
+// command fails the load if it exits with a non-zero code, or times out.
+func (e *Exec) Load(ctx context.Context, opts ...option.LoadKeyFunc) (map[string]string, error) {
+	output, err := e.run(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var values map[string]any
+
+	if e.Configuration.Format == FormatRaw {
+		value := strings.TrimSuffix(strings.TrimSuffix(string(output), "\n"), "\r")
+
+		values = map[string]any{e.Configuration.Key: value}
+	} else {
+		values, err = util.ParseContent(ctx, e.Configuration.Format, bytes.NewReader(output), e.Configuration.ParseOptions...)
+		if err != nil {
+			return nil, customerror.NewFailedToError("parse command output", customerror.WithError(err))
+		}
+	}
+
+	finalValues := make(map[string]string, len(values))
+
+	for key, value := range values {
+		value, err := util.EncodeValue(value)
+		if err != nil {
+			return nil, customerror.NewFailedToError("encode "+key, customerror.WithError(err))
+		}
+
+		// Should allow to specify options.
+		for _, opt := range opts {
+			key = opt(key)
+		}
+
+		finalValue, err := provider.ExportToEnvVar(e, key, value)
+		if err != nil {
+			return nil, err
+		}
+
+		finalValues[key] = finalValue
+	}
+
+	return finalValues, nil
+}
+
+// Write isn't supported, commands are a read-only source.
+func (e *Exec) Write(ctx context.Context, values map[string]interface{}, opts ...option.WriteFunc) error {
+	return provider.ErrNotSupported
+}
+
+//////
+// Helpers.
+//////
+
+// run runs the command, and returns its stdout.
+func (e *Exec) run(ctx context.Context) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, e.Configuration.Timeout)
+	defer cancel()
+
+	cmd := osexec.CommandContext(ctx, e.Configuration.Command[0], e.Configuration.Command[1:]...)
+	cmd.Dir = e.Configuration.Dir
+	cmd.Env = append(os.Environ(), e.Configuration.Env...)
+
+	// Don't wait for orphaned grandchildren holding the pipes.
+	cmd.WaitDelay = time.Second
+
+	var stdout, stderr bytes.Buffer
+
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, customerror.NewFailedToError(
+			fmt.Sprintf("run %s, timed out after %s", e.Configuration.Command[0], e.Configuration.Timeout),
+		)
+	}
+
+	if err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if len(message) > maxStderrLength {
+			message = message[:maxStderrLength] + "..."
+		}
+
+		if message != "" {
+			err = fmt.Errorf("%w: %s", err, message)
+		}
+
+		return nil, customerror.NewFailedToError("run "+e.Configuration.Command[0], customerror.WithError(err))
+	}
+
+	return stdout.Bytes(), nil
+}
+
+//////
+// Factory.
+//////
+
+// New creates an exec provider.
+func New(override, rawValue bool, config *Config) (provider.IProvider, error) {
+	if config == nil {
+		return nil, customerror.NewRequiredError("config")
+	}
+
+	if config.Format == "" {
+		config.Format = parser.Auto
+	}
+
+	if config.Timeout == 0 {
+		config.Timeout = DefaultTimeout
+	}
+
+	if err := validation.Validate(config); err != nil {
+		return nil, err
+	}
+
+	switch config.Format {
+	case FormatRaw:
+		if config.Key == "" {
+			return nil, customerror.NewRequiredError("key, the raw format exports the output under a single key")
+		}
+	case parser.Auto:
+	default:
+		if _, err := parser.Get(config.Format); err != nil {
+			return nil, customerror.NewInvalidError(
+				"format, allowed: " + strings.Join(parser.Formats(), ", ") + ", " + parser.Auto + ", " + FormatRaw,
+			)
+		}
+	}
+
+	baseProvider, err := provider.New(Name, override, rawValue)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &Exec{
+		Provider:      baseProvider,
+		Configuration: config,
+	}
+
+	if err := validation.Validate(e); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
@@ -0,0 +1,187 @@
+package exec
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thalesfsp/configurer/option"
+	"github.com/thalesfsp/configurer/provider"
+)
+
+// sh runs `script` with the shell.
+func sh(script string) []string {
+	return []string{"/bin/sh", "-c", script}
+}
+
+//////
+// Constructor.
+//////
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *Config
+		want    *Config
+		wantErr string
+	}{
+		{
+			name:   "happy path defaults",
+			config: &Config{Command: []string{"env"}},
+			want:   &Config{Command: []string{"env"}, Format: "auto", Timeout: DefaultTimeout},
+		},
+		{
+			name:   "happy path raw",
+			config: &Config{Command: []string{"gcloud", "auth", "print-access-token"}, Format: FormatRaw, Key: "TOKEN", Timeout: time.Second},
+			want:   &Config{Command: []string{"gcloud", "auth", "print-access-token"}, Format: FormatRaw, Key: "TOKEN", Timeout: time.Second},
+		},
+		{
+			name:   "happy path format by extension",
+			config: &Config{Command: []string{"cat", "config.yml"}, Format: "yml"},
+			want:   &Config{Command: []string{"cat", "config.yml"}, Format: "yml", Timeout: DefaultTimeout},
+		},
+		{
+			name:    "bad path nil config",
+			wantErr: "config",
+		},
+		{
+			name:    "bad path missing command",
+			config:  &Config{},
+			wantErr: "Command",
+		},
+		{
+			name:    "bad path empty command",
+			config:  &Config{Command: []string{""}},
+			wantErr: "Command",
+		},
+		{
+			name:    "bad path raw without key",
+			config:  &Config{Command: []string{"env"}, Format: FormatRaw},
+			wantErr: "key, the raw format",
+		},
+		{
+			name:    "bad path unknown format",
+			config:  &Config{Command: []string{"env"}, Format: "xml"},
+			wantErr: "auto, raw",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(false, false, tt.config)
+
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				assert.Nil(t, got)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, Name, got.GetName())
+			assert.Equal(t, tt.want, got.(*Exec).Configuration)
+		})
+	}
+}
+
+//////
+// IProvider implementation.
+//////
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		config  *Config
+		opts    []option.LoadKeyFunc
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name:   "raw",
+			config: &Config{Command: sh(`printf 'ya29.token\n'`), Format: FormatRaw, Key: "ACCESS_TOKEN"},
+			opts:   []option.LoadKeyFunc{option.WithKeyPrefixer("GCP_")},
+			want:   map[string]string{"GCP_ACCESS_TOKEN": "ya29.token"},
+		},
+		{
+			name:   "env",
+			config: &Config{Command: sh(`echo "EXEC_A=1"; echo "EXEC_B=$EXEC_INPUT"`), Format: "env", Env: []string{"EXEC_INPUT=from-env"}},
+			want:   map[string]string{"EXEC_A": "1", "EXEC_B": "from-env"},
+		},
+		{
+			name:   "auto JSON, nested values encoded",
+			config: &Config{Command: sh(`echo '{"EXEC_TOKEN": "t", "EXEC_DB": {"host": "h"}}'`)},
+			want:   map[string]string{"EXEC_TOKEN": "t", "EXEC_DB": `{"host":"h"}`},
+		},
+		{
+			name:   "YAML flattened",
+			config: &Config{Command: sh(`printf 'exec_db:\n  host: h\n  port: 5432\n'`), Format: "yaml", ParseOptions: []option.ParseFunc{option.WithFlatten(true)}},
+			want:   map[string]string{"EXEC_DB__HOST": "h", "EXEC_DB__PORT": "5432"},
+		},
+		{
+			name:   "working directory",
+			config: &Config{Command: []string{"pwd"}, Dir: dir, Format: FormatRaw, Key: "EXEC_PWD"},
+			want:   map[string]string{"EXEC_PWD": dir},
+		},
+		{
+			name:    "non-zero exit",
+			config:  &Config{Command: sh(`echo "EXEC_A=1"; echo "token expired" >&2; exit 3`)},
+			wantErr: "exit status 3: token expired",
+		},
+		{
+			name:    "timeout",
+			config:  &Config{Command: sh(`sleep 5`), Timeout: 50 * time.Millisecond},
+			wantErr: "timed out after 50ms",
+		},
+		{
+			name:    "missing command",
+			config:  &Config{Command: []string{"configurer-missing-command"}},
+			wantErr: "run configurer-missing-command",
+		},
+		{
+			name:    "invalid output",
+			config:  &Config{Command: sh(`echo '{"EXEC_A": '`), Format: "json"},
+			wantErr: "parse command output",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(true, false, tt.config)
+			require.NoError(t, err)
+
+			got, err := p.Load(context.Background(), tt.opts...)
+
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			t.Cleanup(func() {
+				for key := range got {
+					os.Unsetenv(key)
+				}
+			})
+
+			assert.Equal(t, tt.want, got)
+
+			for key, value := range tt.want {
+				assert.Equal(t, value, os.Getenv(key))
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	p, err := New(false, false, &Config{Command: []string{"env"}})
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, p.Write(context.Background(), map[string]interface{}{"A": "1"}), provider.ErrNotSupported)
+}
@@ -0,0 +1,4 @@
+// Package files provides a provider for directories with one file per key,
+// e.g.: Docker, and Swarm secrets (`/run/secrets`), systemd credentials
+// (`$CREDENTIALS_DIRECTORY`), or mounted Kubernetes Secrets.
+package files
@@ -0,0 +1,328 @@
+package files
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/thalesfsp/configurer/option"
+	"github.com/thalesfsp/configurer/provider"
+	"github.com/thalesfsp/configurer/util"
+	"github.com/thalesfsp/customerror"
+	"github.com/thalesfsp/validation"
+)
+
+//////
+// Vars, consts, and types.
+//////
+
+// Name of the provider.
+const Name = "files"
+
+// Default settings.
+const (
+	// DefaultDirectory is where Docker, and Swarm mount secrets. The systemd
+	// `$CREDENTIALS_DIRECTORY` has precedence, if set.
+	DefaultDirectory = "/run/secrets"
+
+	// DefaultMaxSize is the default maximum file size: 1 MiB.
+	DefaultMaxSize = 1024 * 1024
+)
+
+// Binary modes, what to do with files which aren't valid UTF-8, or contain
+// NUL bytes, which environment variables can't hold.
+const (
+	// BinaryBase64 exports binary files base64 encoded.
+	BinaryBase64 = "base64"
+
+	// BinarySkip skips binary files.
+	BinarySkip = "skip"
+
+	// BinaryError fails on binary files.
+	BinaryError = "error"
+)
+
+// Config contains the directory of files settings.
+type Config struct {
+	// Binary is what to do with binary files, allowed: base64, skip, error.
+	Binary string `json:"binary" validate:"omitempty,oneof=base64 skip error"`
+
+	// Directory to load, one file per key. Defaults to
+	// `$CREDENTIALS_DIRECTORY`, or `DefaultDirectory`.
+	Directory string `json:"directory"`
+
+	// Exclude skips files whose path, relative to `Directory`, and with `/`
+	// as separator, matches any of the globs, e.g.: `certs/*`. Globs without
+	// `/` match the file name at any depth, e.g.: `*.pem`.
+	Exclude []string `json:"exclude"`
+
+	// Include only loads files whose relative path matches any of the globs,
+	// e.g.: `db/*`. Empty loads all files.
+	Include []string `json:"include"`
+
+	// MaxSize is the maximum file size in bytes, larger files fail the load.
+	// Defaults to `DefaultMaxSize`.
+	MaxSize int64 `json:"maxSize" validate:"gte=0"`
+
+	// Recursive loads subdirectories, keys are relative paths joined with
+	// `Separator`, e.g.: `db/password` -> `db__password`.
+	Recursive bool `json:"recursive"`
+
+	// Separator joins path elements into keys. Defaults to
+	// `option.DefaultSeparator`.
+	Separator string `json:"separator"`
+}
+
+// Files provider definition.
+type Files struct {
+	*provider.Provider `json:"-" validate:"required"`
+
+	Configuration *Config `json:"-" validate:"required"`
+}
+
+//////
+// IProvider implementation.
+//////
+
+// Load exports the content of each file of the directory, with one trailing
+// newline removed, under its name. Binary files are base64 encoded, unless
+// `Binary` says otherwise. Hidden files, and directories, e.g.: the
+// Kubernetes `..data`, are skipped, symlinks are followed.
+func (f *Files) Load(ctx context.Context, opts ...option.LoadKeyFunc) (map[string]string, error) {
+	values := map[string]string{}
+
+	if err := f.loadDirectory(f.Configuration.Directory, "", map[string]bool{}, values); err != nil {
+		return nil, err
+	}
+
+	finalValues := make(map[string]string, len(values))
+
+	for key, value := range values {
+		// Should allow to specify options.
+		for _, opt := range opts {
+			key = opt(key)
+		}
+
+		finalValue, err := provider.ExportToEnvVar(f, key, value)
+		if err != nil {
+			return nil, err
+		}
+
+		finalValues[key] = finalValue
+	}
+
+	return finalValues, nil
+}
+
+// Write writes each value to a file named after its key, only readable by
+// the owner. If `Recursive`, keys are split by `Separator` into
+// subdirectories.
+func (f *Files) Write(ctx context.Context, values map[string]interface{}, opts ...option.WriteFunc) error {
+	if values == nil {
+		return customerror.NewRequiredError("values")
+	}
+
+	var options option.Write
+
+	for _, opt := range opts {
+		if err := opt(&options); err != nil {
+			return err
+		}
+	}
+
+	for key, value := range values {
+		names := []string{key}
+		if f.Configuration.Recursive {
+			names = strings.Split(key, f.Configuration.Separator)
+		}
+
+		for _, name := range names {
+			if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+				return customerror.NewInvalidError("key " + key + ", it must be a file name")
+			}
+		}
+
+		filePath := filepath.Join(append([]string{f.Configuration.Directory}, names...)...)
+
+		if err := os.MkdirAll(filepath.Dir(filePath), 0o700); err != nil {
+			return customerror.NewFailedToError("create directory", customerror.WithError(err))
+		}
+
+		if err := util.WriteFileAtomicBytes(filePath, []byte(fmt.Sprintf("%v", value))); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//////
+// Helpers.
+//////
+
+// loadDirectory adds the files of `directory` to `values`, keys are prefixed
+// with `prefix`. `visited` prevents symlink loops.
+func (f *Files) loadDirectory(directory, prefix string, visited map[string]bool, values map[string]string) error {
+	realPath, err := filepath.EvalSymlinks(directory)
+	if err != nil {
+		return customerror.NewFailedToError("read directory "+directory, customerror.WithError(err))
+	}
+
+	if visited[realPath] {
+		return nil
+	}
+
+	visited[realPath] = true
+
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		return customerror.NewFailedToError("read directory "+directory, customerror.WithError(err))
+	}
+
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		filePath := filepath.Join(directory, entry.Name())
+
+		// Follows symlinks.
+		info, err := os.Stat(filePath)
+		if err != nil {
+			return customerror.NewFailedToError("stat "+filePath, customerror.WithError(err))
+		}
+
+		relativePath := path.Join(prefix, entry.Name())
+
+		if info.IsDir() {
+			if f.Configuration.Recursive {
+				if err := f.loadDirectory(filePath, relativePath, visited, values); err != nil {
+					return err
+				}
+			}
+
+			continue
+		}
+
+		if !info.Mode().IsRegular() || !f.matches(relativePath) {
+			continue
+		}
+
+		if info.Size() > f.Configuration.MaxSize {
+			return customerror.NewInvalidError(fmt.Sprintf(
+				"file %s, %d bytes, larger than the maximum size, %d bytes",
+				filePath, info.Size(), f.Configuration.MaxSize,
+			))
+		}
+
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return customerror.NewFailedToError("read "+filePath, customerror.WithError(err))
+		}
+
+		var value string
+
+		// Binary content is kept byte for byte.
+		if !utf8.Valid(content) || bytes.IndexByte(content, 0) >= 0 {
+			switch f.Configuration.Binary {
+			case BinarySkip:
+				continue
+			case BinaryError:
+				return customerror.NewInvalidError("file " + filePath + ", binary content isn't allowed")
+			default:
+				value = base64.StdEncoding.EncodeToString(content)
+			}
+		} else {
+			value = strings.TrimSuffix(strings.TrimSuffix(string(content), "\n"), "\r")
+		}
+
+		values[strings.ReplaceAll(relativePath, "/", f.Configuration.Separator)] = value
+	}
+
+	return nil
+}
+
+// matches checks `relativePath` against the include, and exclude globs.
+func (f *Files) matches(relativePath string) bool {
+	if matchesAny(f.Configuration.Exclude, relativePath) {
+		return false
+	}
+
+	return len(f.Configuration.Include) == 0 || matchesAny(f.Configuration.Include, relativePath)
+}
+
+// matchesAny checks if `relativePath` matches any of the globs. Like
+// `.gitignore`, globs without `/` match the file name at any depth.
+func matchesAny(patterns []string, relativePath string) bool {
+	for _, pattern := range patterns {
+		name := relativePath
+		if !strings.Contains(pattern, "/") {
+			name = path.Base(relativePath)
+		}
+
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+//////
+// Factory.
+//////
+
+// New creates a directory of files provider.
+func New(override, rawValue bool, config *Config) (provider.IProvider, error) {
+	if config == nil {
+		return nil, customerror.NewRequiredError("config")
+	}
+
+	if config.Directory == "" {
+		config.Directory = DefaultDirectory
+
+		if directory := os.Getenv("CREDENTIALS_DIRECTORY"); directory != "" {
+			config.Directory = directory
+		}
+	}
+
+	if config.MaxSize == 0 {
+		config.MaxSize = DefaultMaxSize
+	}
+
+	if config.Separator == "" {
+		config.Separator = option.DefaultSeparator
+	}
+
+	if err := validation.Validate(config); err != nil {
+		return nil, err
+	}
+
+	for _, pattern := range append(append([]string{}, config.Include...), config.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, customerror.NewInvalidError("glob "+pattern, customerror.WithError(err))
+		}
+	}
+
+	baseProvider, err := provider.New(Name, override, rawValue)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &Files{
+		Provider:      baseProvider,
+		Configuration: config,
+	}
+
+	if err := validation.Validate(f); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
@@ -0,0 +1,264 @@
+package files
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thalesfsp/configurer/option"
+)
+
+// writeTree creates `files`, relative paths to content, under a temporary
+// directory.
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	for name, content := range files {
+		filePath := filepath.Join(dir, filepath.FromSlash(name))
+
+		require.NoError(t, os.MkdirAll(filepath.Dir(filePath), 0o700))
+		require.NoError(t, os.WriteFile(filePath, []byte(content), 0o600))
+	}
+
+	return dir
+}
+
+//////
+// Constructor.
+//////
+
+func TestNew(t *testing.T) {
+	t.Setenv("CREDENTIALS_DIRECTORY", "")
+
+	tests := []struct {
+		name    string
+		config  *Config
+		env     string
+		want    *Config
+		wantErr string
+	}{
+		{
+			name:   "happy path defaults",
+			config: &Config{},
+			want:   &Config{Directory: DefaultDirectory, MaxSize: DefaultMaxSize, Separator: option.DefaultSeparator},
+		},
+		{
+			name:   "happy path systemd credentials",
+			config: &Config{},
+			env:    "/run/credentials/app.service",
+			want:   &Config{Directory: "/run/credentials/app.service", MaxSize: DefaultMaxSize, Separator: option.DefaultSeparator},
+		},
+		{
+			name:   "happy path explicit",
+			config: &Config{Directory: "/secrets", Binary: BinarySkip, MaxSize: 10, Separator: "_", Include: []string{"db/*"}},
+			env:    "/run/credentials/app.service",
+			want:   &Config{Directory: "/secrets", Binary: BinarySkip, MaxSize: 10, Separator: "_", Include: []string{"db/*"}},
+		},
+		{
+			name:    "bad path nil config",
+			wantErr: "config",
+		},
+		{
+			name:    "bad path binary mode",
+			config:  &Config{Binary: "hex"},
+			wantErr: "Binary",
+		},
+		{
+			name:    "bad path glob",
+			config:  &Config{Exclude: []string{"[a-"}},
+			wantErr: "glob [a-",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CREDENTIALS_DIRECTORY", tt.env)
+
+			got, err := New(false, false, tt.config)
+
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				assert.Nil(t, got)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, Name, got.GetName())
+			assert.Equal(t, tt.want, got.(*Files).Configuration)
+		})
+	}
+}
+
+//////
+// IProvider implementation.
+//////
+
+func TestLoad(t *testing.T) {
+	binary := "\x00\xff\x01\n"
+
+	dir := writeTree(t, map[string]string{
+		"db_password":        "s3cr3t\n",
+		"api_token":          "t0k3n\r\n",
+		"multiline":          "line1\nline2\n\n",
+		"tls.key":            binary,
+		"db/user":            "admin",
+		"db/replica/host":    "replica",
+		"..data/hidden":      "hidden",
+		".hidden":            "hidden",
+		"certs/server.pem":   "certificate",
+		"certs/nested/a.pem": "nested",
+	})
+
+	// Kubernetes-like symlinks, and a loop.
+	require.NoError(t, os.Symlink(filepath.Join(dir, "db_password"), filepath.Join(dir, "linked")))
+	require.NoError(t, os.Symlink(dir, filepath.Join(dir, "db", "loop")))
+
+	tests := []struct {
+		name    string
+		config  *Config
+		opts    []option.LoadKeyFunc
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name:   "top level",
+			config: &Config{Directory: dir},
+			opts:   []option.LoadKeyFunc{option.WithKeyCaser("upper")},
+			want: map[string]string{
+				"DB_PASSWORD": "s3cr3t",
+				"API_TOKEN":   "t0k3n",
+				"MULTILINE":   "line1\nline2\n",
+				"TLS.KEY":     base64.StdEncoding.EncodeToString([]byte(binary)),
+				"LINKED":      "s3cr3t",
+			},
+		},
+		{
+			name:   "recursive",
+			config: &Config{Directory: dir, Recursive: true, Binary: BinarySkip, Exclude: []string{"*.pem", "certs/*/*"}},
+			want: map[string]string{
+				"db_password":       "s3cr3t",
+				"api_token":         "t0k3n",
+				"multiline":         "line1\nline2\n",
+				"linked":            "s3cr3t",
+				"db__user":          "admin",
+				"db__replica__host": "replica",
+			},
+		},
+		{
+			name:   "include with separator",
+			config: &Config{Directory: dir, Recursive: true, Separator: "_", Include: []string{"db/*", "certs/*.pem"}},
+			want: map[string]string{
+				"db_user":          "admin",
+				"certs_server.pem": "certificate",
+			},
+		},
+		{
+			name:    "binary error",
+			config:  &Config{Directory: dir, Binary: BinaryError},
+			wantErr: "binary content isn't allowed",
+		},
+		{
+			name:    "larger than the maximum size",
+			config:  &Config{Directory: dir, MaxSize: 5},
+			wantErr: "larger than the maximum size",
+		},
+		{
+			name:    "missing directory",
+			config:  &Config{Directory: filepath.Join(dir, "missing")},
+			wantErr: "read directory",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(true, false, tt.config)
+			require.NoError(t, err)
+
+			got, err := p.Load(context.Background(), tt.opts...)
+
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			t.Cleanup(func() {
+				for key := range got {
+					os.Unsetenv(key)
+				}
+			})
+
+			assert.Equal(t, tt.want, got)
+
+			for key, value := range tt.want {
+				assert.Equal(t, value, os.Getenv(key))
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "secrets")
+
+	p, err := New(false, false, &Config{Directory: dir, Recursive: true})
+	require.NoError(t, err)
+
+	require.NoError(t, p.Write(context.Background(), map[string]interface{}{
+		"db_password":       "s3cr3t\nmultiline",
+		"db__replica__port": 5432,
+	}))
+
+	content, err := os.ReadFile(filepath.Join(dir, "db", "replica", "port"))
+	require.NoError(t, err)
+	assert.Equal(t, "5432", string(content))
+
+	info, err := os.Stat(filepath.Join(dir, "db_password"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	got, err := p.Load(context.Background())
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		for key := range got {
+			os.Unsetenv(key)
+		}
+	})
+
+	assert.Equal(t, map[string]string{
+		"db_password":       "s3cr3t\nmultiline",
+		"db__replica__port": "5432",
+	}, got)
+
+	for _, key := range []string{"../escape", "db____password", ".."} {
+		assert.ErrorContains(t, p.Write(context.Background(), map[string]interface{}{key: "x"}), "it must be a file name", key)
+	}
+
+	assert.ErrorContains(t, p.Write(context.Background(), nil), "values")
+
+	// Not recursive, the separator is part of the file name.
+	p, err = New(false, false, &Config{Directory: dir})
+	require.NoError(t, err)
+	require.NoError(t, p.Write(context.Background(), map[string]interface{}{"db__name": "app"}))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+
+	var names []string
+
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	assert.Equal(t, "db,db__name,db_password", strings.Join(names, ","))
+}
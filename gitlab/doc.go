@@ -0,0 +1,5 @@
+// Package gitlab provides a provider for GitLab CI/CD variables, of a
+// project, a group, or the instance, with environment scopes, masked,
+// protected, and raw flags, variable types, create-or-update writes, and
+// self-managed instances.
+package gitlab
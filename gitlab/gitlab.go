@@ -0,0 +1,419 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/thalesfsp/configurer/option"
+	"github.com/thalesfsp/configurer/provider"
+	"github.com/thalesfsp/configurer/util"
+	"github.com/thalesfsp/customerror"
+	"github.com/thalesfsp/httpclient/v2"
+	"github.com/thalesfsp/validation"
+)
+
+//////
+// Vars, consts, and types.
+//////
+
+// Name of the provider.
+const Name = "gitlab"
+
+// DefaultBaseURL is the default GitLab URL.
+const DefaultBaseURL = "https://gitlab.com"
+
+// DefaultTimeout is the default request timeout.
+const DefaultTimeout = 30 * time.Second
+
+// AllEnvironments is the environment scope of variables available to all
+// environments.
+const AllEnvironments = "*"
+
+// perPage is the page size of list requests.
+const perPage = "100"
+
+// Variable types.
+const (
+	// EnvVar variables are exported as they are.
+	EnvVar = "env_var"
+
+	// File variables are written to a file in CI jobs, and exported as the
+	// path to it. The provider exports the content.
+	File = "file"
+)
+
+// Config contains the GitLab settings.
+type Config struct {
+	// BaseURL of the GitLab instance, e.g.: `https://gitlab.example.com` for
+	// self-managed ones. Defaults to `DefaultBaseURL`.
+	BaseURL string `json:"baseURL" validate:"required,url"`
+
+	// Token is a personal, group, or project access token, with the `api`
+	// scope. Reading variables requires, at least, the Maintainer role.
+	Token string `json:"-" validate:"required"`
+
+	// Project ID, or path, e.g.: `group/project`.
+	Project string `json:"project"`
+
+	// Group ID, or path, e.g.: `group/subgroup`.
+	Group string `json:"group"`
+
+	// Instance targets the instance-level variables. It requires an
+	// administrator token.
+	Instance bool `json:"instance"`
+
+	// EnvironmentScope selects variables for an environment, e.g.:
+	// `production`. Load exports the variables scoped to it, or to a
+	// matching wildcard scope, e.g.: `review/*`, over the ones scoped to all
+	// environments. Write uses it as the scope. Defaults to
+	// `AllEnvironments`.
+	EnvironmentScope string `json:"environmentScope"`
+
+	// Masked, Protected, and Raw are the flags of written variables.
+	Masked    bool `json:"masked"`
+	Protected bool `json:"protected"`
+	Raw       bool `json:"raw"`
+
+	// VariableType of written variables: `env_var`, or `file`. Defaults to
+	// `env_var`.
+	VariableType string `json:"variableType" validate:"required,oneof=env_var file"`
+
+	// Timeout of requests. Defaults to `DefaultTimeout`.
+	Timeout time.Duration `json:"timeout" validate:"gte=0"`
+}
+
+// Variable is a GitLab CI/CD variable.
+type Variable struct {
+	Key              string `json:"key"`
+	Value            string `json:"value"`
+	VariableType     string `json:"variable_type"`
+	Protected        bool   `json:"protected"`
+	Masked           bool   `json:"masked"`
+	Raw              bool   `json:"raw"`
+	EnvironmentScope string `json:"environment_scope,omitempty"`
+}
+
+// GitLab provider definition.
+type GitLab struct {
+	*provider.Provider `json:"-" validate:"required"`
+
+	Configuration *Config            `json:"-" validate:"required"`
+	client        *httpclient.Client `json:"-" validate:"required"`
+}
+
+//////
+// IProvider implementation.
+//////
+
+// Load reads the variables for `EnvironmentScope`, and exports them to the
+// environment.
+func (g *GitLab) Load(ctx context.Context, opts ...option.LoadKeyFunc) (map[string]string, error) {
+	variables, err := List(ctx, g)
+	if err != nil {
+		return nil, err
+	}
+
+	// Variables scoped to the environment have precedence over wildcard, and
+	// global ones, like in CI jobs.
+	values := make(map[string]string)
+	ranks := make(map[string]int)
+
+	for _, variable := range variables {
+		rank := scopeRank(variable.EnvironmentScope, g.Configuration.EnvironmentScope)
+		if rank < 0 {
+			continue
+		}
+
+		if current, ok := ranks[variable.Key]; ok && current >= rank {
+			continue
+		}
+
+		values[variable.Key] = variable.Value
+		ranks[variable.Key] = rank
+	}
+
+	finalValues := make(map[string]string, len(values))
+
+	for key, value := range values {
+		// Should allow to specify options.
+		for _, opt := range opts {
+			key = opt(key)
+		}
+
+		finalValue, err := provider.ExportToEnvVar(g, key, value)
+		if err != nil {
+			return nil, err
+		}
+
+		finalValues[key] = finalValue
+	}
+
+	return finalValues, nil
+}
+
+// Write creates, or updates `values` as variables, with the configured
+// flags, and type. `option.WithEnvironment` overrides `EnvironmentScope`.
+func (g *GitLab) Write(ctx context.Context, values map[string]interface{}, opts ...option.WriteFunc) error {
+	if values == nil {
+		return customerror.NewRequiredError("values")
+	}
+
+	var options option.Write
+
+	for _, opt := range opts {
+		if err := opt(&options); err != nil {
+			return err
+		}
+	}
+
+	scope := g.Configuration.EnvironmentScope
+	if options.Environment != "" {
+		scope = options.Environment
+	}
+
+	if g.Configuration.Instance && scope != AllEnvironments {
+		return customerror.NewInvalidError("environment scope, instance variables apply to all environments")
+	}
+
+	variables, err := List(ctx, g)
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]bool, len(variables))
+
+	for _, variable := range variables {
+		if g.Configuration.Instance || variable.EnvironmentScope == scope {
+			existing[variable.Key] = true
+		}
+	}
+
+	keys := make([]string, 0, len(values))
+
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		encoded, err := util.EncodeValue(values[key])
+		if err != nil {
+			return customerror.NewFailedToError("encode "+key, customerror.WithError(err))
+		}
+
+		value := fmt.Sprint(encoded)
+
+		variable := Variable{
+			Key:          key,
+			Value:        value,
+			VariableType: g.Configuration.VariableType,
+			Protected:    g.Configuration.Protected,
+			Masked:       g.Configuration.Masked,
+			Raw:          g.Configuration.Raw,
+		}
+
+		if !g.Configuration.Instance {
+			variable.EnvironmentScope = scope
+		}
+
+		if err := g.save(ctx, variable, existing[key]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//////
+// Exported feature(s).
+//////
+
+// List returns all the variables of the scope, of all environments.
+func List(ctx context.Context, g *GitLab) ([]Variable, error) {
+	ctx, cancel := context.WithTimeout(ctx, g.Configuration.Timeout)
+	defer cancel()
+
+	var variables []Variable
+
+	for page := "1"; page != ""; {
+		var pageVariables []Variable
+
+		response, err := g.client.Get(
+			ctx,
+			g.variablesURL(),
+			httpclient.WithQueryParam("per_page", perPage),
+			httpclient.WithQueryParam("page", page),
+			httpclient.WithRespBody(&pageVariables),
+		)
+		if err != nil {
+			return nil, customerror.NewFailedToError("list variables", customerror.WithError(err))
+		}
+
+		response.Body.Close()
+
+		variables = append(variables, pageVariables...)
+
+		page = response.Header.Get("X-Next-Page")
+	}
+
+	for i := range variables {
+		if variables[i].EnvironmentScope == "" {
+			variables[i].EnvironmentScope = AllEnvironments
+		}
+	}
+
+	return variables, nil
+}
+
+//////
+// Helpers.
+//////
+
+// variablesURL returns the URL of the variables of the scope.
+func (g *GitLab) variablesURL() string {
+	apiURL := g.Configuration.BaseURL + "/api/v4"
+
+	switch {
+	case g.Configuration.Project != "":
+		return apiURL + "/projects/" + url.PathEscape(g.Configuration.Project) + "/variables"
+	case g.Configuration.Group != "":
+		return apiURL + "/groups/" + url.PathEscape(g.Configuration.Group) + "/variables"
+	default:
+		return apiURL + "/admin/ci/variables"
+	}
+}
+
+// save creates, or, if it exists, updates a variable.
+func (g *GitLab) save(ctx context.Context, variable Variable, exists bool) error {
+	ctx, cancel := context.WithTimeout(ctx, g.Configuration.Timeout)
+	defer cancel()
+
+	if !exists {
+		response, err := g.client.Post(ctx, g.variablesURL(), httpclient.WithReqBody(variable))
+		if err != nil {
+			return customerror.NewFailedToError("create variable "+variable.Key, customerror.WithError(err))
+		}
+
+		response.Body.Close()
+
+		return nil
+	}
+
+	params := []httpclient.Func{httpclient.WithReqBody(variable)}
+
+	// Projects can have the same key in many environment scopes.
+	if g.Configuration.Project != "" {
+		params = append(params, httpclient.WithQueryParam("filter[environment_scope]", variable.EnvironmentScope))
+	}
+
+	response, err := g.client.Put(ctx, g.variablesURL()+"/"+url.PathEscape(variable.Key), params...)
+	if err != nil {
+		return customerror.NewFailedToError("update variable "+variable.Key, customerror.WithError(err))
+	}
+
+	response.Body.Close()
+
+	return nil
+}
+
+// scopeRank ranks how a variable scope applies to an environment: 2 if it's
+// the environment, 1 if it's a matching wildcard, 0 if it's all
+// environments, or -1 if it doesn't apply.
+func scopeRank(scope, environment string) int {
+	switch {
+	case scope == environment:
+		return 2
+	case scope == AllEnvironments:
+		return 0
+	case strings.Contains(scope, "*") && environment != AllEnvironments:
+		pattern := strings.ReplaceAll(regexp.QuoteMeta(scope), `\*`, ".*")
+
+		if regexp.MustCompile("^" + pattern + "$").MatchString(environment) {
+			return 1
+		}
+	}
+
+	return -1
+}
+
+//////
+// Factory.
+//////
+
+// New creates a GitLab provider.
+func New(override, rawValue bool, config *Config) (provider.IProvider, error) {
+	if config == nil {
+		return nil, customerror.NewRequiredError("config")
+	}
+
+	if config.BaseURL == "" {
+		config.BaseURL = DefaultBaseURL
+	}
+
+	config.BaseURL = strings.TrimSuffix(config.BaseURL, "/")
+
+	if config.EnvironmentScope == "" {
+		config.EnvironmentScope = AllEnvironments
+	}
+
+	if config.VariableType == "" {
+		config.VariableType = EnvVar
+	}
+
+	if config.Timeout == 0 {
+		config.Timeout = DefaultTimeout
+	}
+
+	if err := validation.Validate(config); err != nil {
+		return nil, err
+	}
+
+	scopes := 0
+
+	for _, set := range []bool{config.Project != "", config.Group != "", config.Instance} {
+		if set {
+			scopes++
+		}
+	}
+
+	if scopes != 1 {
+		return nil, customerror.NewInvalidError("project, group, and instance, exactly one is required")
+	}
+
+	if config.Instance && config.EnvironmentScope != AllEnvironments {
+		return nil, customerror.NewInvalidError("environment scope, instance variables apply to all environments")
+	}
+
+	baseProvider, err := provider.New(Name, override, rawValue)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := httpclient.NewDefault(
+		httpclient.WithClientName(Name),
+		httpclient.WithClientHeader("PRIVATE-TOKEN", config.Token),
+	)
+	if err != nil {
+		return nil, customerror.NewFailedToError("initialize HTTP client", customerror.WithError(err))
+	}
+
+	client.GetClient().Timeout = config.Timeout
+
+	g := &GitLab{
+		Provider:      baseProvider,
+		Configuration: config,
+		client:        client,
+	}
+
+	if err := validation.Validate(g); err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
@@ -0,0 +1,349 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thalesfsp/configurer/option"
+)
+
+// fakeGitLab serves the CI/CD variables API, two variables per page.
+type fakeGitLab struct {
+	mu        sync.Mutex
+	variables map[string][]Variable
+	requests  []string
+}
+
+func newFakeGitLab(t *testing.T) (*fakeGitLab, *httptest.Server) {
+	t.Helper()
+
+	f := &fakeGitLab{variables: map[string][]Variable{}}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		f.requests = append(f.requests, r.Method+" "+r.URL.EscapedPath()+"?"+r.URL.RawQuery)
+
+		if r.Header.Get("PRIVATE-TOKEN") != "glpat-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"message": "401 Unauthorized"}`))
+
+			return
+		}
+
+		collection, key, _ := strings.Cut(strings.TrimPrefix(r.URL.EscapedPath(), "/api/v4/"), "/variables")
+		key = strings.TrimPrefix(key, "/")
+
+		switch r.Method {
+		case http.MethodGet:
+			variables := f.variables[collection]
+
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			start := min((page-1)*2, len(variables))
+			end := min(start+2, len(variables))
+
+			if end < len(variables) {
+				w.Header().Set("X-Next-Page", strconv.Itoa(page+1))
+			}
+
+			_ = json.NewEncoder(w).Encode(variables[start:end])
+		case http.MethodPost:
+			var variable Variable
+			_ = json.NewDecoder(r.Body).Decode(&variable)
+
+			f.variables[collection] = append(f.variables[collection], variable)
+
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(variable)
+		case http.MethodPut:
+			var variable Variable
+			_ = json.NewDecoder(r.Body).Decode(&variable)
+
+			scope := r.URL.Query().Get("filter[environment_scope]")
+
+			for i, existing := range f.variables[collection] {
+				if existing.Key == key && (scope == "" || existing.EnvironmentScope == scope) {
+					f.variables[collection][i] = variable
+
+					_ = json.NewEncoder(w).Encode(variable)
+
+					return
+				}
+			}
+
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "404 Variable Not Found"}`))
+		}
+	}))
+
+	t.Cleanup(server.Close)
+
+	return f, server
+}
+
+// load loads, and unsets the exported values.
+func load(t *testing.T, g *GitLab, opts ...option.LoadKeyFunc) map[string]string {
+	t.Helper()
+
+	got, err := g.Load(context.Background(), opts...)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		for key := range got {
+			os.Unsetenv(key)
+		}
+	})
+
+	return got
+}
+
+//////
+// Constructor.
+//////
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *Config
+		want    *Config
+		wantErr string
+	}{
+		{
+			name:   "happy path defaults",
+			config: &Config{Token: "glpat-token", Project: "group/project"},
+			want:   &Config{BaseURL: DefaultBaseURL, Token: "glpat-token", Project: "group/project", EnvironmentScope: AllEnvironments, VariableType: EnvVar, Timeout: DefaultTimeout},
+		},
+		{
+			name:   "happy path self-managed group",
+			config: &Config{BaseURL: "https://gitlab.example.com/", Token: "glpat-token", Group: "42", EnvironmentScope: "production", VariableType: File, Masked: true},
+			want:   &Config{BaseURL: "https://gitlab.example.com", Token: "glpat-token", Group: "42", EnvironmentScope: "production", VariableType: File, Masked: true, Timeout: DefaultTimeout},
+		},
+		{
+			name:    "bad path nil config",
+			wantErr: "config",
+		},
+		{
+			name:    "bad path missing token",
+			config:  &Config{Project: "group/project"},
+			wantErr: "Token",
+		},
+		{
+			name:    "bad path invalid variable type",
+			config:  &Config{Token: "glpat-token", Project: "group/project", VariableType: "secret"},
+			wantErr: "VariableType",
+		},
+		{
+			name:    "bad path no scope",
+			config:  &Config{Token: "glpat-token"},
+			wantErr: "exactly one is required",
+		},
+		{
+			name:    "bad path project, and group",
+			config:  &Config{Token: "glpat-token", Project: "group/project", Group: "group"},
+			wantErr: "exactly one is required",
+		},
+		{
+			name:    "bad path instance with an environment scope",
+			config:  &Config{Token: "glpat-token", Instance: true, EnvironmentScope: "production"},
+			wantErr: "instance variables apply to all environments",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(false, false, tt.config)
+
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				assert.Nil(t, got)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, Name, got.GetName())
+			assert.Equal(t, tt.want, got.(*GitLab).Configuration)
+		})
+	}
+}
+
+//////
+// IProvider implementation.
+//////
+
+func TestLoad(t *testing.T) {
+	f, server := newFakeGitLab(t)
+
+	f.variables["projects/group%2Fproject"] = []Variable{
+		{Key: "GITLAB_DB_HOST", Value: "db.internal", EnvironmentScope: "*"},
+		{Key: "GITLAB_DB_HOST", Value: "db.production", EnvironmentScope: "production"},
+		{Key: "GITLAB_DB_HOST", Value: "db.review", EnvironmentScope: "review/*"},
+		{Key: "GITLAB_CERT", Value: "-----BEGIN CERTIFICATE-----", VariableType: File, EnvironmentScope: "*"},
+		{Key: "GITLAB_STAGING", Value: "only", EnvironmentScope: "staging"},
+	}
+	f.variables["groups/42"] = []Variable{{Key: "GITLAB_GROUP", Value: "group", EnvironmentScope: "*"}}
+	f.variables["admin/ci"] = []Variable{{Key: "GITLAB_INSTANCE", Value: "instance"}}
+
+	tests := []struct {
+		name    string
+		config  *Config
+		opts    []option.LoadKeyFunc
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name:   "project, all environments",
+			config: &Config{Project: "group/project"},
+			want:   map[string]string{"GITLAB_DB_HOST": "db.internal", "GITLAB_CERT": "-----BEGIN CERTIFICATE-----"},
+		},
+		{
+			name:   "project, environment",
+			config: &Config{Project: "group/project", EnvironmentScope: "production"},
+			want:   map[string]string{"GITLAB_DB_HOST": "db.production", "GITLAB_CERT": "-----BEGIN CERTIFICATE-----"},
+		},
+		{
+			name:   "project, wildcard environment",
+			config: &Config{Project: "group/project", EnvironmentScope: "review/feature-1"},
+			opts:   []option.LoadKeyFunc{option.WithKeyPrefixer("CI_")},
+			want:   map[string]string{"CI_GITLAB_DB_HOST": "db.review", "CI_GITLAB_CERT": "-----BEGIN CERTIFICATE-----"},
+		},
+		{
+			name:   "group",
+			config: &Config{Group: "42"},
+			want:   map[string]string{"GITLAB_GROUP": "group"},
+		},
+		{
+			name:   "instance",
+			config: &Config{Instance: true},
+			want:   map[string]string{"GITLAB_INSTANCE": "instance"},
+		},
+		{
+			name:    "invalid token",
+			config:  &Config{Project: "group/project", Token: "invalid"},
+			wantErr: "401 Unauthorized",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.BaseURL = server.URL
+
+			if tt.config.Token == "" {
+				tt.config.Token = "glpat-token"
+			}
+
+			p, err := New(true, false, tt.config)
+			require.NoError(t, err)
+
+			if tt.wantErr != "" {
+				_, err := p.Load(context.Background(), tt.opts...)
+				assert.ErrorContains(t, err, tt.wantErr)
+
+				return
+			}
+
+			got := load(t, p.(*GitLab), tt.opts...)
+
+			assert.Equal(t, tt.want, got)
+
+			for key, value := range tt.want {
+				assert.Equal(t, value, os.Getenv(key))
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	f, server := newFakeGitLab(t)
+
+	f.variables["projects/7"] = []Variable{
+		{Key: "GITLAB_A", Value: "old", EnvironmentScope: "*"},
+		{Key: "GITLAB_A", Value: "old-production", EnvironmentScope: "production"},
+		{Key: "GITLAB_C", Value: "unchanged", EnvironmentScope: "*"},
+	}
+
+	ctx := context.Background()
+
+	values := map[string]interface{}{"GITLAB_A": "new", "GITLAB_B": 2, "GITLAB_LIST": []any{"a"}}
+
+	p, err := New(true, false, &Config{BaseURL: server.URL, Token: "glpat-token", Project: "7", EnvironmentScope: "production", Masked: true, Protected: true, Raw: true, VariableType: File})
+	require.NoError(t, err)
+	require.NoError(t, p.Write(ctx, values))
+
+	f.mu.Lock()
+	assert.Equal(t, []Variable{
+		{Key: "GITLAB_A", Value: "old", EnvironmentScope: "*"},
+		{Key: "GITLAB_A", Value: "new", VariableType: File, Protected: true, Masked: true, Raw: true, EnvironmentScope: "production"},
+		{Key: "GITLAB_C", Value: "unchanged", EnvironmentScope: "*"},
+		{Key: "GITLAB_B", Value: "2", VariableType: File, Protected: true, Masked: true, Raw: true, EnvironmentScope: "production"},
+		{Key: "GITLAB_LIST", Value: `["a"]`, VariableType: File, Protected: true, Masked: true, Raw: true, EnvironmentScope: "production"},
+	}, f.variables["projects/7"])
+	assert.Contains(t, f.requests, "PUT /api/v4/projects/7/variables/GITLAB_A?filter%5Benvironment_scope%5D=production")
+	f.mu.Unlock()
+
+	assert.Equal(t, map[string]string{"GITLAB_A": "new", "GITLAB_B": "2", "GITLAB_LIST": `["a"]`, "GITLAB_C": "unchanged"}, load(t, p.(*GitLab)))
+
+	// Environment option.
+	require.NoError(t, p.Write(ctx, map[string]interface{}{"GITLAB_A": "staging"}, option.WithEnvironment("staging")))
+
+	f.mu.Lock()
+	assert.Equal(t, Variable{Key: "GITLAB_A", Value: "staging", VariableType: File, Protected: true, Masked: true, Raw: true, EnvironmentScope: "staging"}, f.variables["projects/7"][5])
+	f.mu.Unlock()
+
+	// Instance.
+	f.variables["admin/ci"] = []Variable{{Key: "GITLAB_A", Value: "old"}}
+
+	p, err = New(true, false, &Config{BaseURL: server.URL, Token: "glpat-token", Instance: true})
+	require.NoError(t, err)
+	require.NoError(t, p.Write(ctx, map[string]interface{}{"GITLAB_A": "new", "GITLAB_EMPTY": nil}))
+
+	f.mu.Lock()
+	assert.Equal(t, []Variable{
+		{Key: "GITLAB_A", Value: "new", VariableType: EnvVar},
+		{Key: "GITLAB_EMPTY", VariableType: EnvVar},
+	}, f.variables["admin/ci"])
+	f.mu.Unlock()
+
+	assert.ErrorContains(t, p.Write(ctx, map[string]interface{}{"GITLAB_A": "new"}, option.WithEnvironment("production")), "instance variables")
+	assert.ErrorContains(t, p.Write(ctx, nil), "values")
+
+	// Errors.
+	p, err = New(true, false, &Config{BaseURL: server.URL, Token: "invalid", Group: "42"})
+	require.NoError(t, err)
+	assert.ErrorContains(t, p.Write(ctx, values), "list variables")
+}
+
+//////
+// Helpers.
+//////
+
+func TestScopeRank(t *testing.T) {
+	tests := []struct {
+		scope       string
+		environment string
+		want        int
+	}{
+		{scope: "production", environment: "production", want: 2},
+		{scope: "review/*", environment: "review/feature-1", want: 1},
+		{scope: "*-eu", environment: "production-eu", want: 1},
+		{scope: "*", environment: "production", want: 0},
+		{scope: "*", environment: "*", want: 2},
+		{scope: "review/*", environment: "*", want: -1},
+		{scope: "review/*", environment: "production", want: -1},
+		{scope: "prod.ction", environment: "production", want: -1},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, scopeRank(tt.scope, tt.environment), tt.scope+" "+tt.environment)
+	}
+}
@@ -0,0 +1,4 @@
+// Package http provides a provider for HTTP(S) endpoints, e.g.: in-house
+// config services, which return JSON, dotenv, or any supported format, with
+// bearer, basic, or mTLS auth, JSON pointers, and ETag caching.
+package http
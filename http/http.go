@@ -0,0 +1,504 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thalesfsp/configurer/option"
+	"github.com/thalesfsp/configurer/parser"
+	"github.com/thalesfsp/configurer/provider"
+	"github.com/thalesfsp/configurer/util"
+	"github.com/thalesfsp/customerror"
+	"github.com/thalesfsp/httpclient/v2"
+	"github.com/thalesfsp/validation"
+)
+
+//////
+// Vars, consts, and types.
+//////
+
+// Name of the provider.
+const Name = "http"
+
+// DefaultTimeout is the default request timeout.
+const DefaultTimeout = 30 * time.Second
+
+// Config contains the HTTP endpoint settings.
+type Config struct {
+	// URL of the endpoint.
+	URL string `json:"url" validate:"required,url"`
+
+	// BearerToken sets the `Authorization: Bearer` header.
+	BearerToken string `json:"-"`
+
+	// Username, and Password set the `Authorization: Basic` header.
+	Username string `json:"-"`
+	Password string `json:"-"`
+
+	// Headers are extra request headers.
+	Headers map[string]string `json:"-"`
+
+	// CAFile is a PEM file with CAs trusted in addition to the system ones.
+	CAFile string `json:"caFile"`
+
+	// CertFile, and KeyFile are the PEM client certificate, and key, for
+	// mTLS.
+	CertFile string `json:"certFile"`
+	KeyFile  string `json:"keyFile"`
+
+	// Format of the response: any `parser` format, e.g.: `json`, `env`, or
+	// `auto` to detect it. Defaults to `auto`.
+	Format string `json:"format"`
+
+	// Pointer is a JSON pointer (RFC 6901) to the object to load, e.g.:
+	// `/data/secrets`. Write nests the values under it.
+	Pointer string `json:"pointer" validate:"omitempty,startswith=/"`
+
+	// CacheFile persists the ETag, and the response, only readable by the
+	// owner, so later runs send `If-None-Match`, and reuse the response when
+	// the endpoint replies `304 Not Modified`. Within a process, the last
+	// response is always reused.
+	CacheFile string `json:"cacheFile"`
+
+	// ParseOptions flattens nested values, e.g.: `database.host` becomes
+	// `DATABASE__HOST`. If not set, nested values are JSON-encoded.
+	ParseOptions []option.ParseFunc `json:"-"`
+
+	// Timeout of requests. Defaults to `DefaultTimeout`.
+	Timeout time.Duration `json:"timeout" validate:"gte=0"`
+
+	// WriteMethod is the verb of Write, which sends the values as a JSON
+	// object. Defaults to `PUT`.
+	WriteMethod string `json:"writeMethod" validate:"omitempty,oneof=POST PUT PATCH"`
+
+	// WriteURL is the URL of Write. Defaults to `URL`.
+	WriteURL string `json:"writeURL" validate:"omitempty,url"`
+}
+
+// HTTP provider definition.
+type HTTP struct {
+	*provider.Provider `json:"-" validate:"required"`
+
+	Configuration *Config            `json:"-" validate:"required"`
+	client        *httpclient.Client `json:"-" validate:"required"`
+
+	mu    sync.Mutex
+	cache *cachedResponse
+}
+
+// cachedResponse is the last response, and its ETag.
+type cachedResponse struct {
+	ETag        string `json:"etag"`
+	ContentType string `json:"contentType"`
+	Body        []byte `json:"body"`
+}
+
+//////
+// IProvider implementation.
+//////
+
+// Load fetches the endpoint, parses the response, and exports the values of
+// the object at `Pointer`, or of the whole document.
+func (h *HTTP) Load(ctx context.Context, opts ...option.LoadKeyFunc) (map[string]string, error) {
+	response, err := h.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	document, err := util.ParseContent(ctx, h.format(response.ContentType), bytes.NewReader(response.Body))
+	if err != nil {
+		return nil, customerror.NewFailedToError("parse response", customerror.WithError(err))
+	}
+
+	values, err := resolvePointer(document, h.Configuration.Pointer)
+	if err != nil {
+		return nil, err
+	}
+
+	parseOptions, err := option.NewParse(h.Configuration.ParseOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	if parseOptions.Flatten {
+		if values, err = util.Flatten(values, h.Configuration.ParseOptions...); err != nil {
+			return nil, err
+		}
+	}
+
+	finalValues := make(map[string]string, len(values))
+
+	for key, value := range values {
+		value, err := util.EncodeValue(value)
+		if err != nil {
+			return nil, customerror.NewFailedToError("encode "+key, customerror.WithError(err))
+		}
+
+		// Should allow to specify options.
+		for _, opt := range opts {
+			key = opt(key)
+		}
+
+		finalValue, err := provider.ExportToEnvVar(h, key, value)
+		if err != nil {
+			return nil, err
+		}
+
+		finalValues[key] = finalValue
+	}
+
+	return finalValues, nil
+}
+
+// Write sends `values` as a JSON object, nested under `Pointer`, if set, with
+// `WriteMethod` to `WriteURL`.
+func (h *HTTP) Write(ctx context.Context, values map[string]interface{}, opts ...option.WriteFunc) error {
+	if values == nil {
+		return customerror.NewRequiredError("values")
+	}
+
+	var options option.Write
+
+	for _, opt := range opts {
+		if err := opt(&options); err != nil {
+			return err
+		}
+	}
+
+	var body any = values
+
+	tokens := pointerTokens(h.Configuration.Pointer)
+
+	for i := len(tokens) - 1; i >= 0; i-- {
+		body = map[string]any{tokens[i]: body}
+	}
+
+	send := h.client.Put
+
+	switch h.Configuration.WriteMethod {
+	case http.MethodPost:
+		send = h.client.Post
+	case http.MethodPatch:
+		send = h.client.Patch
+	}
+
+	response, err := send(ctx, h.Configuration.WriteURL, httpclient.WithReqBody(body))
+	if err != nil {
+		return customerror.NewFailedToError("write values", customerror.WithError(err))
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return customerror.NewFailedToError("write values", customerror.WithError(fmt.Errorf("status %s", response.Status)))
+	}
+
+	h.mu.Lock()
+	h.cache = nil
+	h.mu.Unlock()
+
+	return nil
+}
+
+//////
+// Helpers.
+//////
+
+// fetch gets the endpoint, sending `If-None-Match` if there's a cached
+// response, which is returned if the endpoint replies `304 Not Modified`.
+func (h *HTTP) fetch(ctx context.Context) (*cachedResponse, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.cache == nil && h.Configuration.CacheFile != "" {
+		h.cache = readCache(h.Configuration.CacheFile)
+	}
+
+	var requestOptions []httpclient.Func
+
+	if h.cache != nil && h.cache.ETag != "" {
+		requestOptions = append(requestOptions, httpclient.WithHeader("If-None-Match", h.cache.ETag))
+	}
+
+	response, err := h.client.Get(ctx, h.Configuration.URL, requestOptions...)
+	if err != nil {
+		return nil, customerror.NewFailedToError("fetch "+h.Configuration.URL, customerror.WithError(err))
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotModified && h.cache != nil {
+		return h.cache, nil
+	}
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return nil, customerror.NewFailedToError(
+			"fetch "+h.Configuration.URL,
+			customerror.WithError(fmt.Errorf("status %s", response.Status)),
+		)
+	}
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, customerror.NewFailedToError("read response", customerror.WithError(err))
+	}
+
+	h.cache = &cachedResponse{
+		ETag:        response.Header.Get("ETag"),
+		ContentType: response.Header.Get("Content-Type"),
+		Body:        body,
+	}
+
+	if h.Configuration.CacheFile != "" && h.cache.ETag != "" {
+		if err := writeCache(h.Configuration.CacheFile, h.cache); err != nil {
+			return nil, err
+		}
+	}
+
+	return h.cache, nil
+}
+
+// format returns the configured format, or, if `auto`, the one of the
+// response content type, if known.
+func (h *HTTP) format(contentType string) string {
+	if h.Configuration.Format != parser.Auto {
+		return h.Configuration.Format
+	}
+
+	mediaType := strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0])
+
+	// E.g.: `application/json`, `application/problem+json`, `text/yaml`.
+	for _, suffix := range []string{"/", "+"} {
+		if i := strings.LastIndex(mediaType, suffix); i >= 0 {
+			subtype := strings.TrimPrefix(mediaType[i+1:], "x-")
+
+			if _, err := parser.Get(subtype); err == nil {
+				return subtype
+			}
+		}
+	}
+
+	return parser.Auto
+}
+
+// resolvePointer returns the object at `pointer` in `document`.
+func resolvePointer(document map[string]any, pointer string) (map[string]any, error) {
+	var current any = document
+
+	for _, token := range pointerTokens(pointer) {
+		switch node := current.(type) {
+		case map[string]any:
+			value, ok := node[token]
+			if !ok {
+				return nil, customerror.NewNotFoundError("pointer " + pointer)
+			}
+
+			current = value
+		case []any:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, customerror.NewNotFoundError("pointer " + pointer)
+			}
+
+			current = node[index]
+		default:
+			return nil, customerror.NewNotFoundError("pointer " + pointer)
+		}
+	}
+
+	object, ok := current.(map[string]any)
+	if !ok {
+		return nil, customerror.NewInvalidError("pointer " + pointer + ", it must point to an object")
+	}
+
+	return object, nil
+}
+
+// pointerTokens splits, and unescapes a JSON pointer.
+func pointerTokens(pointer string) []string {
+	if pointer == "" || pointer == "/" {
+		return nil
+	}
+
+	tokens := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+
+	return tokens
+}
+
+// readCache reads the cache file, if valid.
+func readCache(filePath string) *cachedResponse {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil
+	}
+
+	var cache cachedResponse
+
+	if err := json.Unmarshal(content, &cache); err != nil {
+		return nil
+	}
+
+	return &cache
+}
+
+// writeCache writes the cache to a temporary file, only readable by the
+// owner, which atomically replaces `filePath`.
+func writeCache(filePath string, cache *cachedResponse) error {
+	content, err := json.Marshal(cache)
+	if err != nil {
+		return customerror.NewFailedToError("encode cache", customerror.WithError(err))
+	}
+
+	return util.WriteFileAtomicBytes(filePath, content)
+}
+
+// newTLSConfig loads the CAs, and the client certificate.
+func newTLSConfig(config *Config) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if config.CAFile != "" {
+		certificate, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, customerror.NewFailedToError("read CA file", customerror.WithError(err))
+		}
+
+		certificates, err := x509.SystemCertPool()
+		if err != nil {
+			certificates = x509.NewCertPool()
+		}
+
+		if !certificates.AppendCertsFromPEM(certificate) {
+			return nil, customerror.NewInvalidError("CA file, no PEM certificates")
+		}
+
+		tlsConfig.RootCAs = certificates
+	}
+
+	if config.CertFile != "" || config.KeyFile != "" {
+		certificate, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+		if err != nil {
+			return nil, customerror.NewFailedToError("load client certificate", customerror.WithError(err))
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return tlsConfig, nil
+}
+
+//////
+// Factory.
+//////
+
+// New creates an HTTP provider.
+func New(override, rawValue bool, config *Config) (provider.IProvider, error) {
+	if config == nil {
+		return nil, customerror.NewRequiredError("config")
+	}
+
+	if config.Format == "" {
+		config.Format = parser.Auto
+	}
+
+	if config.Timeout == 0 {
+		config.Timeout = DefaultTimeout
+	}
+
+	config.WriteMethod = strings.ToUpper(config.WriteMethod)
+
+	if config.WriteMethod == "" {
+		config.WriteMethod = http.MethodPut
+	}
+
+	if config.WriteURL == "" {
+		config.WriteURL = config.URL
+	}
+
+	if err := validation.Validate(config); err != nil {
+		return nil, err
+	}
+
+	if config.Format != parser.Auto {
+		if _, err := parser.Get(config.Format); err != nil {
+			return nil, customerror.NewInvalidError("format, allowed: " + strings.Join(parser.Formats(), ", ") + ", " + parser.Auto)
+		}
+	}
+
+	if config.BearerToken != "" && (config.Username != "" || config.Password != "") {
+		return nil, customerror.NewInvalidError("auth, bearer token, and basic auth are mutually exclusive")
+	}
+
+	if (config.CertFile == "") != (config.KeyFile == "") {
+		return nil, customerror.NewRequiredError("cert file, and key file, for mTLS")
+	}
+
+	tlsConfig, err := newTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	baseProvider, err := provider.New(Name, override, rawValue)
+	if err != nil {
+		return nil, err
+	}
+
+	clientOptions := []httpclient.ClientFunc{httpclient.WithClientName(Name)}
+
+	for key, value := range config.Headers {
+		clientOptions = append(clientOptions, httpclient.WithClientHeader(key, value))
+	}
+
+	switch {
+	case config.BearerToken != "":
+		clientOptions = append(clientOptions, httpclient.WithClientHeader("Authorization", "Bearer "+config.BearerToken))
+	case config.Username != "" || config.Password != "":
+		credentials := base64.StdEncoding.EncodeToString([]byte(config.Username + ":" + config.Password))
+
+		clientOptions = append(clientOptions, httpclient.WithClientHeader("Authorization", "Basic "+credentials))
+	}
+
+	client, err := httpclient.NewDefault(clientOptions...)
+	if err != nil {
+		return nil, customerror.NewFailedToError("initialize HTTP client", customerror.WithError(err))
+	}
+
+	transport, ok := http.DefaultTransport.(*http.Transport)
+	if ok {
+		transport = transport.Clone()
+	} else {
+		transport = &http.Transport{}
+	}
+
+	transport.TLSClientConfig = tlsConfig
+
+	client.GetClient().Transport = transport
+	client.GetClient().Timeout = config.Timeout
+
+	h := &HTTP{
+		Provider:      baseProvider,
+		Configuration: config,
+		client:        client,
+	}
+
+	if err := validation.Validate(h); err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
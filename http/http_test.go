@@ -0,0 +1,405 @@
+package http
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thalesfsp/configurer/option"
+)
+
+// writePEM writes a PEM block to a temporary file.
+func writePEM(t *testing.T, name, blockType string, der []byte) string {
+	t.Helper()
+
+	filePath := filepath.Join(t.TempDir(), name)
+
+	require.NoError(t, os.WriteFile(filePath, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600))
+
+	return filePath
+}
+
+// clientCertificate creates a self-signed client certificate, and key.
+func clientCertificate(t *testing.T) (*x509.Certificate, string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "configurer"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	certificate, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return certificate, writePEM(t, "client.crt", "CERTIFICATE", der), writePEM(t, "client.key", "EC PRIVATE KEY", keyDER)
+}
+
+// load loads, and unsets the exported values.
+func load(t *testing.T, p interface {
+	Load(context.Context, ...option.LoadKeyFunc) (map[string]string, error)
+}, opts ...option.LoadKeyFunc,
+) map[string]string {
+	t.Helper()
+
+	got, err := p.Load(context.Background(), opts...)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		for key := range got {
+			os.Unsetenv(key)
+		}
+	})
+
+	return got
+}
+
+//////
+// Constructor.
+//////
+
+func TestNew(t *testing.T) {
+	_, certFile, keyFile := clientCertificate(t)
+
+	tests := []struct {
+		name    string
+		config  *Config
+		wantErr string
+	}{
+		{
+			name:   "happy path",
+			config: &Config{URL: "https://config.internal/app"},
+		},
+		{
+			name:   "happy path mTLS, and write",
+			config: &Config{URL: "https://config.internal/app", CertFile: certFile, KeyFile: keyFile, WriteMethod: "post", Format: "env"},
+		},
+		{
+			name:    "bad path nil config",
+			wantErr: "config",
+		},
+		{
+			name:    "bad path invalid URL",
+			config:  &Config{URL: "config.internal"},
+			wantErr: "URL",
+		},
+		{
+			name:    "bad path invalid pointer",
+			config:  &Config{URL: "https://config.internal/app", Pointer: "data"},
+			wantErr: "Pointer",
+		},
+		{
+			name:    "bad path invalid write method",
+			config:  &Config{URL: "https://config.internal/app", WriteMethod: "DELETE"},
+			wantErr: "WriteMethod",
+		},
+		{
+			name:    "bad path unknown format",
+			config:  &Config{URL: "https://config.internal/app", Format: "xml"},
+			wantErr: "format, allowed",
+		},
+		{
+			name:    "bad path bearer, and basic auth",
+			config:  &Config{URL: "https://config.internal/app", BearerToken: "t", Username: "u"},
+			wantErr: "mutually exclusive",
+		},
+		{
+			name:    "bad path cert without key",
+			config:  &Config{URL: "https://config.internal/app", CertFile: certFile},
+			wantErr: "cert file, and key file",
+		},
+		{
+			name:    "bad path invalid CA file",
+			config:  &Config{URL: "https://config.internal/app", CAFile: keyFile},
+			wantErr: "no PEM certificates",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(false, false, tt.config)
+
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				assert.Nil(t, got)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, Name, got.GetName())
+		})
+	}
+}
+
+//////
+// IProvider implementation.
+//////
+
+func TestLoad(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/json":
+			assert.Equal(t, "Bearer t0k3n", r.Header.Get("Authorization"))
+			assert.Equal(t, "app", r.Header.Get("X-Tenant"))
+
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			_, _ = w.Write([]byte(`{"data": {"secrets": {"HTTP_DB": {"host": "h", "port": 5432}, "HTTP_TOKEN": "t", "HTTP_NULL": null}, "list": [{"HTTP_ITEM": "i"}]}}`))
+		case "/dotenv":
+			user, password, ok := r.BasicAuth()
+			assert.True(t, ok)
+			assert.Equal(t, "admin:s3cr3t", user+":"+password)
+
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = w.Write([]byte("HTTP_A=1\nHTTP_B=two\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	tests := []struct {
+		name    string
+		config  *Config
+		opts    []option.LoadKeyFunc
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name:   "JSON pointer, nested values encoded",
+			config: &Config{URL: server.URL + "/json", BearerToken: "t0k3n", Headers: map[string]string{"X-Tenant": "app"}, Pointer: "/data/secrets"},
+			want:   map[string]string{"HTTP_DB": `{"host":"h","port":5432}`, "HTTP_TOKEN": "t", "HTTP_NULL": ""},
+		},
+		{
+			name:   "JSON pointer, flattened",
+			config: &Config{URL: server.URL + "/json", BearerToken: "t0k3n", Headers: map[string]string{"X-Tenant": "app"}, Pointer: "/data/secrets", ParseOptions: []option.ParseFunc{option.WithFlatten(true)}},
+			opts:   []option.LoadKeyFunc{option.WithKeyPrefixer("APP_")},
+			want:   map[string]string{"APP_HTTP_DB__HOST": "h", "APP_HTTP_DB__PORT": "5432", "APP_HTTP_TOKEN": "t", "APP_HTTP_NULL": ""},
+		},
+		{
+			name:   "JSON pointer, array index",
+			config: &Config{URL: server.URL + "/json", BearerToken: "t0k3n", Headers: map[string]string{"X-Tenant": "app"}, Pointer: "/data/list/0"},
+			want:   map[string]string{"HTTP_ITEM": "i"},
+		},
+		{
+			name:   "dotenv, basic auth",
+			config: &Config{URL: server.URL + "/dotenv", Username: "admin", Password: "s3cr3t", Format: "env"},
+			want:   map[string]string{"HTTP_A": "1", "HTTP_B": "two"},
+		},
+		{
+			name:    "missing pointer",
+			config:  &Config{URL: server.URL + "/json", BearerToken: "t0k3n", Headers: map[string]string{"X-Tenant": "app"}, Pointer: "/data/missing"},
+			wantErr: "pointer /data/missing",
+		},
+		{
+			name:    "pointer to a value",
+			config:  &Config{URL: server.URL + "/json", BearerToken: "t0k3n", Headers: map[string]string{"X-Tenant": "app"}, Pointer: "/data/secrets/HTTP_TOKEN"},
+			wantErr: "it must point to an object",
+		},
+		{
+			name:    "not found",
+			config:  &Config{URL: server.URL + "/missing"},
+			wantErr: "fetch " + server.URL + "/missing",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(true, false, tt.config)
+			require.NoError(t, err)
+
+			if tt.wantErr != "" {
+				_, err := p.Load(context.Background(), tt.opts...)
+				assert.ErrorContains(t, err, tt.wantErr)
+
+				return
+			}
+
+			got := load(t, p, tt.opts...)
+
+			assert.Equal(t, tt.want, got)
+
+			for key, value := range tt.want {
+				assert.Equal(t, value, os.Getenv(key))
+			}
+		})
+	}
+}
+
+func TestLoadETag(t *testing.T) {
+	var requests, notModified atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+
+			return
+		}
+
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"HTTP_ETAG": "cached"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	cacheFile := filepath.Join(t.TempDir(), "cache.json")
+
+	p, err := New(true, false, &Config{URL: server.URL, CacheFile: cacheFile})
+	require.NoError(t, err)
+
+	// Fetched, then revalidated in process.
+	for range 2 {
+		assert.Equal(t, map[string]string{"HTTP_ETAG": "cached"}, load(t, p))
+	}
+
+	info, err := os.Stat(cacheFile)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	// Revalidated from the cache file, like a later run.
+	p, err = New(true, false, &Config{URL: server.URL, CacheFile: cacheFile})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{"HTTP_ETAG": "cached"}, load(t, p))
+	assert.Equal(t, int32(3), requests.Load())
+	assert.Equal(t, int32(2), notModified.Load())
+}
+
+func TestLoadMTLS(t *testing.T) {
+	certificate, certFile, keyFile := clientCertificate(t)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(certificate)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Len(t, r.TLS.PeerCertificates, 1)
+		assert.Equal(t, "configurer", r.TLS.PeerCertificates[0].Subject.CommonName)
+
+		_, _ = w.Write([]byte(`{"HTTP_MTLS": "ok"}`))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs, MinVersion: tls.VersionTLS12}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	caFile := writePEM(t, "ca.crt", "CERTIFICATE", server.Certificate().Raw)
+
+	p, err := New(true, false, &Config{URL: server.URL, CAFile: caFile, CertFile: certFile, KeyFile: keyFile})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{"HTTP_MTLS": "ok"}, load(t, p))
+
+	// Without the client certificate.
+	p, err = New(true, false, &Config{URL: server.URL, CAFile: caFile})
+	require.NoError(t, err)
+
+	_, err = p.Load(context.Background())
+	assert.ErrorContains(t, err, "fetch")
+}
+
+func TestWrite(t *testing.T) {
+	type request struct {
+		method string
+		path   string
+		body   map[string]any
+	}
+
+	var got request
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		got = request{method: r.Method, path: r.URL.Path}
+		require.NoError(t, json.Unmarshal(body, &got.body))
+
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+
+	tests := []struct {
+		name    string
+		config  *Config
+		want    request
+		wantErr string
+	}{
+		{
+			name:   "default PUT",
+			config: &Config{URL: server.URL + "/app"},
+			want:   request{method: http.MethodPut, path: "/app", body: map[string]any{"HTTP_A": "1"}},
+		},
+		{
+			name:   "PATCH under the pointer, to the write URL",
+			config: &Config{URL: server.URL + "/app", WriteURL: server.URL + "/app/secrets", WriteMethod: http.MethodPatch, Pointer: "/data/a~1b"},
+			want:   request{method: http.MethodPatch, path: "/app/secrets", body: map[string]any{"data": map[string]any{"a/b": map[string]any{"HTTP_A": "1"}}}},
+		},
+		{
+			name:   "POST",
+			config: &Config{URL: server.URL + "/app", WriteMethod: http.MethodPost},
+			want:   request{method: http.MethodPost, path: "/app", body: map[string]any{"HTTP_A": "1"}},
+		},
+		{
+			name:    "error status",
+			config:  &Config{URL: server.URL + "/fail"},
+			wantErr: "write values",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(false, false, tt.config)
+			require.NoError(t, err)
+
+			err = p.Write(context.Background(), map[string]interface{}{"HTTP_A": "1"})
+
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	p, err := New(false, false, &Config{URL: server.URL})
+	require.NoError(t, err)
+	assert.ErrorContains(t, p.Write(context.Background(), nil), "values")
+}
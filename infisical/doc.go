@@ -0,0 +1,5 @@
+// Package infisical provides a provider for Infisical secrets, with machine
+// identity universal auth, or service tokens, project, environment, and path
+// selection, recursive folders, imports, secret references, batch writes, and
+// self-hosted deployments.
+package infisical
@@ -0,0 +1,421 @@
+package infisical
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thalesfsp/configurer/option"
+	"github.com/thalesfsp/configurer/provider"
+	"github.com/thalesfsp/configurer/util"
+	"github.com/thalesfsp/customerror"
+	"github.com/thalesfsp/httpclient/v2"
+	"github.com/thalesfsp/validation"
+)
+
+//////
+// Vars, consts, and types.
+//////
+
+// Name of the provider.
+const Name = "infisical"
+
+// DefaultBaseURL is the default Infisical URL.
+const DefaultBaseURL = "https://app.infisical.com"
+
+// DefaultSecretPath is the default secret path, the root folder.
+const DefaultSecretPath = "/"
+
+// DefaultTimeout is the default request timeout.
+const DefaultTimeout = 30 * time.Second
+
+// tokenExpiryMargin renews access tokens before they expire.
+const tokenExpiryMargin = 30 * time.Second
+
+// Config contains the Infisical settings.
+type Config struct {
+	// BaseURL of the Infisical instance, e.g.: `https://infisical.example.com`
+	// for self-hosted ones. A trailing `/api`, like `INFISICAL_API_URL`'s, is
+	// removed. Defaults to `DefaultBaseURL`.
+	BaseURL string `json:"baseURL" validate:"required,url"`
+
+	// ClientID, and ClientSecret of a machine identity authenticate with
+	// universal auth.
+	ClientID     string `json:"-"`
+	ClientSecret string `json:"-"`
+
+	// ServiceToken authenticates instead of a machine identity.
+	ServiceToken string `json:"-"`
+
+	// ProjectID of the secrets.
+	ProjectID string `json:"projectID" validate:"required"`
+
+	// Environment slug, e.g.: `prod`.
+	Environment string `json:"environment" validate:"required"`
+
+	// SecretPath is the folder of the secrets, e.g.: `/backend`. Defaults to
+	// `DefaultSecretPath`.
+	SecretPath string `json:"secretPath" validate:"required,startswith=/"`
+
+	// Recursive loads the secrets of the subfolders, too. Secrets of the
+	// shallowest folder have precedence.
+	Recursive bool `json:"recursive"`
+
+	// Imports loads the secrets imported into the folder, too. Secrets of the
+	// folder have precedence.
+	Imports bool `json:"imports"`
+
+	// KeepReferences doesn't expand secret references, e.g.:
+	// `${prod.backend.DB_HOST}`.
+	KeepReferences bool `json:"keepReferences"`
+
+	// Timeout of requests. Defaults to `DefaultTimeout`.
+	Timeout time.Duration `json:"timeout" validate:"gte=0"`
+}
+
+// Infisical provider definition.
+type Infisical struct {
+	*provider.Provider `json:"-" validate:"required"`
+
+	Configuration *Config            `json:"-" validate:"required"`
+	client        *httpclient.Client `json:"-" validate:"required"`
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// secret is a secret of the raw secrets API.
+type secret struct {
+	SecretKey   string `json:"secretKey"`
+	SecretValue string `json:"secretValue"`
+	SecretPath  string `json:"secretPath,omitempty"`
+}
+
+// secretsResponse is the response of listing secrets.
+type secretsResponse struct {
+	Secrets []secret `json:"secrets"`
+	Imports []struct {
+		Secrets []secret `json:"secrets"`
+	} `json:"imports"`
+}
+
+// batchRequest is the request to create, or update secrets.
+type batchRequest struct {
+	WorkspaceID string   `json:"workspaceId"`
+	Environment string   `json:"environment"`
+	SecretPath  string   `json:"secretPath"`
+	Secrets     []secret `json:"secrets"`
+}
+
+// loginResponse is the response of the universal auth login.
+type loginResponse struct {
+	AccessToken string `json:"accessToken"`
+	ExpiresIn   int64  `json:"expiresIn"`
+}
+
+//////
+// IProvider implementation.
+//////
+
+// Load reads the secrets of `SecretPath`, and, if set, of its subfolders, and
+// imports, and exports them to the environment.
+func (i *Infisical) Load(ctx context.Context, opts ...option.LoadKeyFunc) (map[string]string, error) {
+	response, err := i.list(ctx, i.Configuration.Recursive, i.Configuration.Imports, !i.Configuration.KeepReferences)
+	if err != nil {
+		return nil, err
+	}
+
+	// Shallowest folders first.
+	secrets := response.Secrets
+
+	sort.SliceStable(secrets, func(a, b int) bool {
+		return depth(secrets[a].SecretPath) < depth(secrets[b].SecretPath)
+	})
+
+	for _, imported := range response.Imports {
+		secrets = append(secrets, imported.Secrets...)
+	}
+
+	values := make(map[string]string, len(secrets))
+
+	for _, s := range secrets {
+		if _, ok := values[s.SecretKey]; !ok {
+			values[s.SecretKey] = s.SecretValue
+		}
+	}
+
+	finalValues := make(map[string]string, len(values))
+
+	for key, value := range values {
+		// Should allow to specify options.
+		for _, opt := range opts {
+			key = opt(key)
+		}
+
+		finalValue, err := provider.ExportToEnvVar(i, key, value)
+		if err != nil {
+			return nil, err
+		}
+
+		finalValues[key] = finalValue
+	}
+
+	return finalValues, nil
+}
+
+// Write creates, or updates `values` as secrets of `SecretPath`, in two
+// batches.
+func (i *Infisical) Write(ctx context.Context, values map[string]interface{}, opts ...option.WriteFunc) error {
+	if values == nil {
+		return customerror.NewRequiredError("values")
+	}
+
+	var options option.Write
+
+	for _, opt := range opts {
+		if err := opt(&options); err != nil {
+			return err
+		}
+	}
+
+	response, err := i.list(ctx, false, false, false)
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]bool, len(response.Secrets))
+
+	for _, s := range response.Secrets {
+		existing[s.SecretKey] = true
+	}
+
+	keys := make([]string, 0, len(values))
+
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	var created, updated []secret
+
+	for _, key := range keys {
+		encoded, err := util.EncodeValue(values[key])
+		if err != nil {
+			return customerror.NewFailedToError("encode "+key, customerror.WithError(err))
+		}
+
+		value := fmt.Sprint(encoded)
+
+		if existing[key] {
+			updated = append(updated, secret{SecretKey: key, SecretValue: value})
+		} else {
+			created = append(created, secret{SecretKey: key, SecretValue: value})
+		}
+	}
+
+	if len(created) > 0 {
+		if err := i.batch(ctx, "create", created); err != nil {
+			return err
+		}
+	}
+
+	if len(updated) > 0 {
+		if err := i.batch(ctx, "update", updated); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//////
+// Helpers.
+//////
+
+// authorization returns the Authorization header value, logging in with
+// universal auth, if the access token is missing, or about to expire.
+func (i *Infisical) authorization(ctx context.Context) (string, error) {
+	if i.Configuration.ServiceToken != "" {
+		return "Bearer " + i.Configuration.ServiceToken, nil
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.accessToken != "" && time.Now().Before(i.expiresAt) {
+		return "Bearer " + i.accessToken, nil
+	}
+
+	var login loginResponse
+
+	response, err := i.client.Post(
+		ctx,
+		i.Configuration.BaseURL+"/api/v1/auth/universal-auth/login",
+		httpclient.WithReqBody(map[string]string{
+			"clientId":     i.Configuration.ClientID,
+			"clientSecret": i.Configuration.ClientSecret,
+		}),
+		httpclient.WithRespBody(&login),
+	)
+	if err != nil {
+		return "", customerror.NewFailedToError("authenticate", customerror.WithError(err))
+	}
+
+	response.Body.Close()
+
+	if login.AccessToken == "" {
+		return "", customerror.NewFailedToError("authenticate, missing access token")
+	}
+
+	i.accessToken = login.AccessToken
+	i.expiresAt = time.Now().Add(time.Duration(login.ExpiresIn)*time.Second - tokenExpiryMargin)
+
+	return "Bearer " + i.accessToken, nil
+}
+
+// depth returns the number of folders of `secretPath`, 0 for the root one.
+func depth(secretPath string) int {
+	secretPath = strings.Trim(secretPath, "/")
+	if secretPath == "" {
+		return 0
+	}
+
+	return strings.Count(secretPath, "/") + 1
+}
+
+// list reads the secrets of `SecretPath`.
+func (i *Infisical) list(ctx context.Context, recursive, imports, expand bool) (*secretsResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, i.Configuration.Timeout)
+	defer cancel()
+
+	authorization, err := i.authorization(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var secrets secretsResponse
+
+	response, err := i.client.Get(
+		ctx,
+		i.Configuration.BaseURL+"/api/v3/secrets/raw",
+		httpclient.WithHeader("Authorization", authorization),
+		httpclient.WithQueryParam("workspaceId", i.Configuration.ProjectID),
+		httpclient.WithQueryParam("environment", i.Configuration.Environment),
+		httpclient.WithQueryParam("secretPath", i.Configuration.SecretPath),
+		httpclient.WithQueryParam("recursive", strconv.FormatBool(recursive)),
+		httpclient.WithQueryParam("include_imports", strconv.FormatBool(imports)),
+		httpclient.WithQueryParam("expandSecretReferences", strconv.FormatBool(expand)),
+		httpclient.WithRespBody(&secrets),
+	)
+	if err != nil {
+		return nil, customerror.NewFailedToError("list secrets of "+i.Configuration.SecretPath, customerror.WithError(err))
+	}
+
+	response.Body.Close()
+
+	return &secrets, nil
+}
+
+// batch creates, or updates secrets of `SecretPath`.
+func (i *Infisical) batch(ctx context.Context, operation string, secrets []secret) error {
+	ctx, cancel := context.WithTimeout(ctx, i.Configuration.Timeout)
+	defer cancel()
+
+	authorization, err := i.authorization(ctx)
+	if err != nil {
+		return err
+	}
+
+	send := i.client.Post
+	if operation == "update" {
+		send = i.client.Patch
+	}
+
+	response, err := send(
+		ctx,
+		i.Configuration.BaseURL+"/api/v3/secrets/batch/raw",
+		httpclient.WithHeader("Authorization", authorization),
+		httpclient.WithReqBody(batchRequest{
+			WorkspaceID: i.Configuration.ProjectID,
+			Environment: i.Configuration.Environment,
+			SecretPath:  i.Configuration.SecretPath,
+			Secrets:     secrets,
+		}),
+	)
+	if err != nil {
+		return customerror.NewFailedToError(operation+" secrets", customerror.WithError(err))
+	}
+
+	response.Body.Close()
+
+	return nil
+}
+
+//////
+// Factory.
+//////
+
+// New creates an Infisical provider.
+func New(override, rawValue bool, config *Config) (provider.IProvider, error) {
+	if config == nil {
+		return nil, customerror.NewRequiredError("config")
+	}
+
+	if config.BaseURL == "" {
+		config.BaseURL = DefaultBaseURL
+	}
+
+	config.BaseURL = strings.TrimSuffix(strings.TrimSuffix(config.BaseURL, "/"), "/api")
+
+	if config.SecretPath == "" {
+		config.SecretPath = DefaultSecretPath
+	}
+
+	if config.Timeout == 0 {
+		config.Timeout = DefaultTimeout
+	}
+
+	if err := validation.Validate(config); err != nil {
+		return nil, err
+	}
+
+	universalAuth := config.ClientID != "" || config.ClientSecret != ""
+
+	if universalAuth == (config.ServiceToken != "") {
+		return nil, customerror.NewInvalidError("client ID, and secret, or service token, exactly one is required")
+	}
+
+	if universalAuth && (config.ClientID == "" || config.ClientSecret == "") {
+		return nil, customerror.NewRequiredError("client ID, and client secret")
+	}
+
+	baseProvider, err := provider.New(Name, override, rawValue)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := httpclient.NewDefault(httpclient.WithClientName(Name))
+	if err != nil {
+		return nil, customerror.NewFailedToError("initialize HTTP client", customerror.WithError(err))
+	}
+
+	client.GetClient().Timeout = config.Timeout
+
+	i := &Infisical{
+		Provider:      baseProvider,
+		Configuration: config,
+		client:        client,
+	}
+
+	if err := validation.Validate(i); err != nil {
+		return nil, err
+	}
+
+	return i, nil
+}
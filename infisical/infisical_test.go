@@ -0,0 +1,390 @@
+package infisical
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thalesfsp/configurer/option"
+)
+
+// fakeInfisical serves the universal auth login, and the raw secrets API, for
+// the `p1` project.
+type fakeInfisical struct {
+	mu       sync.Mutex
+	secrets  map[string]map[string]string // Path -> key -> value.
+	imported map[string]string
+	logins   int
+	requests []string
+}
+
+func newFakeInfisical(t *testing.T) (*fakeInfisical, *httptest.Server) {
+	t.Helper()
+
+	f := &fakeInfisical{secrets: map[string]map[string]string{"/": {}}}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		f.requests = append(f.requests, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery)
+
+		if r.URL.Path == "/api/v1/auth/universal-auth/login" {
+			var body map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&body)
+
+			if body["clientId"] != "client-id" || body["clientSecret"] != "client-secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte(`{"message": "Invalid credentials"}`))
+
+				return
+			}
+
+			f.logins++
+
+			_, _ = w.Write([]byte(`{"accessToken": "access-token", "expiresIn": 7200, "tokenType": "Bearer"}`))
+
+			return
+		}
+
+		if authorization := r.Header.Get("Authorization"); authorization != "Bearer access-token" && authorization != "Bearer st.service-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"message": "Invalid token"}`))
+
+			return
+		}
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v3/secrets/raw":
+			query := r.URL.Query()
+
+			if query.Get("workspaceId") != "p1" || query.Get("environment") != "prod" {
+				w.WriteHeader(http.StatusNotFound)
+
+				return
+			}
+
+			var response secretsResponse
+
+			// Deepest folders first, the API doesn't sort them.
+			folders := make([]string, 0, len(f.secrets))
+
+			for folder := range f.secrets {
+				folders = append(folders, folder)
+			}
+
+			sort.Sort(sort.Reverse(sort.StringSlice(folders)))
+
+			for _, folder := range folders {
+				secrets := f.secrets[folder]
+
+				if folder != query.Get("secretPath") && (query.Get("recursive") != "true" || !strings.HasPrefix(folder, strings.TrimSuffix(query.Get("secretPath"), "/")+"/")) {
+					continue
+				}
+
+				for key, value := range secrets {
+					if query.Get("expandSecretReferences") == "true" {
+						value = strings.ReplaceAll(value, "${DB_HOST}", f.secrets["/"]["DB_HOST"])
+					}
+
+					response.Secrets = append(response.Secrets, secret{SecretKey: key, SecretValue: value, SecretPath: folder})
+				}
+			}
+
+			if query.Get("include_imports") == "true" {
+				response.Imports = append(response.Imports, struct {
+					Secrets []secret `json:"secrets"`
+				}{})
+
+				for key, value := range f.imported {
+					response.Imports[0].Secrets = append(response.Imports[0].Secrets, secret{SecretKey: key, SecretValue: value})
+				}
+			}
+
+			_ = json.NewEncoder(w).Encode(response)
+		case r.URL.Path == "/api/v3/secrets/batch/raw":
+			var body batchRequest
+			_ = json.NewDecoder(r.Body).Decode(&body)
+
+			folder, ok := f.secrets[body.SecretPath]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"message": "Folder not found"}`))
+
+				return
+			}
+
+			for _, s := range body.Secrets {
+				_, exists := folder[s.SecretKey]
+
+				if exists == (r.Method == http.MethodPost) {
+					w.WriteHeader(http.StatusBadRequest)
+					_, _ = w.Write([]byte(`{"message": "Secret ` + s.SecretKey + ` conflict"}`))
+
+					return
+				}
+			}
+
+			for _, s := range body.Secrets {
+				folder[s.SecretKey] = s.SecretValue
+			}
+
+			_, _ = w.Write([]byte(`{"secrets": []}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	t.Cleanup(server.Close)
+
+	return f, server
+}
+
+// load loads, and unsets the exported values.
+func load(t *testing.T, i *Infisical, opts ...option.LoadKeyFunc) map[string]string {
+	t.Helper()
+
+	got, err := i.Load(context.Background(), opts...)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		for key := range got {
+			os.Unsetenv(key)
+		}
+	})
+
+	return got
+}
+
+//////
+// Constructor.
+//////
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *Config
+		want    *Config
+		wantErr string
+	}{
+		{
+			name:   "happy path defaults",
+			config: &Config{ClientID: "client-id", ClientSecret: "client-secret", ProjectID: "p1", Environment: "prod"},
+			want:   &Config{BaseURL: DefaultBaseURL, ClientID: "client-id", ClientSecret: "client-secret", ProjectID: "p1", Environment: "prod", SecretPath: DefaultSecretPath, Timeout: DefaultTimeout},
+		},
+		{
+			name:   "happy path self-hosted API URL",
+			config: &Config{BaseURL: "https://infisical.example.com/api/", ServiceToken: "st.service-token", ProjectID: "p1", Environment: "prod", SecretPath: "/backend"},
+			want:   &Config{BaseURL: "https://infisical.example.com", ServiceToken: "st.service-token", ProjectID: "p1", Environment: "prod", SecretPath: "/backend", Timeout: DefaultTimeout},
+		},
+		{
+			name:    "bad path nil config",
+			wantErr: "config",
+		},
+		{
+			name:    "bad path missing project",
+			config:  &Config{ServiceToken: "st.service-token", Environment: "prod"},
+			wantErr: "ProjectID",
+		},
+		{
+			name:    "bad path relative secret path",
+			config:  &Config{ServiceToken: "st.service-token", ProjectID: "p1", Environment: "prod", SecretPath: "backend"},
+			wantErr: "SecretPath",
+		},
+		{
+			name:    "bad path no auth",
+			config:  &Config{ProjectID: "p1", Environment: "prod"},
+			wantErr: "exactly one is required",
+		},
+		{
+			name:    "bad path both auth methods",
+			config:  &Config{ClientID: "client-id", ClientSecret: "client-secret", ServiceToken: "st.service-token", ProjectID: "p1", Environment: "prod"},
+			wantErr: "exactly one is required",
+		},
+		{
+			name:    "bad path missing client secret",
+			config:  &Config{ClientID: "client-id", ProjectID: "p1", Environment: "prod"},
+			wantErr: "client ID, and client secret",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(false, false, tt.config)
+
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				assert.Nil(t, got)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, Name, got.GetName())
+			assert.Equal(t, tt.want, got.(*Infisical).Configuration)
+		})
+	}
+}
+
+//////
+// IProvider implementation.
+//////
+
+func TestLoad(t *testing.T) {
+	f, server := newFakeInfisical(t)
+
+	f.secrets = map[string]map[string]string{
+		"/":                {"DB_HOST": "db.internal", "INFISICAL_SHARED": "root"},
+		"/backend":         {"INFISICAL_DSN": "postgres://${DB_HOST}", "INFISICAL_SHARED": "backend"},
+		"/backend/workers": {"INFISICAL_QUEUE": "jobs", "INFISICAL_SHARED": "workers"},
+	}
+	f.imported = map[string]string{"INFISICAL_IMPORTED": "imported", "INFISICAL_DSN": "overridden"}
+
+	tests := []struct {
+		name    string
+		config  *Config
+		opts    []option.LoadKeyFunc
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name:   "universal auth, folder, and references",
+			config: &Config{ClientID: "client-id", ClientSecret: "client-secret", SecretPath: "/backend"},
+			want:   map[string]string{"INFISICAL_DSN": "postgres://db.internal", "INFISICAL_SHARED": "backend"},
+		},
+		{
+			name:   "service token, recursive, and imports",
+			config: &Config{ServiceToken: "st.service-token", SecretPath: "/backend", Recursive: true, Imports: true},
+			opts:   []option.LoadKeyFunc{option.WithKeyCaser("lower")},
+			want: map[string]string{
+				"infisical_dsn":      "postgres://db.internal",
+				"infisical_shared":   "backend",
+				"infisical_queue":    "jobs",
+				"infisical_imported": "imported",
+			},
+		},
+		{
+			name:   "recursive from the root, subfolder secrets returned first",
+			config: &Config{ServiceToken: "st.service-token", SecretPath: "/", Recursive: true},
+			want: map[string]string{
+				"DB_HOST":          "db.internal",
+				"INFISICAL_DSN":    "postgres://db.internal",
+				"INFISICAL_SHARED": "root",
+				"INFISICAL_QUEUE":  "jobs",
+			},
+		},
+		{
+			name:   "keep references",
+			config: &Config{ServiceToken: "st.service-token", SecretPath: "/backend", KeepReferences: true},
+			want:   map[string]string{"INFISICAL_DSN": "postgres://${DB_HOST}", "INFISICAL_SHARED": "backend"},
+		},
+		{
+			name:    "invalid credentials",
+			config:  &Config{ClientID: "client-id", ClientSecret: "wrong"},
+			wantErr: "authenticate",
+		},
+		{
+			name:    "invalid service token",
+			config:  &Config{ServiceToken: "st.invalid"},
+			wantErr: "list secrets of /",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.BaseURL = server.URL
+			tt.config.ProjectID = "p1"
+			tt.config.Environment = "prod"
+
+			p, err := New(true, false, tt.config)
+			require.NoError(t, err)
+
+			if tt.wantErr != "" {
+				_, err := p.Load(context.Background(), tt.opts...)
+				assert.ErrorContains(t, err, tt.wantErr)
+
+				return
+			}
+
+			got := load(t, p.(*Infisical), tt.opts...)
+
+			assert.Equal(t, tt.want, got)
+
+			for key, value := range tt.want {
+				assert.Equal(t, value, os.Getenv(key))
+			}
+		})
+	}
+}
+
+func TestLoadReusesAccessToken(t *testing.T) {
+	f, server := newFakeInfisical(t)
+
+	p, err := New(true, false, &Config{BaseURL: server.URL, ClientID: "client-id", ClientSecret: "client-secret", ProjectID: "p1", Environment: "prod"})
+	require.NoError(t, err)
+
+	load(t, p.(*Infisical))
+	load(t, p.(*Infisical))
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	assert.Equal(t, 1, f.logins)
+}
+
+func TestWrite(t *testing.T) {
+	f, server := newFakeInfisical(t)
+
+	f.secrets["/backend"] = map[string]string{"INFISICAL_A": "old", "INFISICAL_C": "unchanged"}
+
+	ctx := context.Background()
+
+	p, err := New(true, false, &Config{BaseURL: server.URL, ClientID: "client-id", ClientSecret: "client-secret", ProjectID: "p1", Environment: "prod", SecretPath: "/backend"})
+	require.NoError(t, err)
+
+	require.NoError(t, p.Write(ctx, map[string]interface{}{
+		"INFISICAL_A":     "new",
+		"INFISICAL_B":     2,
+		"INFISICAL_LIST":  []any{"a"},
+		"INFISICAL_EMPTY": nil,
+	}))
+
+	f.mu.Lock()
+	assert.Equal(t, map[string]string{
+		"INFISICAL_A":     "new",
+		"INFISICAL_B":     "2",
+		"INFISICAL_C":     "unchanged",
+		"INFISICAL_EMPTY": "",
+		"INFISICAL_LIST":  `["a"]`,
+	}, f.secrets["/backend"])
+	assert.Contains(t, f.requests, "POST /api/v3/secrets/batch/raw?")
+	assert.Contains(t, f.requests, "PATCH /api/v3/secrets/batch/raw?")
+	f.mu.Unlock()
+
+	// Only updates.
+	require.NoError(t, p.Write(ctx, map[string]interface{}{"INFISICAL_C": "changed"}))
+
+	f.mu.Lock()
+	assert.Equal(t, "changed", f.secrets["/backend"]["INFISICAL_C"])
+	f.mu.Unlock()
+
+	require.NoError(t, p.Write(ctx, map[string]interface{}{}))
+	assert.ErrorContains(t, p.Write(ctx, nil), "values")
+
+	// Errors.
+	p, err = New(true, false, &Config{BaseURL: server.URL, ServiceToken: "st.service-token", ProjectID: "p1", Environment: "prod", SecretPath: "/missing"})
+	require.NoError(t, err)
+	assert.ErrorContains(t, p.Write(ctx, map[string]interface{}{"INFISICAL_A": "new"}), "Folder not found")
+
+	p, err = New(true, false, &Config{BaseURL: server.URL, ServiceToken: "st.service-token", ProjectID: "p2", Environment: "prod"})
+	require.NoError(t, err)
+	assert.ErrorContains(t, p.Write(ctx, map[string]interface{}{"INFISICAL_A": "new"}), "list secrets")
+}
@@ -0,0 +1,6 @@
+// Package keepass provides a provider for KeePass KDBX4 databases, unlocked
+// with a password, a key file, or both. Entries, or whole groups are loaded,
+// and entries are added, or updated natively, without KeePass.
+//
+// SEE: https://keepass.info/help/kb/kdbx_4.html
+package keepass
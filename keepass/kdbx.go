@@ -0,0 +1,982 @@
+package keepass
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20"
+)
+
+//////
+// Vars, consts, and types.
+//////
+
+// KDBX4.
+//
+// SEE: https://keepass.info/help/kb/kdbx_4.html
+const (
+	signature1 = 0x9AA2D903
+	signature2 = 0xB54BFB67
+
+	versionMajor4 = 4
+
+	headerEnd           = 0
+	headerCipherID      = 2
+	headerCompression   = 3
+	headerMasterSeed    = 4
+	headerEncryptionIV  = 7
+	headerKdfParameters = 11
+
+	innerHeaderEnd          = 0
+	innerHeaderStreamID     = 1
+	innerHeaderStreamKey    = 2
+	innerHeaderBinary       = 3
+	innerStreamChaCha20     = 3
+	compressionGzip         = 1
+	hmacBlockSize           = 1024 * 1024
+	variantDictionaryFormat = 0x0100
+
+	variantUInt32    = 0x04
+	variantUInt64    = 0x05
+	variantByteArray = 0x42
+)
+
+// Cipher, and KDF UUIDs.
+var (
+	cipherAES256   = mustUUID("31c1f2e6bf714350be5805216afc5aff")
+	cipherChaCha20 = mustUUID("d6038a2b8b6f4cb5a524339a31dbb59a")
+	kdfAES         = mustUUID("c9d9f39a628a4460bf740d08c18a4fea")
+	kdfArgon2d     = mustUUID("ef636ddf8c29444b91f7a9a403e30a0c")
+	kdfArgon2id    = mustUUID("9e298b1956db4773b23dfc3ec6f0a1e6")
+)
+
+// headerField is a TLV field of the outer, or inner header.
+type headerField struct {
+	id   byte
+	data []byte
+}
+
+// variant is a typed value of a `VariantDictionary`.
+type variant struct {
+	kind  byte
+	key   string
+	value []byte
+}
+
+// variantDictionary is an ordered KDBX4 `VariantDictionary`.
+type variantDictionary []variant
+
+// database is a decrypted KDBX4 database. Header fields are kept, so unknown
+// ones are written back.
+type database struct {
+	version      uint32
+	header       []headerField
+	innerHeader  []headerField
+	root         *xmlNode
+	compositeKey []byte
+}
+
+// xmlNode is a generic XML element, so unknown elements are written back.
+type xmlNode struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Content string     `xml:",chardata"`
+	Nodes   []*xmlNode `xml:",any"`
+}
+
+//////
+// Methods.
+//////
+
+// get returns the value of `key`.
+func (d variantDictionary) get(key string) ([]byte, bool) {
+	for _, v := range d {
+		if v.key == key {
+			return v.value, true
+		}
+	}
+
+	return nil, false
+}
+
+// uint returns the integer value of `key`.
+func (d variantDictionary) uint(key string) (uint64, error) {
+	value, ok := d.get(key)
+
+	switch {
+	case !ok:
+		return 0, fmt.Errorf("missing KDF parameter %s", key)
+	case len(value) == 4:
+		return uint64(binary.LittleEndian.Uint32(value)), nil
+	case len(value) == 8:
+		return binary.LittleEndian.Uint64(value), nil
+	default:
+		return 0, fmt.Errorf("invalid KDF parameter %s", key)
+	}
+}
+
+// field returns the data of the header field `id`.
+func (d *database) field(id byte) []byte {
+	for _, f := range d.header {
+		if f.id == id {
+			return f.data
+		}
+	}
+
+	return nil
+}
+
+// setField sets the data of the header field `id`.
+func (d *database) setField(id byte, data []byte) {
+	for i, f := range d.header {
+		if f.id == id {
+			d.header[i].data = data
+
+			return
+		}
+	}
+
+	d.header = append(d.header, headerField{id: id, data: data})
+}
+
+// child returns the first child element named `name`.
+func (n *xmlNode) child(name string) *xmlNode {
+	for _, c := range n.Nodes {
+		if c.XMLName.Local == name {
+			return c
+		}
+	}
+
+	return nil
+}
+
+// children returns the child elements named `name`.
+func (n *xmlNode) children(name string) []*xmlNode {
+	var out []*xmlNode
+
+	for _, c := range n.Nodes {
+		if c.XMLName.Local == name {
+			out = append(out, c)
+		}
+	}
+
+	return out
+}
+
+// attr returns the value of the attribute `name`.
+func (n *xmlNode) attr(name string) string {
+	for _, a := range n.Attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+
+	return ""
+}
+
+// protected reports whether the value is encrypted with the inner stream.
+func (n *xmlNode) protected() bool {
+	return strings.EqualFold(n.attr("Protected"), "True")
+}
+
+// walk calls `fn` for `n`, and its descendants, in document order.
+func (n *xmlNode) walk(fn func(*xmlNode) error) error {
+	if err := fn(n); err != nil {
+		return err
+	}
+
+	for _, c := range n.Nodes {
+		if err := c.walk(fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//////
+// Helpers.
+//////
+
+// openDatabase decrypts a KDBX4 database with the `compositeKey`.
+func openDatabase(content, compositeKey []byte) (*database, error) {
+	r := bytes.NewReader(content)
+
+	var preamble [3]uint32
+
+	if err := binary.Read(r, binary.LittleEndian, &preamble); err != nil || preamble[0] != signature1 || preamble[1] != signature2 {
+		return nil, errors.New("not a KeePass database")
+	}
+
+	if major := preamble[2] >> 16; major != versionMajor4 {
+		return nil, fmt.Errorf("KDBX version %d.%d isn't supported, only KDBX4", major, preamble[2]&0xFFFF)
+	}
+
+	db := &database{version: preamble[2], compositeKey: compositeKey}
+
+	header, err := readHeaderFields(r, true)
+	if err != nil {
+		return nil, err
+	}
+
+	db.header = header
+	headerBytes := content[:len(content)-r.Len()]
+
+	var hashes [64]byte
+
+	if _, err := io.ReadFull(r, hashes[:]); err != nil {
+		return nil, errors.New("truncated header")
+	}
+
+	if headerHash := sha256.Sum256(headerBytes); !hmac.Equal(hashes[:32], headerHash[:]) {
+		return nil, errors.New("corrupted header")
+	}
+
+	encryptionKey, hmacKey, err := db.keys()
+	if err != nil {
+		return nil, err
+	}
+
+	if !hmac.Equal(hashes[32:], blockHMAC(hmacKey, math.MaxUint64, headerBytes)) {
+		return nil, errors.New("wrong credentials, or corrupted file")
+	}
+
+	encrypted, err := readHMACBlocks(r, hmacKey)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := db.crypt(encryptionKey, encrypted, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if compression := db.field(headerCompression); len(compression) == 4 && binary.LittleEndian.Uint32(compression) == compressionGzip {
+		gz, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+
+		if payload, err = io.ReadAll(gz); err != nil {
+			return nil, err
+		}
+	}
+
+	payloadReader := bytes.NewReader(payload)
+
+	if db.innerHeader, err = readHeaderFields(payloadReader, false); err != nil {
+		return nil, err
+	}
+
+	stream, err := db.innerStream()
+	if err != nil {
+		return nil, err
+	}
+
+	root := &xmlNode{}
+
+	if err := xml.NewDecoder(payloadReader).Decode(root); err != nil {
+		return nil, fmt.Errorf("invalid XML: %w", err)
+	}
+
+	// Protected values are decrypted in document order.
+	if err := root.walk(func(n *xmlNode) error {
+		if len(n.Nodes) > 0 {
+			n.Content = ""
+		}
+
+		if !n.protected() {
+			return nil
+		}
+
+		value, err := base64.StdEncoding.DecodeString(n.Content)
+		if err != nil {
+			return fmt.Errorf("invalid protected value: %w", err)
+		}
+
+		stream.XORKeyStream(value, value)
+
+		n.Content = string(value)
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	db.root = root
+
+	return db, nil
+}
+
+// save encrypts the database, with a new master seed, IV, and inner stream
+// key.
+func (d *database) save() ([]byte, error) {
+	masterSeed, err := randomBytes(32)
+	if err != nil {
+		return nil, err
+	}
+
+	iv, err := randomBytes(len(d.field(headerEncryptionIV)))
+	if err != nil {
+		return nil, err
+	}
+
+	streamKey, err := randomBytes(64)
+	if err != nil {
+		return nil, err
+	}
+
+	d.setField(headerMasterSeed, masterSeed)
+	d.setField(headerEncryptionIV, iv)
+
+	inner := []headerField{
+		{id: innerHeaderStreamID, data: binary.LittleEndian.AppendUint32(nil, innerStreamChaCha20)},
+		{id: innerHeaderStreamKey, data: streamKey},
+	}
+
+	// Attachments are kept.
+	for _, f := range d.innerHeader {
+		if f.id == innerHeaderBinary {
+			inner = append(inner, f)
+		}
+	}
+
+	d.innerHeader = inner
+
+	stream, err := d.innerStream()
+	if err != nil {
+		return nil, err
+	}
+
+	// Protected values are encrypted in document order, on a copy.
+	root := cloneNode(d.root)
+
+	if err := root.walk(func(n *xmlNode) error {
+		if n.protected() {
+			value := []byte(n.Content)
+
+			stream.XORKeyStream(value, value)
+
+			n.Content = base64.StdEncoding.EncodeToString(value)
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	var payload bytes.Buffer
+
+	writeHeaderFields(&payload, d.innerHeader, false)
+
+	payload.WriteString(xml.Header)
+
+	encoder := xml.NewEncoder(&payload)
+	encoder.Indent("", "\t")
+
+	if err := encoder.Encode(root); err != nil {
+		return nil, err
+	}
+
+	plaintext := payload.Bytes()
+
+	if compression := d.field(headerCompression); len(compression) == 4 && binary.LittleEndian.Uint32(compression) == compressionGzip {
+		var compressed bytes.Buffer
+
+		gz := gzip.NewWriter(&compressed)
+
+		if _, err := gz.Write(plaintext); err != nil {
+			return nil, err
+		}
+
+		if err := gz.Close(); err != nil {
+			return nil, err
+		}
+
+		plaintext = compressed.Bytes()
+	}
+
+	encryptionKey, hmacKey, err := d.keys()
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, err := d.crypt(encryptionKey, plaintext, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+
+	_ = binary.Write(&out, binary.LittleEndian, [3]uint32{signature1, signature2, d.version})
+
+	writeHeaderFields(&out, d.header, true)
+
+	headerBytes := append([]byte{}, out.Bytes()...)
+	headerHash := sha256.Sum256(headerBytes)
+
+	out.Write(headerHash[:])
+	out.Write(blockHMAC(hmacKey, math.MaxUint64, headerBytes))
+
+	writeHMACBlocks(&out, hmacKey, encrypted)
+
+	return out.Bytes(), nil
+}
+
+// keys derives the encryption, and HMAC keys from the composite key.
+func (d *database) keys() ([]byte, []byte, error) {
+	masterSeed := d.field(headerMasterSeed)
+	if len(masterSeed) != 32 {
+		return nil, nil, errors.New("invalid master seed")
+	}
+
+	parameters, err := readVariantDictionary(d.field(headerKdfParameters))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	transformedKey, err := transformKey(d.compositeKey, parameters)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	encryptionKey := sha256.Sum256(concat(masterSeed, transformedKey))
+	hmacKey := sha512.Sum512(concat(masterSeed, transformedKey, []byte{1}))
+
+	return encryptionKey[:], hmacKey[:], nil
+}
+
+// crypt encrypts, or decrypts the payload with the header cipher.
+func (d *database) crypt(key, data []byte, encrypt bool) ([]byte, error) {
+	iv := d.field(headerEncryptionIV)
+
+	switch cipherID := d.field(headerCipherID); {
+	case bytes.Equal(cipherID, cipherAES256):
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(iv) != aes.BlockSize {
+			return nil, errors.New("invalid encryption IV")
+		}
+
+		if encrypt {
+			padding := aes.BlockSize - len(data)%aes.BlockSize
+			out := concat(data, bytes.Repeat([]byte{byte(padding)}, padding))
+
+			cipher.NewCBCEncrypter(block, iv).CryptBlocks(out, out)
+
+			return out, nil
+		}
+
+		if len(data) == 0 || len(data)%aes.BlockSize != 0 {
+			return nil, errors.New("invalid payload length")
+		}
+
+		out := make([]byte, len(data))
+
+		cipher.NewCBCDecrypter(block, iv).CryptBlocks(out, data)
+
+		padding := int(out[len(out)-1])
+		if padding == 0 || padding > aes.BlockSize || padding > len(out) {
+			return nil, errors.New("invalid payload padding")
+		}
+
+		return out[:len(out)-padding], nil
+	case bytes.Equal(cipherID, cipherChaCha20):
+		stream, err := chacha20.NewUnauthenticatedCipher(key, iv)
+		if err != nil {
+			return nil, err
+		}
+
+		out := make([]byte, len(data))
+
+		stream.XORKeyStream(out, data)
+
+		return out, nil
+	default:
+		return nil, fmt.Errorf("cipher %x isn't supported, allowed: AES-256, ChaCha20", cipherID)
+	}
+}
+
+// innerStream returns the ChaCha20 stream of protected values.
+func (d *database) innerStream() (*chacha20.Cipher, error) {
+	var (
+		streamID  uint32
+		streamKey []byte
+	)
+
+	for _, f := range d.innerHeader {
+		switch f.id {
+		case innerHeaderStreamID:
+			if len(f.data) == 4 {
+				streamID = binary.LittleEndian.Uint32(f.data)
+			}
+		case innerHeaderStreamKey:
+			streamKey = f.data
+		}
+	}
+
+	if streamID != innerStreamChaCha20 {
+		return nil, fmt.Errorf("inner stream %d isn't supported, allowed: ChaCha20", streamID)
+	}
+
+	hash := sha512.Sum512(streamKey)
+
+	return chacha20.NewUnauthenticatedCipher(hash[:32], hash[32:44])
+}
+
+// transformKey applies the KDF to the composite key.
+func transformKey(compositeKey []byte, parameters variantDictionary) ([]byte, error) {
+	uuid, _ := parameters.get("$UUID")
+	salt, _ := parameters.get("S")
+
+	switch {
+	case bytes.Equal(uuid, kdfAES):
+		rounds, err := parameters.uint("R")
+		if err != nil {
+			return nil, err
+		}
+
+		block, err := aes.NewCipher(salt)
+		if err != nil {
+			return nil, err
+		}
+
+		key := append([]byte{}, compositeKey...)
+
+		for range rounds {
+			block.Encrypt(key[:16], key[:16])
+			block.Encrypt(key[16:], key[16:])
+		}
+
+		hash := sha256.Sum256(key)
+
+		return hash[:], nil
+	case bytes.Equal(uuid, kdfArgon2d), bytes.Equal(uuid, kdfArgon2id):
+		iterations, err := parameters.uint("I")
+		if err != nil {
+			return nil, err
+		}
+
+		memory, err := parameters.uint("M")
+		if err != nil {
+			return nil, err
+		}
+
+		parallelism, err := parameters.uint("P")
+		if err != nil {
+			return nil, err
+		}
+
+		if iterations == 0 || iterations > math.MaxUint32 || memory/1024 > math.MaxUint32 || parallelism == 0 || parallelism > math.MaxUint8 {
+			return nil, errors.New("invalid Argon2 parameters")
+		}
+
+		secret, _ := parameters.get("K")
+		data, _ := parameters.get("A")
+
+		if bytes.Equal(uuid, kdfArgon2d) {
+			return argon2dKey(compositeKey, salt, secret, data, uint32(iterations), uint32(memory/1024), uint8(parallelism), 32), nil
+		}
+
+		if len(secret) > 0 || len(data) > 0 {
+			return nil, errors.New("argon2id secret, and associated data aren't supported")
+		}
+
+		return argon2.IDKey(compositeKey, salt, uint32(iterations), uint32(memory/1024), uint8(parallelism), 32), nil
+	default:
+		return nil, fmt.Errorf("KDF %x isn't supported, allowed: AES-KDF, Argon2d, Argon2id", uuid)
+	}
+}
+
+// compositeKey combines the password, and the key file content.
+func compositeKey(password string, hasPassword bool, keyFile []byte) ([]byte, error) {
+	var components []byte
+
+	if hasPassword {
+		hash := sha256.Sum256([]byte(password))
+		components = append(components, hash[:]...)
+	}
+
+	if keyFile != nil {
+		key, err := keyFileKey(keyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		components = append(components, key...)
+	}
+
+	hash := sha256.Sum256(components)
+
+	return hash[:], nil
+}
+
+// keyFileKey returns the key of a key file: XML (version 1, and 2), 32 raw
+// bytes, 64 hex characters, or the SHA-256 of any other file.
+func keyFileKey(content []byte) ([]byte, error) {
+	var keyFile struct {
+		XMLName xml.Name `xml:"KeyFile"`
+		Version string   `xml:"Meta>Version"`
+		Data    struct {
+			Hash  string `xml:"Hash,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"Key>Data"`
+	}
+
+	if xml.Unmarshal(content, &keyFile) == nil {
+		data := strings.Join(strings.Fields(keyFile.Data.Value), "")
+
+		if strings.HasPrefix(keyFile.Version, "2.") {
+			key, err := hex.DecodeString(data)
+			if err != nil || len(key) != 32 {
+				return nil, errors.New("invalid key file data")
+			}
+
+			if hash := sha256.Sum256(key); keyFile.Data.Hash != "" && !strings.EqualFold(hex.EncodeToString(hash[:4]), keyFile.Data.Hash) {
+				return nil, errors.New("key file hash mismatch")
+			}
+
+			return key, nil
+		}
+
+		key, err := base64.StdEncoding.DecodeString(data)
+		if err != nil || len(key) != 32 {
+			return nil, errors.New("invalid key file data")
+		}
+
+		return key, nil
+	}
+
+	if len(content) == 32 {
+		return content, nil
+	}
+
+	if len(content) == 64 {
+		if key, err := hex.DecodeString(string(content)); err == nil {
+			return key, nil
+		}
+	}
+
+	hash := sha256.Sum256(content)
+
+	return hash[:], nil
+}
+
+// readHeaderFields reads TLV fields up to the end field.
+func readHeaderFields(r io.Reader, outer bool) ([]headerField, error) {
+	var fields []headerField
+
+	for {
+		var (
+			id   byte
+			size int32
+		)
+
+		if err := binary.Read(r, binary.LittleEndian, &id); err != nil {
+			return nil, errors.New("truncated header")
+		}
+
+		if err := binary.Read(r, binary.LittleEndian, &size); err != nil || size < 0 {
+			return nil, errors.New("truncated header")
+		}
+
+		data := make([]byte, size)
+
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, errors.New("truncated header")
+		}
+
+		if (outer && id == headerEnd) || (!outer && id == innerHeaderEnd) {
+			return fields, nil
+		}
+
+		fields = append(fields, headerField{id: id, data: data})
+	}
+}
+
+// writeHeaderFields writes TLV fields, and the end field.
+func writeHeaderFields(w *bytes.Buffer, fields []headerField, outer bool) {
+	end := []byte{}
+
+	// The outer end field is conventionally `\r\n\r\n`.
+	if outer {
+		end = []byte("\r\n\r\n")
+	}
+
+	for _, f := range append(fields, headerField{id: headerEnd, data: end}) {
+		w.WriteByte(f.id)
+
+		_ = binary.Write(w, binary.LittleEndian, int32(len(f.data)))
+
+		w.Write(f.data)
+	}
+}
+
+// readHMACBlocks reads, and authenticates the HMAC block stream.
+func readHMACBlocks(r io.Reader, hmacKey []byte) ([]byte, error) {
+	var out bytes.Buffer
+
+	for index := uint64(0); ; index++ {
+		var (
+			mac  [32]byte
+			size int32
+		)
+
+		if _, err := io.ReadFull(r, mac[:]); err != nil {
+			return nil, errors.New("truncated payload")
+		}
+
+		if err := binary.Read(r, binary.LittleEndian, &size); err != nil || size < 0 {
+			return nil, errors.New("truncated payload")
+		}
+
+		data := make([]byte, size)
+
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, errors.New("truncated payload")
+		}
+
+		if !hmac.Equal(mac[:], blockHMAC(hmacKey, index, binary.LittleEndian.AppendUint32(nil, uint32(size)), data)) {
+			return nil, errors.New("corrupted payload")
+		}
+
+		if size == 0 {
+			return out.Bytes(), nil
+		}
+
+		out.Write(data)
+	}
+}
+
+// writeHMACBlocks writes `data` as an HMAC block stream.
+func writeHMACBlocks(w *bytes.Buffer, hmacKey, data []byte) {
+	for index := uint64(0); ; index++ {
+		size := min(len(data), hmacBlockSize)
+		block := data[:size]
+		data = data[size:]
+
+		sizeBytes := binary.LittleEndian.AppendUint32(nil, uint32(size))
+
+		w.Write(blockHMAC(hmacKey, index, sizeBytes, block))
+		w.Write(sizeBytes)
+		w.Write(block)
+
+		if size == 0 {
+			return
+		}
+	}
+}
+
+// blockHMAC authenticates a block, the index is part of the data, and the
+// key.
+func blockHMAC(hmacKey []byte, index uint64, data ...[]byte) []byte {
+	indexBytes := binary.LittleEndian.AppendUint64(nil, index)
+	key := sha512.Sum512(concat(indexBytes, hmacKey))
+
+	mac := hmac.New(sha256.New, key[:])
+	mac.Write(indexBytes)
+
+	for _, d := range data {
+		mac.Write(d)
+	}
+
+	return mac.Sum(nil)
+}
+
+// readVariantDictionary parses a `VariantDictionary`.
+func readVariantDictionary(data []byte) (variantDictionary, error) {
+	r := bytes.NewReader(data)
+
+	var format uint16
+
+	if err := binary.Read(r, binary.LittleEndian, &format); err != nil || format&0xFF00 != variantDictionaryFormat&0xFF00 {
+		return nil, errors.New("invalid KDF parameters")
+	}
+
+	var d variantDictionary
+
+	for {
+		kind, err := r.ReadByte()
+		if err != nil {
+			return nil, errors.New("invalid KDF parameters")
+		}
+
+		if kind == 0 {
+			return d, nil
+		}
+
+		var parts [2][]byte
+
+		for i := range parts {
+			var size int32
+
+			if err := binary.Read(r, binary.LittleEndian, &size); err != nil || size < 0 || int(size) > r.Len() {
+				return nil, errors.New("invalid KDF parameters")
+			}
+
+			parts[i] = make([]byte, size)
+
+			_, _ = io.ReadFull(r, parts[i])
+		}
+
+		d = append(d, variant{kind: kind, key: string(parts[0]), value: parts[1]})
+	}
+}
+
+// writeVariantDictionary serializes a `VariantDictionary`.
+func writeVariantDictionary(d variantDictionary) []byte {
+	var w bytes.Buffer
+
+	_ = binary.Write(&w, binary.LittleEndian, uint16(variantDictionaryFormat))
+
+	for _, v := range d {
+		w.WriteByte(v.kind)
+
+		_ = binary.Write(&w, binary.LittleEndian, int32(len(v.key)))
+
+		w.WriteString(v.key)
+
+		_ = binary.Write(&w, binary.LittleEndian, int32(len(v.value)))
+
+		w.Write(v.value)
+	}
+
+	w.WriteByte(0)
+
+	return w.Bytes()
+}
+
+// newDatabase creates an empty database, AES-256, Argon2d, and gzip, like
+// KeePass, with a root group named `rootName`.
+func newDatabase(compositeKey []byte, rootName string, kdf kdfParameters) (*database, error) {
+	salt, err := randomBytes(32)
+	if err != nil {
+		return nil, err
+	}
+
+	parameters := variantDictionary{
+		{kind: variantByteArray, key: "$UUID", value: kdfArgon2d},
+		{kind: variantUInt64, key: "I", value: binary.LittleEndian.AppendUint64(nil, kdf.iterations)},
+		{kind: variantUInt64, key: "M", value: binary.LittleEndian.AppendUint64(nil, kdf.memory)},
+		{kind: variantUInt32, key: "P", value: binary.LittleEndian.AppendUint32(nil, kdf.parallelism)},
+		{kind: variantByteArray, key: "S", value: salt},
+		{kind: variantUInt32, key: "V", value: binary.LittleEndian.AppendUint32(nil, argon2Version)},
+	}
+
+	db := &database{
+		version: versionMajor4 << 16,
+		header: []headerField{
+			{id: headerCipherID, data: cipherAES256},
+			{id: headerCompression, data: binary.LittleEndian.AppendUint32(nil, compressionGzip)},
+			{id: headerMasterSeed, data: make([]byte, 32)},
+			{id: headerEncryptionIV, data: make([]byte, aes.BlockSize)},
+			{id: headerKdfParameters, data: writeVariantDictionary(parameters)},
+		},
+		compositeKey: compositeKey,
+	}
+
+	rootGroup, err := newGroup(rootName)
+	if err != nil {
+		return nil, err
+	}
+
+	db.root = &xmlNode{
+		XMLName: xml.Name{Local: "KeePassFile"},
+		Nodes: []*xmlNode{
+			{
+				XMLName: xml.Name{Local: "Meta"},
+				Nodes: []*xmlNode{
+					textNode("Generator", "configurer"),
+					textNode("DatabaseName", rootName),
+					{
+						XMLName: xml.Name{Local: "MemoryProtection"},
+						Nodes: []*xmlNode{
+							textNode("ProtectTitle", "False"),
+							textNode("ProtectUserName", "False"),
+							textNode("ProtectPassword", "True"),
+							textNode("ProtectURL", "False"),
+							textNode("ProtectNotes", "False"),
+						},
+					},
+				},
+			},
+			{
+				XMLName: xml.Name{Local: "Root"},
+				Nodes:   []*xmlNode{rootGroup},
+			},
+		},
+	}
+
+	return db, nil
+}
+
+// textNode creates an element with text content.
+func textNode(name, content string) *xmlNode {
+	return &xmlNode{XMLName: xml.Name{Local: name}, Content: content}
+}
+
+// cloneNode deeply copies `n`.
+func cloneNode(n *xmlNode) *xmlNode {
+	c := &xmlNode{
+		XMLName: n.XMLName,
+		Attrs:   append([]xml.Attr{}, n.Attrs...),
+		Content: n.Content,
+	}
+
+	for _, child := range n.Nodes {
+		c.Nodes = append(c.Nodes, cloneNode(child))
+	}
+
+	return c
+}
+
+// randomBytes returns `n` random bytes.
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// mustUUID decodes a hex UUID.
+func mustUUID(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return b
+}
+
+// concat concatenates byte slices into a new one.
+func concat(parts ...[]byte) []byte {
+	var out []byte
+
+	for _, part := range parts {
+		out = append(out, part...)
+	}
+
+	return out
+}
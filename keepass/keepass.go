@@ -0,0 +1,660 @@
+package keepass
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/thalesfsp/configurer/option"
+	"github.com/thalesfsp/configurer/provider"
+	"github.com/thalesfsp/configurer/util"
+	"github.com/thalesfsp/customerror"
+	"github.com/thalesfsp/validation"
+)
+
+//////
+// Vars, consts, and types.
+//////
+
+// Name of the provider.
+const Name = "keepass"
+
+// PathSeparator separates group, and entry names in paths, e.g.:
+// `Production/Database`.
+const PathSeparator = "/"
+
+// Standard entry fields, and the keys they're exported as. The title names
+// the entry, it isn't exported.
+var standardFields = []struct {
+	field string
+	key   string
+}{
+	{"UserName", "USERNAME"},
+	{"Password", "PASSWORD"},
+	{"URL", "URL"},
+	{"Notes", "NOTES"},
+}
+
+// keySanitizerRegex matches characters which aren't allowed in group, and
+// entry names used as key prefixes.
+var keySanitizerRegex = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// keepassEpoch is the epoch of KDBX4 times.
+var keepassEpoch = time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// kdfParameters are the Argon2d parameters of new databases.
+type kdfParameters struct {
+	iterations  uint64
+	memory      uint64
+	parallelism uint32
+}
+
+// newDatabaseKDF is KeePass' default: 2 iterations, 64 MiB, 2 lanes.
+var newDatabaseKDF = kdfParameters{iterations: 2, memory: 64 * 1024 * 1024, parallelism: 2}
+
+// Config contains the KeePass settings.
+type Config struct {
+	// FilePath of the KDBX4 database.
+	FilePath string `json:"filePath" validate:"required"`
+
+	// KeyFile is the path of the key file, if any.
+	KeyFile string `json:"keyFile"`
+
+	// Password of the database, if any.
+	Password string `json:"-"`
+
+	// Path of the group, or entry to load, e.g.: `Production/Database`,
+	// relative to the root group. Empty loads the root group. Write requires
+	// an entry path.
+	Path string `json:"path"`
+}
+
+// KeePass provider definition.
+type KeePass struct {
+	*provider.Provider `json:"-" validate:"required"`
+
+	Configuration *Config `json:"-" validate:"required"`
+}
+
+//////
+// IProvider implementation.
+//////
+
+// Load opens the database, and exports the fields of the entry, or of the
+// entries of the group, and its subgroups, at `Path`. Standard fields are
+// exported as `USERNAME`, `PASSWORD`, `URL`, and `NOTES`, custom string
+// fields as is. In group mode, keys are prefixed with the subgroup, and entry
+// names, e.g.: `DATABASE__PASSWORD`. The recycle bin is skipped.
+func (k *KeePass) Load(ctx context.Context, opts ...option.LoadKeyFunc) (map[string]string, error) {
+	db, err := k.open()
+	if err != nil {
+		return nil, err
+	}
+
+	group, entry, err := resolve(db, k.Configuration.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]string{}
+
+	if entry != nil {
+		exportEntry(entry, "", values)
+	} else {
+		exportGroup(group, "", recycleBinUUID(db), values)
+	}
+
+	finalValues := make(map[string]string, len(values))
+
+	for key, value := range values {
+		// Should allow to specify options.
+		for _, opt := range opts {
+			key = opt(key)
+		}
+
+		finalValue, err := provider.ExportToEnvVar(k, key, value)
+		if err != nil {
+			return nil, err
+		}
+
+		finalValues[key] = finalValue
+	}
+
+	return finalValues, nil
+}
+
+// Write adds, or updates the entry at `Path`, creating missing groups, or the
+// database, if it doesn't exist. `USERNAME`, `PASSWORD`, `URL`, and `NOTES`
+// (any case) set standard fields, other keys set custom string fields, which
+// are protected. The previous version of an updated entry is kept in its
+// history.
+func (k *KeePass) Write(ctx context.Context, values map[string]interface{}, opts ...option.WriteFunc) error {
+	if values == nil {
+		return customerror.NewRequiredError("values")
+	}
+
+	names := splitPath(k.Configuration.Path)
+	if len(names) == 0 {
+		return customerror.NewRequiredError("path, Write needs an entry path, e.g.: Production/Database")
+	}
+
+	var options option.Write
+
+	for _, opt := range opts {
+		if err := opt(&options); err != nil {
+			return err
+		}
+	}
+
+	db, err := k.open()
+	if errors.Is(err, os.ErrNotExist) {
+		db, err = k.create()
+	}
+
+	if err != nil {
+		return err
+	}
+
+	group, err := rootGroup(db)
+	if err != nil {
+		return err
+	}
+
+	names = trimRootName(group, names)
+
+	for _, name := range names[:len(names)-1] {
+		child := findGroup(group, name)
+
+		if child == nil {
+			if child, err = newGroup(name); err != nil {
+				return customerror.NewFailedToError("create group "+name, customerror.WithError(err))
+			}
+
+			insertBeforeGroups(group, child)
+		}
+
+		group = child
+	}
+
+	title := names[len(names)-1]
+
+	if findGroup(group, title) != nil {
+		return customerror.NewInvalidError("path " + k.Configuration.Path + ", is a group, Write needs an entry path")
+	}
+
+	entry := findEntry(group, title)
+	if entry == nil {
+		if entry, err = newEntry(title); err != nil {
+			return customerror.NewFailedToError("create entry "+title, customerror.WithError(err))
+		}
+
+		insertBeforeGroups(group, entry)
+	} else {
+		archiveEntry(entry)
+	}
+
+	for key, value := range values {
+		field, protected := key, true
+
+		for _, standard := range standardFields {
+			if strings.EqualFold(key, standard.field) || strings.EqualFold(key, standard.key) {
+				field, protected = standard.field, standard.field == "Password"
+			}
+		}
+
+		setString(entry, field, fmt.Sprintf("%v", value), protected)
+	}
+
+	touch(entry, "LastModificationTime")
+
+	content, err := db.save()
+	if err != nil {
+		return customerror.NewFailedToError("encrypt database", customerror.WithError(err))
+	}
+
+	return util.WriteFileAtomicBytes(k.Configuration.FilePath, content)
+}
+
+//////
+// Helpers.
+//////
+
+// open reads, and decrypts the database.
+func (k *KeePass) open() (*database, error) {
+	key, err := k.compositeKey()
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(k.Configuration.FilePath)
+	if err != nil {
+		return nil, customerror.NewFailedToError("read path", customerror.WithError(err))
+	}
+
+	db, err := openDatabase(content, key)
+	if err != nil {
+		return nil, customerror.NewFailedToError("open "+k.Configuration.FilePath, customerror.WithError(err))
+	}
+
+	return db, nil
+}
+
+// create creates an empty database, named after the file.
+func (k *KeePass) create() (*database, error) {
+	key, err := k.compositeKey()
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSuffix(filepath.Base(k.Configuration.FilePath), filepath.Ext(k.Configuration.FilePath))
+
+	db, err := newDatabase(key, name, newDatabaseKDF)
+	if err != nil {
+		return nil, customerror.NewFailedToError("create database", customerror.WithError(err))
+	}
+
+	return db, nil
+}
+
+// compositeKey combines the password, and the key file.
+func (k *KeePass) compositeKey() ([]byte, error) {
+	var keyFile []byte
+
+	if k.Configuration.KeyFile != "" {
+		content, err := os.ReadFile(k.Configuration.KeyFile)
+		if err != nil {
+			return nil, customerror.NewFailedToError("read key file", customerror.WithError(err))
+		}
+
+		keyFile = content
+	}
+
+	key, err := compositeKey(k.Configuration.Password, k.Configuration.Password != "", keyFile)
+	if err != nil {
+		return nil, customerror.NewInvalidError("key file", customerror.WithError(err))
+	}
+
+	return key, nil
+}
+
+// resolve returns the group, or the entry at `path`.
+func resolve(db *database, path string) (*xmlNode, *xmlNode, error) {
+	group, err := rootGroup(db)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	names := trimRootName(group, splitPath(path))
+
+	for i, name := range names {
+		if child := findGroup(group, name); child != nil {
+			group = child
+
+			continue
+		}
+
+		if i == len(names)-1 {
+			if entry := findEntry(group, name); entry != nil {
+				return nil, entry, nil
+			}
+		}
+
+		return nil, nil, customerror.NewNotFoundError("group, or entry " + path)
+	}
+
+	return group, nil, nil
+}
+
+// rootGroup returns the root group of the database.
+func rootGroup(db *database) (*xmlNode, error) {
+	if root := db.root.child("Root"); root != nil {
+		if group := root.child("Group"); group != nil {
+			return group, nil
+		}
+	}
+
+	return nil, customerror.NewInvalidError("database, missing root group")
+}
+
+// trimRootName drops the root group name from `names`, if present, and it
+// isn't also the name of a subgroup.
+func trimRootName(root *xmlNode, names []string) []string {
+	if len(names) > 0 && names[0] == textOf(root, "Name") && findGroup(root, names[0]) == nil {
+		return names[1:]
+	}
+
+	return names
+}
+
+// splitPath splits `path` into names.
+func splitPath(path string) []string {
+	var names []string
+
+	for _, name := range strings.Split(path, PathSeparator) {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// findGroup returns the subgroup named `name`.
+func findGroup(group *xmlNode, name string) *xmlNode {
+	for _, child := range group.children("Group") {
+		if textOf(child, "Name") == name {
+			return child
+		}
+	}
+
+	return nil
+}
+
+// findEntry returns the entry titled `title`.
+func findEntry(group *xmlNode, title string) *xmlNode {
+	for _, entry := range group.children("Entry") {
+		if value, ok := getString(entry, "Title"); ok && value == title {
+			return entry
+		}
+	}
+
+	return nil
+}
+
+// exportEntry adds the fields of `entry` to `values`.
+func exportEntry(entry *xmlNode, prefix string, values map[string]string) {
+	standard := map[string]string{"Title": ""}
+
+	for _, f := range standardFields {
+		standard[f.field] = f.key
+
+		if value, ok := getString(entry, f.field); ok && value != "" {
+			values[prefix+f.key] = value
+		}
+	}
+
+	for _, s := range entry.children("String") {
+		key := textOf(s, "Key")
+
+		if _, ok := standard[key]; !ok {
+			values[prefix+key] = textOf(s, "Value")
+		}
+	}
+}
+
+// exportGroup adds the fields of the entries of `group`, and its subgroups,
+// to `values`, except the recycle bin.
+func exportGroup(group *xmlNode, prefix, recycleBin string, values map[string]string) {
+	for _, entry := range group.children("Entry") {
+		title, _ := getString(entry, "Title")
+
+		exportEntry(entry, prefix+keyPart(title)+option.DefaultSeparator, values)
+	}
+
+	for _, child := range group.children("Group") {
+		if recycleBin != "" && textOf(child, "UUID") == recycleBin {
+			continue
+		}
+
+		exportGroup(child, prefix+keyPart(textOf(child, "Name"))+option.DefaultSeparator, recycleBin, values)
+	}
+}
+
+// keyPart converts a group, or entry name to a key part.
+func keyPart(name string) string {
+	return strings.Trim(keySanitizerRegex.ReplaceAllString(name, "_"), "_")
+}
+
+// recycleBinUUID returns the UUID of the recycle bin, if enabled.
+func recycleBinUUID(db *database) string {
+	meta := db.root.child("Meta")
+	if meta == nil || strings.EqualFold(textOf(meta, "RecycleBinEnabled"), "False") {
+		return ""
+	}
+
+	uuid := textOf(meta, "RecycleBinUUID")
+
+	// An all-zero UUID means there's no recycle bin.
+	if decoded, err := base64.StdEncoding.DecodeString(uuid); err == nil && strings.Trim(string(decoded), "\x00") == "" {
+		return ""
+	}
+
+	return uuid
+}
+
+// textOf returns the content of the child element `name`.
+func textOf(n *xmlNode, name string) string {
+	if child := n.child(name); child != nil {
+		return child.Content
+	}
+
+	return ""
+}
+
+// getString returns the value of the string field `key` of `entry`.
+func getString(entry *xmlNode, key string) (string, bool) {
+	for _, s := range entry.children("String") {
+		if textOf(s, "Key") == key {
+			return textOf(s, "Value"), true
+		}
+	}
+
+	return "", false
+}
+
+// setString sets the string field `key` of `entry`.
+func setString(entry *xmlNode, key, value string, protected bool) {
+	var valueNode *xmlNode
+
+	for _, s := range entry.children("String") {
+		if textOf(s, "Key") == key {
+			valueNode = s.child("Value")
+
+			if valueNode == nil {
+				valueNode = textNode("Value", "")
+				s.Nodes = append(s.Nodes, valueNode)
+			}
+
+			break
+		}
+	}
+
+	if valueNode == nil {
+		valueNode = textNode("Value", "")
+
+		s := &xmlNode{XMLName: xml.Name{Local: "String"}, Nodes: []*xmlNode{textNode("Key", key), valueNode}}
+
+		// Strings come before `AutoType`, and `History`.
+		index := len(entry.Nodes)
+
+		for i, child := range entry.Nodes {
+			if name := child.XMLName.Local; name == "AutoType" || name == "History" {
+				index = i
+
+				break
+			}
+		}
+
+		entry.Nodes = append(entry.Nodes[:index], append([]*xmlNode{s}, entry.Nodes[index:]...)...)
+	}
+
+	valueNode.Content = value
+	valueNode.Attrs = nil
+
+	if protected {
+		valueNode.Attrs = []xml.Attr{{Name: xml.Name{Local: "Protected"}, Value: "True"}}
+	}
+}
+
+// archiveEntry copies `entry`, without its history, to its history.
+func archiveEntry(entry *xmlNode) {
+	previous := cloneNode(entry)
+
+	nodes := previous.Nodes[:0]
+
+	for _, child := range previous.Nodes {
+		if child.XMLName.Local != "History" {
+			nodes = append(nodes, child)
+		}
+	}
+
+	previous.Nodes = nodes
+
+	history := entry.child("History")
+	if history == nil {
+		history = &xmlNode{XMLName: xml.Name{Local: "History"}}
+		entry.Nodes = append(entry.Nodes, history)
+	}
+
+	history.Nodes = append(history.Nodes, previous)
+}
+
+// insertBeforeGroups inserts `n` into `group`, entries come before subgroups.
+func insertBeforeGroups(group, n *xmlNode) {
+	index := len(group.Nodes)
+
+	if n.XMLName.Local == "Entry" {
+		for i, child := range group.Nodes {
+			if child.XMLName.Local == "Group" {
+				index = i
+
+				break
+			}
+		}
+	}
+
+	group.Nodes = append(group.Nodes[:index], append([]*xmlNode{n}, group.Nodes[index:]...)...)
+}
+
+// newGroup creates an empty group.
+func newGroup(name string) (*xmlNode, error) {
+	uuid, err := newUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	return &xmlNode{
+		XMLName: xml.Name{Local: "Group"},
+		Nodes: []*xmlNode{
+			textNode("UUID", uuid),
+			textNode("Name", name),
+			textNode("IconID", "48"),
+			newTimes(),
+			textNode("IsExpanded", "True"),
+		},
+	}, nil
+}
+
+// newEntry creates an entry titled `title`.
+func newEntry(title string) (*xmlNode, error) {
+	uuid, err := newUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &xmlNode{
+		XMLName: xml.Name{Local: "Entry"},
+		Nodes: []*xmlNode{
+			textNode("UUID", uuid),
+			textNode("IconID", "0"),
+			newTimes(),
+		},
+	}
+
+	setString(entry, "Title", title, false)
+
+	return entry, nil
+}
+
+// newTimes creates the `Times` element, set to now.
+func newTimes() *xmlNode {
+	now := formatTime(time.Now())
+
+	return &xmlNode{
+		XMLName: xml.Name{Local: "Times"},
+		Nodes: []*xmlNode{
+			textNode("CreationTime", now),
+			textNode("LastModificationTime", now),
+			textNode("LastAccessTime", now),
+			textNode("ExpiryTime", now),
+			textNode("Expires", "False"),
+			textNode("UsageCount", "0"),
+			textNode("LocationChanged", now),
+		},
+	}
+}
+
+// touch sets the time `name` of `entry` to now.
+func touch(entry *xmlNode, name string) {
+	times := entry.child("Times")
+	if times == nil {
+		times = newTimes()
+		entry.Nodes = append(entry.Nodes, times)
+	}
+
+	if t := times.child(name); t != nil {
+		t.Content = formatTime(time.Now())
+	}
+}
+
+// formatTime formats KDBX4 times: base64 of the seconds since year 1.
+func formatTime(t time.Time) string {
+	// Durations overflow past ~292 years, so subtract Unix times instead.
+	seconds := t.UTC().Unix() - keepassEpoch.Unix()
+
+	return base64.StdEncoding.EncodeToString(binary.LittleEndian.AppendUint64(nil, uint64(seconds)))
+}
+
+// newUUID returns a random, base64 encoded UUID.
+func newUUID() (string, error) {
+	uuid, err := randomBytes(16)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(uuid), nil
+}
+
+//////
+// Factory.
+//////
+
+// New creates a KeePass provider. A password, a key file, or both are
+// required.
+func New(override, rawValue bool, config *Config) (provider.IProvider, error) {
+	if config == nil {
+		return nil, customerror.NewRequiredError("config")
+	}
+
+	if err := validation.Validate(config); err != nil {
+		return nil, err
+	}
+
+	if config.Password == "" && config.KeyFile == "" {
+		return nil, customerror.NewRequiredError("password, or key file")
+	}
+
+	baseProvider, err := provider.New(Name, override, rawValue)
+	if err != nil {
+		return nil, err
+	}
+
+	k := &KeePass{
+		Provider:      baseProvider,
+		Configuration: config,
+	}
+
+	if err := validation.Validate(k); err != nil {
+		return nil, err
+	}
+
+	return k, nil
+}
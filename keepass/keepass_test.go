@@ -0,0 +1,422 @@
+package keepass
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thalesfsp/configurer/internal/testenv"
+	"github.com/thalesfsp/configurer/option"
+)
+
+// Fixtures. `argon2d.kdbx` is AES-256, and Argon2d, with a password.
+// `chacha20.kdbx` is ChaCha20, and AES-KDF, with a password, and a key file.
+const (
+	argon2dFixture      = "testdata/argon2d.kdbx"
+	argon2dPassword     = "correct horse"
+	chacha20Fixture     = "testdata/chacha20.kdbx"
+	chacha20KeyFile     = "testdata/chacha20.keyx"
+	chacha20KeyFileData = "A7007945D07D54BA28DF64341B4500FC9750DFB1D36ADA2D9C32DC194C7AB01B"
+	chacha20Password    = "hunter2"
+)
+
+// fastKDF makes new databases cheap to open.
+func fastKDF(t *testing.T) {
+	t.Helper()
+
+	original := newDatabaseKDF
+	newDatabaseKDF = kdfParameters{iterations: 1, memory: 64 * 1024, parallelism: 1}
+
+	t.Cleanup(func() { newDatabaseKDF = original })
+}
+
+// load loads, and unsets the exported values.
+func assertLoad(t *testing.T, config *Config, want map[string]string, opts ...option.LoadKeyFunc) {
+	t.Helper()
+
+	for key := range want {
+		testenv.Unset(t, key)
+	}
+
+	p, err := New(true, false, config)
+	require.NoError(t, err)
+
+	got, err := p.Load(context.Background(), opts...)
+	require.NoError(t, err)
+
+	assert.Equal(t, want, got)
+}
+
+//////
+// Constructor.
+//////
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *Config
+		wantErr string
+	}{
+		{
+			name:   "happy path password",
+			config: &Config{FilePath: "secrets.kdbx", Password: "p"},
+		},
+		{
+			name:   "happy path key file",
+			config: &Config{FilePath: "secrets.kdbx", KeyFile: "secrets.keyx", Path: "Production/Database"},
+		},
+		{
+			name:    "bad path nil config",
+			wantErr: "config",
+		},
+		{
+			name:    "bad path missing file",
+			config:  &Config{Password: "p"},
+			wantErr: "FilePath",
+		},
+		{
+			name:    "bad path missing credentials",
+			config:  &Config{FilePath: "secrets.kdbx"},
+			wantErr: "password, or key file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(false, false, tt.config)
+
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				assert.Nil(t, got)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, Name, got.GetName())
+		})
+	}
+}
+
+//////
+// KDBX4.
+//////
+
+func TestArgon2d(t *testing.T) {
+	// SEE: https://www.rfc-editor.org/rfc/rfc9106#section-5.1
+	got := argon2dKey(
+		bytes.Repeat([]byte{0x01}, 32),
+		bytes.Repeat([]byte{0x02}, 16),
+		bytes.Repeat([]byte{0x03}, 8),
+		bytes.Repeat([]byte{0x04}, 12),
+		3, 32, 4, 32,
+	)
+
+	assert.Equal(t, "512b391b6f1162975371d30919734294f868e3be3984f3c1a13a4db9fabe4acb", hex.EncodeToString(got))
+
+	// Longer than a BLAKE2b digest.
+	assert.Len(t, argon2dKey([]byte("password"), []byte("somesalt"), nil, nil, 1, 64, 1, 100), 100)
+}
+
+func TestKeyFile(t *testing.T) {
+	data, err := hex.DecodeString(chacha20KeyFileData)
+	require.NoError(t, err)
+
+	xmlV2, err := os.ReadFile(chacha20KeyFile)
+	require.NoError(t, err)
+
+	hash := sha256.Sum256([]byte("any file"))
+
+	tests := []struct {
+		name    string
+		content []byte
+		want    []byte
+		wantErr string
+	}{
+		{
+			name:    "XML v2",
+			content: xmlV2,
+			want:    data,
+		},
+		{
+			name:    "XML v1",
+			content: []byte("<KeyFile><Meta><Version>1.00</Version></Meta><Key><Data>" + base64.StdEncoding.EncodeToString(data) + "</Data></Key></KeyFile>"),
+			want:    data,
+		},
+		{
+			name:    "raw 32 bytes",
+			content: data,
+			want:    data,
+		},
+		{
+			name:    "64 hex characters",
+			content: []byte(chacha20KeyFileData),
+			want:    data,
+		},
+		{
+			name:    "any other file is hashed",
+			content: []byte("any file"),
+			want:    hash[:],
+		},
+		{
+			name:    "XML v2 with wrong hash",
+			content: bytes.Replace(xmlV2, []byte("FE2949B8"), []byte("00000000"), 1),
+			wantErr: "hash",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := keyFileKey(tt.content)
+
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+//////
+// IProvider implementation.
+//////
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *Config
+		opts   []option.LoadKeyFunc
+		want   map[string]string
+	}{
+		{
+			name:   "entry",
+			config: &Config{FilePath: argon2dFixture, Password: argon2dPassword, Path: "Production/Database"},
+			want: map[string]string{
+				"USERNAME": "admin",
+				"PASSWORD": "s3cr3t",
+				"URL":      "postgres://db:5432",
+				"DB_NAME":  "app",
+			},
+		},
+		{
+			name:   "entry with root group name",
+			config: &Config{FilePath: argon2dFixture, Password: argon2dPassword, Path: "/argon2d/Production/Cache"},
+			opts:   []option.LoadKeyFunc{option.WithKeyPrefixer("CACHE_")},
+			want: map[string]string{
+				"CACHE_PASSWORD": "r3d1s",
+				"CACHE_NOTES":    "primary",
+			},
+		},
+		{
+			name:   "group",
+			config: &Config{FilePath: argon2dFixture, Password: argon2dPassword, Path: "Staging"},
+			want: map[string]string{
+				"API__Token__PASSWORD": "t0k3n",
+			},
+		},
+		{
+			name:   "root group",
+			config: &Config{FilePath: argon2dFixture, Password: argon2dPassword},
+			want: map[string]string{
+				"Production__Database__USERNAME": "admin",
+				"Production__Database__PASSWORD": "s3cr3t",
+				"Production__Database__URL":      "postgres://db:5432",
+				"Production__Database__DB_NAME":  "app",
+				"Production__Cache__PASSWORD":    "r3d1s",
+				"Production__Cache__NOTES":       "primary",
+				"Staging__API__Token__PASSWORD":  "t0k3n",
+			},
+		},
+		{
+			name:   "ChaCha20, AES-KDF, and key file",
+			config: &Config{FilePath: chacha20Fixture, Password: chacha20Password, KeyFile: chacha20KeyFile, Path: "Mail"},
+			want: map[string]string{
+				"USERNAME":  "ops@example.com",
+				"PASSWORD":  "m41l",
+				"SMTP_HOST": "smtp.example.com",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertLoad(t, tt.config, tt.want, tt.opts...)
+
+			for key, value := range tt.want {
+				assert.Equal(t, value, os.Getenv(key))
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	fastKDF(t)
+
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "secrets.kdbx")
+	keyFile := filepath.Join(dir, "secrets.key")
+
+	require.NoError(t, os.WriteFile(keyFile, []byte("key file"), 0o600))
+
+	config := &Config{FilePath: filePath, Password: "p", KeyFile: keyFile, Path: "Production/Database"}
+
+	writer, err := New(false, false, config)
+	require.NoError(t, err)
+
+	// Creates the database.
+	require.NoError(t, writer.Write(context.Background(), map[string]interface{}{
+		"username": "admin",
+		"PASSWORD": "s3cr3t\nmultiline <&>",
+		"PORT":     5432,
+	}))
+
+	info, err := os.Stat(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	content, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "s3cr3t")
+
+	assertLoad(t, config, map[string]string{
+		"USERNAME": "admin",
+		"PASSWORD": "s3cr3t\nmultiline <&>",
+		"PORT":     "5432",
+	})
+
+	// Updates the entry, and keeps the previous version in its history.
+	require.NoError(t, writer.Write(context.Background(), map[string]interface{}{
+		"PASSWORD": "rotated",
+		"Notes":    "rotated by configurer",
+	}))
+
+	// Adds a sibling entry.
+	sibling := *config
+	sibling.Path = "Production/Cache"
+
+	writer, err = New(false, false, &sibling)
+	require.NoError(t, err)
+	require.NoError(t, writer.Write(context.Background(), map[string]interface{}{"PASSWORD": "r3d1s"}))
+
+	assertLoad(t, config, map[string]string{
+		"USERNAME": "admin",
+		"PASSWORD": "rotated",
+		"NOTES":    "rotated by configurer",
+		"PORT":     "5432",
+	})
+
+	db, err := (&KeePass{Configuration: config}).open()
+	require.NoError(t, err)
+
+	group, entry, err := resolve(db, config.Path)
+	require.NoError(t, err)
+	require.Nil(t, group)
+
+	history := entry.child("History").children("Entry")
+	require.Len(t, history, 1)
+
+	password, _ := getString(history[0], "Password")
+	assert.Equal(t, "s3cr3t\nmultiline <&>", password)
+	assert.Nil(t, history[0].child("History"))
+
+	// History isn't exported.
+	sibling.Path = "Production"
+
+	assertLoad(t, &sibling, map[string]string{
+		"Database__USERNAME": "admin",
+		"Database__PASSWORD": "rotated",
+		"Database__NOTES":    "rotated by configurer",
+		"Database__PORT":     "5432",
+		"Cache__PASSWORD":    "r3d1s",
+	})
+}
+
+func TestLoadWriteErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	notKeePassPath := filepath.Join(dir, "plain.kdbx")
+	require.NoError(t, os.WriteFile(notKeePassPath, []byte("KEY=value"), 0o600))
+
+	content, err := os.ReadFile(argon2dFixture)
+	require.NoError(t, err)
+
+	tamperedPath := filepath.Join(dir, "tampered.kdbx")
+	content[len(content)-100] ^= 0xFF
+	require.NoError(t, os.WriteFile(tamperedPath, content, 0o600))
+
+	tests := []struct {
+		name    string
+		config  *Config
+		wantErr string
+	}{
+		{
+			name:    "wrong password",
+			config:  &Config{FilePath: argon2dFixture, Password: "wrong"},
+			wantErr: "wrong credentials",
+		},
+		{
+			name:    "missing key file",
+			config:  &Config{FilePath: chacha20Fixture, Password: chacha20Password},
+			wantErr: "wrong credentials",
+		},
+		{
+			name:    "not a KeePass database",
+			config:  &Config{FilePath: notKeePassPath, Password: "p"},
+			wantErr: "KeePass",
+		},
+		{
+			name:    "tampered database",
+			config:  &Config{FilePath: tamperedPath, Password: argon2dPassword},
+			wantErr: "corrupted payload",
+		},
+		{
+			name:    "missing database",
+			config:  &Config{FilePath: filepath.Join(dir, "missing.kdbx"), Password: "p"},
+			wantErr: "read path",
+		},
+		{
+			name:    "unreadable key file",
+			config:  &Config{FilePath: argon2dFixture, KeyFile: filepath.Join(dir, "missing.key")},
+			wantErr: "read key file",
+		},
+		{
+			name:    "missing path",
+			config:  &Config{FilePath: argon2dFixture, Password: argon2dPassword, Path: "Production/Missing"},
+			wantErr: "group, or entry Production/Missing",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(false, false, tt.config)
+			require.NoError(t, err)
+
+			_, err = p.Load(context.Background())
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+
+	writer, err := New(false, false, &Config{FilePath: filepath.Join(dir, "new.kdbx"), Password: "p"})
+	require.NoError(t, err)
+	assert.ErrorContains(t, writer.Write(context.Background(), map[string]interface{}{"A": "1"}), "entry path")
+	assert.ErrorContains(t, writer.Write(context.Background(), nil), "values")
+
+	writer, err = New(false, false, &Config{FilePath: argon2dFixture, Password: argon2dPassword, Path: "Production"})
+	require.NoError(t, err)
+	assert.ErrorContains(t, writer.Write(context.Background(), map[string]interface{}{"A": "1"}), "is a group")
+
+	writer, err = New(false, false, &Config{FilePath: argon2dFixture, Password: "wrong", Path: "Production/Database"})
+	require.NoError(t, err)
+	assert.ErrorContains(t, writer.Write(context.Background(), map[string]interface{}{"A": "1"}), "wrong credentials")
+}
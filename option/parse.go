@@ -0,0 +1,108 @@
+// Package option provides a set of options for the providers.
+package option
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/thalesfsp/customerror"
+)
+
+const (
+	// ArrayIndex expands arrays into indexed keys, e.g.: `HOSTS__0`.
+	ArrayIndex = "index"
+
+	// ArrayJSON keeps arrays as a single JSON-encoded value.
+	ArrayJSON = "json"
+
+	// DefaultSeparator is the default separator between nested keys.
+	DefaultSeparator = "__"
+)
+
+// AllowedArrayModes is the list of allowed array modes.
+var AllowedArrayModes = []string{ArrayIndex, ArrayJSON}
+
+// Parse definition.
+type Parse struct {
+	// Arrays is how arrays are flattened. See `AllowedArrayModes`.
+	Arrays string
+
+	// Case of flattened keys. See `AllowedCases`.
+	Case string
+
+	// Flatten indicates nested documents should be flattened.
+	Flatten bool
+
+	// Separator between nested keys.
+	Separator string
+}
+
+// ParseFunc allows to specify parsing options.
+type ParseFunc func(o *Parse) error
+
+// NewParse returns the default parsing options: flattening disabled, and when
+// enabled, `DefaultSeparator`, `Upper` case, and `ArrayIndex`.
+func NewParse(opts ...ParseFunc) (*Parse, error) {
+	o := &Parse{
+		Arrays:    ArrayIndex,
+		Case:      Upper,
+		Separator: DefaultSeparator,
+	}
+
+	for _, opt := range opts {
+		if err := opt(o); err != nil {
+			return nil, err
+		}
+	}
+
+	return o, nil
+}
+
+// WithFlatten enables flattening nested documents into keys like
+// `DATABASE__HOST`.
+func WithFlatten(flatten bool) ParseFunc {
+	return func(o *Parse) error {
+		o.Flatten = flatten
+
+		return nil
+	}
+}
+
+// WithFlattenSeparator specifies the separator between nested keys.
+func WithFlattenSeparator(separator string) ParseFunc {
+	return func(o *Parse) error {
+		if separator == "" {
+			return customerror.NewInvalidError("separator, can't be empty")
+		}
+
+		o.Separator = separator
+
+		return nil
+	}
+}
+
+// WithFlattenCase specifies the case of flattened keys.
+func WithFlattenCase(caseType string) ParseFunc {
+	return func(o *Parse) error {
+		if !slices.Contains(AllowedCases, caseType) {
+			return customerror.NewInvalidError("case, allowed: " + strings.Join(AllowedCases, ", "))
+		}
+
+		o.Case = caseType
+
+		return nil
+	}
+}
+
+// WithFlattenArrays specifies how arrays are flattened.
+func WithFlattenArrays(mode string) ParseFunc {
+	return func(o *Parse) error {
+		if !slices.Contains(AllowedArrayModes, mode) {
+			return customerror.NewInvalidError("arrays mode, allowed: " + strings.Join(AllowedArrayModes, ", "))
+		}
+
+		o.Arrays = mode
+
+		return nil
+	}
+}
@@ -0,0 +1,171 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/thalesfsp/customerror"
+)
+
+//////
+// Vars, consts, and types.
+//////
+
+// Auto is the format which triggers detection by content sniffing.
+const Auto = "auto"
+
+// Factory creates a parser.
+type Factory func() (IParser, error)
+
+// ISniffer is optionally implemented by parsers to decide if they take part in
+// content detection (`Detect`). By default, a parser is tried by parsing the
+// content. Parsers with a permissive grammar, which would accept content in
+// other formats, should implement it.
+type ISniffer interface {
+	// Sniff reports whether `content` is in the parser's format.
+	Sniff(content []byte) bool
+}
+
+// registration is a registered parser.
+type registration struct {
+	extensions []string
+	factory    Factory
+	name       string
+}
+
+var (
+	registryMu sync.RWMutex
+
+	// Registration order matters, it's the order formats are tried by
+	// `Detect`.
+	registry []*registration
+)
+
+//////
+// Exported feature(s).
+//////
+
+// Register makes a parser available to `util.ParseContent`, `util.ParseFile`,
+// and the `text` command's `--format` flag, by `name`, and by any of the file
+// `extensions` (with, or without the leading dot). Parsers usually register
+// themselves in their package `init`.
+func Register(name string, extensions []string, factory Factory) error {
+	name = normalize(name)
+
+	if name == "" {
+		return customerror.NewRequiredError("name")
+	}
+
+	if factory == nil {
+		return customerror.NewRequiredError("factory")
+	}
+
+	r := &registration{factory: factory, name: name}
+
+	for _, extension := range extensions {
+		if extension = normalize(extension); extension != "" && extension != name {
+			r.extensions = append(r.extensions, extension)
+		}
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	for _, format := range append([]string{name}, r.extensions...) {
+		if existing := lookup(format); existing != nil {
+			return customerror.NewInvalidError(
+				"format " + format + ", already registered by the " + existing.name + " parser",
+			)
+		}
+	}
+
+	registry = append(registry, r)
+
+	return nil
+}
+
+// Get creates the parser registered for `format`, a name, or an extension.
+func Get(format string) (IParser, error) {
+	registryMu.RLock()
+	r := lookup(normalize(format))
+	registryMu.RUnlock()
+
+	if r == nil {
+		return nil, customerror.NewInvalidError("format, allowed: " + strings.Join(Formats(), ", "))
+	}
+
+	return r.factory()
+}
+
+// Formats lists the registered formats, in registration order, with their
+// extensions, e.g.: `yaml | yml`.
+func Formats() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	formats := make([]string, 0, len(registry))
+
+	for _, r := range registry {
+		formats = append(formats, strings.Join(append([]string{r.name}, r.extensions...), " | "))
+	}
+
+	return formats
+}
+
+// Detect sniffs `content`, returning the name of the first registered parser,
+// in registration order, which parses it, or whose `ISniffer.Sniff` accepts
+// it.
+func Detect(ctx context.Context, content []byte) (string, error) {
+	registryMu.RLock()
+	registrations := append([]*registration{}, registry...)
+	registryMu.RUnlock()
+
+	for _, r := range registrations {
+		p, err := r.factory()
+		if err != nil {
+			continue
+		}
+
+		if sniffer, ok := p.(ISniffer); ok {
+			if sniffer.Sniff(content) {
+				return r.name, nil
+			}
+
+			continue
+		}
+
+		if _, err := p.Read(ctx, bytes.NewReader(content)); err == nil {
+			return r.name, nil
+		}
+	}
+
+	return "", customerror.NewInvalidError("content, format couldn't be detected")
+}
+
+//////
+// Helpers.
+//////
+
+// lookup finds the registration for `format`. The caller must hold the lock.
+func lookup(format string) *registration {
+	for _, r := range registry {
+		if r.name == format {
+			return r
+		}
+
+		for _, extension := range r.extensions {
+			if extension == format {
+				return r
+			}
+		}
+	}
+
+	return nil
+}
+
+// normalize lower-cases `format`, and removes the leading dot.
+func normalize(format string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(format)), ".")
+}
@@ -0,0 +1,174 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//////
+// Helpers.
+//////
+
+// prefixParser parses `<prefix>key=value` lines.
+type prefixParser struct {
+	prefix string
+}
+
+func (p *prefixParser) Read(_ context.Context, r io.Reader) (map[string]any, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]any{}
+
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, p.prefix), "=")
+		if !ok || !strings.HasPrefix(line, p.prefix) {
+			return nil, errors.New("invalid line")
+		}
+
+		values[key] = value
+	}
+
+	return values, nil
+}
+
+// optOutParser accepts any content, but opts out of detection.
+type optOutParser struct{}
+
+func (optOutParser) Read(_ context.Context, _ io.Reader) (map[string]any, error) {
+	return map[string]any{}, nil
+}
+
+func (optOutParser) Sniff(_ []byte) bool {
+	return false
+}
+
+func prefixFactory(prefix string) Factory {
+	return func() (IParser, error) {
+		return &prefixParser{prefix: prefix}, nil
+	}
+}
+
+// isolateRegistry runs the test against an empty registry.
+func isolateRegistry(t *testing.T) {
+	t.Helper()
+
+	registryMu.Lock()
+	original := registry
+	registry = nil
+	registryMu.Unlock()
+
+	t.Cleanup(func() {
+		registryMu.Lock()
+		registry = original
+		registryMu.Unlock()
+	})
+}
+
+//////
+// Registry.
+//////
+
+func TestRegister(t *testing.T) {
+	isolateRegistry(t)
+
+	require.NoError(t, Register("Plus", []string{".plus", "PL", "plus", ""}, prefixFactory("+")))
+	require.NoError(t, Register("minus", nil, prefixFactory("-")))
+
+	assert.Equal(t, []string{"plus | pl", "minus"}, Formats())
+
+	for _, format := range []string{"plus", "PLUS", ".pl", "pl"} {
+		p, err := Get(format)
+		require.NoError(t, err, format)
+
+		got, err := p.Read(context.Background(), strings.NewReader("+a=1"))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"a": "1"}, got)
+	}
+
+	_, err := Get("xml")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "format, allowed: plus | pl, minus")
+}
+
+func TestRegisterErrors(t *testing.T) {
+	isolateRegistry(t)
+
+	require.NoError(t, Register("plus", []string{"pl"}, prefixFactory("+")))
+
+	tests := []struct {
+		name       string
+		format     string
+		extensions []string
+		factory    Factory
+		wantErr    string
+	}{
+		{
+			name:    "missing name",
+			format:  " ",
+			factory: prefixFactory("+"),
+			wantErr: "name required",
+		},
+		{
+			name:    "missing factory",
+			format:  "minus",
+			wantErr: "factory required",
+		},
+		{
+			name:    "name already registered",
+			format:  "Plus",
+			factory: prefixFactory("+"),
+			wantErr: "already registered by the plus parser",
+		},
+		{
+			name:       "extension already registered",
+			format:     "minus",
+			extensions: []string{".pl"},
+			factory:    prefixFactory("-"),
+			wantErr:    "format pl, already registered",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Register(tt.format, tt.extensions, tt.factory)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+
+	assert.Equal(t, []string{"plus | pl"}, Formats())
+}
+
+func TestDetect(t *testing.T) {
+	isolateRegistry(t)
+
+	require.NoError(t, Register("broken", nil, func() (IParser, error) {
+		return nil, errors.New("broken")
+	}))
+	require.NoError(t, Register("opt-out", nil, func() (IParser, error) {
+		return optOutParser{}, nil
+	}))
+	require.NoError(t, Register("plus", nil, prefixFactory("+")))
+	require.NoError(t, Register("minus", nil, prefixFactory("-")))
+
+	got, err := Detect(context.Background(), []byte("-a=1\n-b=2"))
+	require.NoError(t, err)
+	assert.Equal(t, "minus", got)
+
+	got, err = Detect(context.Background(), []byte("+a=1"))
+	require.NoError(t, err)
+	assert.Equal(t, "plus", got)
+
+	_, err = Detect(context.Background(), []byte("a=1"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "couldn't be detected")
+}
@@ -0,0 +1,131 @@
+package dockerenv
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/thalesfsp/configurer/parser"
+	"github.com/thalesfsp/validation"
+)
+
+//////
+// Vars, consts, and types.
+//////
+
+// Name of the parser.
+const Name = "dockerenv"
+
+var newBaseParser = parser.New
+
+// DockerEnv parser.
+type DockerEnv struct {
+	*parser.Parser `validate:"required"`
+}
+
+//////
+// Methods.
+//////
+
+// Read implementation of the Reader interface for `docker run --env-file`
+// files. Unlike dotenv, there's no quoting, escaping, or interpolation:
+//   - Lines starting with `#` are comments, blank lines are skipped
+//   - Everything after the first `=` is the value, verbatim, including quotes,
+//     and trailing whitespace
+//   - A line without `=`, e.g.: `VAR`, takes the value from the environment,
+//     it's skipped if the variable isn't set
+//
+// SEE: https://docs.docker.com/reference/cli/docker/container/run/#env
+func (d *DockerEnv) Read(ctx context.Context, r io.Reader) (map[string]any, error) {
+	values := make(map[string]any)
+	scanner := bufio.NewScanner(r)
+
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber++
+
+		line := strings.TrimLeftFunc(scanner.Text(), unicode.IsSpace)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		key, value, hasValue := strings.Cut(line, "=")
+
+		if key == "" {
+			return nil, fmt.Errorf("line %d: missing variable name", lineNumber)
+		}
+
+		if strings.IndexFunc(key, unicode.IsSpace) >= 0 {
+			return nil, fmt.Errorf("line %d: variable %q contains whitespaces", lineNumber, key)
+		}
+
+		if !hasValue {
+			v, ok := os.LookupEnv(key)
+			if !ok {
+				continue
+			}
+
+			value = v
+		}
+
+		values[key] = value
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// Sniff implements `parser.ISniffer`. Its grammar accepts most env content, so
+// it takes no part in detection.
+func (d *DockerEnv) Sniff(_ []byte) bool {
+	return false
+}
+
+//////
+// Factory.
+//////
+
+// New creates a new converter.
+func New() (*DockerEnv, error) {
+	// Enforces interface implementation.
+	var _ parser.IParser = (*DockerEnv)(nil)
+
+	p, err := newBaseParser(Name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parser instance.
+	pI := &DockerEnv{
+		Parser: p,
+	}
+
+	// Validation.
+	if err := validation.Validate(pI); err != nil {
+		return nil, err
+	}
+
+	return pI, nil
+}
+
+// Registers the parser, making it available by name.
+func init() {
+	if err := parser.Register(Name, nil, func() (parser.IParser, error) {
+		p, err := New()
+		if err != nil {
+			return nil, err
+		}
+
+		return p, nil
+	}); err != nil {
+		panic(err)
+	}
+}
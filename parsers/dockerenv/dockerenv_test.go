@@ -0,0 +1,111 @@
+package dockerenv
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thalesfsp/configurer/parser"
+)
+
+//////
+// Factory.
+//////
+
+func TestNew(t *testing.T) {
+	got, err := New()
+
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	require.NotNil(t, got.Parser)
+	assert.Equal(t, Name, got.Name)
+}
+
+func TestNewBaseParserError(t *testing.T) {
+	wantErr := errors.New("base parser failed")
+	original := newBaseParser
+	t.Cleanup(func() {
+		newBaseParser = original
+	})
+	newBaseParser = func(_ string) (*parser.Parser, error) {
+		return nil, wantErr
+	}
+
+	got, err := New()
+
+	require.ErrorIs(t, err, wantErr)
+	assert.Nil(t, got)
+}
+
+//////
+// Read.
+//////
+
+func TestDockerEnvRead(t *testing.T) {
+	t.Setenv("CONFIGURER_DOCKERENV_SET", "from env")
+
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]any
+		wantErr string
+	}{
+		{
+			name: "verbatim values",
+			content: `# comment
+  PLAIN=value
+QUOTED="quoted" # not a comment
+EQUALS=a=b
+EMPTY=
+
+CONFIGURER_DOCKERENV_SET
+CONFIGURER_DOCKERENV_UNSET
+`,
+			want: map[string]any{
+				"PLAIN":                    "value",
+				"QUOTED":                   `"quoted" # not a comment`,
+				"EQUALS":                   "a=b",
+				"EMPTY":                    "",
+				"CONFIGURER_DOCKERENV_SET": "from env",
+			},
+		},
+		{
+			name:    "missing name",
+			content: "A=1\n=value\n",
+			wantErr: "line 2: missing variable name",
+		},
+		{
+			name:    "whitespace in name",
+			content: "export A=1\n",
+			wantErr: "line 1: variable \"export A\" contains whitespaces",
+		},
+		{
+			name:    "empty input",
+			content: "",
+			want:    map[string]any{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New()
+			require.NoError(t, err)
+
+			got, err := p.Read(context.Background(), strings.NewReader(tt.content))
+
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				assert.Nil(t, got)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
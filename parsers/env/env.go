@@ -1,11 +1,8 @@
 package env
 
 import (
-	"bufio"
 	"context"
-	"fmt"
 	"io"
-	"strings"
 
 	"github.com/thalesfsp/configurer/parser"
 	"github.com/thalesfsp/validation"
@@ -27,39 +24,16 @@ type ENV struct {
 // Methods.
 //////
 
-// Read implementation of the Reader interface for ENV files.
-//
-//nolint:gomnd
+// Read implementation of the Reader interface for ENV files. It follows the
+// dotenv grammar, the same as `dotenv.Load`. Syntax errors are `*SyntaxError`,
+// reporting the line, and column.
 func (e *ENV) Read(ctx context.Context, r io.Reader) (map[string]any, error) {
-	values := make(map[string]any)
-	scanner := bufio.NewScanner(r)
-
-	for scanner.Scan() {
-		line := scanner.Text()
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) != 2 {
-			return nil, fmt.Errorf("invalid line: %s", line)
-		}
-
-		key := parts[0]
-		value := parts[1]
-
-		// Remove any \" from the beginning and end of the value.
-		value = strings.TrimPrefix(value, "\"")
-		value = strings.TrimSuffix(value, "\"")
-
-		values[key] = value
-	}
-
-	if err := scanner.Err(); err != nil {
+	content, err := io.ReadAll(r)
+	if err != nil {
 		return nil, err
 	}
 
-	return values, nil
+	return parse(string(content))
 }
 
 //////
@@ -88,3 +62,17 @@ func New() (*ENV, error) {
 
 	return pI, nil
 }
+
+// Registers the parser, making it available by name, and extension.
+func init() {
+	if err := parser.Register(Name, []string{"env"}, func() (parser.IParser, error) {
+		p, err := New()
+		if err != nil {
+			return nil, err
+		}
+
+		return p, nil
+	}); err != nil {
+		panic(err)
+	}
+}
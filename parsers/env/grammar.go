@@ -0,0 +1,451 @@
+package env
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+//////
+// Vars, consts, and types.
+//////
+
+// SyntaxError is a dotenv syntax error, located by line and column, both
+// starting at 1.
+type SyntaxError struct {
+	// Column where the error was found.
+	Column int `json:"column"`
+
+	// Line where the error was found.
+	Line int `json:"line"`
+
+	// Message describes the error.
+	Message string `json:"message"`
+}
+
+// Error implements the error interface.
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+}
+
+// scanner walks the content rune by rune, tracking the position.
+type scanner struct {
+	column int
+	line   int
+	pos    int
+	runes  []rune
+}
+
+//////
+// Helpers.
+//////
+
+// parse parses `content` following the dotenv grammar:
+//   - Blank lines, and lines starting with `#` are ignored
+//   - `export` before the key is ignored
+//   - Unquoted values end at an inline comment (` #`), and are trimmed
+//   - Single-quoted values are literal
+//   - Double-quoted values support `\n`, `\r`, `\t`, `\"`, `\\`, and `\$`
+//     escapes
+//   - Quoted values can span multiple lines
+//   - Unquoted, and double-quoted values expand `${VAR}`, `${VAR:-default}`,
+//     and `$VAR`, looking up keys defined earlier in the content, then the
+//     environment. Escape the `$` with `\$` to keep it literal
+//   - Keys defined twice take the last value, like godotenv. References
+//     before the redefinition expand to the previous one
+func parse(content string) (map[string]any, error) {
+	s := &scanner{runes: []rune(content), line: 1, column: 1}
+
+	values := map[string]any{}
+
+	for {
+		s.skipBlank()
+
+		if s.eof() {
+			return values, nil
+		}
+
+		// Full-line comment.
+		if s.peek() == '#' {
+			s.skipLine()
+
+			continue
+		}
+
+		key, err := s.key()
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := s.value(values)
+		if err != nil {
+			return nil, err
+		}
+
+		values[key] = value
+	}
+}
+
+// eof reports whether the whole content was consumed.
+func (s *scanner) eof() bool {
+	return s.pos >= len(s.runes)
+}
+
+// peek returns the current rune, or 0 at the end.
+func (s *scanner) peek() rune {
+	if s.eof() {
+		return 0
+	}
+
+	return s.runes[s.pos]
+}
+
+// next consumes, and returns the current rune.
+func (s *scanner) next() rune {
+	r := s.runes[s.pos]
+
+	s.pos++
+
+	if r == '\n' {
+		s.line++
+		s.column = 1
+	} else {
+		s.column++
+	}
+
+	return r
+}
+
+// errorf returns a `SyntaxError` at the current position.
+func (s *scanner) errorf(format string, args ...any) error {
+	return s.errorAt(s.line, s.column, format, args...)
+}
+
+// errorAt returns a `SyntaxError` at `line`, and `column`.
+func (s *scanner) errorAt(line, column int, format string, args ...any) error {
+	return &SyntaxError{Line: line, Column: column, Message: fmt.Sprintf(format, args...)}
+}
+
+// skipBlank skips whitespace, including new lines.
+func (s *scanner) skipBlank() {
+	for !s.eof() && unicode.IsSpace(s.peek()) {
+		s.next()
+	}
+}
+
+// skipSpaces skips whitespace, excluding new lines.
+func (s *scanner) skipSpaces() {
+	for !s.eof() && s.peek() != '\n' && unicode.IsSpace(s.peek()) {
+		s.next()
+	}
+}
+
+// skipLine skips to the beginning of the next line.
+func (s *scanner) skipLine() {
+	for !s.eof() {
+		if s.next() == '\n' {
+			return
+		}
+	}
+}
+
+// endOfLine consumes the rest of the line after a value, which can only hold
+// whitespace, and a comment.
+func (s *scanner) endOfLine() error {
+	s.skipSpaces()
+
+	switch {
+	case s.eof():
+	case s.peek() == '#':
+		s.skipLine()
+	case s.peek() == '\n':
+		s.next()
+	default:
+		return s.errorf("unexpected character %q after value", s.peek())
+	}
+
+	return nil
+}
+
+// key parses `[export] KEY =`.
+func (s *scanner) key() (string, error) {
+	line, column := s.line, s.column
+
+	key := s.identifier()
+
+	// `export` prefix.
+	if key == "export" && !s.eof() && (s.peek() == ' ' || s.peek() == '\t') {
+		s.skipSpaces()
+
+		line, column = s.line, s.column
+
+		key = s.identifier()
+	}
+
+	if key == "" {
+		if s.eof() || s.peek() == '\n' {
+			return "", s.errorf("expected key")
+		}
+
+		return "", s.errorf("unexpected character %q, expected key", s.peek())
+	}
+
+	if unicode.IsDigit([]rune(key)[0]) {
+		return "", s.errorAt(line, column, "invalid key %q, must not start with a digit", key)
+	}
+
+	s.skipSpaces()
+
+	if s.eof() || s.peek() != '=' {
+		if s.eof() || s.peek() == '\n' {
+			return "", s.errorf("expected \"=\" after key %q", key)
+		}
+
+		return "", s.errorf("unexpected character %q in key %q, expected \"=\"", s.peek(), key)
+	}
+
+	s.next()
+
+	return key, nil
+}
+
+// identifier consumes `[A-Za-z0-9_.-]*`.
+func (s *scanner) identifier() string {
+	var b strings.Builder
+
+	for !s.eof() {
+		r := s.peek()
+
+		if r != '_' && r != '.' && r != '-' && !isASCIILetterOrDigit(r) {
+			break
+		}
+
+		b.WriteRune(s.next())
+	}
+
+	return b.String()
+}
+
+// value parses the value after `=`, up to the end of the line.
+func (s *scanner) value(values map[string]any) (string, error) {
+	s.skipSpaces()
+
+	switch s.peek() {
+	case '\'':
+		return s.singleQuoted()
+	case '"':
+		return s.doubleQuoted(values)
+	default:
+		return s.unquoted(values)
+	}
+}
+
+// singleQuoted parses a literal value.
+func (s *scanner) singleQuoted() (string, error) {
+	line, column := s.line, s.column
+
+	s.next()
+
+	var b strings.Builder
+
+	for {
+		if s.eof() {
+			return "", s.errorAt(line, column, "unterminated single-quoted value")
+		}
+
+		r := s.next()
+		if r == '\'' {
+			break
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String(), s.endOfLine()
+}
+
+// doubleQuoted parses a value with escapes, and references.
+func (s *scanner) doubleQuoted(values map[string]any) (string, error) {
+	line, column := s.line, s.column
+
+	s.next()
+
+	var b strings.Builder
+
+	for {
+		if s.eof() {
+			return "", s.errorAt(line, column, "unterminated double-quoted value")
+		}
+
+		switch r := s.peek(); r {
+		case '"':
+			s.next()
+
+			return b.String(), s.endOfLine()
+		case '\\':
+			escaped, err := s.escape()
+			if err != nil {
+				return "", err
+			}
+
+			b.WriteString(escaped)
+		case '$':
+			ref, err := s.reference(values)
+			if err != nil {
+				return "", err
+			}
+
+			b.WriteString(ref)
+		default:
+			b.WriteRune(s.next())
+		}
+	}
+}
+
+// unquoted parses a value up to the end of the line, or an inline comment.
+func (s *scanner) unquoted(values map[string]any) (string, error) {
+	var b strings.Builder
+
+	for !s.eof() && s.peek() != '\n' {
+		r := s.peek()
+
+		// Inline comment, only if preceded by whitespace.
+		if r == '#' && unicode.IsSpace(s.runes[s.pos-1]) {
+			s.skipLine()
+
+			return strings.TrimSpace(b.String()), nil
+		}
+
+		switch {
+		case r == '\\' && s.pos+1 < len(s.runes) && s.runes[s.pos+1] == '$':
+			s.next()
+			b.WriteRune(s.next())
+		case r == '$':
+			ref, err := s.reference(values)
+			if err != nil {
+				return "", err
+			}
+
+			b.WriteString(ref)
+		default:
+			b.WriteRune(s.next())
+		}
+	}
+
+	if !s.eof() {
+		s.next()
+	}
+
+	return strings.TrimSpace(b.String()), nil
+}
+
+// escape parses an escape sequence in a double-quoted value.
+func (s *scanner) escape() (string, error) {
+	line, column := s.line, s.column
+
+	s.next()
+
+	if s.eof() {
+		return "", s.errorAt(line, column, "unterminated escape sequence")
+	}
+
+	switch r := s.next(); r {
+	case 'n':
+		return "\n", nil
+	case 'r':
+		return "\r", nil
+	case 't':
+		return "\t", nil
+	case '"', '\\', '$', '\'':
+		return string(r), nil
+	default:
+		return "", s.errorAt(line, column, "invalid escape sequence \"\\%c\"", r)
+	}
+}
+
+// reference parses `${VAR}`, `${VAR:-default}`, or `$VAR`. A lone `$` is
+// literal.
+func (s *scanner) reference(values map[string]any) (string, error) {
+	line, column := s.line, s.column
+
+	s.next()
+
+	if s.peek() != '{' {
+		name := s.variable()
+		if name == "" {
+			return "$", nil
+		}
+
+		return lookup(name, values), nil
+	}
+
+	s.next()
+
+	name := s.variable()
+	if name == "" {
+		return "", s.errorf("invalid variable name in reference")
+	}
+
+	fallback := ""
+	hasFallback := false
+
+	if s.peek() == ':' && s.pos+1 < len(s.runes) && s.runes[s.pos+1] == '-' {
+		s.next()
+		s.next()
+
+		hasFallback = true
+
+		var b strings.Builder
+
+		for !s.eof() && s.peek() != '}' && s.peek() != '\n' {
+			b.WriteRune(s.next())
+		}
+
+		fallback = b.String()
+	}
+
+	if s.eof() || s.peek() != '}' {
+		return "", s.errorAt(line, column, "unterminated reference to %q", name)
+	}
+
+	s.next()
+
+	value := lookup(name, values)
+	if value == "" && hasFallback {
+		return fallback, nil
+	}
+
+	return value, nil
+}
+
+// variable consumes `[A-Za-z_][A-Za-z0-9_]*`.
+func (s *scanner) variable() string {
+	var b strings.Builder
+
+	for !s.eof() {
+		r := s.peek()
+
+		if r != '_' && !isASCIILetterOrDigit(r) || (b.Len() == 0 && unicode.IsDigit(r)) {
+			break
+		}
+
+		b.WriteRune(s.next())
+	}
+
+	return b.String()
+}
+
+// lookup resolves `name` from the keys defined so far, then the environment.
+func lookup(name string, values map[string]any) string {
+	if v, ok := values[name]; ok {
+		return fmt.Sprint(v)
+	}
+
+	return os.Getenv(name)
+}
+
+// isASCIILetterOrDigit reports whether `r` is `[A-Za-z0-9]`.
+func isASCIILetterOrDigit(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
@@ -0,0 +1,183 @@
+package env
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thalesfsp/configurer/internal/testenv"
+)
+
+//////
+// Grammar.
+//////
+
+func TestENVReadGrammar(t *testing.T) {
+	testenv.Set(t, "GRAMMAR_FROM_ENV", "env")
+	testenv.Unset(t, "GRAMMAR_UNSET")
+
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]any
+	}{
+		{
+			name:    "export prefix",
+			content: "export FOO=bar\nexport\tBAZ = qux\nexport=value\n",
+			want:    map[string]any{"FOO": "bar", "BAZ": "qux", "export": "value"},
+		},
+		{
+			name:    "duplicate keys, last one wins",
+			content: "FOO=bar\nREF=${FOO}\n# comment\nexport FOO=baz\n",
+			want:    map[string]any{"FOO": "baz", "REF": "bar"},
+		},
+		{
+			name:    "single quotes are literal",
+			content: `FOO='a \n ${GRAMMAR_FROM_ENV} # not a comment'`,
+			want:    map[string]any{"FOO": `a \n ${GRAMMAR_FROM_ENV} # not a comment`},
+		},
+		{
+			name:    "double quotes escapes",
+			content: `FOO="tab\there\nnew \"quoted\" \\ \$HOME \'"`,
+			want:    map[string]any{"FOO": "tab\there\nnew \"quoted\" \\ $HOME '"},
+		},
+		{
+			name:    "inline comments",
+			content: "FOO=bar # comment\nBAR=\"baz\" # comment\nBAZ='qux'   # comment\nHASH=a#b\nEMPTY= # comment\nLEADING=#value\n",
+			want: map[string]any{
+				"FOO":     "bar",
+				"BAR":     "baz",
+				"BAZ":     "qux",
+				"HASH":    "a#b",
+				"EMPTY":   "",
+				"LEADING": "#value",
+			},
+		},
+		{
+			name:    "multi-line quoted values",
+			content: "CERT=\"-----BEGIN-----\nline\n-----END-----\"\nLITERAL='a\nb'\nNEXT=value\n",
+			want: map[string]any{
+				"CERT":    "-----BEGIN-----\nline\n-----END-----",
+				"LITERAL": "a\nb",
+				"NEXT":    "value",
+			},
+		},
+		{
+			name: "references",
+			content: "HOST=localhost\nPORT=5432\nURL=\"postgres://${HOST}:$PORT/db\"\nRAW=${GRAMMAR_FROM_ENV}-x\n" +
+				"MISSING=${GRAMMAR_UNSET}\nFALLBACK=${GRAMMAR_UNSET:-fallback}\nSET=${HOST:-fallback}\nESCAPED=\\${HOST}\nDOLLAR=cost $ 5\n",
+			want: map[string]any{
+				"HOST":     "localhost",
+				"PORT":     "5432",
+				"URL":      "postgres://localhost:5432/db",
+				"RAW":      "env-x",
+				"MISSING":  "",
+				"FALLBACK": "fallback",
+				"SET":      "localhost",
+				"ESCAPED":  "${HOST}",
+				"DOLLAR":   "cost $ 5",
+			},
+		},
+		{
+			name:    "whitespace, and CRLF",
+			content: "  FOO = bar  \r\n\r\n\tBAR=\"baz\"\r\nDOTTED.KEY-1=x\n",
+			want:    map[string]any{"FOO": "bar", "BAR": "baz", "DOTTED.KEY-1": "x"},
+		},
+		{
+			name:    "empty values",
+			content: "FOO=\nBAR=\"\"\nBAZ=''",
+			want:    map[string]any{"FOO": "", "BAR": "", "BAZ": ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New()
+			require.NoError(t, err)
+
+			got, err := p.Read(context.Background(), strings.NewReader(tt.content))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestENVReadSyntaxErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    SyntaxError
+	}{
+		{
+			name:    "missing separator",
+			content: "FOO=bar\nMISSING\n",
+			want:    SyntaxError{Line: 2, Column: 8, Message: `expected "=" after key "MISSING"`},
+		},
+		{
+			name:    "invalid key character",
+			content: "FOO BAR=baz",
+			want:    SyntaxError{Line: 1, Column: 5, Message: `unexpected character 'B' in key "FOO", expected "="`},
+		},
+		{
+			name:    "missing key",
+			content: "=value",
+			want:    SyntaxError{Line: 1, Column: 1, Message: `unexpected character '=', expected key`},
+		},
+		{
+			name:    "key starting with a digit",
+			content: "1FOO=bar",
+			want:    SyntaxError{Line: 1, Column: 1, Message: `invalid key "1FOO", must not start with a digit`},
+		},
+		{
+			name:    "unterminated double quote",
+			content: "FOO=bar\nBAR=\"baz\n\n",
+			want:    SyntaxError{Line: 2, Column: 5, Message: "unterminated double-quoted value"},
+		},
+		{
+			name:    "unterminated single quote",
+			content: "FOO='bar",
+			want:    SyntaxError{Line: 1, Column: 5, Message: "unterminated single-quoted value"},
+		},
+		{
+			name:    "content after closing quote",
+			content: "FOO=\"bar\"baz",
+			want:    SyntaxError{Line: 1, Column: 10, Message: `unexpected character 'b' after value`},
+		},
+		{
+			name:    "invalid escape",
+			content: `FOO="\x"`,
+			want:    SyntaxError{Line: 1, Column: 6, Message: `invalid escape sequence "\x"`},
+		},
+		{
+			name:    "unterminated reference",
+			content: "FOO=${BAR",
+			want:    SyntaxError{Line: 1, Column: 5, Message: `unterminated reference to "BAR"`},
+		},
+		{
+			name:    "invalid reference",
+			content: "FOO=${}",
+			want:    SyntaxError{Line: 1, Column: 7, Message: "invalid variable name in reference"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New()
+			require.NoError(t, err)
+
+			got, err := p.Read(context.Background(), strings.NewReader(tt.content))
+			require.Error(t, err)
+			assert.Nil(t, got)
+
+			var syntaxErr *SyntaxError
+
+			require.True(t, errors.As(err, &syntaxErr))
+			assert.Equal(t, tt.want, *syntaxErr)
+			assert.Equal(t, fmt.Sprintf("line %d, column %d: %s", tt.want.Line, tt.want.Column, tt.want.Message), err.Error())
+		})
+	}
+}
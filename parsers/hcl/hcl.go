@@ -0,0 +1,133 @@
+package hcl
+
+import (
+	"context"
+	"io"
+
+	"github.com/hashicorp/hcl"
+	"github.com/thalesfsp/configurer/parser"
+	"github.com/thalesfsp/validation"
+)
+
+//////
+// Vars, consts, and types.
+//////
+
+// Name of the parser.
+const Name = "hcl"
+
+var newBaseParser = parser.New
+
+// HCL parser.
+type HCL struct {
+	*parser.Parser `validate:"required"`
+}
+
+//////
+// Methods.
+//////
+
+// Read implementation of the Reader interface for HCL files, e.g.: Terraform
+// `.tfvars`, and Nomad variable files. Blocks are nested maps, e.g.:
+// `database { host = "x" }` becomes `{database: {host: x}}`. Blocks declared
+// more than once are arrays.
+//
+// NOTE: Expressions, and functions aren't evaluated, only literals are
+// supported.
+func (h *HCL) Read(ctx context.Context, r io.Reader) (map[string]any, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]any)
+
+	if err := hcl.UnmarshalErrorOnDuplicates(content, &values); err != nil {
+		return nil, err
+	}
+
+	return normalize(values).(map[string]any), nil
+}
+
+// Sniff implements `parser.ISniffer`. HCL is a superset of JSON, and accepts
+// most env content, so it takes no part in detection.
+func (h *HCL) Sniff(_ []byte) bool {
+	return false
+}
+
+//////
+// Helpers.
+//////
+
+// normalize unwraps blocks, decoded as single-element lists of maps, into
+// maps, recursively.
+func normalize(v any) any {
+	switch value := v.(type) {
+	case map[string]any:
+		for k, child := range value {
+			value[k] = normalize(child)
+		}
+
+		return value
+	case []map[string]any:
+		if len(value) == 1 {
+			return normalize(value[0])
+		}
+
+		list := make([]any, 0, len(value))
+		for _, child := range value {
+			list = append(list, normalize(child))
+		}
+
+		return list
+	case []any:
+		for i, child := range value {
+			value[i] = normalize(child)
+		}
+
+		return value
+	default:
+		return v
+	}
+}
+
+//////
+// Factory.
+//////
+
+// New creates a new converter.
+func New() (*HCL, error) {
+	// Enforces interface implementation.
+	var _ parser.IParser = (*HCL)(nil)
+
+	p, err := newBaseParser(Name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parser instance.
+	pI := &HCL{
+		Parser: p,
+	}
+
+	// Validation.
+	if err := validation.Validate(pI); err != nil {
+		return nil, err
+	}
+
+	return pI, nil
+}
+
+// Registers the parser, making it available by name, and extension.
+func init() {
+	if err := parser.Register(Name, []string{"hcl", "tfvars", "nomad"}, func() (parser.IParser, error) {
+		p, err := New()
+		if err != nil {
+			return nil, err
+		}
+
+		return p, nil
+	}); err != nil {
+		panic(err)
+	}
+}
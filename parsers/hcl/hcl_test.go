@@ -0,0 +1,134 @@
+package hcl
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thalesfsp/configurer/parser"
+)
+
+//////
+// Factory.
+//////
+
+func TestNew(t *testing.T) {
+	got, err := New()
+
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	require.NotNil(t, got.Parser)
+	assert.Equal(t, Name, got.Name)
+}
+
+func TestNewBaseParserError(t *testing.T) {
+	wantErr := errors.New("base parser failed")
+	original := newBaseParser
+	t.Cleanup(func() {
+		newBaseParser = original
+	})
+	newBaseParser = func(_ string) (*parser.Parser, error) {
+		return nil, wantErr
+	}
+
+	got, err := New()
+
+	require.ErrorIs(t, err, wantErr)
+	assert.Nil(t, got)
+}
+
+//////
+// Read.
+//////
+
+func TestHCLRead(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]any
+		wantErr bool
+	}{
+		{
+			name: "tfvars",
+			content: `region   = "us-east-1"
+replicas = 3
+enabled  = true
+zones    = ["a", "b"]
+tags = {
+  team = "platform"
+}
+`,
+			want: map[string]any{
+				"region":   "us-east-1",
+				"replicas": 3,
+				"enabled":  true,
+				"zones":    []any{"a", "b"},
+				"tags":     map[string]any{"team": "platform"},
+			},
+		},
+		{
+			name: "blocks",
+			content: `database {
+  host = "localhost"
+}
+
+service "api" {
+  port = 8080
+}
+
+rule { name = "a" }
+rule { name = "b" }
+`,
+			want: map[string]any{
+				"database": map[string]any{"host": "localhost"},
+				"service":  map[string]any{"api": map[string]any{"port": 8080}},
+				"rule": []any{
+					map[string]any{"name": "a"},
+					map[string]any{"name": "b"},
+				},
+			},
+		},
+		{
+			name:    "heredoc",
+			content: "script = <<EOF\necho hi\nEOF\n",
+			want:    map[string]any{"script": "echo hi\n"},
+		},
+		{
+			name:    "duplicate key",
+			content: "a = 1\na = 2\n",
+			wantErr: true,
+		},
+		{
+			name:    "malformed input",
+			content: `name = "unterminated`,
+			wantErr: true,
+		},
+		{
+			name:    "empty input",
+			content: "",
+			want:    map[string]any{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New()
+			require.NoError(t, err)
+
+			got, err := p.Read(context.Background(), strings.NewReader(tt.content))
+
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Nil(t, got)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package ini
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/thalesfsp/configurer/parser"
+	"github.com/thalesfsp/validation"
+)
+
+//////
+// Vars, consts, and types.
+//////
+
+// Name of the parser.
+const Name = "ini"
+
+var newBaseParser = parser.New
+
+// INI parser.
+type INI struct {
+	*parser.Parser `validate:"required"`
+}
+
+//////
+// Methods.
+//////
+
+// Read implementation of the Reader interface for INI files:
+//   - Lines starting with `;`, or `#` are comments, inline comments must be
+//     preceded by whitespace
+//   - Key, and value are separated by `=`, or `:`
+//   - Values wrapped in matching single, or double quotes are unquoted
+//   - Keys under a `[section]` are nested, e.g.: `{section: {key: value}}`.
+//     Keys before the first section are top-level. Sections named more than
+//     once are merged.
+func (i *INI) Read(ctx context.Context, r io.Reader) (map[string]any, error) {
+	values := make(map[string]any)
+	scanner := bufio.NewScanner(r)
+
+	current := values
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber++
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == ';' || line[0] == '#' {
+			continue
+		}
+
+		if line[0] == '[' {
+			end := strings.IndexByte(line, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated section %q", lineNumber, line)
+			}
+
+			name := strings.TrimSpace(line[1:end])
+			if name == "" {
+				return nil, fmt.Errorf("line %d: empty section name", lineNumber)
+			}
+
+			if rest := stripComment(line[end+1:]); rest != "" {
+				return nil, fmt.Errorf("line %d: unexpected %q after section", lineNumber, rest)
+			}
+
+			section, ok := values[name].(map[string]any)
+			if !ok {
+				if _, exists := values[name]; exists {
+					return nil, fmt.Errorf("line %d: section %q conflicts with a key", lineNumber, name)
+				}
+
+				section = map[string]any{}
+
+				values[name] = section
+			}
+
+			current = section
+
+			continue
+		}
+
+		separator := strings.IndexAny(line, "=:")
+		if separator < 0 {
+			return nil, fmt.Errorf("line %d: invalid line %q, expected key = value", lineNumber, line)
+		}
+
+		key := strings.TrimSpace(line[:separator])
+		if key == "" {
+			return nil, fmt.Errorf("line %d: missing key", lineNumber)
+		}
+
+		current[key] = unquote(stripComment(line[separator+1:]))
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// Sniff implements `parser.ISniffer`. TOML, and env content is often valid INI,
+// so it takes no part in detection.
+func (i *INI) Sniff(_ []byte) bool {
+	return false
+}
+
+//////
+// Helpers.
+//////
+
+// stripComment removes an inline comment, and trims `value`. Quoted values are
+// kept whole.
+func stripComment(value string) string {
+	value = strings.TrimSpace(value)
+
+	if strings.HasPrefix(value, ";") || strings.HasPrefix(value, "#") {
+		return ""
+	}
+
+	if len(value) > 1 && (value[0] == '"' || value[0] == '\'') {
+		if end := strings.IndexByte(value[1:], value[0]); end >= 0 {
+			return value[:end+2]
+		}
+	}
+
+	for i := 1; i < len(value); i++ {
+		if (value[i] == ';' || value[i] == '#') && (value[i-1] == ' ' || value[i-1] == '\t') {
+			return strings.TrimSpace(value[:i])
+		}
+	}
+
+	return value
+}
+
+// unquote removes matching single, or double quotes around `value`.
+func unquote(value string) string {
+	if len(value) > 1 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+
+	return value
+}
+
+//////
+// Factory.
+//////
+
+// New creates a new converter.
+func New() (*INI, error) {
+	// Enforces interface implementation.
+	var _ parser.IParser = (*INI)(nil)
+
+	p, err := newBaseParser(Name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parser instance.
+	pI := &INI{
+		Parser: p,
+	}
+
+	// Validation.
+	if err := validation.Validate(pI); err != nil {
+		return nil, err
+	}
+
+	return pI, nil
+}
+
+// Registers the parser, making it available by name, and extension.
+func init() {
+	if err := parser.Register(Name, []string{"ini", "cfg"}, func() (parser.IParser, error) {
+		p, err := New()
+		if err != nil {
+			return nil, err
+		}
+
+		return p, nil
+	}); err != nil {
+		panic(err)
+	}
+}
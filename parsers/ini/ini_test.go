@@ -0,0 +1,151 @@
+package ini
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thalesfsp/configurer/parser"
+)
+
+//////
+// Factory.
+//////
+
+func TestNew(t *testing.T) {
+	got, err := New()
+
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	require.NotNil(t, got.Parser)
+	assert.Equal(t, Name, got.Name)
+}
+
+func TestNewBaseParserError(t *testing.T) {
+	wantErr := errors.New("base parser failed")
+	original := newBaseParser
+	t.Cleanup(func() {
+		newBaseParser = original
+	})
+	newBaseParser = func(_ string) (*parser.Parser, error) {
+		return nil, wantErr
+	}
+
+	got, err := New()
+
+	require.ErrorIs(t, err, wantErr)
+	assert.Nil(t, got)
+}
+
+//////
+// Read.
+//////
+
+func TestINIRead(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]any
+		wantErr string
+	}{
+		{
+			name: "sections",
+			content: `; global
+name = configurer
+
+[database]
+host: localhost ; inline comment
+password = "p;a ss#"
+url = http://host/#fragment
+
+# repeated sections are merged
+[database]
+port = 5432
+`,
+			want: map[string]any{
+				"name": "configurer",
+				"database": map[string]any{
+					"host":     "localhost",
+					"password": "p;a ss#",
+					"url":      "http://host/#fragment",
+					"port":     "5432",
+				},
+			},
+		},
+		{
+			name:    "empty value",
+			content: "a =\nb = ; comment\n",
+			want:    map[string]any{"a": "", "b": ""},
+		},
+		{
+			name:    "unterminated section",
+			content: "a = 1\n[database\n",
+			wantErr: "line 2: unterminated section",
+		},
+		{
+			name:    "empty section name",
+			content: "[ ]\n",
+			wantErr: "line 1: empty section name",
+		},
+		{
+			name:    "content after section",
+			content: "[a] b\n",
+			wantErr: "line 1: unexpected",
+		},
+		{
+			name:    "missing separator",
+			content: "\n\nkey\n",
+			wantErr: "line 3: invalid line",
+		},
+		{
+			name:    "missing key",
+			content: "= value\n",
+			wantErr: "line 1: missing key",
+		},
+		{
+			name:    "section conflicts with key",
+			content: "a = 1\n[a]\n",
+			wantErr: "line 2: section \"a\" conflicts with a key",
+		},
+		{
+			name:    "same key in different sections",
+			content: "[a]\n[b]\na = 1\n",
+			want:    map[string]any{"a": map[string]any{}, "b": map[string]any{"a": "1"}},
+		},
+		{
+			name:    "empty input",
+			content: "",
+			want:    map[string]any{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New()
+			require.NoError(t, err)
+
+			got, err := p.Read(context.Background(), strings.NewReader(tt.content))
+
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				assert.Nil(t, got)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestINISniff(t *testing.T) {
+	p, err := New()
+	require.NoError(t, err)
+
+	assert.False(t, p.Sniff([]byte("[section]\na = 1\n")))
+}
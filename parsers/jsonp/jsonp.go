@@ -64,3 +64,17 @@ func New() (*JSON, error) {
 
 	return pI, nil
 }
+
+// Registers the parser, making it available by name, and extension.
+func init() {
+	if err := parser.Register(Name, []string{"json"}, func() (parser.IParser, error) {
+		p, err := New()
+		if err != nil {
+			return nil, err
+		}
+
+		return p, nil
+	}); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,221 @@
+package properties
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/thalesfsp/configurer/parser"
+	"github.com/thalesfsp/validation"
+)
+
+//////
+// Vars, consts, and types.
+//////
+
+// Name of the parser.
+const Name = "properties"
+
+var newBaseParser = parser.New
+
+// Properties parser.
+type Properties struct {
+	*parser.Parser `validate:"required"`
+}
+
+//////
+// Methods.
+//////
+
+// Read implementation of the Reader interface for Java `.properties` files:
+//   - Lines starting with `#`, or `!` are comments
+//   - Key, and value are separated by `=`, `:`, or whitespace
+//   - A line ending with an odd number of `\` continues on the next line
+//   - `\t`, `\n`, `\r`, `\f`, and `\uXXXX` are unescaped, any other escaped
+//     character is itself, e.g.: `\=`, and `\ ` in keys.
+//
+// SEE: https://docs.oracle.com/javase/8/docs/api/java/util/Properties.html#load-java.io.Reader-
+func (p *Properties) Read(ctx context.Context, r io.Reader) (map[string]any, error) {
+	values := make(map[string]any)
+	scanner := bufio.NewScanner(r)
+
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber++
+
+		line := strings.TrimLeft(scanner.Text(), " \t\f")
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+
+		start := lineNumber
+
+		// Logical line, joining continuations.
+		for continues(line) && scanner.Scan() {
+			lineNumber++
+
+			line = line[:len(line)-1] + strings.TrimLeft(scanner.Text(), " \t\f")
+		}
+
+		if continues(line) {
+			line = line[:len(line)-1]
+		}
+
+		key, value := split(line)
+
+		k, err := unescape(key)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", start, err)
+		}
+
+		v, err := unescape(value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", start, err)
+		}
+
+		values[k] = v
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// Sniff implements `parser.ISniffer`. Its grammar accepts most content, so it
+// takes no part in detection.
+func (p *Properties) Sniff(_ []byte) bool {
+	return false
+}
+
+//////
+// Helpers.
+//////
+
+// continues reports whether `line` ends with an odd number of `\`.
+func continues(line string) bool {
+	count := 0
+
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		count++
+	}
+
+	return count%2 == 1
+}
+
+// split splits the logical `line` on the first unescaped separator.
+func split(line string) (string, string) {
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			// Skip the escaped character.
+			i++
+		case '=', ':':
+			return line[:i], strings.TrimLeft(line[i+1:], " \t\f")
+		case ' ', '\t', '\f':
+			value := strings.TrimLeft(line[i:], " \t\f")
+
+			// Whitespace, optionally followed by `=`, or `:`.
+			if value != "" && (value[0] == '=' || value[0] == ':') {
+				value = strings.TrimLeft(value[1:], " \t\f")
+			}
+
+			return line[:i], value
+		}
+	}
+
+	return line, ""
+}
+
+// unescape resolves the escape sequences in `s`.
+func unescape(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+
+			continue
+		}
+
+		i++
+
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if i+5 > len(s) {
+				return "", fmt.Errorf("invalid unicode escape %q", s[i-1:])
+			}
+
+			code, err := strconv.ParseUint(s[i+1:i+5], 16, 32)
+			if err != nil {
+				return "", fmt.Errorf("invalid unicode escape %q", s[i-1:i+5])
+			}
+
+			b.WriteRune(rune(code))
+
+			i += 4
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+
+	return b.String(), nil
+}
+
+//////
+// Factory.
+//////
+
+// New creates a new converter.
+func New() (*Properties, error) {
+	// Enforces interface implementation.
+	var _ parser.IParser = (*Properties)(nil)
+
+	p, err := newBaseParser(Name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parser instance.
+	pI := &Properties{
+		Parser: p,
+	}
+
+	// Validation.
+	if err := validation.Validate(pI); err != nil {
+		return nil, err
+	}
+
+	return pI, nil
+}
+
+// Registers the parser, making it available by name, and extension.
+func init() {
+	if err := parser.Register(Name, []string{"properties"}, func() (parser.IParser, error) {
+		p, err := New()
+		if err != nil {
+			return nil, err
+		}
+
+		return p, nil
+	}); err != nil {
+		panic(err)
+	}
+}
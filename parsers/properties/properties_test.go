@@ -0,0 +1,134 @@
+package properties
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thalesfsp/configurer/parser"
+)
+
+//////
+// Factory.
+//////
+
+func TestNew(t *testing.T) {
+	got, err := New()
+
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	require.NotNil(t, got.Parser)
+	assert.Equal(t, Name, got.Name)
+}
+
+func TestNewBaseParserError(t *testing.T) {
+	wantErr := errors.New("base parser failed")
+	original := newBaseParser
+	t.Cleanup(func() {
+		newBaseParser = original
+	})
+	newBaseParser = func(_ string) (*parser.Parser, error) {
+		return nil, wantErr
+	}
+
+	got, err := New()
+
+	require.ErrorIs(t, err, wantErr)
+	assert.Nil(t, got)
+}
+
+//////
+// Read.
+//////
+
+func TestPropertiesRead(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]any
+		wantErr string
+	}{
+		{
+			name: "separators",
+			content: `# comment
+! also a comment
+equals=1
+colon: 2
+space 3
+  padded   =   4
+empty
+`,
+			want: map[string]any{
+				"equals": "1",
+				"colon":  "2",
+				"space":  "3",
+				"padded": "4",
+				"empty":  "",
+			},
+		},
+		{
+			name:    "continuation",
+			content: "fruits = apple, \\\n         banana, \\\n         pear\nnext = 1\n",
+			want: map[string]any{
+				"fruits": "apple, banana, pear",
+				"next":   "1",
+			},
+		},
+		{
+			name:    "escaped backslash isn't a continuation",
+			content: "path = c:\\\\dir\\\\\nnext = 1\n",
+			want: map[string]any{
+				"path": `c:\dir\`,
+				"next": "1",
+			},
+		},
+		{
+			name:    "continuation at end of input",
+			content: "a = 1\\",
+			want:    map[string]any{"a": "1"},
+		},
+		{
+			name:    "escapes",
+			content: "key\\ with\\=sep = tab\\tnew\\nline \\u00e9\\q\n",
+			want:    map[string]any{"key with=sep": "tab\tnew\nline éq"},
+		},
+		{
+			name:    "truncated unicode escape",
+			content: "a = 1\nb = \\u00\n",
+			wantErr: "line 2: invalid unicode escape",
+		},
+		{
+			name:    "invalid unicode escape",
+			content: "\\uzzzz = 1\n",
+			wantErr: "line 1: invalid unicode escape",
+		},
+		{
+			name:    "empty input",
+			content: "",
+			want:    map[string]any{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New()
+			require.NoError(t, err)
+
+			got, err := p.Read(context.Background(), strings.NewReader(tt.content))
+
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				assert.Nil(t, got)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
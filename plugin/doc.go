@@ -0,0 +1,18 @@
+// Package plugin provides a provider for external plugins: executables named
+// `configurer-provider-<name>`, on PATH, speaking JSON-RPC 2.0 over stdio.
+//
+// Each call starts the plugin, writes one request, a single line, to its
+// stdin, then closes it, reads one response from its stdout, and waits for
+// it to exit. The plugin inherits the environment, and anything written to
+// stderr is included in errors. Methods are:
+//
+//   - load: returns `{"values": {...}}`, nested values are allowed.
+//   - write: stores `params.values`.
+//   - list: returns `{"keys": [...]}`.
+//   - delete: removes `params.keys`.
+//
+// Every request carries `params.protocolVersion`, and `params.settings`, the
+// plugin-specific settings. Plugins report unsupported methods with the
+// `CodeMethodNotFound` error code. `Serve` implements the protocol for
+// plugins written in Go.
+package plugin
@@ -0,0 +1,336 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/thalesfsp/configurer/option"
+	"github.com/thalesfsp/configurer/provider"
+	"github.com/thalesfsp/configurer/util"
+	"github.com/thalesfsp/customerror"
+	"github.com/thalesfsp/validation"
+)
+
+//////
+// Vars, consts, and types.
+//////
+
+// Name of the provider.
+const Name = "plugin"
+
+// Prefix of plugin executables, e.g.: `configurer-provider-acme`.
+const Prefix = "configurer-provider-"
+
+// DefaultTimeout is the default timeout of each call.
+const DefaultTimeout = 30 * time.Second
+
+const (
+	// maxMessageSize limits the size of requests, and responses.
+	maxMessageSize = 16 * 1024 * 1024
+
+	// maxStderrLength limits the stderr included in errors.
+	maxStderrLength = 4096
+)
+
+// nameRegex validates plugin names.
+var nameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// Config contains the plugin settings.
+type Config struct {
+	// Name of the plugin, e.g.: `acme` for `configurer-provider-acme`.
+	Name string `json:"name" validate:"required"`
+
+	// Path of the plugin executable. Defaults to `Prefix` + `Name` on PATH.
+	Path string `json:"path"`
+
+	// ParseOptions flattens nested values, e.g.: `database.host` becomes
+	// `DATABASE__HOST`. If not set, nested values are JSON-encoded.
+	ParseOptions []option.ParseFunc `json:"-"`
+
+	// Settings are the plugin-specific settings, sent with every request.
+	Settings map[string]string `json:"-"`
+
+	// Timeout of each call. Defaults to `DefaultTimeout`.
+	Timeout time.Duration `json:"timeout" validate:"gte=0"`
+}
+
+// Plugin provider definition.
+type Plugin struct {
+	*provider.Provider `json:"-" validate:"required"`
+
+	Configuration *Config `json:"-" validate:"required"`
+}
+
+//////
+// IProvider implementation.
+//////
+
+// Load calls the plugin's load method, and exports the values to the
+// environment.
+func (p *Plugin) Load(ctx context.Context, opts ...option.LoadKeyFunc) (map[string]string, error) {
+	parseOptions, err := option.NewParse(p.Configuration.ParseOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	var result LoadResult
+
+	if err := p.call(ctx, MethodLoad, Params{}, &result); err != nil {
+		return nil, err
+	}
+
+	values := result.Values
+
+	if parseOptions.Flatten {
+		if values, err = util.Flatten(values, p.Configuration.ParseOptions...); err != nil {
+			return nil, err
+		}
+	}
+
+	finalValues := make(map[string]string, len(values))
+
+	for key, value := range values {
+		value, err := util.EncodeValue(value)
+		if err != nil {
+			return nil, customerror.NewFailedToError("encode "+key, customerror.WithError(err))
+		}
+
+		// Should allow to specify options.
+		for _, opt := range opts {
+			key = opt(key)
+		}
+
+		finalValue, err := provider.ExportToEnvVar(p, key, value)
+		if err != nil {
+			return nil, err
+		}
+
+		finalValues[key] = finalValue
+	}
+
+	return finalValues, nil
+}
+
+// Write calls the plugin's write method with `values`, and the environment,
+// and target options, if any.
+func (p *Plugin) Write(ctx context.Context, values map[string]interface{}, opts ...option.WriteFunc) error {
+	if values == nil {
+		return customerror.NewRequiredError("values")
+	}
+
+	var options option.Write
+
+	for _, opt := range opts {
+		if err := opt(&options); err != nil {
+			return err
+		}
+	}
+
+	return p.call(ctx, MethodWrite, Params{
+		Environment: options.Environment,
+		Target:      options.Target,
+		Values:      values,
+	}, nil)
+}
+
+//////
+// Exported feature(s).
+//////
+
+// List calls the plugin's list method, and returns the keys.
+func List(ctx context.Context, p *Plugin) ([]string, error) {
+	var result ListResult
+
+	if err := p.call(ctx, MethodList, Params{}, &result); err != nil {
+		return nil, err
+	}
+
+	return result.Keys, nil
+}
+
+// Delete calls the plugin's delete method with `keys`.
+func Delete(ctx context.Context, p *Plugin, keys ...string) error {
+	if len(keys) == 0 {
+		return customerror.NewRequiredError("keys")
+	}
+
+	return p.call(ctx, MethodDelete, Params{Keys: keys}, nil)
+}
+
+// Discover returns the plugins on PATH, their names to their executable
+// paths. Earlier PATH entries win, and invalid names are skipped.
+func Discover() map[string]string {
+	plugins := make(map[string]string)
+
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir == "" {
+			continue
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			name, found := strings.CutPrefix(entry.Name(), Prefix)
+			if !found || !nameRegex.MatchString(name) {
+				continue
+			}
+
+			if _, exists := plugins[name]; exists {
+				continue
+			}
+
+			filePath := filepath.Join(dir, entry.Name())
+
+			// Follows symlinks.
+			info, err := os.Stat(filePath)
+			if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0o111 == 0 {
+				continue
+			}
+
+			plugins[name] = filePath
+		}
+	}
+
+	return plugins
+}
+
+//////
+// Helpers.
+//////
+
+// call runs the plugin with a `method` request, and decodes the result into
+// `result`, if not nil.
+func (p *Plugin) call(ctx context.Context, method string, params Params, result any) error {
+	params.ProtocolVersion = ProtocolVersion
+	params.Settings = p.Configuration.Settings
+
+	request, err := json.Marshal(Request{JSONRPC: "2.0", ID: 1, Method: method, Params: params})
+	if err != nil {
+		return customerror.NewFailedToError("encode "+method+" request", customerror.WithError(err))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.Configuration.Timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, p.Configuration.Path)
+	cmd.Stdin = bytes.NewReader(append(request, '\n'))
+
+	// Don't wait for orphaned grandchildren holding the pipes.
+	cmd.WaitDelay = time.Second
+
+	var stdout, stderr bytes.Buffer
+
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err = cmd.Run()
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return customerror.NewFailedToError(
+			fmt.Sprintf("call %s %s, timed out after %s", p.Configuration.Name, method, p.Configuration.Timeout),
+		)
+	}
+
+	if err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if len(message) > maxStderrLength {
+			message = message[:maxStderrLength] + "..."
+		}
+
+		if message != "" {
+			err = fmt.Errorf("%w: %s", err, message)
+		}
+
+		return customerror.NewFailedToError("call "+p.Configuration.Name+" "+method, customerror.WithError(err))
+	}
+
+	if stdout.Len() > maxMessageSize {
+		return customerror.NewFailedToError(
+			fmt.Sprintf("call %s %s, response larger than %d bytes", p.Configuration.Name, method, maxMessageSize),
+		)
+	}
+
+	var response Response
+
+	if err := json.NewDecoder(&stdout).Decode(&response); err != nil {
+		return customerror.NewFailedToError("decode "+p.Configuration.Name+" "+method+" response", customerror.WithError(err))
+	}
+
+	if response.Error != nil {
+		if response.Error.Code == CodeMethodNotFound {
+			return provider.ErrNotSupported
+		}
+
+		return customerror.NewFailedToError("call "+p.Configuration.Name+" "+method, customerror.WithError(response.Error))
+	}
+
+	if result == nil || len(response.Result) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(response.Result, result); err != nil {
+		return customerror.NewFailedToError("decode "+p.Configuration.Name+" "+method+" result", customerror.WithError(err))
+	}
+
+	return nil
+}
+
+//////
+// Factory.
+//////
+
+// New creates a plugin provider.
+func New(override, rawValue bool, config *Config) (provider.IProvider, error) {
+	if config == nil {
+		return nil, customerror.NewRequiredError("config")
+	}
+
+	if config.Timeout == 0 {
+		config.Timeout = DefaultTimeout
+	}
+
+	if err := validation.Validate(config); err != nil {
+		return nil, err
+	}
+
+	if !nameRegex.MatchString(config.Name) {
+		return nil, customerror.NewInvalidError("name, allowed: lowercase letters, digits, -, and _")
+	}
+
+	if config.Path == "" {
+		path, err := exec.LookPath(Prefix + config.Name)
+		if err != nil {
+			return nil, customerror.NewNotFoundError("plugin "+Prefix+config.Name, customerror.WithError(err))
+		}
+
+		config.Path = path
+	}
+
+	baseProvider, err := provider.New(Name+"-"+config.Name, override, rawValue)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Plugin{
+		Provider:      baseProvider,
+		Configuration: config,
+	}
+
+	if err := validation.Validate(p); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
@@ -0,0 +1,391 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thalesfsp/configurer/option"
+	"github.com/thalesfsp/configurer/provider"
+)
+
+// helperEnv makes the test binary act as the fake plugin.
+const helperEnv = "CONFIGURER_PLUGIN_HELPER"
+
+// fakeHandler stores values in the JSON file of the `state` setting.
+type fakeHandler struct{}
+
+func (fakeHandler) read(settings map[string]string) (map[string]any, error) {
+	values := map[string]any{}
+
+	content, err := os.ReadFile(settings["state"])
+	if errors.Is(err, os.ErrNotExist) {
+		return values, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return values, json.Unmarshal(content, &values)
+}
+
+func (h fakeHandler) save(settings map[string]string, values map[string]any) error {
+	content, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(settings["state"], content, 0o600)
+}
+
+func (h fakeHandler) Load(_ context.Context, settings map[string]string) (map[string]any, error) {
+	if settings["fail"] != "" {
+		return nil, errors.New(settings["fail"])
+	}
+
+	return h.read(settings)
+}
+
+func (h fakeHandler) Write(_ context.Context, settings map[string]string, values map[string]any) error {
+	state, err := h.read(settings)
+	if err != nil {
+		return err
+	}
+
+	for key, value := range values {
+		state[key] = value
+	}
+
+	return h.save(settings, state)
+}
+
+func (h fakeHandler) List(_ context.Context, settings map[string]string) ([]string, error) {
+	state, err := h.read(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(state))
+
+	for key := range state {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	return keys, nil
+}
+
+func (h fakeHandler) Delete(_ context.Context, settings map[string]string, keys []string) error {
+	if settings["readonly"] == "true" {
+		return provider.ErrNotSupported
+	}
+
+	state, err := h.read(settings)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		delete(state, key)
+	}
+
+	return h.save(settings, state)
+}
+
+func TestHelperPlugin(t *testing.T) {
+	if os.Getenv(helperEnv) == "" {
+		return
+	}
+
+	if err := Serve(context.Background(), os.Stdin, os.Stdout, fakeHandler{}); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	os.Exit(0)
+}
+
+// installPlugins writes executable scripts, names to bodies, as plugins in a
+// temporary directory, and returns it.
+func installPlugins(t *testing.T, plugins map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	for name, body := range plugins {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, Prefix+name), []byte("#!/bin/sh\n"+body+"\n"), 0o700))
+	}
+
+	return dir
+}
+
+// fakePlugin installs the test binary as the `fake` plugin, and puts it on
+// PATH.
+func fakePlugin(t *testing.T) {
+	t.Helper()
+
+	t.Setenv(helperEnv, "1")
+
+	dir := installPlugins(t, map[string]string{
+		"fake": fmt.Sprintf("exec %q -test.run='^TestHelperPlugin$'", os.Args[0]),
+	})
+
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+//////
+// Constructor.
+//////
+
+func TestNew(t *testing.T) {
+	fakePlugin(t)
+
+	tests := []struct {
+		name    string
+		config  *Config
+		wantErr string
+	}{
+		{
+			name:   "happy path PATH lookup",
+			config: &Config{Name: "fake"},
+		},
+		{
+			name:   "happy path explicit path",
+			config: &Config{Name: "other", Path: "/usr/local/bin/acme", Timeout: time.Second},
+		},
+		{
+			name:    "bad path nil config",
+			wantErr: "config",
+		},
+		{
+			name:    "bad path missing name",
+			config:  &Config{},
+			wantErr: "Name",
+		},
+		{
+			name:    "bad path invalid name",
+			config:  &Config{Name: "../fake"},
+			wantErr: "name, allowed",
+		},
+		{
+			name:    "bad path not on PATH",
+			config:  &Config{Name: "missing"},
+			wantErr: Prefix + "missing",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(false, false, tt.config)
+
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				assert.Nil(t, got)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, Name+"-"+tt.config.Name, got.GetName())
+			assert.NotEmpty(t, got.(*Plugin).Configuration.Path)
+		})
+	}
+}
+
+//////
+// IProvider implementation.
+//////
+
+func TestLoad(t *testing.T) {
+	fakePlugin(t)
+
+	state := filepath.Join(t.TempDir(), "state.json")
+	require.NoError(t, os.WriteFile(state, []byte(`{"PLUGIN_TOKEN": "t", "PLUGIN_DB": {"host": "h", "port": 5432}, "PLUGIN_NULL": null}`), 0o600))
+
+	dir := installPlugins(t, map[string]string{
+		"crash":   "echo backend unreachable >&2; exit 3",
+		"garbage": "echo not-json",
+		"slow":    "sleep 5",
+	})
+
+	tests := []struct {
+		name    string
+		config  *Config
+		opts    []option.LoadKeyFunc
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name:   "nested values encoded",
+			config: &Config{Name: "fake", Settings: map[string]string{"state": state}},
+			want:   map[string]string{"PLUGIN_TOKEN": "t", "PLUGIN_DB": `{"host":"h","port":5432}`, "PLUGIN_NULL": ""},
+		},
+		{
+			name:   "flattened, with options",
+			config: &Config{Name: "fake", Settings: map[string]string{"state": state}, ParseOptions: []option.ParseFunc{option.WithFlatten(true)}},
+			opts:   []option.LoadKeyFunc{option.WithKeyPrefixer("APP_")},
+			want:   map[string]string{"APP_PLUGIN_TOKEN": "t", "APP_PLUGIN_DB__HOST": "h", "APP_PLUGIN_DB__PORT": "5432", "APP_PLUGIN_NULL": ""},
+		},
+		{
+			name:    "plugin error",
+			config:  &Config{Name: "fake", Settings: map[string]string{"fail": "token expired"}},
+			wantErr: "call fake load",
+		},
+		{
+			name:    "non-zero exit",
+			config:  &Config{Name: "crash", Path: filepath.Join(dir, Prefix+"crash")},
+			wantErr: "exit status 3: backend unreachable",
+		},
+		{
+			name:    "invalid response",
+			config:  &Config{Name: "garbage", Path: filepath.Join(dir, Prefix+"garbage")},
+			wantErr: "decode garbage load response",
+		},
+		{
+			name:    "timeout",
+			config:  &Config{Name: "slow", Path: filepath.Join(dir, Prefix+"slow"), Timeout: 50 * time.Millisecond},
+			wantErr: "timed out after 50ms",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(true, false, tt.config)
+			require.NoError(t, err)
+
+			got, err := p.Load(context.Background(), tt.opts...)
+
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			t.Cleanup(func() {
+				for key := range got {
+					os.Unsetenv(key)
+				}
+			})
+
+			assert.Equal(t, tt.want, got)
+
+			for key, value := range tt.want {
+				assert.Equal(t, value, os.Getenv(key))
+			}
+		})
+	}
+}
+
+func TestWriteListDelete(t *testing.T) {
+	fakePlugin(t)
+
+	settings := map[string]string{"state": filepath.Join(t.TempDir(), "state.json")}
+
+	p, err := New(false, false, &Config{Name: "fake", Settings: settings})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	require.NoError(t, p.Write(ctx, map[string]interface{}{"PLUGIN_A": "1", "PLUGIN_B": 2}))
+
+	keys, err := List(ctx, p.(*Plugin))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"PLUGIN_A", "PLUGIN_B"}, keys)
+
+	require.NoError(t, Delete(ctx, p.(*Plugin), "PLUGIN_A"))
+
+	keys, err = List(ctx, p.(*Plugin))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"PLUGIN_B"}, keys)
+
+	assert.ErrorContains(t, p.Write(ctx, nil), "values")
+	assert.ErrorContains(t, Delete(ctx, p.(*Plugin)), "keys")
+
+	// Unsupported methods.
+	settings["readonly"] = "true"
+
+	assert.ErrorIs(t, Delete(ctx, p.(*Plugin), "PLUGIN_B"), provider.ErrNotSupported)
+}
+
+//////
+// Exported feature(s).
+//////
+
+func TestDiscover(t *testing.T) {
+	first := installPlugins(t, map[string]string{"acme": "exit 0", "Invalid": "exit 0"})
+	second := installPlugins(t, map[string]string{"acme": "exit 0", "corp-kv": "exit 0"})
+
+	require.NoError(t, os.WriteFile(filepath.Join(second, Prefix+"notexec"), []byte("exit 0"), 0o600))
+	require.NoError(t, os.Mkdir(filepath.Join(second, Prefix+"dir"), 0o700))
+	require.NoError(t, os.Symlink(filepath.Join(second, Prefix+"corp-kv"), filepath.Join(first, Prefix+"linked")))
+
+	t.Setenv("PATH", strings.Join([]string{first, "", filepath.Join(first, "missing"), second}, string(os.PathListSeparator)))
+
+	assert.Equal(t, map[string]string{
+		"acme":    filepath.Join(first, Prefix+"acme"),
+		"corp-kv": filepath.Join(second, Prefix+"corp-kv"),
+		"linked":  filepath.Join(first, Prefix+"linked"),
+	}, Discover())
+}
+
+func TestServe(t *testing.T) {
+	settings := map[string]string{"state": filepath.Join(t.TempDir(), "state.json")}
+
+	var requests bytes.Buffer
+
+	encoder := json.NewEncoder(&requests)
+
+	for id, request := range []Request{
+		{JSONRPC: "2.0", Method: MethodWrite, Params: Params{Settings: settings, Values: map[string]any{"A": "1"}}},
+		{JSONRPC: "2.0", Method: MethodLoad, Params: Params{Settings: settings}},
+		{JSONRPC: "2.0", Method: "rotate"},
+		{JSONRPC: "1.0", Method: MethodLoad},
+	} {
+		request.ID = id + 1
+		require.NoError(t, encoder.Encode(request))
+	}
+
+	requests.WriteString("\n{\n")
+
+	var responses bytes.Buffer
+
+	require.NoError(t, Serve(context.Background(), &requests, &responses, fakeHandler{}))
+
+	var got []Response
+
+	decoder := json.NewDecoder(&responses)
+
+	for decoder.More() {
+		var response Response
+
+		require.NoError(t, decoder.Decode(&response))
+
+		got = append(got, response)
+	}
+
+	require.Len(t, got, 5)
+
+	assert.Nil(t, got[0].Error)
+	assert.JSONEq(t, `{"values": {"A": "1"}}`, string(got[1].Result))
+	assert.Equal(t, CodeMethodNotFound, got[2].Error.Code)
+	assert.Equal(t, CodeInvalidRequest, got[3].Error.Code)
+	assert.Equal(t, CodeParseError, got[4].Error.Code)
+
+	for i, response := range got[:4] {
+		assert.Equal(t, i+1, response.ID)
+	}
+}
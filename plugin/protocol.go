@@ -0,0 +1,201 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/thalesfsp/configurer/provider"
+)
+
+//////
+// Vars, consts, and types.
+//////
+
+// ProtocolVersion is the version of the protocol, sent with every request.
+const ProtocolVersion = 1
+
+// JSON-RPC 2.0 methods.
+const (
+	MethodDelete = "delete"
+	MethodList   = "list"
+	MethodLoad   = "load"
+	MethodWrite  = "write"
+)
+
+// JSON-RPC 2.0 error codes.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInternalError  = -32603
+)
+
+// Params of a request.
+type Params struct {
+	// ProtocolVersion is the version of the protocol.
+	ProtocolVersion int `json:"protocolVersion"`
+
+	// Settings are the plugin-specific settings.
+	Settings map[string]string `json:"settings,omitempty"`
+
+	// Environment option of write, if any.
+	Environment string `json:"environment,omitempty"`
+
+	// Keys to delete.
+	Keys []string `json:"keys,omitempty"`
+
+	// Target option of write, if any.
+	Target string `json:"target,omitempty"`
+
+	// Values to write.
+	Values map[string]any `json:"values,omitempty"`
+}
+
+// Request is a JSON-RPC 2.0 request.
+type Request struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      int    `json:"id"`
+	Method  string `json:"method"`
+	Params  Params `json:"params"`
+}
+
+// Error is a JSON-RPC 2.0 error.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Response is a JSON-RPC 2.0 response.
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      int             `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// LoadResult is the result of the load method.
+type LoadResult struct {
+	Values map[string]any `json:"values"`
+}
+
+// ListResult is the result of the list method.
+type ListResult struct {
+	Keys []string `json:"keys"`
+}
+
+// Handler implements the methods of a plugin. Return
+// `provider.ErrNotSupported` for unsupported ones.
+type Handler interface {
+	// Load returns the values.
+	Load(ctx context.Context, settings map[string]string) (map[string]any, error)
+
+	// Write stores the values.
+	Write(ctx context.Context, settings map[string]string, values map[string]any) error
+
+	// List returns the keys.
+	List(ctx context.Context, settings map[string]string) ([]string, error)
+
+	// Delete removes the keys.
+	Delete(ctx context.Context, settings map[string]string, keys []string) error
+}
+
+//////
+// Exported feature(s).
+//////
+
+// Serve answers requests, one per line, read from `r`, with `handler`, until
+// `r` is closed. Use it as the main loop of plugins written in Go:
+//
+//	plugin.Serve(ctx, os.Stdin, os.Stdout, handler)
+func Serve(ctx context.Context, r io.Reader, w io.Writer, handler Handler) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxMessageSize)
+
+	encoder := json.NewEncoder(w)
+
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		if err := encoder.Encode(handle(ctx, scanner.Bytes(), handler)); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+//////
+// Helpers.
+//////
+
+// handle answers one request.
+func handle(ctx context.Context, line []byte, handler Handler) *Response {
+	var request Request
+
+	if err := json.Unmarshal(line, &request); err != nil {
+		return &Response{JSONRPC: "2.0", Error: &Error{Code: CodeParseError, Message: err.Error()}}
+	}
+
+	response := &Response{JSONRPC: "2.0", ID: request.ID}
+
+	if request.JSONRPC != "2.0" {
+		response.Error = &Error{Code: CodeInvalidRequest, Message: "jsonrpc must be 2.0"}
+
+		return response
+	}
+
+	settings := request.Params.Settings
+
+	var (
+		result any
+		err    error
+	)
+
+	switch request.Method {
+	case MethodLoad:
+		var values map[string]any
+
+		if values, err = handler.Load(ctx, settings); err == nil {
+			result = LoadResult{Values: values}
+		}
+	case MethodWrite:
+		err = handler.Write(ctx, settings, request.Params.Values)
+	case MethodList:
+		var keys []string
+
+		if keys, err = handler.List(ctx, settings); err == nil {
+			result = ListResult{Keys: keys}
+		}
+	case MethodDelete:
+		err = handler.Delete(ctx, settings, request.Params.Keys)
+	default:
+		err = provider.ErrNotSupported
+	}
+
+	if err != nil {
+		code := CodeInternalError
+		if errors.Is(err, provider.ErrNotSupported) {
+			code = CodeMethodNotFound
+		}
+
+		response.Error = &Error{Code: code, Message: err.Error()}
+
+		return response
+	}
+
+	if response.Result, err = json.Marshal(result); err != nil {
+		response.Error = &Error{Code: CodeInternalError, Message: err.Error()}
+	}
+
+	return response
+}
@@ -0,0 +1,5 @@
+// Package redis provides a provider for Redis, loading a HASH, or the keys
+// matching a pattern, with AUTH, and ACL users, TLS, and database selection,
+// writing with HSET, or MSET. It speaks RESP2 directly, without a client
+// library.
+package redis
@@ -0,0 +1,184 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+//////
+// Vars, consts, and types.
+//////
+
+// maxBulkLength limits the length of bulk strings, like Redis'
+// `proto-max-bulk-len`.
+const maxBulkLength = 512 * 1024 * 1024
+
+// errNil is the RESP nil reply.
+var errNil = errors.New("nil reply")
+
+// respError is a RESP error reply, e.g.: `WRONGPASS invalid username-password
+// pair`.
+type respError string
+
+// Error implements the error interface.
+func (e respError) Error() string {
+	return string(e)
+}
+
+// conn is a RESP2 connection.
+type conn struct {
+	net.Conn
+
+	reader *bufio.Reader
+	writer *bufio.Writer
+}
+
+//////
+// Methods.
+//////
+
+// do sends a command, and reads its reply: a string, an int64, a []any, or
+// nil, if the reply is nil.
+func (c *conn) do(ctx context.Context, args ...string) (any, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := c.SetDeadline(deadline); err != nil {
+			return nil, err
+		}
+	} else if err := c.SetDeadline(time.Time{}); err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(c.writer, "*%d\r\n", len(args))
+
+	for _, arg := range args {
+		fmt.Fprintf(c.writer, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+
+	if err := c.writer.Flush(); err != nil {
+		return nil, err
+	}
+
+	reply, err := c.read()
+	if errors.Is(err, errNil) {
+		return nil, nil
+	}
+
+	return reply, err
+}
+
+// read reads a reply.
+func (c *conn) read() (any, error) {
+	line, err := c.readLine()
+	if err != nil {
+		return nil, err
+	}
+
+	if line == "" {
+		return nil, errors.New("empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, respError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		length, err := strconv.Atoi(line[1:])
+		if err != nil || length > maxBulkLength {
+			return nil, fmt.Errorf("invalid bulk length %q", line[1:])
+		}
+
+		if length < 0 {
+			return nil, errNil
+		}
+
+		bulk := make([]byte, length+2)
+
+		if _, err := io.ReadFull(c.reader, bulk); err != nil {
+			return nil, err
+		}
+
+		return string(bulk[:length]), nil
+	case '*':
+		length, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid array length %q", line[1:])
+		}
+
+		if length < 0 {
+			return nil, errNil
+		}
+
+		elements := make([]any, length)
+
+		for i := range elements {
+			element, err := c.read()
+			if err != nil && !errors.Is(err, errNil) {
+				return nil, err
+			}
+
+			elements[i] = element
+		}
+
+		return elements, nil
+	default:
+		return nil, fmt.Errorf("invalid reply %q", line)
+	}
+}
+
+// readLine reads a line, without the trailing CRLF.
+func (c *conn) readLine() (string, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", fmt.Errorf("invalid line %q", line)
+	}
+
+	return line[:len(line)-2], nil
+}
+
+//////
+// Helpers.
+//////
+
+// newConn wraps a network connection.
+func newConn(c net.Conn) *conn {
+	return &conn{
+		Conn:   c,
+		reader: bufio.NewReader(c),
+		writer: bufio.NewWriter(c),
+	}
+}
+
+// stringsReply converts an array reply to strings, nil elements become "".
+func stringsReply(reply any) ([]string, error) {
+	elements, ok := reply.([]any)
+	if !ok {
+		return nil, fmt.Errorf("unexpected reply %v, expected an array", reply)
+	}
+
+	result := make([]string, len(elements))
+
+	for i, element := range elements {
+		switch v := element.(type) {
+		case string:
+			result[i] = v
+		case nil:
+		default:
+			return nil, fmt.Errorf("unexpected reply %v, expected a string", element)
+		}
+	}
+
+	return result, nil
+}
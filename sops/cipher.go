@@ -0,0 +1,157 @@
+package sops
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/thalesfsp/customerror"
+)
+
+//////
+// Vars, consts, and types.
+//////
+
+// Value types, as recorded in encrypted values.
+const (
+	typeBool    = "bool"
+	typeBytes   = "bytes"
+	typeComment = "comment"
+	typeFloat   = "float"
+	typeInt     = "int"
+	typeString  = "str"
+)
+
+// dataKeySize is the size of the AES-256 data key.
+const dataKeySize = 32
+
+// ivSize is the size of the AES-GCM IV used by SOPS.
+const ivSize = 32
+
+// encryptedValueRegex matches `ENC[AES256_GCM,data:...,iv:...,tag:...,type:...]`.
+var encryptedValueRegex = regexp.MustCompile(`^ENC\[AES256_GCM,data:(.*),iv:(.+),tag:(.+),type:(.+)\]$`)
+
+//////
+// Helpers.
+//////
+
+// encryptValue encrypts a leaf value with AES-256-GCM. The path of the value
+// is the additional data, so values can't be moved around.
+func encryptValue(value any, key []byte, additionalData string) (string, error) {
+	var (
+		plaintext string
+		valueType string
+	)
+
+	switch v := value.(type) {
+	case string:
+		// Empty strings aren't encrypted.
+		if v == "" {
+			return "", nil
+		}
+
+		plaintext, valueType = v, typeString
+	case int:
+		plaintext, valueType = strconv.Itoa(v), typeInt
+	case float64:
+		plaintext, valueType = strconv.FormatFloat(v, 'f', -1, 64), typeFloat
+	case bool:
+		plaintext, valueType = strconv.FormatBool(v), typeBool
+	case []byte:
+		plaintext, valueType = string(v), typeBytes
+	case comment:
+		plaintext, valueType = v.value, typeComment
+	default:
+		return "", customerror.NewInvalidError(fmt.Sprintf("value type %T, allowed: string, int, float, bool", value))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+
+	gcm, err := cipher.NewGCMWithNonceSize(block, ivSize)
+	if err != nil {
+		return "", err
+	}
+
+	iv := make([]byte, ivSize)
+
+	if _, err := rand.Read(iv); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nil, iv, []byte(plaintext), []byte(additionalData))
+	tagStart := len(sealed) - gcm.Overhead()
+
+	return fmt.Sprintf(
+		"ENC[AES256_GCM,data:%s,iv:%s,tag:%s,type:%s]",
+		base64.StdEncoding.EncodeToString(sealed[:tagStart]),
+		base64.StdEncoding.EncodeToString(iv),
+		base64.StdEncoding.EncodeToString(sealed[tagStart:]),
+		valueType,
+	), nil
+}
+
+// decryptValue decrypts a value encrypted with `encryptValue`, restoring its
+// type.
+func decryptValue(value string, key []byte, additionalData string) (any, error) {
+	// Empty strings aren't encrypted.
+	if value == "" {
+		return "", nil
+	}
+
+	matches := encryptedValueRegex.FindStringSubmatch(value)
+	if matches == nil {
+		return nil, customerror.NewInvalidError("encrypted value, expected ENC[AES256_GCM,...]")
+	}
+
+	decoded := make([][]byte, 3)
+
+	for i, part := range matches[1:4] {
+		b, err := base64.StdEncoding.DecodeString(part)
+		if err != nil {
+			return nil, customerror.NewInvalidError("encrypted value, not base64", customerror.WithError(err))
+		}
+
+		decoded[i] = b
+	}
+
+	data, iv, tag := decoded[0], decoded[1], decoded[2]
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	if err != nil {
+		return nil, customerror.NewInvalidError("encrypted value IV", customerror.WithError(err))
+	}
+
+	plaintext, err := gcm.Open(nil, iv, append(data, tag...), []byte(additionalData))
+	if err != nil {
+		return nil, customerror.NewFailedToError("decrypt value at "+additionalData+", wrong data key, or tampered value", customerror.WithError(err))
+	}
+
+	switch valueType := matches[4]; valueType {
+	case typeString:
+		return string(plaintext), nil
+	case typeBytes:
+		return plaintext, nil
+	case typeInt:
+		return strconv.Atoi(string(plaintext))
+	case typeFloat:
+		return strconv.ParseFloat(string(plaintext), 64)
+	case typeBool:
+		return strconv.ParseBool(string(plaintext))
+	case typeComment:
+		return comment{value: string(plaintext)}, nil
+	default:
+		return nil, customerror.NewInvalidError("encrypted value type " + valueType)
+	}
+}
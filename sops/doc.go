@@ -0,0 +1,6 @@
+// Package sops provides a provider for SOPS encrypted files, e.g.:
+// `secrets.enc.yaml`, with age recipients. Files are decrypted, and written
+// natively, without the `sops` binary, and the MAC is verified when loading.
+//
+// SEE: https://github.com/getsops/sops
+package sops
@@ -0,0 +1,717 @@
+package sops
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/thalesfsp/customerror"
+	"gopkg.in/yaml.v3"
+)
+
+//////
+// Vars, consts, and types.
+//////
+
+// Supported formats.
+const (
+	formatDotenv = "dotenv"
+	formatJSON   = "json"
+	formatYAML   = "yaml"
+)
+
+// dotenvMetadataPrefix prefixes the flattened metadata keys in dotenv files,
+// e.g.: `sops_age__list_0__map_enc`.
+const dotenvMetadataPrefix = "sops_"
+
+// dotenvSeparatorRegex matches the map, and list separators of flattened
+// metadata keys.
+var dotenvSeparatorRegex = regexp.MustCompile(`__(map|list)_`)
+
+//////
+// Methods.
+//////
+
+// MarshalJSON implements `json.Marshaler`, preserving the order of keys.
+// Comments have no JSON representation, they're dropped.
+func (b branch) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+
+	buf.WriteByte('{')
+
+	first := true
+
+	for _, it := range b {
+		key, ok := it.key.(string)
+		if !ok {
+			continue
+		}
+
+		if !first {
+			buf.WriteByte(',')
+		}
+
+		first = false
+
+		encodedKey, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+
+		encodedValue, err := json.Marshal(it.value)
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(encodedKey)
+		buf.WriteByte(':')
+		buf.Write(encodedValue)
+	}
+
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+//////
+// Helpers.
+//////
+
+// formatOf determines the format of `filePath` from the extension, ignoring
+// `.enc`, e.g.: `secrets.enc.yaml`, or `.env.enc`.
+func formatOf(filePath string) (string, error) {
+	name := strings.TrimSuffix(filepath.Base(filePath), ".enc")
+
+	if strings.HasPrefix(name, ".env") {
+		return formatDotenv, nil
+	}
+
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml":
+		return formatYAML, nil
+	case ".json":
+		return formatJSON, nil
+	case ".env":
+		return formatDotenv, nil
+	default:
+		return "", customerror.NewInvalidError("format of " + filePath + ", allowed: .yaml, .yml, .json, .env")
+	}
+}
+
+// decode decodes an encrypted document into its tree, and metadata.
+func decode(format string, content []byte) (branch, *metadata, error) {
+	var (
+		b   branch
+		m   = &metadata{}
+		err error
+	)
+
+	switch format {
+	case formatYAML:
+		b, err = decodeYAML(content, m)
+	case formatJSON:
+		b, err = decodeJSON(content, m)
+	default:
+		b, err = decodeDotenv(content, m)
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return b, m, nil
+}
+
+// encode encodes an encrypted tree, and its metadata.
+func encode(format string, b branch, m *metadata) ([]byte, error) {
+	switch format {
+	case formatYAML:
+		return encodeYAML(b, m)
+	case formatJSON:
+		return encodeJSON(b, m)
+	default:
+		return encodeDotenv(b, m)
+	}
+}
+
+// decodeYAML decodes a single YAML document, keeping the order of keys, and
+// comments.
+func decodeYAML(content []byte, m *metadata) (branch, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+
+	var document yaml.Node
+
+	if err := decoder.Decode(&document); err != nil {
+		return nil, customerror.NewInvalidError("YAML document", customerror.WithError(err))
+	}
+
+	var extra yaml.Node
+
+	if err := decoder.Decode(&extra); !errors.Is(err, io.EOF) {
+		return nil, customerror.NewInvalidError("YAML document, multiple documents aren't supported")
+	}
+
+	var holder struct {
+		Metadata *metadata `yaml:"sops"`
+	}
+
+	if err := document.Decode(&holder); err != nil {
+		return nil, customerror.NewInvalidError("sops metadata", customerror.WithError(err))
+	}
+
+	if holder.Metadata == nil {
+		return nil, customerror.NewNotFoundError("sops metadata, file isn't encrypted with SOPS")
+	}
+
+	*m = *holder.Metadata
+
+	b, err := yamlBranch(&document, branch{}, false)
+	if err != nil {
+		return nil, err
+	}
+
+	b, _, _ = removeMetadata(b)
+
+	return b, nil
+}
+
+// yamlBranch appends the mapping `node` to `b`, comments included, like SOPS
+// does, so the MAC matches.
+func yamlBranch(node *yaml.Node, b branch, commentsHandled bool) (branch, error) {
+	if !commentsHandled {
+		b = appendCommentItems(b, node.HeadComment, node.LineComment)
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			var err error
+
+			if b, err = yamlBranch(child, b, false); err != nil {
+				return nil, err
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+
+			b = appendCommentItems(b, key.HeadComment, key.LineComment)
+
+			handleValueComments := value.Kind == yaml.ScalarNode || value.Kind == yaml.AliasNode
+			if handleValueComments {
+				b = appendCommentItems(b, value.HeadComment, value.LineComment)
+			}
+
+			treeValue, err := yamlValue(value, handleValueComments)
+			if err != nil {
+				return nil, err
+			}
+
+			b = append(b, item{key: key.Value, value: treeValue})
+
+			if handleValueComments {
+				b = appendCommentItems(b, value.FootComment)
+			}
+
+			b = appendCommentItems(b, key.FootComment)
+		}
+	case yaml.ScalarNode:
+		// Empty document.
+		if node.ShortTag() != "!!null" {
+			return nil, customerror.NewInvalidError("YAML document, expected a map")
+		}
+	case yaml.AliasNode:
+		return yamlBranch(node.Alias, b, false)
+	default:
+		return nil, customerror.NewInvalidError("YAML document, expected a map")
+	}
+
+	if !commentsHandled {
+		b = appendCommentItems(b, node.FootComment)
+	}
+
+	return b, nil
+}
+
+// yamlValue converts a YAML node to a tree value.
+func yamlValue(node *yaml.Node, commentsHandled bool) (any, error) {
+	switch node.Kind {
+	case yaml.SequenceNode:
+		list := []any{}
+
+		if !commentsHandled {
+			list = appendComments(list, node.HeadComment, node.LineComment)
+		}
+
+		for _, child := range node.Content {
+			list = appendComments(list, child.HeadComment, child.LineComment)
+
+			value, err := yamlValue(child, true)
+			if err != nil {
+				return nil, err
+			}
+
+			list = append(list, value)
+			list = appendComments(list, child.FootComment)
+		}
+
+		return list, nil
+	case yaml.MappingNode:
+		return yamlBranch(node, branch{}, false)
+	case yaml.AliasNode:
+		return yamlValue(node.Alias, false)
+	default:
+		var value any
+
+		if err := node.Decode(&value); err != nil {
+			return nil, err
+		}
+
+		return value, nil
+	}
+}
+
+// appendCommentItems appends each line of `comments` to `b`.
+func appendCommentItems(b branch, comments ...string) branch {
+	for _, c := range splitComments(comments...) {
+		b = append(b, item{key: c})
+	}
+
+	return b
+}
+
+// appendComments appends each line of `comments` to `list`.
+func appendComments(list []any, comments ...string) []any {
+	for _, c := range splitComments(comments...) {
+		list = append(list, c)
+	}
+
+	return list
+}
+
+// splitComments splits YAML comments into lines, without the leading `#`.
+func splitComments(comments ...string) []comment {
+	var out []comment
+
+	for _, c := range comments {
+		for _, line := range strings.Split(c, "\n") {
+			if line != "" {
+				out = append(out, comment{value: line[1:]})
+			}
+		}
+	}
+
+	return out
+}
+
+// encodeYAML encodes the tree, followed by the metadata, indented like SOPS.
+func encodeYAML(b branch, m *metadata) ([]byte, error) {
+	root, err := yamlNode(b)
+	if err != nil {
+		return nil, err
+	}
+
+	var metadataNode yaml.Node
+
+	if err := metadataNode.Encode(m); err != nil {
+		return nil, err
+	}
+
+	root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: metadataKey}, &metadataNode)
+
+	var buf bytes.Buffer
+
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(4)
+
+	if err := encoder.Encode(root); err != nil {
+		return nil, err
+	}
+
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// yamlNode converts a tree value to a YAML node.
+func yamlNode(v any) (*yaml.Node, error) {
+	switch value := v.(type) {
+	case branch:
+		node := &yaml.Node{Kind: yaml.MappingNode}
+
+		for _, it := range value {
+			key, ok := it.key.(string)
+			if !ok {
+				continue
+			}
+
+			child, err := yamlNode(it.value)
+			if err != nil {
+				return nil, err
+			}
+
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, child)
+		}
+
+		return node, nil
+	case []any:
+		node := &yaml.Node{Kind: yaml.SequenceNode}
+
+		for _, element := range value {
+			child, err := yamlNode(element)
+			if err != nil {
+				return nil, err
+			}
+
+			node.Content = append(node.Content, child)
+		}
+
+		return node, nil
+	default:
+		node := &yaml.Node{}
+
+		if err := node.Encode(value); err != nil {
+			return nil, err
+		}
+
+		return node, nil
+	}
+}
+
+// decodeJSON decodes a JSON object, keeping the order of keys.
+func decodeJSON(content []byte, m *metadata) (branch, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+
+	value, err := jsonValue(decoder)
+	if err != nil {
+		return nil, customerror.NewInvalidError("JSON document", customerror.WithError(err))
+	}
+
+	b, ok := value.(branch)
+	if !ok {
+		return nil, customerror.NewInvalidError("JSON document, expected an object")
+	}
+
+	b, rawMetadata, found := removeMetadata(b)
+	if !found {
+		return nil, customerror.NewNotFoundError("sops metadata, file isn't encrypted with SOPS")
+	}
+
+	if err := convertMetadata(plain(rawMetadata), m); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// jsonValue decodes the next JSON value.
+func jsonValue(decoder *json.Decoder) (any, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := token.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			b := branch{}
+
+			for decoder.More() {
+				keyToken, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+
+				value, err := jsonValue(decoder)
+				if err != nil {
+					return nil, err
+				}
+
+				b = append(b, item{key: keyToken.(string), value: value})
+			}
+
+			_, err := decoder.Token()
+
+			return b, err
+		case '[':
+			list := []any{}
+
+			for decoder.More() {
+				value, err := jsonValue(decoder)
+				if err != nil {
+					return nil, err
+				}
+
+				list = append(list, value)
+			}
+
+			_, err := decoder.Token()
+
+			return list, err
+		default:
+			return nil, errors.New("unexpected delimiter " + t.String())
+		}
+	case json.Number:
+		if i, err := strconv.Atoi(t.String()); err == nil {
+			return i, nil
+		}
+
+		return t.Float64()
+	default:
+		return t, nil
+	}
+}
+
+// encodeJSON encodes the tree, followed by the metadata.
+func encodeJSON(b branch, m *metadata) ([]byte, error) {
+	content, err := json.MarshalIndent(append(b, item{key: metadataKey, value: m}), "", "\t")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(content, '\n'), nil
+}
+
+// decodeDotenv decodes `KEY=value` lines. Values are verbatim, except `\n`,
+// which is a newline. Metadata is flattened into `sops_` prefixed keys.
+func decodeDotenv(content []byte, m *metadata) (branch, error) {
+	b := branch{}
+	flatMetadata := map[string]string{}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		if line == "" {
+			continue
+		}
+
+		if line[0] == '#' {
+			b = append(b, item{key: comment{value: line[1:]}})
+
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return nil, customerror.NewInvalidError("dotenv line " + line)
+		}
+
+		value = strings.ReplaceAll(value, `\n`, "\n")
+
+		if strings.HasPrefix(key, dotenvMetadataPrefix) {
+			flatMetadata[strings.TrimPrefix(key, dotenvMetadataPrefix)] = value
+
+			continue
+		}
+
+		b = append(b, item{key: key, value: value})
+	}
+
+	if len(flatMetadata) == 0 {
+		return nil, customerror.NewNotFoundError("sops metadata, file isn't encrypted with SOPS")
+	}
+
+	unflattened, err := unflattenMetadata(flatMetadata)
+	if err != nil {
+		return nil, err
+	}
+
+	if onlyEncrypted, ok := unflattened["mac_only_encrypted"].(string); ok {
+		unflattened["mac_only_encrypted"] = onlyEncrypted == "true"
+	}
+
+	if err := convertMetadata(unflattened, m); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// encodeDotenv encodes the tree as `KEY=value` lines, followed by the
+// flattened metadata.
+func encodeDotenv(b branch, m *metadata) ([]byte, error) {
+	var buf bytes.Buffer
+
+	for _, it := range b {
+		if c, ok := it.key.(comment); ok {
+			buf.WriteString("#" + c.value + "\n")
+
+			continue
+		}
+
+		switch it.value.(type) {
+		case branch, []any:
+			return nil, customerror.NewInvalidError("value of " + it.key.(string) + ", dotenv values must be flat, flatten the source")
+		}
+
+		buf.WriteString(it.key.(string) + "=" + strings.ReplaceAll(string(toBytes(it.value)), "\n", `\n`) + "\n")
+	}
+
+	var rawMetadata map[string]any
+
+	if err := convertMetadata(m, &rawMetadata); err != nil {
+		return nil, err
+	}
+
+	flatMetadata := map[string]string{}
+
+	flattenMetadata("", rawMetadata, flatMetadata)
+
+	keys := make([]string, 0, len(flatMetadata))
+
+	for key := range flatMetadata {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		buf.WriteString(dotenvMetadataPrefix + key + "=" + strings.ReplaceAll(flatMetadata[key], "\n", `\n`) + "\n")
+	}
+
+	return buf.Bytes(), nil
+}
+
+// flattenMetadata flattens metadata, e.g.: `age__list_0__map_enc`.
+func flattenMetadata(prefix string, v any, out map[string]string) {
+	switch value := v.(type) {
+	case map[string]any:
+		for key, child := range value {
+			if prefix == "" {
+				flattenMetadata(key, child, out)
+			} else {
+				flattenMetadata(prefix+"__map_"+key, child, out)
+			}
+		}
+	case []any:
+		for i, child := range value {
+			flattenMetadata(prefix+"__list_"+strconv.Itoa(i), child, out)
+		}
+	case nil:
+	case bool:
+		out[prefix] = strconv.FormatBool(value)
+	default:
+		out[prefix] = fmt.Sprint(value)
+	}
+}
+
+// unflattenMetadata reverses `flattenMetadata`.
+func unflattenMetadata(flat map[string]string) (map[string]any, error) {
+	root := map[string]any{}
+
+	for key, value := range flat {
+		separators := dotenvSeparatorRegex.FindAllStringSubmatchIndex(key, -1)
+
+		// Path elements, and whether each is a list index.
+		names := []string{}
+		lists := []bool{false}
+		start := 0
+
+		for _, separator := range separators {
+			names = append(names, key[start:separator[0]])
+			lists = append(lists, key[separator[2]:separator[3]] == "list")
+			start = separator[1]
+		}
+
+		names = append(names, key[start:])
+
+		var container any = root
+
+		for i, name := range names {
+			last := i == len(names)-1
+
+			var child any = value
+
+			if !last {
+				if lists[i+1] {
+					child = map[int]any{}
+				} else {
+					child = map[string]any{}
+				}
+			}
+
+			var err error
+
+			if container, err = descend(container, name, child, last); err != nil {
+				return nil, customerror.NewInvalidError("dotenv metadata key "+key, customerror.WithError(err))
+			}
+		}
+	}
+
+	return listify(root).(map[string]any), nil
+}
+
+// descend returns the child `name` of `container`, setting it to `child` if
+// missing, or if it's the `last` path element.
+func descend(container any, name string, child any, last bool) (any, error) {
+	switch c := container.(type) {
+	case map[string]any:
+		if existing, ok := c[name]; ok && !last {
+			return existing, nil
+		}
+
+		c[name] = child
+	case map[int]any:
+		index, err := strconv.Atoi(name)
+		if err != nil {
+			return nil, err
+		}
+
+		if existing, ok := c[index]; ok && !last {
+			return existing, nil
+		}
+
+		c[index] = child
+	default:
+		return nil, errors.New("conflicting key")
+	}
+
+	return child, nil
+}
+
+// listify converts the index maps of `unflattenMetadata` to lists.
+func listify(v any) any {
+	switch value := v.(type) {
+	case map[string]any:
+		for key, child := range value {
+			value[key] = listify(child)
+		}
+
+		return value
+	case map[int]any:
+		list := make([]any, len(value))
+
+		for index, child := range value {
+			if index >= 0 && index < len(list) {
+				list[index] = listify(child)
+			}
+		}
+
+		return list
+	default:
+		return v
+	}
+}
+
+// convertMetadata converts between metadata representations, through JSON.
+func convertMetadata(from, to any) error {
+	content, err := json.Marshal(from)
+	if err != nil {
+		return customerror.NewInvalidError("sops metadata", customerror.WithError(err))
+	}
+
+	if err := json.Unmarshal(content, to); err != nil {
+		return customerror.NewInvalidError("sops metadata", customerror.WithError(err))
+	}
+
+	return nil
+}
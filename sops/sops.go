@@ -0,0 +1,426 @@
+package sops
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/thalesfsp/configurer/option"
+	"github.com/thalesfsp/configurer/provider"
+	"github.com/thalesfsp/configurer/util"
+	"github.com/thalesfsp/customerror"
+	"github.com/thalesfsp/validation"
+)
+
+//////
+// Vars, consts, and types.
+//////
+
+// Name of the provider.
+const Name = "sops"
+
+// Version of SOPS the written files are compatible with.
+const Version = "3.9.0"
+
+// DefaultUnencryptedSuffix is the suffix of keys which values aren't
+// encrypted, e.g.: `description_unencrypted`.
+const DefaultUnencryptedSuffix = "_unencrypted"
+
+// Config contains the SOPS settings.
+type Config struct {
+	// FilePaths is the list of SOPS files to load. The extension determines
+	// the format: `.yaml` | `.yml`, `.json`, or `.env`.
+	FilePaths []string `json:"filePaths" validate:"required,gte=1"`
+
+	// Identities are age identities (`AGE-SECRET-KEY-1...`) which decrypt the
+	// data key when loading.
+	Identities []string `json:"-"`
+
+	// ParseOptions flattens nested values, e.g.: `database.host` becomes
+	// `DATABASE__HOST`. If not set, nested values are JSON-encoded.
+	ParseOptions []option.ParseFunc `json:"-"`
+
+	// Recipients are age recipients (`age1...`) the data key is encrypted to
+	// when writing. If not set, the recipients of the existing file are used.
+	Recipients []string `json:"recipients"`
+}
+
+// SOPS provider definition.
+type SOPS struct {
+	*provider.Provider `json:"-" validate:"required"`
+
+	Configuration *Config `json:"-" validate:"required"`
+
+	identities []age.Identity
+}
+
+//////
+// IProvider implementation.
+//////
+
+// Load decrypts the files, verifying their MAC, and exports the values to the
+// environment. Files are loaded in order, later files win.
+func (s *SOPS) Load(ctx context.Context, opts ...option.LoadKeyFunc) (map[string]string, error) {
+	if len(s.identities) == 0 {
+		return nil, customerror.NewRequiredError("age identities")
+	}
+
+	parseOptions, err := option.NewParse(s.Configuration.ParseOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]any)
+
+	for _, filePath := range s.Configuration.FilePaths {
+		decrypted, err := s.decryptFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+
+		for key, value := range decrypted {
+			values[key] = value
+		}
+	}
+
+	if parseOptions.Flatten {
+		if values, err = util.Flatten(values, s.Configuration.ParseOptions...); err != nil {
+			return nil, err
+		}
+	}
+
+	finalValues := make(map[string]string, len(values))
+
+	for key, value := range values {
+		value, err := util.EncodeValue(value)
+		if err != nil {
+			return nil, customerror.NewFailedToError("encode "+key, customerror.WithError(err))
+		}
+
+		// Should allow to specify options.
+		for _, opt := range opts {
+			key = opt(key)
+		}
+
+		finalValue, err := provider.ExportToEnvVar(s, key, value)
+		if err != nil {
+			return nil, err
+		}
+
+		finalValues[key] = finalValue
+	}
+
+	return finalValues, nil
+}
+
+// Write encrypts `values` with a new data key, encrypted to the recipients,
+// and writes them to the file, replacing it atomically. Keys ending with
+// `DefaultUnencryptedSuffix` aren't encrypted.
+func (s *SOPS) Write(ctx context.Context, values map[string]interface{}, opts ...option.WriteFunc) error {
+	if values == nil {
+		return customerror.NewRequiredError("values")
+	}
+
+	// This operation is 1:1.
+	if len(s.Configuration.FilePaths) > 1 {
+		return customerror.NewInvalidError("filePaths, for the Write operation only one file should be used")
+	}
+
+	var options option.Write
+
+	for _, opt := range opts {
+		if err := opt(&options); err != nil {
+			return err
+		}
+	}
+
+	filePath := s.Configuration.FilePaths[0]
+
+	format, err := formatOf(filePath)
+	if err != nil {
+		return err
+	}
+
+	recipients, err := s.writeRecipients(filePath, format)
+	if err != nil {
+		return err
+	}
+
+	dataKey := make([]byte, dataKeySize)
+
+	if _, err := rand.Read(dataKey); err != nil {
+		return customerror.NewFailedToError("generate data key", customerror.WithError(err))
+	}
+
+	m := &metadata{
+		LastModified:      time.Now().UTC().Format(time.RFC3339),
+		UnencryptedSuffix: DefaultUnencryptedSuffix,
+		Version:           Version,
+	}
+
+	// One copy of the data key per recipient, like SOPS.
+	for _, recipient := range recipients {
+		parsed, err := parseAgeRecipient(recipient)
+		if err != nil {
+			return err
+		}
+
+		encrypted, err := encryptDataKey(dataKey, parsed)
+		if err != nil {
+			return err
+		}
+
+		m.Age = append(m.Age, ageKey{Recipient: recipient, EncryptedDataKey: encrypted})
+	}
+
+	tree := fromPlain(values).(branch)
+
+	mac, err := encryptTree(tree, dataKey, m)
+	if err != nil {
+		return err
+	}
+
+	if m.MAC, err = encryptValue(mac, dataKey, m.LastModified); err != nil {
+		return err
+	}
+
+	content, err := encode(format, tree, m)
+	if err != nil {
+		return customerror.NewFailedToError("encode "+filePath, customerror.WithError(err))
+	}
+
+	return util.WriteFileAtomicBytes(filePath, content)
+}
+
+//////
+// Exported feature(s).
+//////
+
+// GenerateIdentity generates an age X25519 identity (`AGE-SECRET-KEY-1...`),
+// and its recipient (`age1...`), like `age-keygen`.
+func GenerateIdentity() (identity string, recipient string, err error) {
+	generated, err := age.GenerateX25519Identity()
+	if err != nil {
+		return "", "", customerror.NewFailedToError("generate identity", customerror.WithError(err))
+	}
+
+	return generated.String(), generated.Recipient().String(), nil
+}
+
+//////
+// Helpers.
+//////
+
+// parseAgeIdentity parses an `AGE-SECRET-KEY-1...` identity, in any case.
+func parseAgeIdentity(s string) (*age.X25519Identity, error) {
+	identity, err := age.ParseX25519Identity(strings.ToUpper(s))
+	if err != nil {
+		return nil, customerror.NewInvalidError("age identity", customerror.WithError(err))
+	}
+
+	return identity, nil
+}
+
+// parseAgeRecipient parses an `age1...` recipient.
+func parseAgeRecipient(s string) (*age.X25519Recipient, error) {
+	recipient, err := age.ParseX25519Recipient(s)
+	if err != nil {
+		return nil, customerror.NewInvalidError("age recipient "+s, customerror.WithError(err))
+	}
+
+	return recipient, nil
+}
+
+// encryptDataKey encrypts `dataKey` to `recipient`, ASCII armored, like SOPS.
+func encryptDataKey(dataKey []byte, recipient age.Recipient) (string, error) {
+	var b bytes.Buffer
+
+	armored := armor.NewWriter(&b)
+
+	w, err := age.Encrypt(armored, recipient)
+	if err != nil {
+		return "", customerror.NewFailedToError("encrypt data key", customerror.WithError(err))
+	}
+
+	if _, err := w.Write(dataKey); err != nil {
+		return "", customerror.NewFailedToError("encrypt data key", customerror.WithError(err))
+	}
+
+	if err := w.Close(); err != nil {
+		return "", customerror.NewFailedToError("encrypt data key", customerror.WithError(err))
+	}
+
+	if err := armored.Close(); err != nil {
+		return "", customerror.NewFailedToError("encrypt data key", customerror.WithError(err))
+	}
+
+	return b.String(), nil
+}
+
+// decryptDataKey decrypts the ASCII armored `encrypted` data key with the
+// first of the `identities` which matches a recipient.
+func decryptDataKey(encrypted string, identities []age.Identity) ([]byte, error) {
+	r, err := age.Decrypt(armor.NewReader(strings.NewReader(encrypted)), identities...)
+	if err != nil {
+		return nil, customerror.NewFailedToError("decrypt data key", customerror.WithError(err))
+	}
+
+	dataKey, err := io.ReadAll(r)
+	if err != nil {
+		return nil, customerror.NewFailedToError("decrypt data key", customerror.WithError(err))
+	}
+
+	return dataKey, nil
+}
+
+// decryptFile decrypts `filePath`, verifying the MAC.
+func (s *SOPS) decryptFile(filePath string) (map[string]any, error) {
+	format, err := formatOf(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, customerror.NewFailedToError("read path", customerror.WithError(err))
+	}
+
+	tree, m, err := decode(format, content)
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey, err := s.dataKey(m)
+	if err != nil {
+		return nil, customerror.NewFailedToError("decrypt "+filePath, customerror.WithError(err))
+	}
+
+	mac, err := decryptTree(tree, dataKey, m)
+	if err != nil {
+		return nil, customerror.NewFailedToError("decrypt "+filePath, customerror.WithError(err))
+	}
+
+	storedMAC, err := decryptValue(m.MAC, dataKey, m.LastModified)
+	if err != nil {
+		return nil, customerror.NewFailedToError("decrypt MAC of "+filePath, customerror.WithError(err))
+	}
+
+	if storedMAC != mac {
+		return nil, customerror.NewInvalidError("MAC of " + filePath + ", file was tampered with")
+	}
+
+	return plain(tree).(map[string]any), nil
+}
+
+// dataKey decrypts the data key with the first matching age identity.
+func (s *SOPS) dataKey(m *metadata) ([]byte, error) {
+	keys, err := m.ageKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keys) == 0 {
+		return nil, customerror.NewNotFoundError("age recipients, only age is supported")
+	}
+
+	for _, key := range keys {
+		dataKey, err := decryptDataKey(key.EncryptedDataKey, s.identities)
+		if err == nil && len(dataKey) == dataKeySize {
+			return dataKey, nil
+		}
+	}
+
+	return nil, customerror.NewNotFoundError("age identity matching the file recipients")
+}
+
+// writeRecipients returns the configured recipients, or, if not set, the
+// ones of the existing file.
+func (s *SOPS) writeRecipients(filePath, format string) ([]string, error) {
+	if len(s.Configuration.Recipients) > 0 {
+		return s.Configuration.Recipients, nil
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, customerror.NewRequiredError("age recipients")
+	}
+
+	_, m, err := decode(format, content)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := m.ageKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	recipients := make([]string, 0, len(keys))
+
+	for _, key := range keys {
+		recipients = append(recipients, key.Recipient)
+	}
+
+	if len(recipients) == 0 {
+		return nil, customerror.NewRequiredError("age recipients")
+	}
+
+	return recipients, nil
+}
+
+//////
+// Factory.
+//////
+
+// New creates a SOPS provider. Identities are required to load, recipients
+// to write a new file.
+func New(override, rawValue bool, config *Config) (provider.IProvider, error) {
+	if config == nil {
+		return nil, customerror.NewRequiredError("config")
+	}
+
+	if err := validation.Validate(config); err != nil {
+		return nil, err
+	}
+
+	identities := make([]age.Identity, 0, len(config.Identities))
+
+	for _, identity := range config.Identities {
+		parsed, err := parseAgeIdentity(identity)
+		if err != nil {
+			return nil, err
+		}
+
+		identities = append(identities, parsed)
+	}
+
+	for _, recipient := range config.Recipients {
+		if _, err := parseAgeRecipient(recipient); err != nil {
+			return nil, err
+		}
+	}
+
+	baseProvider, err := provider.New(Name, override, rawValue)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &SOPS{
+		Provider:      baseProvider,
+		Configuration: config,
+
+		identities: identities,
+	}
+
+	if err := validation.Validate(s); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
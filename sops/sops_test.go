@@ -0,0 +1,503 @@
+package sops
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thalesfsp/configurer/internal/testenv"
+	"github.com/thalesfsp/configurer/option"
+	"github.com/thalesfsp/configurer/util"
+	"gopkg.in/yaml.v3"
+)
+
+// identity generates an age identity, and its recipient for tests.
+func identity(t *testing.T) (string, string) {
+	t.Helper()
+
+	id, recipient, err := GenerateIdentity()
+	require.NoError(t, err)
+
+	return id, recipient
+}
+
+//////
+// Constructor.
+//////
+
+func TestNew(t *testing.T) {
+	id, recipient := identity(t)
+
+	tests := []struct {
+		name    string
+		config  *Config
+		wantErr string
+	}{
+		{
+			name:   "happy path load",
+			config: &Config{FilePaths: []string{"secrets.enc.yaml"}, Identities: []string{id}},
+		},
+		{
+			name:   "happy path lowercase identity",
+			config: &Config{FilePaths: []string{"secrets.enc.yaml"}, Identities: []string{strings.ToLower(id)}},
+		},
+		{
+			name:   "happy path write",
+			config: &Config{FilePaths: []string{"secrets.enc.yaml"}, Recipients: []string{recipient}},
+		},
+		{
+			name:    "bad path nil config",
+			wantErr: "config",
+		},
+		{
+			name:    "bad path missing files",
+			config:  &Config{Identities: []string{id}},
+			wantErr: "FilePaths",
+		},
+		{
+			name:    "bad path recipient as identity",
+			config:  &Config{FilePaths: []string{"secrets.enc.yaml"}, Identities: []string{recipient}},
+			wantErr: "age identity",
+		},
+		{
+			name:    "bad path invalid recipient checksum",
+			config:  &Config{FilePaths: []string{"secrets.enc.yaml"}, Recipients: []string{strings.Replace(recipient, "age1", "age1q", 1)}},
+			wantErr: "age recipient",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(false, false, tt.config)
+
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				assert.Nil(t, got)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, Name, got.GetName())
+		})
+	}
+}
+
+//////
+// age.
+//////
+
+func TestDataKey(t *testing.T) {
+	id, recipient := identity(t)
+	otherID, otherRecipient := identity(t)
+
+	parsedID, err := parseAgeIdentity(id)
+	require.NoError(t, err)
+	assert.Equal(t, recipient, parsedID.Recipient().String())
+
+	parsedOtherID, err := parseAgeIdentity(otherID)
+	require.NoError(t, err)
+
+	public, err := parseAgeRecipient(recipient)
+	require.NoError(t, err)
+
+	dataKey := bytes.Repeat([]byte{1}, dataKeySize)
+
+	encrypted, err := encryptDataKey(dataKey, public)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encrypted, armor.Header+"\n"), encrypted)
+
+	decrypted, err := decryptDataKey(encrypted, []age.Identity{parsedOtherID, parsedID})
+	require.NoError(t, err)
+	assert.Equal(t, dataKey, decrypted)
+
+	_, err = decryptDataKey(encrypted, []age.Identity{parsedOtherID})
+	assert.ErrorContains(t, err, "identity did not match any of the recipients")
+
+	_, err = decryptDataKey("not armored", []age.Identity{parsedID})
+	assert.ErrorContains(t, err, "decrypt data key")
+
+	_, err = parseAgeRecipient(otherRecipient + "q")
+	assert.ErrorContains(t, err, "age recipient")
+}
+
+//////
+// IProvider implementation.
+//////
+
+func TestWriteLoad(t *testing.T) {
+	id, recipient := identity(t)
+	otherID, otherRecipient := identity(t)
+
+	values := map[string]interface{}{
+		"CONFIGURER_SOPS_PASSWORD":          "s3cr3t\nmultiline",
+		"CONFIGURER_SOPS_PORT":              8080,
+		"CONFIGURER_SOPS_EMPTY":             "",
+		"CONFIGURER_SOPS_NOTE_unencrypted":  "visible",
+		"CONFIGURER_SOPS_DEBUG":             true,
+		"CONFIGURER_SOPS_RATIO":             0.5,
+		"CONFIGURER_SOPS_HOSTS_unencrypted": "a,b",
+	}
+
+	for _, fileName := range []string{"secrets.enc.yaml", "secrets.json", ".env"} {
+		t.Run(fileName, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), fileName)
+
+			writer, err := New(false, false, &Config{
+				FilePaths:  []string{filePath},
+				Recipients: []string{recipient, otherRecipient},
+			})
+			require.NoError(t, err)
+			require.NoError(t, writer.Write(context.Background(), values))
+
+			content, err := os.ReadFile(filePath)
+			require.NoError(t, err)
+
+			assert.NotContains(t, string(content), "s3cr3t")
+			assert.Contains(t, string(content), "ENC[AES256_GCM,data:")
+			assert.Contains(t, string(content), "visible")
+			assert.Contains(t, string(content), armor.Header)
+
+			info, err := os.Stat(filePath)
+			require.NoError(t, err)
+			assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+			want := map[string]string{
+				"APP_CONFIGURER_SOPS_PASSWORD":          "s3cr3t\nmultiline",
+				"APP_CONFIGURER_SOPS_PORT":              "8080",
+				"APP_CONFIGURER_SOPS_EMPTY":             "",
+				"APP_CONFIGURER_SOPS_NOTE_unencrypted":  "visible",
+				"APP_CONFIGURER_SOPS_DEBUG":             "true",
+				"APP_CONFIGURER_SOPS_RATIO":             "0.5",
+				"APP_CONFIGURER_SOPS_HOSTS_unencrypted": "a,b",
+			}
+
+			// Any of the recipients can decrypt.
+			for _, identity := range []string{id, otherID} {
+				for key := range want {
+					testenv.Unset(t, key)
+				}
+
+				reader, err := New(true, false, &Config{FilePaths: []string{filePath}, Identities: []string{identity}})
+				require.NoError(t, err)
+
+				got, err := reader.Load(context.Background(), option.WithKeyPrefixer("APP_"))
+				require.NoError(t, err)
+
+				assert.Equal(t, want, got)
+				assert.Equal(t, "s3cr3t\nmultiline", os.Getenv("APP_CONFIGURER_SOPS_PASSWORD"))
+			}
+
+			// Re-encrypting without recipients keeps the file's ones.
+			reWriter, err := New(false, false, &Config{FilePaths: []string{filePath}})
+			require.NoError(t, err)
+			require.NoError(t, reWriter.Write(context.Background(), map[string]interface{}{"CONFIGURER_SOPS_ROTATED": "yes"}))
+
+			testenv.Unset(t, "CONFIGURER_SOPS_ROTATED")
+
+			reader, err := New(true, false, &Config{FilePaths: []string{filePath}, Identities: []string{otherID}})
+			require.NoError(t, err)
+
+			got, err := reader.Load(context.Background())
+			require.NoError(t, err)
+
+			assert.Equal(t, map[string]string{"CONFIGURER_SOPS_ROTATED": "yes"}, got)
+		})
+	}
+}
+
+func TestLoadNested(t *testing.T) {
+	id, recipient := identity(t)
+
+	filePath := filepath.Join(t.TempDir(), "secrets.yaml")
+
+	writer, err := New(false, false, &Config{FilePaths: []string{filePath}, Recipients: []string{recipient}})
+	require.NoError(t, err)
+	require.NoError(t, writer.Write(context.Background(), map[string]interface{}{
+		"configurer_sops_db": map[string]any{
+			"host":  "localhost",
+			"ports": []any{5432, 5433},
+		},
+	}))
+
+	tests := []struct {
+		name         string
+		parseOptions []option.ParseFunc
+		want         map[string]string
+	}{
+		{
+			name: "nested values are JSON-encoded",
+			want: map[string]string{"configurer_sops_db": `{"host":"localhost","ports":[5432,5433]}`},
+		},
+		{
+			name:         "flattened",
+			parseOptions: []option.ParseFunc{option.WithFlatten(true)},
+			want: map[string]string{
+				"CONFIGURER_SOPS_DB__HOST":     "localhost",
+				"CONFIGURER_SOPS_DB__PORTS__0": "5432",
+				"CONFIGURER_SOPS_DB__PORTS__1": "5433",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key := range tt.want {
+				testenv.Unset(t, key)
+			}
+
+			reader, err := New(true, false, &Config{
+				FilePaths:    []string{filePath},
+				Identities:   []string{id},
+				ParseOptions: tt.parseOptions,
+			})
+			require.NoError(t, err)
+
+			got, err := reader.Load(context.Background())
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLoadComments(t *testing.T) {
+	id, recipient := identity(t)
+
+	// Encrypted comments are part of the MAC, like SOPS.
+	tree := branch{
+		{key: comment{value: " Database"}},
+		{key: "CONFIGURER_SOPS_COMMENTED", value: "value"},
+	}
+
+	public, err := parseAgeRecipient(recipient)
+	require.NoError(t, err)
+
+	dataKey := bytes.Repeat([]byte{1}, dataKeySize)
+
+	encryptedDataKey, err := encryptDataKey(dataKey, public)
+	require.NoError(t, err)
+
+	m := &metadata{
+		Age:               []ageKey{{Recipient: recipient, EncryptedDataKey: encryptedDataKey}},
+		LastModified:      "2024-01-01T00:00:00Z",
+		UnencryptedSuffix: DefaultUnencryptedSuffix,
+		Version:           Version,
+	}
+
+	mac, err := encryptTree(tree, dataKey, m)
+	require.NoError(t, err)
+
+	m.MAC, err = encryptValue(mac, dataKey, m.LastModified)
+	require.NoError(t, err)
+
+	metadataContent, err := yaml.Marshal(map[string]any{"sops": m})
+	require.NoError(t, err)
+
+	content := "#" + tree[0].key.(comment).value + "\n" +
+		"CONFIGURER_SOPS_COMMENTED: " + tree[1].value.(string) + "\n" +
+		string(metadataContent)
+
+	filePath := filepath.Join(t.TempDir(), "secrets.yaml")
+	require.NoError(t, os.WriteFile(filePath, []byte(content), 0o600))
+
+	testenv.Unset(t, "CONFIGURER_SOPS_COMMENTED")
+
+	reader, err := New(true, false, &Config{FilePaths: []string{filePath}, Identities: []string{id}})
+	require.NoError(t, err)
+
+	got, err := reader.Load(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{"CONFIGURER_SOPS_COMMENTED": "value"}, got)
+
+	// Removing the comment breaks the MAC.
+	require.NoError(t, os.WriteFile(filePath, []byte(strings.SplitN(content, "\n", 2)[1]), 0o600))
+
+	_, err = reader.Load(context.Background())
+	assert.ErrorContains(t, err, "file was tampered with")
+}
+
+// TestLoadTestdata loads SOPS 3.9 files which weren't written by the provider,
+// encrypted to the identity in `testdata/keys.txt`, and compares them with
+// their plaintext in `testdata/plain`. `testdata/generate.sh` regenerates
+// them with `sops`.
+func TestLoadTestdata(t *testing.T) {
+	keys, err := os.ReadFile(filepath.Join("testdata", "keys.txt"))
+	require.NoError(t, err)
+
+	var id string
+
+	for _, line := range strings.Split(string(keys), "\n") {
+		if strings.HasPrefix(line, "AGE-SECRET-KEY-") {
+			id = line
+		}
+	}
+
+	require.NotEmpty(t, id)
+
+	tree := map[string]string{
+		"database":                `{"host":"db.internal","port":5432,"ratio":0.75,"tls":true}`,
+		"hosts":                   `["a.internal","b.internal"]`,
+		"api_key":                 "s3cr3t",
+		"description_unencrypted": "plain text",
+	}
+
+	tests := []struct {
+		fileName  string
+		want      map[string]string
+		plaintext string
+	}{
+		{fileName: "secrets.enc.yaml", want: tree, plaintext: "plain text"},
+		{fileName: "secrets.enc.json", want: tree, plaintext: "plain text"},
+		{
+			fileName: "secrets.enc.env",
+			want: map[string]string{
+				"DB_HOST":               "db.internal",
+				"DB_PORT":               "5432",
+				"API_KEY":               "s3cr3t",
+				"LOG_LEVEL_unencrypted": "debug",
+			},
+			plaintext: "debug",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fileName, func(t *testing.T) {
+			for key := range tt.want {
+				testenv.Unset(t, key)
+			}
+
+			filePath := filepath.Join("testdata", tt.fileName)
+
+			p, err := New(true, false, &Config{FilePaths: []string{filePath}, Identities: []string{id}})
+			require.NoError(t, err)
+
+			got, err := p.Load(context.Background())
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want, got)
+
+			// Same values as the plaintext.
+			plain, err := os.Open(filepath.Join("testdata", "plain", strings.Replace(tt.fileName, ".enc", "", 1)))
+			require.NoError(t, err)
+
+			defer plain.Close()
+
+			parsed, err := util.ParseContent(context.Background(), strings.TrimPrefix(filepath.Ext(tt.fileName), "."), plain)
+			require.NoError(t, err)
+			require.Len(t, parsed, len(tt.want))
+
+			for key, value := range parsed {
+				encoded, err := util.EncodeValue(value)
+				require.NoError(t, err)
+				assert.Equal(t, tt.want[key], fmt.Sprint(encoded), key)
+			}
+
+			// Unencrypted values are part of the MAC.
+			content, err := os.ReadFile(filePath)
+			require.NoError(t, err)
+
+			tamperedPath := filepath.Join(t.TempDir(), tt.fileName)
+			require.NoError(t, os.WriteFile(tamperedPath, bytes.Replace(content, []byte(tt.plaintext), []byte("tampered"), 1), 0o600))
+
+			p, err = New(false, false, &Config{FilePaths: []string{tamperedPath}, Identities: []string{id}})
+			require.NoError(t, err)
+
+			_, err = p.Load(context.Background())
+			assert.ErrorContains(t, err, "file was tampered with")
+		})
+	}
+}
+
+func TestLoadWriteErrors(t *testing.T) {
+	id, recipient := identity(t)
+	otherID, _ := identity(t)
+
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "secrets.yaml")
+
+	writer, err := New(false, false, &Config{FilePaths: []string{filePath}, Recipients: []string{recipient}})
+	require.NoError(t, err)
+	require.NoError(t, writer.Write(context.Background(), map[string]interface{}{
+		"KEY":              "value",
+		"NOTE_unencrypted": "plain",
+	}))
+
+	content, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+
+	tamperedPath := filepath.Join(dir, "tampered.yaml")
+	require.NoError(t, os.WriteFile(tamperedPath, bytes.Replace(content, []byte("plain"), []byte("other"), 1), 0o600))
+
+	notSOPSPath := filepath.Join(dir, "plain.json")
+	require.NoError(t, os.WriteFile(notSOPSPath, []byte(`{"KEY": "value"}`), 0o600))
+
+	tests := []struct {
+		name    string
+		config  *Config
+		wantErr string
+	}{
+		{
+			name:    "load without identities",
+			config:  &Config{FilePaths: []string{filePath}},
+			wantErr: "age identities",
+		},
+		{
+			name:    "load with wrong identity",
+			config:  &Config{FilePaths: []string{filePath}, Identities: []string{otherID}},
+			wantErr: "age identity matching the file recipients",
+		},
+		{
+			name:    "load tampered file",
+			config:  &Config{FilePaths: []string{tamperedPath}, Identities: []string{id}},
+			wantErr: "file was tampered with",
+		},
+		{
+			name:    "load not a SOPS file",
+			config:  &Config{FilePaths: []string{notSOPSPath}, Identities: []string{id}},
+			wantErr: "isn't encrypted with SOPS",
+		},
+		{
+			name:    "load unsupported format",
+			config:  &Config{FilePaths: []string{filepath.Join(dir, "secrets.ini")}, Identities: []string{id}},
+			wantErr: "allowed: .yaml, .yml, .json, .env",
+		},
+		{
+			name:    "load missing file",
+			config:  &Config{FilePaths: []string{filepath.Join(dir, "missing.yaml")}, Identities: []string{id}},
+			wantErr: "read path",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(false, false, tt.config)
+			require.NoError(t, err)
+
+			_, err = p.Load(context.Background())
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+
+	writer, err = New(false, false, &Config{FilePaths: []string{filepath.Join(dir, "new.yaml")}})
+	require.NoError(t, err)
+	assert.ErrorContains(t, writer.Write(context.Background(), map[string]interface{}{"A": "1"}), "age recipients")
+	assert.ErrorContains(t, writer.Write(context.Background(), nil), "values")
+
+	writer, err = New(false, false, &Config{FilePaths: []string{filePath, filePath}, Recipients: []string{recipient}})
+	require.NoError(t, err)
+	assert.ErrorContains(t, writer.Write(context.Background(), map[string]interface{}{"A": "1"}), "only one file")
+
+	writer, err = New(false, false, &Config{FilePaths: []string{filepath.Join(dir, ".env")}, Recipients: []string{recipient}})
+	require.NoError(t, err)
+	assert.ErrorContains(t, writer.Write(context.Background(), map[string]interface{}{"A": map[string]any{"B": "1"}}), "dotenv values must be flat")
+}
@@ -0,0 +1,14 @@
+#!/bin/sh
+# Regenerates the SOPS fixtures from the plaintext files in `plain/`, with
+# `sops` 3.9 or later, encrypting to the identity in `keys.txt`:
+#
+#   sh sops/testdata/generate.sh
+set -eu
+
+cd "$(dirname "$0")"
+
+recipient=$(sed -n 's/^# public key: //p' keys.txt)
+
+for format in yaml json env; do
+	sops encrypt --age "$recipient" --output "secrets.enc.$format" "plain/secrets.$format"
+done
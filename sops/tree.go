@@ -0,0 +1,375 @@
+package sops
+
+import (
+	"crypto/sha512"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/thalesfsp/customerror"
+)
+
+//////
+// Vars, consts, and types.
+//////
+
+// metadataKey is the key of the SOPS metadata in encrypted documents.
+const metadataKey = "sops"
+
+// comment is a document comment. SOPS encrypts, and authenticates comments
+// like values.
+type comment struct {
+	value string
+}
+
+// item is a key/value pair of a branch. The key is either a string, or a
+// comment, in which case there's no value.
+type item struct {
+	key   any
+	value any
+}
+
+// branch is an ordered map, values are branches, lists (`[]any`), or leaves.
+type branch []item
+
+// ageKey is the data key encrypted to an age recipient.
+type ageKey struct {
+	Recipient        string `json:"recipient" yaml:"recipient"`
+	EncryptedDataKey string `json:"enc" yaml:"enc"`
+}
+
+// keyGroup is a group of master keys.
+type keyGroup struct {
+	Age []ageKey `json:"age,omitempty" yaml:"age,omitempty"`
+}
+
+// metadata is the `sops` section of encrypted documents.
+type metadata struct {
+	KeyGroups         []keyGroup `json:"key_groups,omitempty" yaml:"key_groups,omitempty"`
+	Age               []ageKey   `json:"age" yaml:"age"`
+	LastModified      string     `json:"lastmodified" yaml:"lastmodified"`
+	MAC               string     `json:"mac" yaml:"mac"`
+	UnencryptedSuffix string     `json:"unencrypted_suffix,omitempty" yaml:"unencrypted_suffix,omitempty"`
+	EncryptedSuffix   string     `json:"encrypted_suffix,omitempty" yaml:"encrypted_suffix,omitempty"`
+	UnencryptedRegex  string     `json:"unencrypted_regex,omitempty" yaml:"unencrypted_regex,omitempty"`
+	EncryptedRegex    string     `json:"encrypted_regex,omitempty" yaml:"encrypted_regex,omitempty"`
+	MACOnlyEncrypted  bool       `json:"mac_only_encrypted,omitempty" yaml:"mac_only_encrypted,omitempty"`
+	Version           string     `json:"version" yaml:"version"`
+}
+
+//////
+// Methods.
+//////
+
+// ageKeys returns the age keys, from the top-level, or the key group.
+func (m *metadata) ageKeys() ([]ageKey, error) {
+	switch len(m.KeyGroups) {
+	case 0:
+		return m.Age, nil
+	case 1:
+		return m.KeyGroups[0].Age, nil
+	default:
+		return nil, customerror.NewInvalidError("key_groups, Shamir secret sharing isn't supported")
+	}
+}
+
+// shouldEncrypt determines whether the value at `path` is encrypted, based on
+// the suffix, and regex settings, applied to each path element.
+func (m *metadata) shouldEncrypt(path []string) (bool, error) {
+	encrypted := true
+
+	if m.UnencryptedSuffix != "" {
+		for _, p := range path {
+			if strings.HasSuffix(p, m.UnencryptedSuffix) {
+				encrypted = false
+
+				break
+			}
+		}
+	}
+
+	if m.EncryptedSuffix != "" {
+		encrypted = false
+
+		for _, p := range path {
+			if strings.HasSuffix(p, m.EncryptedSuffix) {
+				encrypted = true
+
+				break
+			}
+		}
+	}
+
+	for _, rule := range []struct {
+		expression string
+		encrypted  bool
+	}{
+		{m.UnencryptedRegex, false},
+		{m.EncryptedRegex, true},
+	} {
+		if rule.expression == "" {
+			continue
+		}
+
+		r, err := regexp.Compile(rule.expression)
+		if err != nil {
+			return false, customerror.NewInvalidError("metadata regex "+rule.expression, customerror.WithError(err))
+		}
+
+		encrypted = !rule.encrypted
+
+		for _, p := range path {
+			if r.MatchString(p) {
+				encrypted = rule.encrypted
+
+				break
+			}
+		}
+	}
+
+	return encrypted, nil
+}
+
+//////
+// Helpers.
+//////
+
+// encryptTree encrypts the values of `b` with `key`, in place, returning the
+// MAC of the plaintext values.
+func encryptTree(b branch, key []byte, m *metadata) (string, error) {
+	hash := sha512.New()
+
+	_, err := walk(b, nil, func(v any, path []string) (any, error) {
+		encrypted, err := m.shouldEncrypt(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !m.MACOnlyEncrypted || encrypted {
+			hash.Write(toBytes(v))
+		}
+
+		if !encrypted {
+			return v, nil
+		}
+
+		return encryptValue(v, key, additionalData(path))
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%X", hash.Sum(nil)), nil
+}
+
+// decryptTree decrypts the values of `b` with `key`, in place, returning the
+// MAC of the plaintext values.
+func decryptTree(b branch, key []byte, m *metadata) (string, error) {
+	hash := sha512.New()
+
+	_, err := walk(b, nil, func(v any, path []string) (any, error) {
+		encrypted, err := m.shouldEncrypt(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if encrypted {
+			switch value := v.(type) {
+			case comment:
+				// Comments added after encryption are plaintext.
+				if decrypted, err := decryptValue(value.value, key, additionalData(path)); err == nil {
+					v = decrypted
+				}
+			case string:
+				if v, err = decryptValue(value, key, additionalData(path)); err != nil {
+					return nil, err
+				}
+			default:
+				return nil, customerror.NewInvalidError("value at " + additionalData(path) + ", expected an encrypted value")
+			}
+		}
+
+		if !m.MACOnlyEncrypted || encrypted {
+			hash.Write(toBytes(v))
+		}
+
+		return v, nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%X", hash.Sum(nil)), nil
+}
+
+// walk calls `fn` for each leaf, and comment, in document order, replacing it
+// with the result. List items share the path of the list.
+func walk(v any, path []string, fn func(v any, path []string) (any, error)) (any, error) {
+	switch value := v.(type) {
+	case branch:
+		for i, it := range value {
+			if c, ok := it.key.(comment); ok {
+				result, err := fn(c, path)
+				if err != nil {
+					return nil, err
+				}
+
+				switch r := result.(type) {
+				case comment:
+					value[i].key = r
+				case string:
+					value[i].key = comment{value: r}
+				default:
+					value[i].key = comment{value: fmt.Sprint(r)}
+				}
+
+				continue
+			}
+
+			key, ok := it.key.(string)
+			if !ok {
+				return nil, customerror.NewInvalidError(fmt.Sprintf("key %v, only string keys are supported", it.key))
+			}
+
+			childPath := append(append([]string{}, path...), key)
+
+			result, err := walk(it.value, childPath, fn)
+			if err != nil {
+				return nil, err
+			}
+
+			value[i].value = result
+		}
+
+		return value, nil
+	case []any:
+		for i, child := range value {
+			result, err := walk(child, path, fn)
+			if err != nil {
+				return nil, err
+			}
+
+			value[i] = result
+		}
+
+		return value, nil
+	case nil:
+		return nil, nil
+	default:
+		return fn(v, path)
+	}
+}
+
+// additionalData is the AES-GCM additional data of the value at `path`.
+func additionalData(path []string) string {
+	return strings.Join(path, ":") + ":"
+}
+
+// toBytes is the representation of `v` used by the MAC.
+func toBytes(v any) []byte {
+	switch value := v.(type) {
+	case string:
+		return []byte(value)
+	case []byte:
+		return value
+	case int:
+		return []byte(strconv.Itoa(value))
+	case float64:
+		return []byte(strconv.FormatFloat(value, 'f', -1, 64))
+	case bool:
+		if value {
+			return []byte("True")
+		}
+
+		return []byte("False")
+	case comment:
+		return []byte(value.value)
+	default:
+		return []byte(fmt.Sprint(value))
+	}
+}
+
+// removeMetadata removes, and returns the metadata item from `b`.
+func removeMetadata(b branch) (branch, any, bool) {
+	for i, it := range b {
+		if it.key == metadataKey {
+			return append(b[:i:i], b[i+1:]...), it.value, true
+		}
+	}
+
+	return b, nil, false
+}
+
+// plain converts a tree to plain maps, and lists, dropping comments.
+func plain(v any) any {
+	switch value := v.(type) {
+	case branch:
+		m := make(map[string]any, len(value))
+
+		for _, it := range value {
+			if key, ok := it.key.(string); ok {
+				m[key] = plain(it.value)
+			}
+		}
+
+		return m
+	case []any:
+		list := make([]any, 0, len(value))
+
+		for _, child := range value {
+			if _, ok := child.(comment); !ok {
+				list = append(list, plain(child))
+			}
+		}
+
+		return list
+	case []byte:
+		return string(value)
+	default:
+		return v
+	}
+}
+
+// fromPlain converts plain maps, and lists to a tree, keys sorted.
+func fromPlain(v any) any {
+	switch value := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(value))
+
+		for key := range value {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		b := make(branch, 0, len(keys))
+
+		for _, key := range keys {
+			b = append(b, item{key: key, value: fromPlain(value[key])})
+		}
+
+		return b
+	case []any:
+		list := make([]any, 0, len(value))
+
+		for _, child := range value {
+			list = append(list, fromPlain(child))
+		}
+
+		return list
+	case nil, string, bool, int, float64:
+		return value
+	case int64:
+		return int(value)
+	case int32:
+		return int(value)
+	case uint64:
+		return int(value)
+	case float32:
+		return float64(value)
+	default:
+		return fmt.Sprint(value)
+	}
+}
@@ -1,22 +1,77 @@
 package util
 
 import (
+	"encoding/base64"
 	"encoding/json"
-	"fmt"
 	"os"
 	"reflect"
+	"regexp"
+	"sort"
+	"strings"
 
+	"github.com/pelletier/go-toml"
+	"github.com/thalesfsp/configurer/option"
 	"github.com/thalesfsp/customerror"
 	"github.com/thalesfsp/validation"
 	"gopkg.in/yaml.v2"
 )
 
+//////
+// Vars, consts, and types.
+//////
+
+// Shells supported by `DumpToShell`.
+const (
+	ShellBash       = "bash"
+	ShellFish       = "fish"
+	ShellPowerShell = "powershell"
+	ShellZsh        = "zsh"
+)
+
+// Shells is the list of shells supported by `DumpToShell`.
+var Shells = []string{ShellBash, ShellZsh, ShellFish, ShellPowerShell}
+
+var (
+	// bareEnvValueRegex matches values which don't need quoting in `.env`
+	// files.
+	bareEnvValueRegex = regexp.MustCompile(`^[A-Za-z0-9_./:@,+=%^~?&-]*$`)
+
+	// envEscaper escapes double-quoted `.env` values.
+	envEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "\n", `\n`, "\r", `\r`)
+
+	// envNameRegex matches portable env var names.
+	envNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+	// k8sKeyRegex matches valid Secret data keys.
+	k8sKeyRegex = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+
+	// k8sNameRegex matches RFC 1123 subdomains, the valid Secret names.
+	k8sNameRegex = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+)
+
+// k8sSecret is a Kubernetes Secret manifest.
+type k8sSecret struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Metadata   struct {
+		Name string `yaml:"name"`
+	} `yaml:"metadata"`
+	Type string            `yaml:"type"`
+	Data map[string]string `yaml:"data"`
+}
+
 //////
 // Exported feature(s).
 //////
 
 // SetDefault For a given struct `v`, set default values based on the struct
-// field tags (`default`).
+// field tags (`default`). Besides literals, defaults can be expressions:
+// - `${VAR}`, and `${VAR:-fallback}` are replaced by the env var value, e.g.:
+// `${HOME}/.cache/app`.
+// - `now`, `now+<duration>`, and `now-<duration>` for time.Time fields, e.g.:
+// `now+24h`.
+// - `cpu`, and `cpu<op><n>` for numeric fields, where `op` is one of `*`, `/`,
+// `+`, `-`, e.g.: `cpu*2`. The result is never lower than 1.
 //
 // NOTE: It only sets default values for fields that are not set.
 //
@@ -24,7 +79,17 @@ import (
 // exported, and if tag is set to `-`.
 func SetDefault(v any) error {
 	if err := process("default", v, func(v reflect.Value, field reflect.StructField, tag string) error {
-		if err := setValueFromTag(v, field, tag, tag, false); err != nil {
+		content, err := expandDefault(v, tag)
+		if err != nil {
+			return err
+		}
+
+		// Expanded to nothing, e.g.: an unset env var.
+		if content == "" {
+			return nil
+		}
+
+		if err := setValueFromTag(v, field, tag, content, false); err != nil {
 			return err
 		}
 
@@ -64,9 +129,16 @@ func SetEnv(v any) error {
 	return nil
 }
 
-// SetID For a given struct `v`, set field with the specified ID type.
-//
-// NOTE: Currently only UUID is supported.
+// SetID For a given struct `v`, set field with the specified ID type. Supported
+// types:
+// - uuid: RFC4122 UUID (v4).
+// - uuidv7: RFC9562 UUID (v7), time-ordered.
+// - ulid: ULID, lexicographically sortable.
+// - ksuid: KSUID, K-sortable.
+// - nanoid[:size]: Nano ID, e.g.: `nanoid:21`. Size defaults to 21.
+// - random[:encoding[:bytes]]: random bytes, e.g.: `random:hex:32`. Encoding is
+// hex (default), or base64. Bytes defaults to 16.
+// - hostname: the machine's hostname.
 //
 // NOTE: It only sets default values for fields that are not set.
 //
@@ -74,13 +146,9 @@ func SetEnv(v any) error {
 // exported, and if tag is set to `-`.
 func SetID(v any) error {
 	if err := process("id", v, func(v reflect.Value, field reflect.StructField, tag string) error {
-		var finalID string
-
-		switch tag {
-		case "uuid":
-			finalID = GenerateUUID()
-		default:
-			return customerror.NewInvalidError("invalid ID type")
+		finalID, err := generateID(tag)
+		if err != nil {
+			return err
 		}
 
 		if err := setValueFromTag(v, field, tag, finalID, false); err != nil {
@@ -147,18 +215,30 @@ func Process(v any) error {
 	return Dump(v)
 }
 
-// DumpToEnv dumps `finalValue` to a `.env` file.
+// DumpToEnv dumps `finalValue` to a `.env` file, which can be read back by
+// the `env` parser, and the `dotenv` provider. Keys are sorted. Values are
+// written bare when they only contain safe characters, otherwise they're
+// double-quoted with `\`, `"`, `$`, line feeds, and carriage returns escaped.
+// If `rawValue` is set, all values are double-quoted.
 func DumpToEnv(file *os.File, content map[string]string, rawValue bool) error {
-	for key, value := range content {
-		if rawValue {
-			if _, err := fmt.Fprintf(file, "%s=%#v\n", key, value); err != nil {
-				return customerror.NewFailedToError("write to .env file", customerror.WithError(err))
-			}
-		} else {
-			if _, err := file.WriteString(key + "=" + value + "\n"); err != nil {
-				return customerror.NewFailedToError("write to .env file", customerror.WithError(err))
-			}
+	var b strings.Builder
+
+	for _, key := range sortedKeys(content) {
+		if key == "" || strings.ContainsAny(key, "=#'\" \t\r\n") {
+			return customerror.NewInvalidError("key " + key + ", not a valid variable name")
 		}
+
+		value := content[key]
+
+		if rawValue || !bareEnvValueRegex.MatchString(value) {
+			value = `"` + envEscaper.Replace(value) + `"`
+		}
+
+		b.WriteString(key + "=" + value + "\n")
+	}
+
+	if _, err := file.WriteString(b.String()); err != nil {
+		return customerror.NewFailedToError("write to .env file", customerror.WithError(err))
 	}
 
 	// Flush the file.
@@ -170,8 +250,16 @@ func DumpToEnv(file *os.File, content map[string]string, rawValue bool) error {
 }
 
 // DumpToJSON dumps `finalValue` to a `configurer.json` file.
-func DumpToJSON(file *os.File, content map[string]string) error {
-	b, err := json.MarshalIndent(content, "", "  ")
+//
+// NOTE: Use `option.WithFlatten` to unflatten keys like `DATABASE__HOST` into
+// nested objects. SEE: `Unflatten`.
+func DumpToJSON(file *os.File, content map[string]string, opts ...option.ParseFunc) error {
+	document, err := dumpDocument(content, opts)
+	if err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(document, "", "  ")
 	if err != nil {
 		return customerror.NewFailedToError("marshal final values to json", customerror.WithError(err))
 	}
@@ -189,8 +277,16 @@ func DumpToJSON(file *os.File, content map[string]string) error {
 }
 
 // DumpToYAML dumps `finalValue` to a `configurer.yaml` file.
-func DumpToYAML(file *os.File, content map[string]string) error {
-	b, err := yaml.Marshal(content)
+//
+// NOTE: Use `option.WithFlatten` to unflatten keys like `DATABASE__HOST` into
+// nested objects. SEE: `Unflatten`.
+func DumpToYAML(file *os.File, content map[string]string, opts ...option.ParseFunc) error {
+	document, err := dumpDocument(content, opts)
+	if err != nil {
+		return err
+	}
+
+	b, err := yaml.Marshal(document)
 	if err != nil {
 		return customerror.NewFailedToError("marshal final values to yaml", customerror.WithError(err))
 	}
@@ -206,3 +302,208 @@ func DumpToYAML(file *os.File, content map[string]string) error {
 
 	return nil
 }
+
+// DumpToTOML dumps `finalValue` to a `configurer.toml` file.
+//
+// NOTE: Use `option.WithFlatten` to unflatten keys like `DATABASE__HOST` into
+// tables. SEE: `Unflatten`.
+func DumpToTOML(file *os.File, content map[string]string, opts ...option.ParseFunc) error {
+	document, err := dumpDocument(content, opts)
+	if err != nil {
+		return err
+	}
+
+	raw, ok := document.(map[string]any)
+	if !ok {
+		raw = make(map[string]any, len(content))
+		for k, v := range content {
+			raw[k] = v
+		}
+	}
+
+	tree, err := toml.TreeFromMap(raw)
+	if err != nil {
+		return customerror.NewFailedToError("marshal final values to toml", customerror.WithError(err))
+	}
+
+	b, err := tree.Marshal()
+	if err != nil {
+		return customerror.NewFailedToError("marshal final values to toml", customerror.WithError(err))
+	}
+
+	return writeDump(file, b)
+}
+
+// DumpToShell dumps `finalValue` to a script which exports the values when
+// sourced by `shell`, one of `Shells`, e.g.: `export K='v'` for bash.
+func DumpToShell(file *os.File, content map[string]string, shell string) error {
+	var format func(key, value string) string
+
+	switch shell {
+	case ShellBash, ShellZsh:
+		format = func(key, value string) string {
+			return "export " + key + "='" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+		}
+	case ShellFish:
+		format = func(key, value string) string {
+			value = strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(value)
+
+			return "set -gx " + key + " '" + value + "'"
+		}
+	case ShellPowerShell:
+		format = func(key, value string) string {
+			return "$env:" + key + " = '" + strings.ReplaceAll(value, "'", "''") + "'"
+		}
+	default:
+		return customerror.NewInvalidError("shell, allowed: " + strings.Join(Shells, ", "))
+	}
+
+	var b strings.Builder
+
+	for _, key := range sortedKeys(content) {
+		if !envNameRegex.MatchString(key) {
+			return customerror.NewInvalidError("key " + key + ", not a valid variable name")
+		}
+
+		b.WriteString(format(key, content[key]) + "\n")
+	}
+
+	return writeDump(file, []byte(b.String()))
+}
+
+// DumpToDockerEnv dumps `finalValue` to a `docker run --env-file` file. Values
+// are written verbatim, the format has no quoting.
+func DumpToDockerEnv(file *os.File, content map[string]string) error {
+	var b strings.Builder
+
+	for _, key := range sortedKeys(content) {
+		if key == "" || strings.ContainsAny(key, "= \t\n") {
+			return customerror.NewInvalidError("key " + key + ", not a valid variable name")
+		}
+
+		if strings.ContainsAny(content[key], "\r\n") {
+			return customerror.NewInvalidError("key " + key + " value, docker env files can't contain line breaks")
+		}
+
+		b.WriteString(key + "=" + content[key] + "\n")
+	}
+
+	return writeDump(file, []byte(b.String()))
+}
+
+// DumpToK8sSecret dumps `finalValue` to a Kubernetes `Secret` manifest named
+// `name`, ready for `kubectl apply -f`.
+func DumpToK8sSecret(file *os.File, content map[string]string, name string) error {
+	if !k8sNameRegex.MatchString(name) {
+		return customerror.NewInvalidError("secret name " + name + ", must be a lowercase RFC 1123 subdomain")
+	}
+
+	secret := k8sSecret{
+		APIVersion: "v1",
+		Data:       make(map[string]string, len(content)),
+		Kind:       "Secret",
+		Type:       "Opaque",
+	}
+
+	secret.Metadata.Name = name
+
+	for key, value := range content {
+		if !k8sKeyRegex.MatchString(key) {
+			return customerror.NewInvalidError("key " + key + ", allowed: alphanumerics, -, _, and .")
+		}
+
+		secret.Data[key] = base64.StdEncoding.EncodeToString([]byte(value))
+	}
+
+	b, err := yaml.Marshal(secret)
+	if err != nil {
+		return customerror.NewFailedToError("marshal final values to a secret manifest", customerror.WithError(err))
+	}
+
+	return writeDump(file, b)
+}
+
+// DumpToSystemd dumps `finalValue` to a systemd `EnvironmentFile`. Values are
+// double-quoted, and escaped.
+func DumpToSystemd(file *os.File, content map[string]string) error {
+	escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`")
+
+	var b strings.Builder
+
+	for _, key := range sortedKeys(content) {
+		if !envNameRegex.MatchString(key) {
+			return customerror.NewInvalidError("key " + key + ", not a valid variable name")
+		}
+
+		b.WriteString(key + `="` + escaper.Replace(content[key]) + "\"\n")
+	}
+
+	return writeDump(file, []byte(b.String()))
+}
+
+// DumpToGitHubEnv dumps `finalValue` to a `$GITHUB_ENV` file, using the
+// multi-line (heredoc) syntax with a random delimiter, so values can't end
+// the block early.
+//
+// SEE: https://docs.github.com/en/actions/reference/workflows-and-actions/workflow-commands#multiline-strings
+func DumpToGitHubEnv(file *os.File, content map[string]string) error {
+	var b strings.Builder
+
+	for _, key := range sortedKeys(content) {
+		if key == "" || strings.ContainsAny(key, "=<\r\n") {
+			return customerror.NewInvalidError("key " + key + ", not a valid variable name")
+		}
+
+		delimiter := "ghadelimiter_" + GenerateUUID()
+
+		b.WriteString(key + "<<" + delimiter + "\n" + content[key] + "\n" + delimiter + "\n")
+	}
+
+	return writeDump(file, []byte(b.String()))
+}
+
+// dumpDocument returns `content` as is, or unflattened if the `Flatten` option
+// is set.
+func dumpDocument(content map[string]string, opts []option.ParseFunc) (any, error) {
+	o, err := option.NewParse(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if !o.Flatten {
+		return content, nil
+	}
+
+	m := make(map[string]any, len(content))
+	for k, v := range content {
+		m[k] = v
+	}
+
+	return Unflatten(m, opts...)
+}
+
+// sortedKeys returns the keys of `content`, sorted, so dumps are stable.
+func sortedKeys(content map[string]string) []string {
+	keys := make([]string, 0, len(content))
+	for k := range content {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// writeDump writes `b` to `file`, and flushes it.
+func writeDump(file *os.File, b []byte) error {
+	if _, err := file.Write(b); err != nil {
+		return customerror.NewFailedToError("write to "+file.Name()+" file", customerror.WithError(err))
+	}
+
+	// Flush the file.
+	if err := file.Sync(); err != nil {
+		return customerror.NewFailedToError("flush "+file.Name()+" file", customerror.WithError(err))
+	}
+
+	return nil
+}
@@ -1,13 +1,17 @@
 package util
 
 import (
+	"context"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/thalesfsp/configurer/option"
+	"github.com/thalesfsp/godotenv"
 )
 
 //////
@@ -362,6 +366,92 @@ func TestDumpErrors(t *testing.T) {
 // File dump helpers.
 //////
 
+func TestDumpToFormatsErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		dump    func(file *os.File) error
+		wantErr string
+	}{
+		{
+			name: "unknown shell",
+			dump: func(file *os.File) error {
+				return DumpToShell(file, map[string]string{"KEY": "value"}, "tcsh")
+			},
+			wantErr: "shell, allowed: bash, zsh, fish, powershell",
+		},
+		{
+			name: "invalid shell variable",
+			dump: func(file *os.File) error {
+				return DumpToShell(file, map[string]string{"database.host": "value"}, ShellBash)
+			},
+			wantErr: "key database.host, not a valid variable name",
+		},
+		{
+			name: "docker env-file multi-line value",
+			dump: func(file *os.File) error {
+				return DumpToDockerEnv(file, map[string]string{"KEY": "a\nb"})
+			},
+			wantErr: "can't contain line breaks",
+		},
+		{
+			name: "docker env-file invalid key",
+			dump: func(file *os.File) error {
+				return DumpToDockerEnv(file, map[string]string{"A KEY": "value"})
+			},
+			wantErr: "key A KEY, not a valid variable name",
+		},
+		{
+			name: "kubernetes secret invalid name",
+			dump: func(file *os.File) error {
+				return DumpToK8sSecret(file, map[string]string{"KEY": "value"}, "App")
+			},
+			wantErr: "secret name App",
+		},
+		{
+			name: "kubernetes secret invalid key",
+			dump: func(file *os.File) error {
+				return DumpToK8sSecret(file, map[string]string{"A/KEY": "value"}, "app")
+			},
+			wantErr: "key A/KEY",
+		},
+		{
+			name: "systemd invalid key",
+			dump: func(file *os.File) error {
+				return DumpToSystemd(file, map[string]string{"1KEY": "value"})
+			},
+			wantErr: "key 1KEY, not a valid variable name",
+		},
+		{
+			name: "github env invalid key",
+			dump: func(file *os.File) error {
+				return DumpToGitHubEnv(file, map[string]string{"A=B": "value"})
+			},
+			wantErr: "key A=B, not a valid variable name",
+		},
+		{
+			name: "toml write",
+			dump: func(file *os.File) error {
+				require.NoError(t, file.Close())
+
+				return DumpToTOML(file, map[string]string{"KEY": "value"})
+			},
+			wantErr: "write to",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := os.CreateTemp(t.TempDir(), "dump")
+			require.NoError(t, err)
+			t.Cleanup(func() {
+				_ = file.Close()
+			})
+
+			assert.ErrorContains(t, tt.dump(file), tt.wantErr)
+		})
+	}
+}
+
 func TestDumpToFile(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -396,6 +486,73 @@ func TestDumpToFile(t *testing.T) {
 			},
 			contain: "KEY: value",
 		},
+		{
+			name: "toml",
+			dump: func(file *os.File) error {
+				return DumpToTOML(file, map[string]string{"KEY": "value"})
+			},
+			contain: `KEY = "value"`,
+		},
+		{
+			name: "toml unflattened",
+			dump: func(file *os.File) error {
+				return DumpToTOML(
+					file,
+					map[string]string{"DATABASE__HOST": "localhost"},
+					option.WithFlatten(true),
+				)
+			},
+			contain: "[database]\n  host = \"localhost\"",
+		},
+		{
+			name: "bash",
+			dump: func(file *os.File) error {
+				return DumpToShell(file, map[string]string{"KEY": "it's", "A": "1"}, ShellBash)
+			},
+			contain: "export A='1'\nexport KEY='it'\\''s'\n",
+		},
+		{
+			name: "fish",
+			dump: func(file *os.File) error {
+				return DumpToShell(file, map[string]string{"KEY": `it's \`}, ShellFish)
+			},
+			contain: `set -gx KEY 'it\'s \\'`,
+		},
+		{
+			name: "powershell",
+			dump: func(file *os.File) error {
+				return DumpToShell(file, map[string]string{"KEY": "it's"}, ShellPowerShell)
+			},
+			contain: `$env:KEY = 'it''s'`,
+		},
+		{
+			name: "docker env-file",
+			dump: func(file *os.File) error {
+				return DumpToDockerEnv(file, map[string]string{"KEY": `"quoted" value`})
+			},
+			contain: `KEY="quoted" value`,
+		},
+		{
+			name: "kubernetes secret",
+			dump: func(file *os.File) error {
+				return DumpToK8sSecret(file, map[string]string{"KEY": "value"}, "app")
+			},
+			contain: "apiVersion: v1\nkind: Secret\nmetadata:\n  name: app\ntype: Opaque\ndata:\n  KEY: dmFsdWU=\n",
+		},
+		{
+			name: "systemd",
+			dump: func(file *os.File) error {
+				return DumpToSystemd(file, map[string]string{"KEY": `say "$HI" \`})
+			},
+			contain: `KEY="say \"\$HI\" \\"`,
+		},
+		{
+			name: "github env",
+			dump: func(file *os.File) error {
+				return DumpToGitHubEnv(file, map[string]string{"KEY": "line 1\nline 2"})
+			},
+			contain: "KEY<<ghadelimiter_",
+		},
 	}
 
 	for _, tt := range tests {
@@ -520,3 +677,61 @@ func withPipeWriter(t *testing.T, dump func(file *os.File) error) error {
 
 	return dump(writer)
 }
+
+func TestDumpToEnvRoundTrip(t *testing.T) {
+	content := map[string]string{
+		"PEM":      "-----BEGIN KEY-----\nMIIB\r\n-----END KEY-----\n",
+		"JSON":     `{"a": "b\\c", "d": [1, 2]}`,
+		"COMMENT":  "value # not a comment",
+		"QUOTES":   `it's "quoted"`,
+		"SPACES":   "  leading and trailing  ",
+		"DOLLAR":   "$HOME and ${USER}",
+		"TAB":      "a\tb",
+		"URL":      "postgres://user:p@ss@host:5432/db?sslmode=disable",
+		"EMPTY":    "",
+		"BACKTICK": "`cmd` !bang",
+	}
+
+	path := filepath.Join(t.TempDir(), "dump.env")
+
+	file, err := os.Create(path)
+	require.NoError(t, err)
+	require.NoError(t, DumpToEnv(file, content, false))
+	require.NoError(t, file.Close())
+
+	dumped, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	// Sorted, and unquoted when safe.
+	assert.True(t, strings.HasPrefix(string(dumped), "BACKTICK="))
+	assert.Contains(t, string(dumped), "\nEMPTY=\n")
+	assert.Contains(t, string(dumped), "\nURL=postgres://user:p@ss@host:5432/db?sslmode=disable\n")
+
+	file, err = os.Open(path)
+	require.NoError(t, err)
+
+	defer file.Close()
+
+	parsed, err := ParseFile(context.Background(), file)
+	require.NoError(t, err)
+
+	want := make(map[string]any, len(content))
+	for k, v := range content {
+		want[k] = v
+	}
+
+	assert.Equal(t, want, parsed)
+
+	loaded, err := godotenv.Read(path)
+	require.NoError(t, err)
+	assert.Equal(t, content, loaded)
+}
+
+func TestDumpToEnvInvalidKey(t *testing.T) {
+	file, err := os.CreateTemp(t.TempDir(), "dump")
+	require.NoError(t, err)
+
+	defer file.Close()
+
+	assert.ErrorContains(t, DumpToEnv(file, map[string]string{"A B": "value"}, false), "key A B, not a valid variable name")
+}
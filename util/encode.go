@@ -0,0 +1,27 @@
+package util
+
+import "encoding/json"
+
+//////
+// Exported feature(s).
+//////
+
+// EncodeValue encodes `value` for providers storing strings, e.g.:
+// environment variables. Nested objects, and arrays are JSON-encoded, and nil
+// is empty. Other values are returned as they are, to be formatted with
+// `fmt.Sprint`. The error is the one of `json.Marshal`.
+func EncodeValue(value any) (any, error) {
+	switch value.(type) {
+	case map[string]any, []any:
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+
+		return string(encoded), nil
+	case nil:
+		return "", nil
+	default:
+		return value, nil
+	}
+}
@@ -0,0 +1,43 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//////
+// Value encoding.
+//////
+
+func TestEncodeValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   any
+		want    any
+		wantErr bool
+	}{
+		{name: "object", value: map[string]any{"host": "db", "port": 5432}, want: `{"host":"db","port":5432}`},
+		{name: "array", value: []any{"a", 1, true}, want: `["a",1,true]`},
+		{name: "nil", value: nil, want: ""},
+		{name: "string", value: "value", want: "value"},
+		{name: "number", value: 5432, want: 5432},
+		{name: "bool", value: true, want: true},
+		{name: "not encodable", value: map[string]any{"ch": make(chan int)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EncodeValue(tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/thalesfsp/customerror"
+)
+
+//////
+// Exported feature(s).
+//////
+
+// WriteFileAtomic calls `write` with a temporary file, only readable by the
+// owner, in the same directory as `filePath`, flushes it to disk, and renames
+// it over `filePath`. Readers see either the previous content, or the new
+// one, even if the process, or the machine crashes midway.
+func WriteFileAtomic(filePath string, write func(file *os.File) error) error {
+	f, err := os.CreateTemp(filepath.Dir(filePath), "."+filepath.Base(filePath)+".*.tmp")
+	if err != nil {
+		return customerror.NewFailedToError("write path", customerror.WithError(err))
+	}
+
+	// Removes the temporary file, unless renamed.
+	defer os.Remove(f.Name())
+
+	if err := write(f); err != nil {
+		f.Close()
+
+		return err
+	}
+
+	// The content must be on disk before the rename is, otherwise a crash can
+	// leave an empty file behind.
+	if err := f.Sync(); err != nil {
+		f.Close()
+
+		return customerror.NewFailedToError("write path", customerror.WithError(err))
+	}
+
+	if err := f.Close(); err != nil {
+		return customerror.NewFailedToError("write path", customerror.WithError(err))
+	}
+
+	if err := os.Rename(f.Name(), filePath); err != nil {
+		return customerror.NewFailedToError("write path", customerror.WithError(err))
+	}
+
+	// Persists the rename. Best effort, directories can't be synced on every
+	// platform.
+	if dir, err := os.Open(filepath.Dir(filePath)); err == nil {
+		_ = dir.Sync()
+		_ = dir.Close()
+	}
+
+	return nil
+}
+
+// WriteFileAtomicBytes writes `content` with `WriteFileAtomic`.
+func WriteFileAtomicBytes(filePath string, content []byte) error {
+	return WriteFileAtomic(filePath, func(file *os.File) error {
+		if _, err := file.Write(content); err != nil {
+			return customerror.NewFailedToError("write path", customerror.WithError(err))
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,122 @@
+package util
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//////
+// Atomic writes.
+//////
+
+func TestWriteFileAtomic(t *testing.T) {
+	tests := []struct {
+		name       string
+		existing   string
+		write      func(file *os.File) error
+		missingDir bool
+		want       string
+		wantErr    string
+	}{
+		{
+			name: "creates the file",
+			write: func(file *os.File) error {
+				_, err := file.WriteString("new")
+
+				return err
+			},
+			want: "new",
+		},
+		{
+			name:     "replaces the file",
+			existing: "old",
+			write: func(file *os.File) error {
+				_, err := file.WriteString("new")
+
+				return err
+			},
+			want: "new",
+		},
+		{
+			name:     "keeps the file, if writing fails",
+			existing: "old",
+			write: func(file *os.File) error {
+				if _, err := file.WriteString("partial"); err != nil {
+					return err
+				}
+
+				return errors.New("write failed")
+			},
+			want:    "old",
+			wantErr: "write failed",
+		},
+		{
+			name: "directory doesn't exist",
+			write: func(file *os.File) error {
+				return nil
+			},
+			missingDir: true,
+			wantErr:    "write path",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			filePath := filepath.Join(dir, ".env")
+			if tt.missingDir {
+				filePath = filepath.Join(dir, "missing", ".env")
+			}
+
+			if tt.existing != "" {
+				require.NoError(t, os.WriteFile(filePath, []byte(tt.existing), 0o644))
+			}
+
+			err := WriteFileAtomic(filePath, tt.write)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			// No temporary file is left behind.
+			entries, err := os.ReadDir(dir)
+			require.NoError(t, err)
+
+			for _, entry := range entries {
+				assert.NotContains(t, entry.Name(), ".tmp")
+			}
+
+			if tt.want == "" {
+				return
+			}
+
+			content, err := os.ReadFile(filePath)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(content))
+
+			if tt.wantErr == "" && runtime.GOOS != "windows" {
+				info, err := os.Stat(filePath)
+				require.NoError(t, err)
+				assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+			}
+		})
+	}
+}
+
+func TestWriteFileAtomicBytes(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "cache.json")
+
+	require.NoError(t, WriteFileAtomicBytes(filePath, []byte(`{"a":1}`)))
+
+	content, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":1}`, string(content))
+}
@@ -0,0 +1,215 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/thalesfsp/configurer/option"
+	"github.com/thalesfsp/customerror"
+)
+
+//////
+// Exported feature(s).
+//////
+
+// Flatten turns the nested document `m` into a single level map, joining
+// nested keys with the separator, e.g.: `{database: {host: x}}` becomes
+// `DATABASE__HOST=x`. Each key segment is converted to the configured case.
+// Arrays are expanded into indexed keys (`HOSTS__0`), or kept as JSON.
+//
+// NOTE: The `Flatten` option is ignored, `m` is always flattened.
+func Flatten(m map[string]any, opts ...option.ParseFunc) (map[string]any, error) {
+	o, err := option.NewParse(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	flattened := map[string]any{}
+
+	if err := flatten(flattened, "", reflect.ValueOf(m), o); err != nil {
+		return nil, err
+	}
+
+	return flattened, nil
+}
+
+// Unflatten is the reverse of `Flatten`: keys are split on the separator into
+// nested maps. Consecutive indexed keys (`0`, `1`, ...) become arrays, and with
+// `ArrayJSON`, JSON arrays are decoded. Segments are lower-cased if the case is
+// `Upper` (default), otherwise they're kept as is.
+//
+// NOTE: The `Flatten` option is ignored, `m` is always unflattened.
+func Unflatten(m map[string]any, opts ...option.ParseFunc) (map[string]any, error) {
+	o, err := option.NewParse(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sorted, so errors are deterministic.
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	root := map[string]any{}
+
+	for _, key := range keys {
+		segments := strings.Split(key, o.Separator)
+
+		if o.Case == option.Upper {
+			for i := range segments {
+				segments[i] = strings.ToLower(segments[i])
+			}
+		}
+
+		value := m[key]
+
+		if s, ok := value.(string); ok && o.Arrays == option.ArrayJSON && strings.HasPrefix(s, "[") {
+			var arr []any
+
+			if err := json.Unmarshal([]byte(s), &arr); err == nil {
+				value = arr
+			}
+		}
+
+		node := root
+
+		for i, segment := range segments[:len(segments)-1] {
+			child, ok := node[segment]
+			if !ok {
+				child = map[string]any{}
+
+				node[segment] = child
+			}
+
+			childMap, ok := child.(map[string]any)
+			if !ok {
+				return nil, customerror.NewInvalidError(fmt.Sprintf(
+					"key %q, conflicts with %q",
+					key,
+					strings.Join(segments[:i+1], o.Separator),
+				))
+			}
+
+			node = childMap
+		}
+
+		last := segments[len(segments)-1]
+
+		if _, ok := node[last]; ok {
+			return nil, customerror.NewInvalidError(fmt.Sprintf("key %q, conflicts with a nested key", key))
+		}
+
+		node[last] = value
+	}
+
+	for k, child := range root {
+		root[k] = toArrays(child)
+	}
+
+	return root, nil
+}
+
+//////
+// Helpers.
+//////
+
+// flatten writes `v` into `dst` under `prefix`, recursing into maps, and
+// arrays.
+func flatten(dst map[string]any, prefix string, v reflect.Value, o *option.Parse) error {
+	// Unwrap `any`.
+	for v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	switch {
+	case v.Kind() == reflect.Map && v.Len() > 0:
+		for _, k := range v.MapKeys() {
+			key := option.WithKeyCaser(o.Case)(fmt.Sprint(k.Interface()))
+
+			if prefix != "" {
+				key = prefix + o.Separator + key
+			}
+
+			if err := flatten(dst, key, v.MapIndex(k), o); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 && prefix != "":
+		if o.Arrays == option.ArrayJSON {
+			b, err := json.Marshal(v.Interface())
+			if err != nil {
+				return customerror.NewFailedToError("marshal "+prefix+" to json", customerror.WithError(err))
+			}
+
+			return setFlattened(dst, prefix, string(b))
+		}
+
+		for i := 0; i < v.Len(); i++ {
+			if err := flatten(dst, prefix+o.Separator+strconv.Itoa(i), v.Index(i), o); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case prefix == "":
+		// The root must be a map. Empty ones have nothing to flatten.
+		return nil
+	case !v.IsValid(), v.Kind() == reflect.Interface:
+		return setFlattened(dst, prefix, "")
+	case v.Kind() == reflect.Map:
+		// Empty nested map.
+		return setFlattened(dst, prefix, "")
+	default:
+		return setFlattened(dst, prefix, v.Interface())
+	}
+}
+
+// setFlattened sets `key` in `dst`, failing if it was already set by a
+// different path, e.g.: `a__b`, and `a: {b: ...}`.
+func setFlattened(dst map[string]any, key string, value any) error {
+	if _, ok := dst[key]; ok {
+		return customerror.NewInvalidError(fmt.Sprintf("key %q, it's defined more than once after flattening", key))
+	}
+
+	dst[key] = value
+
+	return nil
+}
+
+// toArrays replaces, recursively, maps whose keys are `0` to `n-1` with arrays.
+func toArrays(v any) any {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return v
+	}
+
+	for k, child := range m {
+		m[k] = toArrays(child)
+	}
+
+	if len(m) == 0 {
+		return m
+	}
+
+	arr := make([]any, len(m))
+
+	for k, child := range m {
+		i, err := strconv.Atoi(k)
+		if err != nil || i < 0 || i >= len(m) || strconv.Itoa(i) != k {
+			return m
+		}
+
+		arr[i] = child
+	}
+
+	return arr
+}
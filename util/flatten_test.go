@@ -0,0 +1,200 @@
+package util
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thalesfsp/configurer/option"
+)
+
+//////
+// Flattening.
+//////
+
+func TestFlatten(t *testing.T) {
+	document := map[string]any{
+		"name": "app",
+		"database": map[string]any{
+			"host":    "localhost",
+			"port":    5432,
+			"options": map[string]any{},
+			"replica": nil,
+		},
+		"hosts": []any{"a", map[string]any{"name": "b"}},
+		"empty": []any{},
+	}
+
+	tests := []struct {
+		name string
+		opts []option.ParseFunc
+		want map[string]any
+	}{
+		{
+			name: "defaults",
+			want: map[string]any{
+				"NAME":              "app",
+				"DATABASE__HOST":    "localhost",
+				"DATABASE__PORT":    5432,
+				"DATABASE__OPTIONS": "",
+				"DATABASE__REPLICA": "",
+				"HOSTS__0":          "a",
+				"HOSTS__1__NAME":    "b",
+			},
+		},
+		{
+			name: "separator, case, and JSON arrays",
+			opts: []option.ParseFunc{
+				option.WithFlattenSeparator("."),
+				option.WithFlattenCase(option.Lower),
+				option.WithFlattenArrays(option.ArrayJSON),
+			},
+			want: map[string]any{
+				"name":             "app",
+				"database.host":    "localhost",
+				"database.port":    5432,
+				"database.options": "",
+				"database.replica": "",
+				"hosts":            `["a",{"name":"b"}]`,
+				"empty":            `[]`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Flatten(document, tt.opts...)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFlattenErrors(t *testing.T) {
+	t.Run("conflicting keys", func(t *testing.T) {
+		_, err := Flatten(map[string]any{
+			"database__host": "a",
+			"database":       map[string]any{"host": "b"},
+		})
+		assert.ErrorContains(t, err, `"DATABASE__HOST"`)
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		for _, opt := range []option.ParseFunc{
+			option.WithFlattenSeparator(""),
+			option.WithFlattenCase("title"),
+			option.WithFlattenArrays("csv"),
+		} {
+			_, err := Flatten(map[string]any{}, opt)
+			assert.Error(t, err)
+		}
+	})
+}
+
+func TestUnflatten(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		got, err := Unflatten(map[string]any{
+			"NAME":           "app",
+			"DATABASE__HOST": "localhost",
+			"HOSTS__0":       "a",
+			"HOSTS__1__NAME": "b",
+			"SPARSE__0":      "a",
+			"SPARSE__2":      "c",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{
+			"name":     "app",
+			"database": map[string]any{"host": "localhost"},
+			"hosts":    []any{"a", map[string]any{"name": "b"}},
+			"sparse":   map[string]any{"0": "a", "2": "c"},
+		}, got)
+	})
+
+	t.Run("JSON arrays, and kept case", func(t *testing.T) {
+		got, err := Unflatten(map[string]any{
+			"Database.Hosts": `["a","b"]`,
+			"Database.Name":  "[not json",
+		},
+			option.WithFlattenSeparator("."),
+			option.WithFlattenCase(option.Camel),
+			option.WithFlattenArrays(option.ArrayJSON),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{
+			"Database": map[string]any{"Hosts": []any{"a", "b"}, "Name": "[not json"},
+		}, got)
+	})
+
+	t.Run("conflicting keys", func(t *testing.T) {
+		_, err := Unflatten(map[string]any{"A": "1", "A__B": "2"})
+		assert.ErrorContains(t, err, `conflicts with "a"`)
+
+		_, err = Unflatten(map[string]any{"a": "1", "A__B": "2"})
+		assert.ErrorContains(t, err, "conflicts with a nested key")
+	})
+}
+
+func TestFlattenRoundTrip(t *testing.T) {
+	document := map[string]any{
+		"database": map[string]any{"host": "localhost", "port": "5432"},
+		"hosts":    []any{"a", "b"},
+	}
+
+	flattened, err := Flatten(document)
+	require.NoError(t, err)
+
+	got, err := Unflatten(flattened)
+	require.NoError(t, err)
+	assert.Equal(t, document, got)
+}
+
+func TestParseContentFlatten(t *testing.T) {
+	content := "database:\n  host: localhost\nhosts:\n  - a\n"
+
+	got, err := ParseFromText(context.Background(), "yaml", content)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"database": map[string]any{"host": "localhost"},
+		"hosts":    []any{"a"},
+	}, got)
+
+	got, err = ParseFromText(context.Background(), "yaml", content, option.WithFlatten(true))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"DATABASE__HOST": "localhost", "HOSTS__0": "a"}, got)
+
+	_, err = ParseFromText(context.Background(), "yaml", content, option.WithFlattenCase("title"))
+	assert.Error(t, err)
+}
+
+func TestDumpUnflattened(t *testing.T) {
+	content := map[string]string{"DATABASE__HOST": "localhost", "HOSTS__0": "a"}
+
+	dir := t.TempDir()
+
+	jsonFile, err := os.Create(filepath.Join(dir, "configurer.json"))
+	require.NoError(t, err)
+
+	defer jsonFile.Close()
+
+	require.NoError(t, DumpToJSON(jsonFile, content, option.WithFlatten(true)))
+
+	b, err := os.ReadFile(jsonFile.Name())
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"database":{"host":"localhost"},"hosts":["a"]}`, string(b))
+
+	yamlFile, err := os.Create(filepath.Join(dir, "configurer.yaml"))
+	require.NoError(t, err)
+
+	defer yamlFile.Close()
+
+	require.NoError(t, DumpToYAML(yamlFile, content, option.WithFlatten(true)))
+
+	b, err = os.ReadFile(yamlFile.Name())
+	require.NoError(t, err)
+	assert.Equal(t, "database:\n  host: localhost\nhosts:\n- a\n", string(b))
+
+	require.Error(t, DumpToJSON(jsonFile, map[string]string{"A": "1", "A__B": "2"}, option.WithFlatten(true)))
+}
@@ -0,0 +1,235 @@
+package util
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thalesfsp/customerror"
+)
+
+//////
+// Vars, consts, and types.
+//////
+
+const (
+	// Crockford's base32, used by ULID.
+	crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+	// Base62, used by KSUID.
+	base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+	// URL-safe alphabet, used by Nano ID. 64 symbols so masking a random byte
+	// with 63 keeps the distribution uniform.
+	nanoIDAlphabet = "useandom-26T198340PX75pxJACKVERYMINDBUSHWOLF_GQZbfghjklqvwyzrict"
+
+	// KSUID's epoch, 2014-05-13T16:53:20Z.
+	ksuidEpoch = 1400000000
+
+	defaultNanoIDSize  = 21
+	defaultRandomBytes = 16
+)
+
+//////
+// Exported feature(s).
+//////
+
+// GenerateUUIDv7 generates a RFC9562 UUID version 7, which is time-ordered.
+func GenerateUUIDv7() (string, error) {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return "", customerror.NewFailedToError("generate UUIDv7", customerror.WithError(err))
+	}
+
+	return id.String(), nil
+}
+
+// GenerateULID generates a 26 characters, lexicographically sortable ULID.
+//
+// SEE: https://github.com/ulid/spec
+func GenerateULID() (string, error) {
+	b := make([]byte, 16)
+
+	if _, err := rand.Read(b[6:]); err != nil {
+		return "", customerror.NewFailedToError("generate ULID", customerror.WithError(err))
+	}
+
+	// 48 bits timestamp (ms), big-endian.
+	ms := uint64(time.Now().UnixMilli())
+	for i := 5; i >= 0; i-- {
+		b[i] = byte(ms)
+		ms >>= 8
+	}
+
+	return encodeBase(b, crockfordAlphabet, 26), nil
+}
+
+// GenerateKSUID generates a 27 characters, K-sortable KSUID.
+//
+// SEE: https://github.com/segmentio/ksuid
+func GenerateKSUID() (string, error) {
+	b := make([]byte, 20)
+
+	binary.BigEndian.PutUint32(b, uint32(time.Now().Unix()-ksuidEpoch))
+
+	if _, err := rand.Read(b[4:]); err != nil {
+		return "", customerror.NewFailedToError("generate KSUID", customerror.WithError(err))
+	}
+
+	return encodeBase(b, base62Alphabet, 27), nil
+}
+
+// GenerateNanoID generates a URL-safe Nano ID with `size` characters.
+//
+// SEE: https://github.com/ai/nanoid
+func GenerateNanoID(size int) (string, error) {
+	if size <= 0 {
+		return "", customerror.NewInvalidError("size, must be greater than 0")
+	}
+
+	b := make([]byte, size)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", customerror.NewFailedToError("generate Nano ID", customerror.WithError(err))
+	}
+
+	for i := range b {
+		b[i] = nanoIDAlphabet[b[i]&63]
+	}
+
+	return string(b), nil
+}
+
+// GenerateRandom generates `size` random bytes, encoded with `encoding`:
+// `hex`, or `base64` (URL-safe, no padding).
+func GenerateRandom(encoding string, size int) (string, error) {
+	if size <= 0 {
+		return "", customerror.NewInvalidError("size, must be greater than 0")
+	}
+
+	b := make([]byte, size)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", customerror.NewFailedToError("generate random bytes", customerror.WithError(err))
+	}
+
+	switch encoding {
+	case "hex":
+		return hex.EncodeToString(b), nil
+	case "base64":
+		return base64.RawURLEncoding.EncodeToString(b), nil
+	default:
+		return "", customerror.NewInvalidError("encoding, allowed: hex, base64")
+	}
+}
+
+//////
+// Helpers.
+//////
+
+// encodeBase encodes `b` as a big-endian number in the base of `alphabet`,
+// left-padded to `length`.
+func encodeBase(b []byte, alphabet string, length int) string {
+	n := new(big.Int).SetBytes(b)
+	base := big.NewInt(int64(len(alphabet)))
+	mod := new(big.Int)
+
+	out := make([]byte, length)
+
+	for i := length - 1; i >= 0; i-- {
+		n.DivMod(n, base, mod)
+
+		out[i] = alphabet[mod.Int64()]
+	}
+
+	return string(out)
+}
+
+// generateID generates an ID based on the `id` tag. Supported:
+// - uuid: RFC4122 UUID (v4).
+// - uuidv7: RFC9562 UUID (v7).
+// - ulid: ULID.
+// - ksuid: KSUID.
+// - nanoid[:size]: Nano ID, `size` defaults to 21.
+// - random[:encoding[:bytes]]: random bytes, `encoding` is hex (default), or
+// base64, `bytes` defaults to 16.
+// - hostname: the machine's hostname.
+func generateID(tag string) (string, error) {
+	parts := strings.Split(tag, ":")
+
+	switch parts[0] {
+	case "uuid":
+		if len(parts) == 1 {
+			return GenerateUUID(), nil
+		}
+	case "uuidv7":
+		if len(parts) == 1 {
+			return GenerateUUIDv7()
+		}
+	case "ulid":
+		if len(parts) == 1 {
+			return GenerateULID()
+		}
+	case "ksuid":
+		if len(parts) == 1 {
+			return GenerateKSUID()
+		}
+	case "hostname":
+		if len(parts) == 1 {
+			hostname, err := os.Hostname()
+			if err != nil {
+				return "", customerror.NewFailedToError("get hostname", customerror.WithError(err))
+			}
+
+			return hostname, nil
+		}
+	case "nanoid":
+		size := defaultNanoIDSize
+
+		switch len(parts) {
+		case 1:
+		case 2:
+			s, err := strconv.Atoi(parts[1])
+			if err != nil {
+				return "", customerror.NewInvalidError("nanoid size", customerror.WithError(err))
+			}
+
+			size = s
+		default:
+			return "", customerror.NewInvalidError("ID type, expected nanoid[:size]")
+		}
+
+		return GenerateNanoID(size)
+	case "random":
+		encoding := "hex"
+		size := defaultRandomBytes
+
+		if len(parts) > 3 {
+			return "", customerror.NewInvalidError("ID type, expected random[:encoding[:bytes]]")
+		}
+
+		if len(parts) > 1 {
+			encoding = parts[1]
+		}
+
+		if len(parts) > 2 {
+			s, err := strconv.Atoi(parts[2])
+			if err != nil {
+				return "", customerror.NewInvalidError("random size", customerror.WithError(err))
+			}
+
+			size = s
+		}
+
+		return GenerateRandom(encoding, size)
+	}
+
+	return "", customerror.NewInvalidError("ID type, allowed: uuid, uuidv7, ulid, ksuid, nanoid[:size], random[:encoding[:bytes]], hostname")
+}
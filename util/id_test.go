@@ -0,0 +1,190 @@
+package util
+
+import (
+	"os"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thalesfsp/configurer/internal/testenv"
+)
+
+//////
+// ID generators.
+//////
+
+func TestSetIDTypes(t *testing.T) {
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+
+	type ids struct {
+		UUID     string `id:"uuid"`
+		UUIDv7   string `id:"uuidv7"`
+		ULID     string `id:"ulid"`
+		KSUID    string `id:"ksuid"`
+		NanoID   string `id:"nanoid"`
+		NanoID10 string `id:"nanoid:10"`
+		Random   string `id:"random"`
+		Hex32    string `id:"random:hex:32"`
+		Base64   string `id:"random:base64:6"`
+		Hostname string `id:"hostname"`
+		Existing string `id:"ulid"`
+	}
+
+	got := ids{Existing: "keep"}
+
+	require.NoError(t, SetID(&got))
+
+	assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[0-9a-f]{4}-[0-9a-f]{12}$`, got.UUID)
+	assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[0-9a-f]{4}-[0-9a-f]{12}$`, got.UUIDv7)
+	assert.Regexp(t, `^[0-7][0-9A-HJKMNP-TV-Z]{25}$`, got.ULID)
+	assert.Regexp(t, `^[0-9A-Za-z]{27}$`, got.KSUID)
+	assert.Regexp(t, `^[A-Za-z0-9_-]{21}$`, got.NanoID)
+	assert.Regexp(t, `^[A-Za-z0-9_-]{10}$`, got.NanoID10)
+	assert.Regexp(t, `^[0-9a-f]{32}$`, got.Random)
+	assert.Regexp(t, `^[0-9a-f]{64}$`, got.Hex32)
+	assert.Regexp(t, `^[A-Za-z0-9_-]{8}$`, got.Base64)
+	assert.Equal(t, hostname, got.Hostname)
+	assert.Equal(t, "keep", got.Existing)
+}
+
+func TestSortableIDs(t *testing.T) {
+	tests := []struct {
+		name     string
+		generate func() (string, error)
+		wait     time.Duration
+	}{
+		{name: "ulid", generate: GenerateULID, wait: 2 * time.Millisecond},
+		{name: "uuidv7", generate: GenerateUUIDv7, wait: 2 * time.Millisecond},
+		{name: "ksuid", generate: GenerateKSUID, wait: 1100 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first, err := tt.generate()
+			require.NoError(t, err)
+
+			time.Sleep(tt.wait)
+
+			second, err := tt.generate()
+			require.NoError(t, err)
+
+			assert.Less(t, first, second)
+		})
+	}
+}
+
+func TestEncodeBase(t *testing.T) {
+	assert.Equal(t, "00000000000000000000000000", encodeBase(make([]byte, 16), crockfordAlphabet, 26))
+	assert.Equal(t, "7ZZZZZZZZZZZZZZZZZZZZZZZZZ", encodeBase(
+		[]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		crockfordAlphabet,
+		26,
+	))
+	assert.Equal(t, "00000000000000000000000000Z", encodeBase([]byte{35}, base62Alphabet, 27))
+}
+
+func TestGenerateIDErrors(t *testing.T) {
+	for _, tag := range []string{
+		"snowflake",
+		"uuid:4",
+		"ulid:x",
+		"nanoid:x",
+		"nanoid:0",
+		"nanoid:1:2",
+		"random:base32",
+		"random:hex:x",
+		"random:hex:-1",
+		"random:hex:1:2",
+		"hostname:x",
+	} {
+		t.Run(tag, func(t *testing.T) {
+			got, err := generateID(tag)
+			require.Error(t, err)
+			assert.Empty(t, got)
+		})
+	}
+}
+
+//////
+// Expression defaults.
+//////
+
+func TestSetDefaultExpressions(t *testing.T) {
+	testenv.Set(t, "SET_DEFAULT_EXPR_HOME", "/home/user")
+	testenv.Unset(t, "SET_DEFAULT_EXPR_UNSET")
+
+	type config struct {
+		Cache    string    `default:"${SET_DEFAULT_EXPR_HOME}/.cache/app"`
+		Fallback string    `default:"${SET_DEFAULT_EXPR_UNSET:-/tmp}/app"`
+		Unset    string    `default:"${SET_DEFAULT_EXPR_UNSET}"`
+		Literal  string    `default:"$SET_DEFAULT_EXPR_HOME"`
+		Workers  int       `default:"cpu*2"`
+		Threads  uint      `default:"cpu"`
+		Half     *int      `default:"cpu/1000000"`
+		Extra    float64   `default:"cpu + 1"`
+		Name     string    `default:"cpu*2"`
+		Expires  time.Time `default:"now+24h"`
+		Since    time.Time `default:"now-1h"`
+	}
+
+	var got config
+
+	before := time.Now()
+
+	require.NoError(t, SetDefault(&got))
+
+	after := time.Now()
+
+	assert.Equal(t, "/home/user/.cache/app", got.Cache)
+	assert.Equal(t, "/tmp/app", got.Fallback)
+	assert.Empty(t, got.Unset)
+	assert.Equal(t, "$SET_DEFAULT_EXPR_HOME", got.Literal)
+	assert.Equal(t, runtime.NumCPU()*2, got.Workers)
+	assert.Equal(t, uint(runtime.NumCPU()), got.Threads)
+	require.NotNil(t, got.Half)
+	assert.Equal(t, 1, *got.Half)
+	assert.InDelta(t, float64(runtime.NumCPU()+1), got.Extra, 0)
+	assert.Equal(t, "cpu*2", got.Name)
+	assert.WithinRange(t, got.Expires, before.Add(24*time.Hour), after.Add(24*time.Hour))
+	assert.WithinRange(t, got.Since, before.Add(-time.Hour), after.Add(-time.Hour))
+}
+
+func TestSetDefaultExpressionErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		v    any
+	}{
+		{
+			name: "division by zero",
+			v: &struct {
+				N int `default:"cpu/0"`
+			}{},
+		},
+		{
+			name: "invalid time offset",
+			v: &struct {
+				T time.Time `default:"now+tomorrow"`
+			}{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Error(t, SetDefault(tt.v))
+		})
+	}
+}
+
+func TestNowExpressionFromEnv(t *testing.T) {
+	testenv.Set(t, "SET_ENV_EXPR_NOW", "now+1h")
+
+	var got struct {
+		T time.Time `env:"SET_ENV_EXPR_NOW"`
+	}
+
+	require.NoError(t, SetEnv(&got))
+	assert.WithinDuration(t, time.Now().Add(time.Hour), got.T, time.Minute)
+}
@@ -2,6 +2,8 @@ package util
 
 import (
 	"context"
+	"errors"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -9,6 +11,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/thalesfsp/configurer/parser"
 )
 
 //////
@@ -187,3 +190,157 @@ func TestGetZeroControlChar(t *testing.T) {
 		})
 	}
 }
+
+//////
+// Registry.
+//////
+
+type upperParser struct{}
+
+func (upperParser) Read(_ context.Context, r io.Reader) (map[string]any, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	value, ok := strings.CutPrefix(strings.TrimSpace(string(content)), "upper:")
+	if !ok {
+		return nil, errors.New(`missing "upper:" prefix`)
+	}
+
+	return map[string]any{"CONTENT": strings.ToUpper(value)}, nil
+}
+
+func init() {
+	if err := parser.Register("upper-test", []string{".upt"}, func() (parser.IParser, error) {
+		return upperParser{}, nil
+	}); err != nil {
+		panic(err)
+	}
+}
+
+func TestParseContentRegisteredParser(t *testing.T) {
+	got, err := ParseFromText(context.Background(), "upper-test", "upper:value")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"CONTENT": "VALUE"}, got)
+
+	path := filepath.Join(t.TempDir(), "config.upt")
+	require.NoError(t, os.WriteFile(path, []byte("upper:file"), 0o600))
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+
+	defer file.Close()
+
+	got, err = ParseFile(context.Background(), file)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"CONTENT": "FILE"}, got)
+
+	_, err = ParseFromText(context.Background(), "xml", "irrelevant")
+	assert.ErrorContains(t, err, "upper-test | upt")
+}
+
+func TestParseContentAuto(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]any
+	}{
+		{
+			name:    "env",
+			content: "export FOO=bar\nBAZ='qux'\n",
+			want:    map[string]any{"FOO": "bar", "BAZ": "qux"},
+		},
+		{
+			name:    "json",
+			content: `{"FOO": {"BAR": 1}}`,
+			want:    map[string]any{"FOO": map[string]any{"BAR": float64(1)}},
+		},
+		{
+			name:    "toml",
+			content: "[database]\nhost = \"localhost\"\n",
+			want:    map[string]any{"database": map[string]any{"host": "localhost"}},
+		},
+		{
+			name:    "yaml",
+			content: "database:\n  host: localhost\n",
+			want:    map[string]any{"database": map[string]any{"host": "localhost"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFromText(context.Background(), parser.Auto, tt.content)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+
+			// Files without extension are sniffed too.
+			path := filepath.Join(t.TempDir(), "config")
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0o600))
+
+			file, err := os.Open(path)
+			require.NoError(t, err)
+
+			defer file.Close()
+
+			got, err = ParseFile(context.Background(), file)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("undetectable", func(t *testing.T) {
+		_, err := ParseFromText(context.Background(), parser.Auto, "<xml />")
+		assert.ErrorContains(t, err, "couldn't be detected")
+	})
+}
+
+func TestParseFileBuiltInFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    map[string]any
+	}{
+		{
+			name:    "properties",
+			file:    "app.properties",
+			content: "db.host = localhost\n",
+			want:    map[string]any{"db.host": "localhost"},
+		},
+		{
+			name:    "ini",
+			file:    "app.cfg",
+			content: "[db]\nhost = localhost\n",
+			want:    map[string]any{"db": map[string]any{"host": "localhost"}},
+		},
+		{
+			name:    "hcl",
+			file:    "terraform.tfvars",
+			content: "db { host = \"localhost\" }\n",
+			want:    map[string]any{"db": map[string]any{"host": "localhost"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0o600))
+
+			file, err := os.Open(path)
+			require.NoError(t, err)
+
+			defer file.Close()
+
+			got, err := ParseFile(context.Background(), file)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("dockerenv by name", func(t *testing.T) {
+		got, err := ParseFromText(context.Background(), "dockerenv", "A=\"quoted\"\n")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"A": `"quoted"`}, got)
+	})
+}
@@ -3,7 +3,10 @@ package util
 import (
 	"errors"
 	"fmt"
+	"os"
 	"reflect"
+	"regexp"
+	"runtime"
 	"strconv"
 	"strings"
 	"time"
@@ -15,6 +18,71 @@ import (
 // Func is the callback function type.
 type Func func(v reflect.Value, field reflect.StructField, tag string) error
 
+var (
+	// Matches `${VAR}`, and `${VAR:-fallback}`.
+	envReferenceRegex = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+	// Matches `cpu`, and `cpu<op><n>`, e.g.: `cpu*2`.
+	cpuExpressionRegex = regexp.MustCompile(`^cpu(?:\s*([*/+-])\s*(\d+))?$`)
+)
+
+// expandDefault evaluates the expressions in the `default` tag. See
+// `SetDefault`.
+func expandDefault(v reflect.Value, tag string) (string, error) {
+	content := envReferenceRegex.ReplaceAllStringFunc(tag, func(reference string) string {
+		match := envReferenceRegex.FindStringSubmatch(reference)
+
+		if value := os.Getenv(match[1]); value != "" {
+			return value
+		}
+
+		return match[2]
+	})
+
+	t := v.Type()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		match := cpuExpressionRegex.FindStringSubmatch(strings.TrimSpace(content))
+		if match == nil {
+			return content, nil
+		}
+
+		result := runtime.NumCPU()
+
+		if match[1] != "" {
+			n, err := strconv.Atoi(match[2])
+			if err != nil {
+				return "", err
+			}
+
+			switch match[1] {
+			case "*":
+				result *= n
+			case "/":
+				if n == 0 {
+					return "", fmt.Errorf("invalid default %q: division by zero", tag)
+				}
+
+				result /= n
+			case "+":
+				result += n
+			case "-":
+				result -= n
+			}
+		}
+
+		return strconv.Itoa(max(result, 1)), nil
+	default:
+		return content, nil
+	}
+}
+
 func parseIntValue(v reflect.Value, str string) error {
 	// If the type of the field is time.Duration, we need to parse it as a duration.
 	if v.Type() == reflect.TypeOf(time.Duration(0)) {
@@ -119,8 +187,18 @@ func parseTimeValue(v reflect.Value, str string) error {
 
 	var value time.Time
 
-	if str == "now" {
+	if offset, found := strings.CutPrefix(str, "now"); found && (offset == "" || offset[0] == '+' || offset[0] == '-') {
 		value = time.Now()
+
+		// E.g.: `now+24h`, `now-30m`.
+		if offset != "" {
+			d, err := time.ParseDuration(offset)
+			if err != nil {
+				return err
+			}
+
+			value = value.Add(d)
+		}
 	} else {
 		v, err := dateparse.ParseAny(str)
 		if err != nil {
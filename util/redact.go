@@ -0,0 +1,274 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/thalesfsp/customerror"
+)
+
+//////
+// Vars, consts, and types.
+//////
+
+const (
+	// Mask replaces the value of secret fields.
+	Mask = "******"
+
+	// PrintJSON renders fields as an indented JSON array.
+	PrintJSON = "json"
+
+	// PrintTable renders fields as an aligned table.
+	PrintTable = "table"
+
+	// SourceDefault means the value matches the `default` tag.
+	SourceDefault = "default"
+
+	// SourceEnv means the value came from the env var in the `env` tag.
+	SourceEnv = "env"
+
+	// SourceSet means the value was set by other means, e.g.: code, or file.
+	SourceSet = "set"
+
+	// SourceUnset means the field holds its zero value.
+	SourceUnset = "unset"
+)
+
+// Field describes a struct field, and where its value came from.
+type Field struct {
+	// Default is the `default` tag, if any.
+	Default string `json:"default,omitempty"`
+
+	// Env is the `env` tag, if any.
+	Env string `json:"env,omitempty"`
+
+	// Name is the dotted path of the field, e.g.: `Database.Password`.
+	Name string `json:"name"`
+
+	// Secret indicates the field is tagged `secret`, or `sensitive`.
+	Secret bool `json:"secret"`
+
+	// Source is where the value came from. See the `Source*` constants.
+	Source string `json:"source"`
+
+	// Value is the formatted value, masked if `Secret`.
+	Value string `json:"value"`
+}
+
+//////
+// Exported feature(s).
+//////
+
+// Redacted describes every exported field of the struct `v`, in declaration
+// order, with the value of secret fields replaced by `Mask`. A field is secret
+// if tagged `secret:"true"`, or `sensitive:"true"`. Tagging a nested struct
+// marks all its fields secret.
+//
+// NOTE: `v` can be a struct, or a pointer to a struct.
+//
+// NOTE: Nil pointers to structs are skipped, like `SetEnv` does.
+func Redacted(v any) ([]Field, error) {
+	rV := reflect.ValueOf(v)
+
+	if rV.Kind() == reflect.Ptr && !rV.IsNil() {
+		rV = rV.Elem()
+	}
+
+	if rV.Kind() != reflect.Struct {
+		return nil, customerror.NewInvalidError("`v`, it must be set, and be a struct or a pointer to a struct")
+	}
+
+	fields := []Field{}
+
+	describe(rV, "", false, &fields)
+
+	for i := range fields {
+		fields[i] = fields[i].redacted()
+	}
+
+	return fields, nil
+}
+
+// Print renders the struct `v` to `w` with secret fields masked. `format` is
+// either `PrintJSON`, or `PrintTable`. Use it, for example, to log the
+// effective configuration at startup.
+//
+// SEE: `Redacted` for details.
+func Print(w io.Writer, v any, format string) error {
+	fields, err := Redacted(v)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case PrintJSON:
+		b, err := json.MarshalIndent(fields, "", "  ")
+		if err != nil {
+			return customerror.NewFailedToError("marshal fields to json", customerror.WithError(err))
+		}
+
+		if _, err := fmt.Fprintln(w, string(b)); err != nil {
+			return customerror.NewFailedToError("print fields", customerror.WithError(err))
+		}
+	case PrintTable:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+		fmt.Fprintln(tw, "FIELD\tVALUE\tSOURCE\tENV\tDEFAULT")
+
+		for _, f := range fields {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", f.Name, f.Value, f.Source, f.Env, f.Default)
+		}
+
+		if err := tw.Flush(); err != nil {
+			return customerror.NewFailedToError("print fields", customerror.WithError(err))
+		}
+	default:
+		return customerror.NewInvalidError("format, allowed: json, table")
+	}
+
+	return nil
+}
+
+//////
+// Helpers.
+//////
+
+// isSecret reports whether the field is tagged `secret`, or `sensitive`.
+func isSecret(field reflect.StructField) bool {
+	for _, tagName := range []string{"secret", "sensitive"} {
+		if b, err := strconv.ParseBool(field.Tag.Get(tagName)); err == nil && b {
+			return true
+		}
+	}
+
+	return false
+}
+
+// describe appends the description of each exported field of the struct `v` to
+// `fields`, recursing into nested structs. Values aren't masked.
+//
+//nolint:intrange
+func describe(v reflect.Value, prefix string, secret bool, fields *[]Field) {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		// Skip unexported fields like `json` tag.
+		if field.PkgPath != "" {
+			continue
+		}
+
+		value := v.Field(i)
+
+		name := prefix + field.Name
+
+		fieldSecret := secret || isSecret(field)
+
+		elem := value
+		if elem.Kind() == reflect.Ptr && !elem.IsNil() {
+			elem = elem.Elem()
+		}
+
+		if elem.Kind() == reflect.Struct && elem.Type() != reflect.TypeOf(time.Time{}) {
+			describe(elem, name+".", fieldSecret, fields)
+
+			continue
+		}
+
+		// Skip nil pointers to structs.
+		if elem.Kind() == reflect.Ptr && elem.Type().Elem().Kind() == reflect.Struct &&
+			elem.Type().Elem() != reflect.TypeOf(time.Time{}) {
+			continue
+		}
+
+		f := Field{
+			Default: field.Tag.Get("default"),
+			Env:     field.Tag.Get("env"),
+			Name:    name,
+			Secret:  fieldSecret,
+			Source:  source(elem, field),
+			Value:   display(elem),
+		}
+
+		// Like the built-in `json` tag, `-` means ignored.
+		if f.Default == "-" {
+			f.Default = ""
+		}
+
+		if f.Env == "-" {
+			f.Env = ""
+		}
+
+		*fields = append(*fields, f)
+	}
+}
+
+// redacted returns a copy of `f` with its value, and default masked if secret.
+func (f Field) redacted() Field {
+	if !f.Secret {
+		return f
+	}
+
+	if f.Value != "" {
+		f.Value = Mask
+	}
+
+	if f.Default != "" {
+		f.Default = Mask
+	}
+
+	return f
+}
+
+// display formats `v` the same way `ToMap` does, falling back to `%v` for
+// values `SetEnv` can't parse back.
+func display(v reflect.Value) string {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return ""
+		}
+
+		v = v.Elem()
+	}
+
+	if s, err := formatValue(v); err == nil {
+		return s
+	}
+
+	return fmt.Sprintf("%v", v.Interface())
+}
+
+// source tells where the value of `v` came from, mirroring the precedence
+// `Dump` applies: env var, then default.
+func source(v reflect.Value, field reflect.StructField) string {
+	if envTag := field.Tag.Get("env"); envTag != "" && envTag != "-" && os.Getenv(envTag) != "" {
+		return SourceEnv
+	}
+
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		return SourceUnset
+	}
+
+	if defaultTag := field.Tag.Get("default"); defaultTag != "" && defaultTag != "-" {
+		fromDefault := reflect.New(v.Type()).Elem()
+
+		if content, err := expandDefault(fromDefault, defaultTag); err == nil && content != "" &&
+			setValueFromTag(fromDefault, field, defaultTag, content, true) == nil &&
+			display(fromDefault) == display(v) {
+			return SourceDefault
+		}
+	}
+
+	if v.IsZero() {
+		return SourceUnset
+	}
+
+	return SourceSet
+}
@@ -0,0 +1,130 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thalesfsp/configurer/internal/testenv"
+)
+
+//////
+// Secret-aware printing.
+//////
+
+type redactDatabase struct {
+	Host     string `default:"localhost" env:"REDACT_DB_HOST"`
+	Password string `env:"REDACT_DB_PASSWORD" secret:"true"`
+}
+
+type redactCredentials struct {
+	User  string `default:"admin"`
+	Token string
+}
+
+type redactConfig struct {
+	Name        string        `default:"app"  env:"REDACT_NAME"`
+	Port        int           `default:"8080" env:"REDACT_PORT"`
+	Timeout     time.Duration `default:"5s"`
+	APIKey      string        `sensitive:"true"`
+	Empty       string        `secret:"true"`
+	Ignored     string        `env:"-"`
+	Database    redactDatabase
+	Credentials *redactCredentials `secret:"true"`
+	Missing     *redactDatabase
+	internal    string
+}
+
+func newRedactConfig() *redactConfig {
+	return &redactConfig{
+		Name:     "app",
+		Port:     9090,
+		Timeout:  5 * time.Second,
+		APIKey:   "api-key",
+		Database: redactDatabase{Host: "db", Password: "db-password"},
+		Credentials: &redactCredentials{
+			User:  "admin",
+			Token: "token",
+		},
+		internal: "hidden",
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	testenv.Unset(t, "REDACT_NAME", "REDACT_PORT", "REDACT_DB_HOST")
+	testenv.Set(t, "REDACT_DB_PASSWORD", "db-password")
+
+	got, err := Redacted(newRedactConfig())
+	require.NoError(t, err)
+
+	assert.Equal(t, []Field{
+		{Name: "Name", Env: "REDACT_NAME", Default: "app", Source: SourceDefault, Value: "app"},
+		{Name: "Port", Env: "REDACT_PORT", Default: "8080", Source: SourceSet, Value: "9090"},
+		{Name: "Timeout", Default: "5s", Source: SourceDefault, Value: "5s"},
+		{Name: "APIKey", Secret: true, Source: SourceSet, Value: Mask},
+		{Name: "Empty", Secret: true, Source: SourceUnset, Value: ""},
+		{Name: "Ignored", Source: SourceUnset, Value: ""},
+		{Name: "Database.Host", Env: "REDACT_DB_HOST", Default: "localhost", Source: SourceSet, Value: "db"},
+		{Name: "Database.Password", Env: "REDACT_DB_PASSWORD", Secret: true, Source: SourceEnv, Value: Mask},
+		{Name: "Credentials.User", Default: Mask, Secret: true, Source: SourceDefault, Value: Mask},
+		{Name: "Credentials.Token", Secret: true, Source: SourceSet, Value: Mask},
+	}, got)
+
+	for _, f := range got {
+		assert.NotContains(t, f.Value, "password")
+		assert.NotContains(t, f.Value, "token")
+		assert.NotContains(t, f.Value, "api-key")
+	}
+}
+
+func TestRedactedErrors(t *testing.T) {
+	var nilPtr *redactConfig
+
+	for _, v := range []any{nil, nilPtr, "value"} {
+		got, err := Redacted(v)
+		require.Error(t, err)
+		assert.Nil(t, got)
+	}
+}
+
+func TestPrint(t *testing.T) {
+	testenv.Unset(t, "REDACT_NAME", "REDACT_PORT", "REDACT_DB_HOST", "REDACT_DB_PASSWORD")
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		require.NoError(t, Print(&buf, *newRedactConfig(), PrintJSON))
+
+		var fields []Field
+
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &fields))
+		assert.Len(t, fields, 10)
+		assert.NotContains(t, buf.String(), "db-password")
+	})
+
+	t.Run("table", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		require.NoError(t, Print(&buf, newRedactConfig(), PrintTable))
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		require.Len(t, lines, 11)
+		assert.Regexp(t, `^FIELD\s+VALUE\s+SOURCE\s+ENV\s+DEFAULT$`, lines[0])
+		assert.Regexp(t, `^Database\.Password\s+\*{6}\s+set\s+REDACT_DB_PASSWORD\s*$`, lines[8])
+		assert.NotContains(t, buf.String(), "db-password")
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		err := Print(&bytes.Buffer{}, newRedactConfig(), "xml")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "format")
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		require.Error(t, Print(&bytes.Buffer{}, nil, PrintJSON))
+	})
+}
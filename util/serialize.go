@@ -0,0 +1,174 @@
+package util
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/thalesfsp/customerror"
+)
+
+//////
+// Exported feature(s).
+//////
+
+// ToMap is the inverse of `SetEnv`. For a given struct `v`, it builds a map
+// keyed by the struct field tags (`env`) holding the field values formatted the
+// same way `SetEnv` (and `SetDefault`) parses them back:
+// - time.Duration: Go duration string, e.g.: `1h30m0s`.
+// - time.Time: RFC3339 with nanoseconds.
+// - Slices, and arrays: comma-separated elements.
+// - Maps: comma-separated `key:value` pairs, sorted by key.
+//
+// The result can be passed straight to `IProvider.Write`.
+//
+// NOTE: `v` can be a struct, or a pointer to a struct.
+//
+// NOTE: Nested structs are walked the same way `SetEnv` walks them, nil
+// pointers to structs are skipped.
+//
+// NOTE: Like the built-in `json` tag, it'll ignore the field if it isn't
+// exported, and if tag is set to `-`.
+func ToMap(v any) (map[string]interface{}, error) {
+	rV := reflect.ValueOf(v)
+
+	// `process` walks a pointer to a struct. Copy a struct value so it's
+	// addressable.
+	if rV.Kind() == reflect.Struct {
+		ptr := reflect.New(rV.Type())
+
+		ptr.Elem().Set(rV)
+
+		rV = ptr
+	}
+
+	if !rV.IsValid() || (rV.Kind() == reflect.Ptr && rV.IsNil()) {
+		return nil, customerror.NewInvalidError("`v`, it must be set, and be a struct or a pointer to a struct")
+	}
+
+	values := make(map[string]interface{})
+
+	if err := process("env", rV.Interface(), func(v reflect.Value, field reflect.StructField, tag string) error {
+		if v.Kind() == reflect.Ptr {
+			// Nothing to serialize, and nested structs are walked by `process`.
+			if v.IsNil() || (v.Elem().Kind() == reflect.Struct && v.Elem().Type() != reflect.TypeOf(time.Time{})) {
+				return nil
+			}
+
+			v = v.Elem()
+		}
+
+		// Nested structs are walked by `process`.
+		if v.Kind() == reflect.Struct && v.Type() != reflect.TypeOf(time.Time{}) {
+			return nil
+		}
+
+		value, err := formatValue(v)
+		if err != nil {
+			return customerror.NewFailedToError(
+				fmt.Sprintf("serialize field %s", field.Name),
+				customerror.WithError(err),
+			)
+		}
+
+		values[tag] = value
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+//////
+// Helpers.
+//////
+
+// formatSingleValue is the inverse of `parseSingleValue`.
+func formatSingleValue(v reflect.Value) (string, error) {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Type() == reflect.TypeOf(time.Duration(0)) {
+			return time.Duration(v.Int()).String(), nil
+		}
+
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Struct:
+		if t, ok := v.Interface().(time.Time); ok {
+			return t.Format(time.RFC3339Nano), nil
+		}
+
+		return "", fmt.Errorf("unsupported struct type: %s", v.Type())
+	case reflect.Interface:
+		if v.IsNil() {
+			return "", nil
+		}
+
+		return formatSingleValue(v.Elem())
+	default:
+		return "", fmt.Errorf("unsupported type: %s", v.Type())
+	}
+}
+
+// formatValue is the inverse of `setValueFromTag`.
+//
+//nolint:intrange
+func formatValue(v reflect.Value) (string, error) {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		elements := make([]string, 0, v.Len())
+
+		for i := 0; i < v.Len(); i++ {
+			element, err := formatSingleValue(v.Index(i))
+			if err != nil {
+				return "", fmt.Errorf("failed to format slice element: %w", err)
+			}
+
+			if strings.Contains(element, ",") {
+				return "", fmt.Errorf("slice element %q can't contain ','", element)
+			}
+
+			elements = append(elements, element)
+		}
+
+		return strings.Join(elements, ","), nil
+	case reflect.Map:
+		pairs := make([]string, 0, v.Len())
+
+		for _, key := range v.MapKeys() {
+			k, err := formatSingleValue(key)
+			if err != nil {
+				return "", fmt.Errorf("failed to format map key: %w", err)
+			}
+
+			value, err := formatSingleValue(v.MapIndex(key))
+			if err != nil {
+				return "", fmt.Errorf("failed to format map value %s: %w", k, err)
+			}
+
+			if strings.ContainsAny(k, ",:") || strings.ContainsAny(value, ",:") {
+				return "", fmt.Errorf("map pair %s:%s can't contain ',' or ':'", k, value)
+			}
+
+			pairs = append(pairs, k+":"+value)
+		}
+
+		sort.Strings(pairs)
+
+		return strings.Join(pairs, ","), nil
+	default:
+		return formatSingleValue(v)
+	}
+}
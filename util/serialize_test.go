@@ -0,0 +1,188 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//////
+// Struct serialization.
+//////
+
+func TestToMap(t *testing.T) {
+	type nested struct {
+		Host string `env:"TO_MAP_DB_HOST"`
+		Port int    `env:"TO_MAP_DB_PORT"`
+	}
+
+	type replica struct {
+		Host string `env:"TO_MAP_REPLICA_HOST"`
+	}
+
+	type config struct {
+		Name     string            `env:"TO_MAP_NAME"`
+		Debug    bool              `env:"TO_MAP_DEBUG"`
+		Ratio    float64           `env:"TO_MAP_RATIO"`
+		Workers  uint8             `env:"TO_MAP_WORKERS"`
+		Timeout  time.Duration     `env:"TO_MAP_TIMEOUT"`
+		Start    time.Time         `env:"TO_MAP_START"`
+		Hosts    []string          `env:"TO_MAP_HOSTS"`
+		Weights  map[string]int    `env:"TO_MAP_WEIGHTS"`
+		Limit    *int              `env:"TO_MAP_LIMIT"`
+		Unset    *int              `env:"TO_MAP_UNSET"`
+		Labels   map[string]string `env:"-"`
+		NoTag    string
+		DB       nested
+		Replica  *replica
+		Missing  *replica
+		internal string `env:"TO_MAP_INTERNAL"`
+	}
+
+	limit := 10
+
+	v := config{
+		Name:     "app",
+		Debug:    true,
+		Ratio:    0.25,
+		Workers:  4,
+		Timeout:  90 * time.Second,
+		Start:    time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC),
+		Hosts:    []string{"a", "b"},
+		Weights:  map[string]int{"b": 2, "a": 1},
+		Limit:    &limit,
+		Labels:   map[string]string{"ignored": "true"},
+		NoTag:    "ignored",
+		DB:       nested{Host: "db", Port: 5432},
+		Replica:  &replica{Host: "replica"},
+		internal: "ignored",
+	}
+
+	want := map[string]interface{}{
+		"TO_MAP_NAME":         "app",
+		"TO_MAP_DEBUG":        "true",
+		"TO_MAP_RATIO":        "0.25",
+		"TO_MAP_WORKERS":      "4",
+		"TO_MAP_TIMEOUT":      "1m30s",
+		"TO_MAP_START":        "2024-01-02T03:04:05.000000006Z",
+		"TO_MAP_HOSTS":        "a,b",
+		"TO_MAP_WEIGHTS":      "a:1,b:2",
+		"TO_MAP_LIMIT":        "10",
+		"TO_MAP_DB_HOST":      "db",
+		"TO_MAP_DB_PORT":      "5432",
+		"TO_MAP_REPLICA_HOST": "replica",
+	}
+
+	t.Run("pointer", func(t *testing.T) {
+		got, err := ToMap(&v)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("value", func(t *testing.T) {
+		got, err := ToMap(v)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	})
+}
+
+func TestToMapRoundTrip(t *testing.T) {
+	type config struct {
+		Name    string                 `env:"TO_MAP_RT_NAME"`
+		Count   int64                  `env:"TO_MAP_RT_COUNT"`
+		Ratio   float32                `env:"TO_MAP_RT_RATIO"`
+		Timeout time.Duration          `env:"TO_MAP_RT_TIMEOUT"`
+		Start   time.Time              `env:"TO_MAP_RT_START"`
+		Ports   []int                  `env:"TO_MAP_RT_PORTS"`
+		Flags   map[string]bool        `env:"TO_MAP_RT_FLAGS"`
+		Mixed   map[string]interface{} `env:"TO_MAP_RT_MIXED"`
+	}
+
+	in := config{
+		Name:    "round-trip",
+		Count:   -42,
+		Ratio:   1.5,
+		Timeout: 2*time.Hour + 3*time.Millisecond,
+		Start:   time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.UTC),
+		Ports:   []int{80, 443},
+		Flags:   map[string]bool{"on": true, "off": false},
+		Mixed:   map[string]interface{}{"retries": 3, "delay": 5 * time.Second},
+	}
+
+	values, err := ToMap(&in)
+	require.NoError(t, err)
+
+	for key, value := range values {
+		t.Setenv(key, value.(string))
+	}
+
+	var out config
+
+	require.NoError(t, SetEnv(&out))
+	assert.Equal(t, in.Name, out.Name)
+	assert.Equal(t, in.Count, out.Count)
+	assert.InDelta(t, in.Ratio, out.Ratio, 0)
+	assert.Equal(t, in.Timeout, out.Timeout)
+	assert.True(t, in.Start.Equal(out.Start))
+	assert.Equal(t, in.Ports, out.Ports)
+	assert.Equal(t, in.Flags, out.Flags)
+	assert.Equal(t, in.Mixed, out.Mixed)
+}
+
+func TestToMapErrors(t *testing.T) {
+	var nilPtr *struct{}
+
+	tests := []struct {
+		name    string
+		v       any
+		wantErr string
+	}{
+		{
+			name:    "nil",
+			v:       nil,
+			wantErr: "must be set",
+		},
+		{
+			name:    "nil pointer",
+			v:       nilPtr,
+			wantErr: "must be set",
+		},
+		{
+			name:    "not a struct",
+			v:       "value",
+			wantErr: "must be set",
+		},
+		{
+			name: "unsupported type",
+			v: &struct {
+				C chan int `env:"TO_MAP_CHAN"`
+			}{C: make(chan int)},
+			wantErr: "unsupported type",
+		},
+		{
+			name: "slice element with separator",
+			v: &struct {
+				S []string `env:"TO_MAP_SLICE"`
+			}{S: []string{"a,b"}},
+			wantErr: "can't contain ','",
+		},
+		{
+			name: "map value with separator",
+			v: &struct {
+				M map[string]string `env:"TO_MAP_MAP"`
+			}{M: map[string]string{"url": "http://host"}},
+			wantErr: "can't contain ',' or ':'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ToMap(tt.v)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+			assert.Nil(t, got)
+		})
+	}
+}
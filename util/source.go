@@ -0,0 +1,317 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/thalesfsp/configurer/option"
+	"github.com/thalesfsp/customerror"
+	"gopkg.in/yaml.v3"
+)
+
+//////
+// Vars, consts, and types.
+//////
+
+// sourceFormats are the formats which may contain a manifest, or an export.
+var sourceFormats = map[string]bool{"": true, "json": true, "yaml": true, "yml": true}
+
+// paasCollections are the keys under which PaaS APIs list variables, e.g.:
+// Vercel's `GET /v9/projects/{id}/env`.
+var paasCollections = []string{"envs", "env_vars", "envVars", "variables"}
+
+//////
+// Exported feature(s).
+//////
+
+// ParseSource parses a file to be written to a provider, e.g.:
+// `configurer w --source`. In addition to what `ParseFile` supports, JSON, and
+// YAML files can be:
+//   - Kubernetes Secret, and ConfigMap manifests, including multi-document
+//     YAML, and `kind: List`. Secret `data`, and ConfigMap `binaryData` are
+//     base64-decoded, Secret `stringData` wins over `data`. Other kinds are
+//     ignored
+//   - PaaS exports, a list of `{"key"|"name": ..., "value": ...}` objects,
+//     e.g.: Vercel, Render, DigitalOcean, or ECS, top-level, or under `envs`,
+//     `env_vars`, `envVars`, or `variables`.
+//
+// NOTE: Flat key/value objects, e.g.: `heroku config --json`, are plain JSON.
+func ParseSource(ctx context.Context, file *os.File, opts ...option.ParseFunc) (map[string]any, error) {
+	// Remove the . from the extension.
+	extension := strings.Replace(filepath.Ext(file.Name()), ".", "", 1)
+
+	if !sourceFormats[strings.ToLower(extension)] {
+		return ParseContent(ctx, extension, file, opts...)
+	}
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return nil, customerror.NewFailedToError("read source", customerror.WithError(err))
+	}
+
+	values, ok, err := parseSource(content)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok {
+		return values, nil
+	}
+
+	return ParseContent(ctx, extension, bytes.NewReader(content), opts...)
+}
+
+//////
+// Helpers.
+//////
+
+// parseSource decodes `content` as Kubernetes manifests, or a PaaS export.
+// It reports false if `content` is neither.
+func parseSource(content []byte) (map[string]any, bool, error) {
+	documents := []any{}
+
+	// YAML is a superset of JSON, one decoder handles both.
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+
+	for {
+		var document any
+
+		if err := decoder.Decode(&document); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			// Not YAML, nor JSON, let the parsers handle it.
+			return nil, false, nil
+		}
+
+		if document != nil {
+			documents = append(documents, document)
+		}
+	}
+
+	if len(documents) == 0 {
+		return nil, false, nil
+	}
+
+	if isManifests(documents) {
+		values, err := fromManifests(documents)
+
+		return values, true, err
+	}
+
+	if len(documents) != 1 {
+		return nil, false, nil
+	}
+
+	entries, ok := paasEntries(documents[0])
+	if !ok {
+		return nil, false, nil
+	}
+
+	values, err := fromPaaS(entries)
+
+	return values, true, err
+}
+
+// isManifests reports whether all `documents` are Kubernetes objects.
+func isManifests(documents []any) bool {
+	for _, document := range documents {
+		object, ok := document.(map[string]any)
+		if !ok {
+			return false
+		}
+
+		if _, ok := object["apiVersion"].(string); !ok {
+			return false
+		}
+
+		if _, ok := object["kind"].(string); !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// fromManifests merges the Secrets, and ConfigMaps in `documents`, in order.
+func fromManifests(documents []any) (map[string]any, error) {
+	values := map[string]any{}
+	found := false
+
+	var merge func(object map[string]any) error
+
+	merge = func(object map[string]any) error {
+		name := manifestName(object)
+
+		switch object["kind"] {
+		case "List":
+			items, _ := object["items"].([]any)
+
+			for _, item := range items {
+				if itemObject, ok := item.(map[string]any); ok {
+					if err := merge(itemObject); err != nil {
+						return err
+					}
+				}
+			}
+		case "Secret":
+			found = true
+
+			if err := mergeData(values, object["data"], true, "Secret "+name); err != nil {
+				return err
+			}
+
+			return mergeData(values, object["stringData"], false, "Secret "+name)
+		case "ConfigMap":
+			found = true
+
+			if err := mergeData(values, object["data"], false, "ConfigMap "+name); err != nil {
+				return err
+			}
+
+			return mergeData(values, object["binaryData"], true, "ConfigMap "+name)
+		}
+
+		return nil
+	}
+
+	for _, document := range documents {
+		if err := merge(document.(map[string]any)); err != nil {
+			return nil, err
+		}
+	}
+
+	if !found {
+		return nil, customerror.NewNotFoundError("Secret, or ConfigMap in source")
+	}
+
+	return values, nil
+}
+
+// mergeData merges a manifest `data` field into `values`.
+func mergeData(values map[string]any, data any, encoded bool, object string) error {
+	if data == nil {
+		return nil
+	}
+
+	entries, ok := data.(map[string]any)
+	if !ok {
+		return customerror.NewInvalidError(object + " data, expected key/value pairs")
+	}
+
+	for key, value := range entries {
+		v := fmt.Sprint(value)
+
+		if value == nil {
+			v = ""
+		}
+
+		if encoded {
+			decoded, err := base64.StdEncoding.DecodeString(v)
+			if err != nil {
+				return customerror.NewInvalidError(
+					object+" key "+key+", not base64",
+					customerror.WithError(err),
+				)
+			}
+
+			v = string(decoded)
+		}
+
+		values[key] = v
+	}
+
+	return nil
+}
+
+// manifestName returns the `metadata.name` of a Kubernetes object.
+func manifestName(object map[string]any) string {
+	if metadata, ok := object["metadata"].(map[string]any); ok {
+		if name, ok := metadata["name"].(string); ok {
+			return name
+		}
+	}
+
+	return "(unnamed)"
+}
+
+// paasEntries returns the list of variables of a PaaS export, if `document`
+// is one.
+func paasEntries(document any) ([]any, bool) {
+	if object, ok := document.(map[string]any); ok {
+		for _, key := range paasCollections {
+			if entries, ok := object[key].([]any); ok {
+				document = entries
+
+				break
+			}
+		}
+	}
+
+	entries, ok := document.([]any)
+	if !ok || len(entries) == 0 {
+		return nil, false
+	}
+
+	for _, entry := range entries {
+		object, ok := entry.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+
+		if _, ok := object["value"]; !ok {
+			return nil, false
+		}
+
+		if paasKey(object) == "" {
+			return nil, false
+		}
+	}
+
+	return entries, true
+}
+
+// paasKey returns the name of a PaaS variable.
+func paasKey(entry map[string]any) string {
+	for _, field := range []string{"key", "name"} {
+		if key, ok := entry[field].(string); ok && key != "" {
+			return key
+		}
+	}
+
+	return ""
+}
+
+// fromPaaS converts PaaS variables to key/value pairs. A key listed more than
+// once, e.g.: per deployment target, must have the same value.
+func fromPaaS(entries []any) (map[string]any, error) {
+	values := map[string]any{}
+
+	for _, entry := range entries {
+		object := entry.(map[string]any)
+		key := paasKey(object)
+
+		value := ""
+		if object["value"] != nil {
+			value = fmt.Sprint(object["value"])
+		}
+
+		if existing, ok := values[key]; ok && existing != value {
+			return nil, customerror.NewInvalidError(
+				"source key " + key + ", listed more than once with different values",
+			)
+		}
+
+		values[key] = value
+	}
+
+	return values, nil
+}
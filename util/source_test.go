@@ -0,0 +1,145 @@
+package util
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSource(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    map[string]any
+		wantErr string
+	}{
+		{
+			name: "secret manifest",
+			file: "secret.yaml",
+			content: `apiVersion: v1
+kind: Secret
+metadata:
+  name: app
+data:
+  PASSWORD: czNjcjN0
+  OVERRIDDEN: b2xk
+stringData:
+  OVERRIDDEN: new
+  PORT: 8080
+`,
+			want: map[string]any{"PASSWORD": "s3cr3t", "OVERRIDDEN": "new", "PORT": "8080"},
+		},
+		{
+			name: "multi-document manifests",
+			file: "manifests.yml",
+			content: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+data:
+  LOG_LEVEL: debug
+binaryData:
+  BLOB: YmluYXJ5
+---
+apiVersion: v1
+kind: Secret
+data:
+  LOG_LEVEL: aW5mbw==
+`,
+			want: map[string]any{"LOG_LEVEL": "info", "BLOB": "binary"},
+		},
+		{
+			name:    "json list manifest",
+			file:    "secrets.json",
+			content: `{"apiVersion": "v1", "kind": "List", "items": [{"apiVersion": "v1", "kind": "Secret", "data": {"A": "YQ=="}}]}`,
+			want:    map[string]any{"A": "a"},
+		},
+		{
+			name:    "manifest without secret",
+			file:    "deployment.yaml",
+			content: "apiVersion: apps/v1\nkind: Deployment\n",
+			wantErr: "Secret, or ConfigMap in source",
+		},
+		{
+			name:    "invalid base64",
+			file:    "secret.yaml",
+			content: "apiVersion: v1\nkind: Secret\nmetadata:\n  name: app\ndata:\n  A: '%%%'\n",
+			wantErr: "Secret app key A, not base64",
+		},
+		{
+			name:    "invalid data",
+			file:    "secret.yaml",
+			content: "apiVersion: v1\nkind: ConfigMap\ndata: [a]\n",
+			wantErr: "ConfigMap (unnamed) data, expected key/value pairs",
+		},
+		{
+			name:    "vercel export",
+			file:    "vercel.json",
+			content: `{"envs": [{"key": "API_URL", "value": "https://x", "target": ["production"]}, {"key": "API_URL", "value": "https://x", "target": ["preview"]}]}`,
+			want:    map[string]any{"API_URL": "https://x"},
+		},
+		{
+			name:    "name/value list",
+			file:    "render.json",
+			content: `[{"name": "PORT", "value": 8080}, {"name": "EMPTY", "value": null}]`,
+			want:    map[string]any{"PORT": "8080", "EMPTY": ""},
+		},
+		{
+			name:    "conflicting values",
+			file:    "vercel.json",
+			content: `[{"key": "A", "value": "1"}, {"key": "A", "value": "2"}]`,
+			wantErr: "source key A, listed more than once with different values",
+		},
+		{
+			name:    "plain json",
+			file:    "heroku.json",
+			content: `{"DATABASE_URL": "postgres://x"}`,
+			want:    map[string]any{"DATABASE_URL": "postgres://x"},
+		},
+		{
+			name:    "env without extension",
+			file:    "source",
+			content: "A=1\nB=2\n",
+			want:    map[string]any{"A": "1", "B": "2"},
+		},
+		{
+			name:    "env",
+			file:    "source.env",
+			content: "A=1\n",
+			want:    map[string]any{"A": "1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0o600))
+
+			file, err := os.Open(path)
+			require.NoError(t, err)
+
+			defer file.Close()
+
+			got, err := ParseSource(context.Background(), file)
+
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -2,6 +2,7 @@
 package util
 
 import (
+	"bytes"
 	"context"
 	"io"
 	"os"
@@ -10,12 +11,20 @@ import (
 
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
-	"github.com/thalesfsp/configurer/parsers/env"
-	"github.com/thalesfsp/configurer/parsers/jsonp"
-	"github.com/thalesfsp/configurer/parsers/toml"
-	"github.com/thalesfsp/configurer/parsers/yaml"
+	"github.com/thalesfsp/configurer/option"
+	"github.com/thalesfsp/configurer/parser"
 	"github.com/thalesfsp/customerror"
 	"github.com/thalesfsp/validation"
+
+	// Built-in parsers, registered on import.
+	_ "github.com/thalesfsp/configurer/parsers/dockerenv"
+	_ "github.com/thalesfsp/configurer/parsers/env"
+	_ "github.com/thalesfsp/configurer/parsers/hcl"
+	_ "github.com/thalesfsp/configurer/parsers/ini"
+	_ "github.com/thalesfsp/configurer/parsers/jsonp"
+	_ "github.com/thalesfsp/configurer/parsers/properties"
+	_ "github.com/thalesfsp/configurer/parsers/toml"
+	_ "github.com/thalesfsp/configurer/parsers/yaml"
 )
 
 //////
@@ -49,74 +58,83 @@ func GetZeroControlChar() string {
 	return zeroControlChar
 }
 
-// ParseFile parse file. Extension is used to determine the format.
-func ParseFile(ctx context.Context, file *os.File) (map[string]any, error) {
+// ParseFile parse file. Extension is used to determine the format. Files
+// without extension are detected from the content.
+//
+// SEE: `ParseContent` for the options.
+func ParseFile(ctx context.Context, file *os.File, opts ...option.ParseFunc) (map[string]any, error) {
 	extension := filepath.Ext(file.Name())
 
 	// Remove the . from the extension.
 	extension = strings.Replace(extension, ".", "", 1)
 
-	return ParseContent(ctx, extension, file)
+	return ParseContent(ctx, extension, file, opts...)
 }
 
 // ParseFromText parses the string data in .env format.
-func ParseFromText(ctx context.Context, format string, data string) (map[string]any, error) {
-	return ParseContent(ctx, format, strings.NewReader(data))
+//
+// SEE: `ParseContent` for the options.
+func ParseFromText(
+	ctx context.Context,
+	format string,
+	data string,
+	opts ...option.ParseFunc,
+) (map[string]any, error) {
+	return ParseContent(ctx, format, strings.NewReader(data), opts...)
 }
 
-// ParseContent parses the string data in .env format.
-func ParseContent(ctx context.Context, format string, r io.Reader) (map[string]any, error) {
-	switch {
-	case format == "env":
-		p, err := env.New()
-		if err != nil {
-			return nil, err
-		}
-
-		r, err := p.Read(ctx, r)
-		if err != nil {
-			return nil, err
-		}
+// ParseContent parses `r` with the parser registered for `format`, a name, or
+// an extension. `parser.Formats` lists them. Use `parser.Auto` to detect the
+// format from the content, e.g.: when reading from stdin.
+//
+// SEE: `parser.Register` to add formats.
+//
+// NOTE: Use `option.WithFlatten` to flatten nested documents into keys like
+// `DATABASE__HOST`. SEE: `Flatten`.
+func ParseContent(
+	ctx context.Context,
+	format string,
+	r io.Reader,
+	opts ...option.ParseFunc,
+) (map[string]any, error) {
+	o, err := option.NewParse(opts...)
+	if err != nil {
+		return nil, err
+	}
 
-		return r, nil
-	case format == "json":
-		p, err := jsonp.New()
-		if err != nil {
-			return nil, err
-		}
+	values, err := parseContent(ctx, format, r)
+	if err != nil {
+		return nil, err
+	}
 
-		r, err := p.Read(ctx, r)
-		if err != nil {
-			return nil, err
-		}
+	if !o.Flatten {
+		return values, nil
+	}
 
-		return r, nil
-	case format == "yaml" || format == "yml":
-		p, err := yaml.New()
-		if err != nil {
-			return nil, err
-		}
+	return Flatten(values, opts...)
+}
 
-		r, err := p.Read(ctx, r)
+// parseContent parses `r` with the parser registered for `format`. If `format`
+// is `parser.Auto`, or empty, it's detected from the content.
+func parseContent(ctx context.Context, format string, r io.Reader) (map[string]any, error) {
+	if format == "" || format == parser.Auto {
+		content, err := io.ReadAll(r)
 		if err != nil {
-			return nil, err
+			return nil, customerror.NewFailedToError("read content", customerror.WithError(err))
 		}
 
-		return r, nil
-	case format == "toml":
-		t, err := toml.New()
+		format, err = parser.Detect(ctx, content)
 		if err != nil {
 			return nil, err
 		}
 
-		r, err := t.Read(ctx, r)
-		if err != nil {
-			return nil, err
-		}
+		r = bytes.NewReader(content)
+	}
 
-		return r, nil
-	default:
-		return nil, customerror.
-			NewInvalidError("format, allowed: env.*, json, yaml | yml, toml")
+	p, err := parser.Get(format)
+	if err != nil {
+		return nil, err
 	}
+
+	return p.Read(ctx, r)
 }
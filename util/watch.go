@@ -0,0 +1,264 @@
+package util
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/thalesfsp/configurer/option"
+	"github.com/thalesfsp/customerror"
+)
+
+//////
+// Vars, consts, and types.
+//////
+
+// Loader loads the configuration, and exports it to the environment. Every
+// provider (`provider.IProvider`) satisfies it.
+type Loader interface {
+	// Load retrieves the configuration, and exports it to the environment.
+	Load(ctx context.Context, opts ...option.LoadKeyFunc) (map[string]string, error)
+}
+
+// Change is a field-level difference between two configurations.
+type Change struct {
+	// Name is the dotted path of the field, e.g.: `Database.Password`.
+	Name string `json:"name"`
+
+	// New is the new value, masked if `Secret`.
+	New string `json:"new"`
+
+	// Old is the old value, masked if `Secret`.
+	Old string `json:"old"`
+
+	// Secret indicates the field is tagged `secret`, or `sensitive`.
+	Secret bool `json:"secret"`
+}
+
+// WatchFunc is called by `Watch` after a reload. If the reload changed the
+// configuration, `cfg` is the new one, and `changes` lists what changed. If the
+// reload was rejected, `err` is set, and `cfg` is the last good configuration.
+type WatchFunc[T any] func(cfg *T, changes []Change, err error)
+
+//////
+// Exported feature(s).
+//////
+
+// Watch keeps `cfg` up-to-date with `l`. Every `interval`, it:
+// 1. Runs `l.Load`
+// 2. Populates a fresh `T` using `Dump`
+// 3. Swaps it into `cfg`, and calls `onChange` with the diff, if anything
+// changed.
+//
+// If any step fails, the reload is rejected, `cfg` keeps the last good
+// configuration, and `onChange` is called with the error.
+//
+// It reloads once before waiting for the first tick, storing the result if
+// `cfg` is empty. It blocks until `ctx` is done.
+//
+// NOTE: Create the provider with `override` set to `true`, otherwise values
+// exported by the previous load take precedence, and changes are never seen.
+//
+// NOTE: Nested struct pointers set in the current configuration are allocated
+// in the fresh one. `id` fields, and time fields defaulting to `now`, are
+// carried over, so they aren't regenerated, and don't change on every reload.
+//
+// NOTE: `onChange` is optional.
+func Watch[T any](
+	ctx context.Context,
+	l Loader,
+	cfg *atomic.Pointer[T],
+	interval time.Duration,
+	onChange WatchFunc[T],
+	opts ...option.LoadKeyFunc,
+) error {
+	if l == nil {
+		return customerror.NewRequiredError("loader")
+	}
+
+	if cfg == nil {
+		return customerror.NewRequiredError("cfg")
+	}
+
+	if reflect.TypeOf((*T)(nil)).Elem().Kind() != reflect.Struct {
+		return customerror.NewInvalidError("`T`, it must be a struct")
+	}
+
+	if interval <= 0 {
+		return customerror.NewInvalidError("interval, must be greater than 0")
+	}
+
+	// Without a configuration to fall back to, failing is fatal.
+	if err := reload(ctx, l, cfg, onChange, opts); err != nil && cfg.Load() == nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			// Errors are reported to `onChange`.
+			_ = reload(ctx, l, cfg, onChange, opts)
+		}
+	}
+}
+
+//////
+// Helpers.
+//////
+
+// reload runs one reload cycle. See `Watch`.
+func reload[T any](
+	ctx context.Context,
+	l Loader,
+	cfg *atomic.Pointer[T],
+	onChange WatchFunc[T],
+	opts []option.LoadKeyFunc,
+) error {
+	current := cfg.Load()
+
+	reject := func(err error) error {
+		err = customerror.NewFailedToError("reload configuration", customerror.WithError(err))
+
+		if onChange != nil {
+			onChange(current, nil, err)
+		}
+
+		return err
+	}
+
+	if _, err := l.Load(ctx, opts...); err != nil {
+		return reject(err)
+	}
+
+	fresh := new(T)
+
+	if current != nil {
+		mirror(reflect.ValueOf(fresh).Elem(), reflect.ValueOf(current).Elem())
+	}
+
+	if err := Dump(fresh); err != nil {
+		return reject(err)
+	}
+
+	if current == nil {
+		cfg.Store(fresh)
+
+		return nil
+	}
+
+	changes := diff(current, fresh)
+	if len(changes) == 0 {
+		return nil
+	}
+
+	cfg.Store(fresh)
+
+	if onChange != nil {
+		onChange(fresh, changes, nil)
+	}
+
+	return nil
+}
+
+// mirror prepares the fresh struct `dst` after `src`: nested struct pointers
+// set in `src` are allocated, and `id` fields, and time fields defaulting to
+// `now` are carried over.
+//
+//nolint:intrange
+func mirror(dst, src reflect.Value) {
+	t := dst.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		// Skip unexported fields like `json` tag.
+		if field.PkgPath != "" {
+			continue
+		}
+
+		d := dst.Field(i)
+		s := src.Field(i)
+
+		if tag := field.Tag.Get("id"); (tag != "" && tag != "-") || isNowDefault(field) {
+			d.Set(s)
+
+			continue
+		}
+
+		switch {
+		case d.Kind() == reflect.Struct:
+			mirror(d, s)
+		case d.Kind() == reflect.Ptr && d.Type().Elem().Kind() == reflect.Struct &&
+			d.Type().Elem() != reflect.TypeOf(time.Time{}) && !s.IsNil():
+			d.Set(reflect.New(d.Type().Elem()))
+
+			mirror(d.Elem(), s.Elem())
+		}
+	}
+}
+
+// isNowDefault tells if `field` is a time defaulting to `now`, or to an offset
+// of it, e.g.: `now+24h`, which would change on every evaluation.
+func isNowDefault(field reflect.StructField) bool {
+	t := field.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t == reflect.TypeOf(time.Time{}) && strings.HasPrefix(strings.TrimSpace(field.Tag.Get("default")), "now")
+}
+
+// diff lists the fields that differ between `current`, and `fresh`, masking
+// secret values.
+func diff[T any](current, fresh *T) []Change {
+	var before, after []Field
+
+	describe(reflect.ValueOf(current).Elem(), "", false, &before)
+	describe(reflect.ValueOf(fresh).Elem(), "", false, &after)
+
+	old := make(map[string]Field, len(before))
+	for _, f := range before {
+		old[f.Name] = f
+	}
+
+	changes := []Change{}
+
+	for _, f := range after {
+		o, found := old[f.Name]
+
+		delete(old, f.Name)
+
+		if found && o.Value == f.Value {
+			continue
+		}
+
+		changes = append(changes, Change{
+			Name:   f.Name,
+			New:    f.redacted().Value,
+			Old:    o.redacted().Value,
+			Secret: f.Secret,
+		})
+	}
+
+	// Fields gone from the fresh configuration, e.g.: a nil struct pointer.
+	for _, f := range before {
+		if _, gone := old[f.Name]; !gone {
+			continue
+		}
+
+		changes = append(changes, Change{
+			Name:   f.Name,
+			Old:    f.redacted().Value,
+			Secret: f.Secret,
+		})
+	}
+
+	return changes
+}
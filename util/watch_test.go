@@ -0,0 +1,270 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thalesfsp/configurer/internal/testenv"
+	"github.com/thalesfsp/configurer/option"
+)
+
+//////
+// Live reloading.
+//////
+
+// fakeLoader exports the next set of values on each load.
+type fakeLoader struct {
+	mutex  sync.Mutex
+	loads  []map[string]string
+	errs   []error
+	called int
+}
+
+func (f *fakeLoader) Load(_ context.Context, _ ...option.LoadKeyFunc) (map[string]string, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	i := min(f.called, len(f.loads)-1)
+
+	f.called++
+
+	if i < len(f.errs) && f.errs[i] != nil {
+		return nil, f.errs[i]
+	}
+
+	for key, value := range f.loads[i] {
+		if err := os.Setenv(key, value); err != nil {
+			return nil, err
+		}
+	}
+
+	return f.loads[i], nil
+}
+
+type watchNested struct {
+	Password string `env:"WATCH_PASSWORD" secret:"true"`
+}
+
+type watchConfig struct {
+	ID      string `id:"uuid"`
+	Host    string `default:"localhost" env:"WATCH_HOST"`
+	Port    int    `env:"WATCH_PORT" validate:"gte=1"`
+	Nested  *watchNested
+	Missing *watchNested
+}
+
+func TestWatch(t *testing.T) {
+	testenv.Unset(t, "WATCH_HOST", "WATCH_PORT", "WATCH_PASSWORD")
+
+	loader := &fakeLoader{
+		loads: []map[string]string{
+			{"WATCH_PORT": "80", "WATCH_PASSWORD": "one"},
+			{"WATCH_PORT": "80", "WATCH_PASSWORD": "one"},
+			{"WATCH_PORT": "0"},
+			{},
+			{"WATCH_PORT": "443", "WATCH_PASSWORD": "two"},
+		},
+		errs: []error{nil, nil, nil, errors.New("unavailable")},
+	}
+
+	var cfg atomic.Pointer[watchConfig]
+
+	cfg.Store(&watchConfig{
+		ID:     "fixed-id",
+		Port:   1,
+		Nested: &watchNested{},
+	})
+
+	type event struct {
+		cfg     *watchConfig
+		changes []Change
+		err     error
+	}
+
+	events := make(chan event, 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- Watch(ctx, loader, &cfg, time.Millisecond, func(c *watchConfig, changes []Change, err error) {
+			events <- event{cfg: c, changes: changes, err: err}
+		})
+	}()
+
+	next := func() event {
+		t.Helper()
+
+		select {
+		case e := <-events:
+			return e
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for reload")
+
+			return event{}
+		}
+	}
+
+	// Initial load changes the port, and the password.
+	e := next()
+	require.NoError(t, e.err)
+	assert.Equal(t, []Change{
+		{Name: "Host", Old: "", New: "localhost"},
+		{Name: "Port", Old: "1", New: "80"},
+		{Name: "Nested.Password", Old: "", New: Mask, Secret: true},
+	}, e.changes)
+	assert.Equal(t, "fixed-id", e.cfg.ID)
+	assert.Same(t, e.cfg, cfg.Load())
+
+	good := cfg.Load()
+
+	// Second load changes nothing, third is invalid (port 0).
+	e = next()
+	require.Error(t, e.err)
+	assert.Contains(t, e.err.Error(), "reload configuration")
+	assert.Same(t, good, e.cfg)
+	assert.Same(t, good, cfg.Load())
+
+	// Fourth fails to load.
+	e = next()
+	require.Error(t, e.err)
+	assert.Contains(t, e.err.Error(), "unavailable")
+	assert.Same(t, good, cfg.Load())
+
+	// Fifth rotates the password.
+	e = next()
+	require.NoError(t, e.err)
+	assert.Equal(t, []Change{
+		{Name: "Port", Old: "80", New: "443"},
+		{Name: "Nested.Password", Old: Mask, New: Mask, Secret: true},
+	}, e.changes)
+	assert.Equal(t, "two", cfg.Load().Nested.Password)
+	assert.Equal(t, "fixed-id", cfg.Load().ID)
+	assert.Nil(t, cfg.Load().Missing)
+
+	cancel()
+
+	require.NoError(t, <-done)
+}
+
+func TestWatchInitialLoad(t *testing.T) {
+	testenv.Unset(t, "WATCH_HOST", "WATCH_PORT", "WATCH_PASSWORD")
+
+	t.Run("stores the first configuration", func(t *testing.T) {
+		loader := &fakeLoader{loads: []map[string]string{{"WATCH_PORT": "8080"}}}
+
+		var cfg atomic.Pointer[watchConfig]
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		require.NoError(t, Watch(ctx, loader, &cfg, time.Hour, nil))
+		require.NotNil(t, cfg.Load())
+		assert.Equal(t, 8080, cfg.Load().Port)
+		assert.Equal(t, "localhost", cfg.Load().Host)
+		assert.Len(t, cfg.Load().ID, 36)
+	})
+
+	t.Run("fails without a configuration to fall back to", func(t *testing.T) {
+		loader := &fakeLoader{
+			loads: []map[string]string{{}},
+			errs:  []error{errors.New("unavailable")},
+		}
+
+		var cfg atomic.Pointer[watchConfig]
+
+		err := Watch(context.Background(), loader, &cfg, time.Hour, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unavailable")
+		assert.Nil(t, cfg.Load())
+	})
+}
+
+func TestWatchNowDefaults(t *testing.T) {
+	type config struct {
+		StartedAt time.Time `default:"now"`
+		ExpiresAt time.Time `default:"now+1h"`
+	}
+
+	loader := &fakeLoader{loads: []map[string]string{{}}}
+
+	var cfg atomic.Pointer[config]
+
+	calls := 0
+
+	onChange := func(_ *config, _ []Change, _ error) { calls++ }
+
+	require.NoError(t, reload(context.Background(), loader, &cfg, onChange, nil))
+
+	first := cfg.Load()
+	require.False(t, first.StartedAt.IsZero())
+
+	time.Sleep(2 * time.Millisecond)
+
+	// Nothing changed, the defaults aren't evaluated again.
+	require.NoError(t, reload(context.Background(), loader, &cfg, onChange, nil))
+
+	assert.Zero(t, calls)
+	assert.Same(t, first, cfg.Load())
+}
+
+func TestWatchErrors(t *testing.T) {
+	var (
+		cfg    atomic.Pointer[watchConfig]
+		scalar atomic.Pointer[int]
+	)
+
+	loader := &fakeLoader{loads: []map[string]string{{}}}
+
+	tests := []struct {
+		name    string
+		watch   func() error
+		wantErr string
+	}{
+		{
+			name: "nil loader",
+			watch: func() error {
+				return Watch(context.Background(), nil, &cfg, time.Second, nil)
+			},
+			wantErr: "loader",
+		},
+		{
+			name: "nil cfg",
+			watch: func() error {
+				return Watch[watchConfig](context.Background(), loader, nil, time.Second, nil)
+			},
+			wantErr: "cfg",
+		},
+		{
+			name: "not a struct",
+			watch: func() error {
+				return Watch(context.Background(), loader, &scalar, time.Second, nil)
+			},
+			wantErr: "must be a struct",
+		},
+		{
+			name: "invalid interval",
+			watch: func() error {
+				return Watch(context.Background(), loader, &cfg, 0, nil)
+			},
+			wantErr: "interval",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.watch()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}